@@ -3,6 +3,7 @@ package adapter
 import (
 	"context"
 	"net/http"
+	"os"
 	"strings"
 	"time"
 
@@ -23,9 +24,10 @@ type RequestConfig struct {
 
 func RunRequest(cfg RequestConfig) error {
 	sess := session.New()
+	contract := openapi.New()
 	mods := []app.Module{
 		sess,
-		openapi.New(),
+		contract,
 		export.New(),
 		bench.New(),
 	}
@@ -58,6 +60,7 @@ func RunRequest(cfg RequestConfig) error {
 
 	println("status:", resp.StatusCode)
 	println(string(resp.Body))
+	openapi.PrintDrift(os.Stderr, contract.Take())
 	return nil
 }
 
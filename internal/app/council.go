@@ -1,6 +1,10 @@
 package app
 
-import "github.com/bspippi1337/restless/internal/council"
+import (
+	"time"
+
+	"github.com/bspippi1337/restless/internal/council"
+)
 
 var GlobalBlackboard = council.NewBlackboard()
 
@@ -11,5 +15,6 @@ func PublishFinding(engine, kind, target, evidence string, confidence float64) {
 		Target:     target,
 		Evidence:   evidence,
 		Confidence: confidence,
+		Timestamp:  time.Now(),
 	})
 }
@@ -0,0 +1,189 @@
+package audit
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/bspippi1337/restless/internal/modules/openapi/guard/model"
+	"github.com/bspippi1337/restless/internal/modules/openapi/guard/report"
+)
+
+// ProbeOrigin is sent as Origin on the CORS preflight so a server that
+// reflects arbitrary origins gives itself away. The .invalid TLD never
+// resolves.
+const ProbeOrigin = "https://restless-audit.invalid"
+
+// Observation is one response discovery already fetched. Auditing never
+// sends requests of its own.
+type Observation struct {
+	Method string
+	URL    string
+	Status int
+	Header http.Header
+	Body   []byte
+
+	// Origin is the Origin header the request carried, if any.
+	Origin string
+}
+
+type Finding struct {
+	Rule     string
+	Severity model.FindingSeverity
+	Title    string
+	Detail   string
+	URLs     []string
+}
+
+// Auditor accumulates findings across observations; the same issue on
+// many paths is reported once with every affected URL.
+type Auditor struct {
+	mu    sync.Mutex
+	byKey map[string]*Finding
+}
+
+func New() *Auditor {
+	return &Auditor{byKey: map[string]*Finding{}}
+}
+
+// Check audits a fixed set of observations.
+func Check(obs ...Observation) []Finding {
+	a := New()
+	for _, o := range obs {
+		a.Observe(o)
+	}
+	return a.Findings()
+}
+
+func (a *Auditor) Observe(o Observation) {
+	if o.Status == 0 || o.Header == nil {
+		return
+	}
+	u, err := url.Parse(o.URL)
+	if err != nil {
+		return
+	}
+
+	for _, c := range checks {
+		for _, f := range c(o, u) {
+			a.add(f, o.URL)
+		}
+	}
+}
+
+func (a *Auditor) add(f Finding, rawURL string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	key := f.Rule + "\x00" + f.Detail
+	cur, ok := a.byKey[key]
+	if !ok {
+		f.URLs = nil
+		cur = &f
+		a.byKey[key] = cur
+	}
+	for _, u := range cur.URLs {
+		if u == rawURL {
+			return
+		}
+	}
+	cur.URLs = append(cur.URLs, rawURL)
+}
+
+// Findings returns everything found so far, most severe first.
+func (a *Auditor) Findings() []Finding {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	out := make([]Finding, 0, len(a.byKey))
+	for _, f := range a.byKey {
+		out = append(out, *f)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if ri, rj := Rank(out[i].Severity), Rank(out[j].Severity); ri != rj {
+			return ri > rj
+		}
+		if out[i].Rule != out[j].Rule {
+			return out[i].Rule < out[j].Rule
+		}
+		return out[i].Detail < out[j].Detail
+	})
+	return out
+}
+
+// Rank orders severities, info lowest.
+func Rank(s model.FindingSeverity) int {
+	switch s {
+	case model.SevCritical:
+		return 4
+	case model.SevHigh:
+		return 3
+	case model.SevMedium:
+		return 2
+	case model.SevLow:
+		return 1
+	}
+	return 0
+}
+
+// AtLeast reports whether any finding is at or above min.
+func AtLeast(fs []Finding, min model.FindingSeverity) bool {
+	for _, f := range fs {
+		if Rank(f.Severity) >= Rank(min) {
+			return true
+		}
+	}
+	return false
+}
+
+// PrintHuman renders findings the way guard reports read.
+func PrintHuman(fs []Finding) string {
+	var b strings.Builder
+
+	if len(fs) == 0 {
+		b.WriteString("No security findings.\n")
+		return b.String()
+	}
+
+	for _, f := range fs {
+		fmt.Fprintf(&b, "[%s] %s  (%s)\n", strings.ToUpper(string(f.Severity)), f.Title, f.Rule)
+		if f.Detail != "" {
+			fmt.Fprintf(&b, "  %s\n", f.Detail)
+		}
+		for i, u := range f.URLs {
+			if i == 3 {
+				fmt.Fprintf(&b, "  … and %d more\n", len(f.URLs)-3)
+				break
+			}
+			fmt.Fprintf(&b, "  %s\n", u)
+		}
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
+
+// ToSARIF renders findings with the same SARIF writer guard uses, one
+// result per affected URL.
+func ToSARIF(appVersion string, fs []Finding) ([]byte, error) {
+	rules := make([]report.Rule, 0, len(Rules))
+	for _, r := range Rules {
+		rules = append(rules, report.Rule{ID: r.ID, Description: r.Title})
+	}
+
+	var results []report.Result
+	for _, f := range fs {
+		msg := f.Title
+		if f.Detail != "" {
+			msg += ": " + f.Detail
+		}
+		for _, u := range f.URLs {
+			results = append(results, report.Result{RuleID: f.Rule, Severity: f.Severity, Message: msg, URI: u})
+		}
+	}
+
+	return report.SARIF("restless-audit", appVersion, rules, results)
+}
@@ -0,0 +1,103 @@
+package audit
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/bspippi1337/restless/internal/modules/openapi/guard/model"
+)
+
+func TestCheckAggregatesAcrossPaths(t *testing.T) {
+	h := http.Header{}
+	h.Set("Content-Type", "application/json")
+	h.Set("Server", "nginx/1.18.0")
+	h.Add("Set-Cookie", "sid=abc; Path=/")
+
+	fs := Check(
+		Observation{Method: "GET", URL: "https://api.example.com/", Status: 200, Header: h, Body: []byte(`{}`)},
+		Observation{Method: "GET", URL: "https://api.example.com/users", Status: 200, Header: h, Body: []byte(`[]`)},
+	)
+
+	byRule := map[string]Finding{}
+	for _, f := range fs {
+		byRule[f.Rule] = f
+	}
+
+	hsts, ok := byRule["security.hsts_missing"]
+	if !ok || len(hsts.URLs) != 2 {
+		t.Fatalf("expected one HSTS finding over two URLs, got %+v", hsts)
+	}
+	if f := byRule["security.version_banner"]; f.Detail != "Server: nginx/1.18.0" {
+		t.Fatalf("unexpected banner finding: %+v", f)
+	}
+	if f := byRule["security.cookie_flags"]; f.Severity != model.SevMedium || !strings.Contains(f.Detail, "Secure") {
+		t.Fatalf("unexpected cookie finding: %+v", f)
+	}
+	if Rank(fs[0].Severity) < Rank(fs[len(fs)-1].Severity) {
+		t.Fatalf("findings not sorted by severity")
+	}
+}
+
+func TestCheckCORSAndLeaks(t *testing.T) {
+	pre := http.Header{}
+	pre.Set("Access-Control-Allow-Origin", ProbeOrigin)
+	pre.Set("Access-Control-Allow-Credentials", "true")
+
+	errH := http.Header{}
+	errH.Set("Content-Type", "text/plain")
+	errH.Set("X-Content-Type-Options", "nosniff")
+	errH.Set("Content-Security-Policy", "default-src 'none'")
+
+	env := http.Header{}
+	env.Set("Content-Type", "text/plain")
+
+	fs := Check(
+		Observation{Method: "OPTIONS", URL: "http://x/", Status: 204, Header: pre, Origin: ProbeOrigin},
+		Observation{Method: "GET", URL: "http://x/boom", Status: 500, Header: errH,
+			Body: []byte("Traceback (most recent call last):\n  File \"app.py\"")},
+		Observation{Method: "GET", URL: "http://x/.env", Status: 200, Header: env, Body: []byte("DB_PASSWORD=hunter2\n")},
+		Observation{Method: "GET", URL: "http://x/actuator/env", Status: 200, Header: env, Body: []byte("<html>app</html>")},
+	)
+
+	want := map[string]model.FindingSeverity{
+		"security.cors_reflected_origin": model.SevHigh,
+		"security.stack_trace":           model.SevMedium,
+		"security.sensitive_path":        model.SevCritical,
+	}
+	got := map[string]int{}
+	for _, f := range fs {
+		if sev, ok := want[f.Rule]; ok {
+			got[f.Rule]++
+			if f.Severity != sev {
+				t.Fatalf("%s: severity %s, want %s", f.Rule, f.Severity, sev)
+			}
+		}
+	}
+	for rule := range want {
+		if got[rule] != 1 {
+			t.Fatalf("expected exactly one %s finding, got %d (%+v)", rule, got[rule], fs)
+		}
+	}
+}
+
+func TestCheckCookiesSecureOnce(t *testing.T) {
+	h := http.Header{}
+	h.Add("Set-Cookie", "sid=abc; SameSite=None")
+
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://api.example.com/", "sid: missing Secure, HttpOnly"},
+		{"http://api.example.com/", "sid: missing HttpOnly, Secure (required by SameSite=None)"},
+	}
+	for _, tt := range tests {
+		u, _ := url.Parse(tt.url)
+		fs := checkCookies(Observation{Method: "GET", URL: tt.url, Status: 200, Header: h}, u)
+		if len(fs) != 1 || fs[0].Detail != tt.want {
+			t.Fatalf("%s: got %+v, want detail %q", tt.url, fs, tt.want)
+		}
+	}
+}
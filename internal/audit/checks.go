@@ -0,0 +1,265 @@
+package audit
+
+import (
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/bspippi1337/restless/internal/modules/openapi/guard/model"
+)
+
+// Rule describes one check, for SARIF rule tables and docs.
+type Rule struct {
+	ID    string
+	Title string
+}
+
+var Rules = []Rule{
+	{"security.hsts_missing", "Strict-Transport-Security header missing"},
+	{"security.csp_missing", "Content-Security-Policy header missing"},
+	{"security.nosniff_missing", "X-Content-Type-Options: nosniff missing"},
+	{"security.cors_wildcard", "CORS allows any origin"},
+	{"security.cors_wildcard_credentials", "CORS allows any origin with credentials"},
+	{"security.cors_reflected_origin", "CORS reflects arbitrary origins"},
+	{"security.version_banner", "Server software and version disclosed"},
+	{"security.stack_trace", "Stack trace or internal error in response body"},
+	{"security.sensitive_path", "Sensitive file or debug endpoint exposed"},
+	{"security.cookie_flags", "Cookie set without protective flags"},
+}
+
+var checks = []func(Observation, *url.URL) []Finding{
+	checkHeaders,
+	checkCORS,
+	checkBanner,
+	checkStackTrace,
+	checkSensitivePath,
+	checkCookies,
+}
+
+func checkHeaders(o Observation, u *url.URL) []Finding {
+	// Preflights and error pages say little about what real responses
+	// carry; judge header hygiene on served content only.
+	if o.Method == http.MethodOptions || o.Status >= 400 {
+		return nil
+	}
+
+	var out []Finding
+	h := o.Header
+
+	if u.Scheme == "https" && h.Get("Strict-Transport-Security") == "" {
+		out = append(out, Finding{
+			Rule: "security.hsts_missing", Severity: model.SevMedium,
+			Title:  "Strict-Transport-Security header missing",
+			Detail: "host " + u.Host,
+		})
+	}
+
+	if h.Get("Content-Security-Policy") == "" {
+		f := Finding{
+			Rule: "security.csp_missing", Severity: model.SevLow,
+			Title: "Content-Security-Policy header missing",
+		}
+		if isHTML(h) {
+			f.Severity, f.Detail = model.SevMedium, "on HTML pages"
+		}
+		out = append(out, f)
+	}
+
+	if !strings.EqualFold(strings.TrimSpace(h.Get("X-Content-Type-Options")), "nosniff") {
+		out = append(out, Finding{
+			Rule: "security.nosniff_missing", Severity: model.SevLow,
+			Title: "X-Content-Type-Options: nosniff missing",
+		})
+	}
+
+	return out
+}
+
+func checkCORS(o Observation, _ *url.URL) []Finding {
+	acao := strings.TrimSpace(o.Header.Get("Access-Control-Allow-Origin"))
+	if acao == "" {
+		return nil
+	}
+	creds := strings.EqualFold(strings.TrimSpace(o.Header.Get("Access-Control-Allow-Credentials")), "true")
+
+	switch {
+	case o.Origin != "" && acao == o.Origin:
+		sev := model.SevMedium
+		detail := "origin " + o.Origin + " echoed back"
+		if creds {
+			sev = model.SevHigh
+			detail += " with Access-Control-Allow-Credentials: true"
+		}
+		return []Finding{{
+			Rule: "security.cors_reflected_origin", Severity: sev,
+			Title: "CORS reflects arbitrary origins", Detail: detail,
+		}}
+	case acao == "*" && creds:
+		return []Finding{{
+			Rule: "security.cors_wildcard_credentials", Severity: model.SevHigh,
+			Title:  "CORS allows any origin with credentials",
+			Detail: "Access-Control-Allow-Origin: * with Access-Control-Allow-Credentials: true",
+		}}
+	case acao == "*":
+		return []Finding{{
+			Rule: "security.cors_wildcard", Severity: model.SevInfo,
+			Title:  "CORS allows any origin",
+			Detail: "Access-Control-Allow-Origin: *",
+		}}
+	}
+	return nil
+}
+
+var hasVersion = regexp.MustCompile(`\d+\.\d+`)
+
+// bannerHeaders give away the software stack; a version number makes it
+// directly searchable against CVE databases.
+var bannerHeaders = []string{"Server", "X-Powered-By", "X-AspNet-Version", "X-AspNetMvc-Version", "X-Generator", "X-Runtime-Version"}
+
+func checkBanner(o Observation, _ *url.URL) []Finding {
+	var out []Finding
+	for _, name := range bannerHeaders {
+		v := strings.TrimSpace(o.Header.Get(name))
+		if v == "" {
+			continue
+		}
+		switch {
+		case hasVersion.MatchString(v):
+			out = append(out, Finding{
+				Rule: "security.version_banner", Severity: model.SevLow,
+				Title: "Server software and version disclosed", Detail: name + ": " + v,
+			})
+		case name != "Server":
+			out = append(out, Finding{
+				Rule: "security.version_banner", Severity: model.SevInfo,
+				Title: "Server software disclosed", Detail: name + ": " + v,
+			})
+		}
+	}
+	return out
+}
+
+var stackTraces = []struct {
+	name string
+	re   *regexp.Regexp
+}{
+	{"python traceback", regexp.MustCompile(`Traceback \(most recent call last\)`)},
+	{"java stack trace", regexp.MustCompile(`(?m)^\s*at [\w$.]+\([\w$]+\.(java|kt|scala):\d+\)`)},
+	{".NET stack trace", regexp.MustCompile(`(?m)^\s*at [\w.<>` + "`" + `]+\(.*\) in .+:line \d+`)},
+	{"go panic", regexp.MustCompile(`goroutine \d+ \[running\]`)},
+	{"node stack trace", regexp.MustCompile(`(?m)^\s*at .+ \((/|[A-Za-z]:\\|file://).+\.[cm]?js:\d+:\d+\)`)},
+	{"ruby backtrace", regexp.MustCompile(`\.rb:\d+:in ` + "`")},
+	{"php error", regexp.MustCompile(`(?i)(fatal error|parse error|warning)(</b>)?:.+ on line (<b>)?\d+`)},
+	{"sql error", regexp.MustCompile(`(?i)(SQLSTATE\[|ORA-\d{5}|you have an error in your sql syntax|unclosed quotation mark|pg_query\(\))`)},
+}
+
+func checkStackTrace(o Observation, _ *url.URL) []Finding {
+	if len(o.Body) == 0 {
+		return nil
+	}
+	body := o.Body
+	if len(body) > 256*1024 {
+		body = body[:256*1024]
+	}
+	for _, st := range stackTraces {
+		if st.re.Match(body) {
+			return []Finding{{
+				Rule: "security.stack_trace", Severity: model.SevMedium,
+				Title: "Stack trace or internal error in response body", Detail: st.name,
+			}}
+		}
+	}
+	return nil
+}
+
+// Sensitive is a path worth requesting during discovery, with the content
+// that proves it is really exposed (not a catch-all 200 page).
+type Sensitive struct {
+	Path     string
+	Severity model.FindingSeverity
+	Title    string
+	Proof    *regexp.Regexp
+}
+
+var SensitivePaths = []Sensitive{
+	{"/.env", model.SevCritical, "environment file exposed", regexp.MustCompile(`(?m)^[A-Z][A-Z0-9_]*=`)},
+	{"/.git/config", model.SevCritical, "git repository exposed", regexp.MustCompile(`\[core\]`)},
+	{"/.git/HEAD", model.SevCritical, "git repository exposed", regexp.MustCompile(`^ref: refs/`)},
+	{"/actuator", model.SevMedium, "Spring Boot actuator exposed", regexp.MustCompile(`"_links"`)},
+	{"/actuator/env", model.SevHigh, "Spring Boot environment exposed", regexp.MustCompile(`"propertySources"`)},
+	{"/actuator/heapdump", model.SevCritical, "JVM heap dump downloadable", regexp.MustCompile(`JAVA PROFILE`)},
+	{"/actuator/configprops", model.SevHigh, "Spring Boot configuration exposed", regexp.MustCompile(`"contexts"`)},
+	{"/debug/vars", model.SevMedium, "Go expvar debug endpoint exposed", regexp.MustCompile(`"memstats"`)},
+	{"/debug/pprof/", model.SevMedium, "Go pprof profiler exposed", regexp.MustCompile(`(?i)goroutine|heap`)},
+	{"/server-status", model.SevMedium, "Apache server-status exposed", regexp.MustCompile(`Apache Server Status`)},
+	{"/phpinfo.php", model.SevHigh, "phpinfo() page exposed", regexp.MustCompile(`PHP Version`)},
+}
+
+// SensitiveSeeds are the SensitivePaths, ready to append to a seed list.
+func SensitiveSeeds() []string {
+	out := make([]string, 0, len(SensitivePaths))
+	for _, s := range SensitivePaths {
+		out = append(out, s.Path)
+	}
+	return out
+}
+
+func checkSensitivePath(o Observation, u *url.URL) []Finding {
+	if o.Status < 200 || o.Status >= 300 || len(o.Body) == 0 {
+		return nil
+	}
+	p := strings.TrimRight(u.Path, "/")
+	for _, s := range SensitivePaths {
+		if p != strings.TrimRight(s.Path, "/") {
+			continue
+		}
+		if s.Proof.Match(o.Body) {
+			return []Finding{{
+				Rule: "security.sensitive_path", Severity: s.Severity,
+				Title: s.Title, Detail: s.Path,
+			}}
+		}
+	}
+	return nil
+}
+
+func checkCookies(o Observation, u *url.URL) []Finding {
+	var out []Finding
+	for _, line := range o.Header.Values("Set-Cookie") {
+		c, err := http.ParseSetCookie(line)
+		if err != nil {
+			continue
+		}
+		var missing []string
+		sev := model.SevLow
+		if u.Scheme == "https" && !c.Secure {
+			missing = append(missing, "Secure")
+			sev = model.SevMedium
+		}
+		if !c.HttpOnly {
+			missing = append(missing, "HttpOnly")
+		}
+		if c.SameSite == http.SameSiteDefaultMode {
+			missing = append(missing, "SameSite")
+		}
+		if c.SameSite == http.SameSiteNoneMode && !c.Secure && !slices.Contains(missing, "Secure") {
+			missing = append(missing, "Secure (required by SameSite=None)")
+			sev = model.SevMedium
+		}
+		if len(missing) == 0 {
+			continue
+		}
+		out = append(out, Finding{
+			Rule: "security.cookie_flags", Severity: sev,
+			Title:  "Cookie set without protective flags",
+			Detail: c.Name + ": missing " + strings.Join(missing, ", "),
+		})
+	}
+	return out
+}
+
+func isHTML(h http.Header) bool {
+	return strings.Contains(strings.ToLower(h.Get("Content-Type")), "html")
+}
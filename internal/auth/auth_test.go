@@ -0,0 +1,78 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+
+	"github.com/bspippi1337/restless/internal/store"
+)
+
+func TestParseChallenges(t *testing.T) {
+	got := ParseChallenges(`Bearer realm="api, v2", error="invalid_token", Basic realm="admin"`)
+	if len(got) != 2 {
+		t.Fatalf("want 2 challenges, got %+v", got)
+	}
+	if got[0].Scheme != "bearer" || got[0].Realm != "api, v2" {
+		t.Fatalf("bearer challenge: %+v", got[0])
+	}
+	if got[1].Scheme != "basic" || got[1].Realm != "admin" {
+		t.Fatalf("basic challenge: %+v", got[1])
+	}
+}
+
+func TestClassifyFromStatuses(t *testing.T) {
+	api := &store.API{
+		BaseURL: "https://api.example.com",
+		Endpoints: []store.Endpoint{
+			{Path: "/health", Statuses: map[string][]int{"GET": {200}}},
+			{Path: "/me", Statuses: map[string][]int{"GET": {401}}},
+			{Path: "/oauth/token", Statuses: map[string][]int{"POST": {400}}},
+		},
+	}
+	Classify(api, Evidence{Challenges: map[string]string{"/me": `Bearer realm="x"`}})
+
+	if api.Endpoints[0].Access != Public {
+		t.Fatalf("/health: %q", api.Endpoints[0].Access)
+	}
+	if ep := api.Endpoints[1]; ep.Access != Protected || len(ep.Auth) != 1 || ep.Auth[0] != "bearer" {
+		t.Fatalf("/me: %+v", ep)
+	}
+	if api.Auth == nil || len(api.Auth.TokenEndpoints) != 1 {
+		t.Fatalf("token endpoints: %+v", api.Auth)
+	}
+	if _, ok := api.Auth.Scheme("oauth2"); !ok {
+		t.Fatalf("token endpoint should imply an oauth2 scheme: %+v", api.Auth.Schemes)
+	}
+}
+
+func TestClassifySpecWins(t *testing.T) {
+	doc := &openapi3.T{
+		OpenAPI: "3.0.3",
+		Components: &openapi3.Components{SecuritySchemes: openapi3.SecuritySchemes{
+			"key": {Value: &openapi3.SecurityScheme{Type: "apiKey", In: "header", Name: "X-Key"}},
+		}},
+		Security: openapi3.SecurityRequirements{{"key": {}}},
+		Paths:    openapi3.NewPaths(),
+	}
+	open := openapi3.NewOperation()
+	open.Security = &openapi3.SecurityRequirements{}
+	doc.Paths.Set("/pets", &openapi3.PathItem{Get: open})
+	doc.Paths.Set("/pets/{id}", &openapi3.PathItem{Get: openapi3.NewOperation()})
+
+	api := &store.API{Endpoints: []store.Endpoint{
+		{Path: "/pets", Statuses: map[string][]int{"GET": {401}}},
+		{Path: "/pets/{petId}", Statuses: map[string][]int{"GET": {200}}},
+	}}
+	Classify(api, Evidence{Spec: doc})
+
+	if api.Endpoints[0].Access != Public {
+		t.Fatalf("/pets: %+v", api.Endpoints[0])
+	}
+	if ep := api.Endpoints[1]; ep.Access != Protected || len(ep.Auth) != 1 || ep.Auth[0] != "key" {
+		t.Fatalf("/pets/{petId}: %+v", ep)
+	}
+	if h := Hint(api, "/pets/7", 0); h != "protected — needs API key header (X-Key: <key>)" {
+		t.Fatalf("hint: %q", h)
+	}
+}
@@ -0,0 +1,88 @@
+package auth
+
+import (
+	"strings"
+
+	"github.com/bspippi1337/restless/internal/store"
+)
+
+// ParseChallenges reads a WWW-Authenticate header (RFC 9110 §11.6.1),
+// which may hold several comma-separated challenges, each with its own
+// comma-separated auth-params.
+func ParseChallenges(header string) []store.AuthScheme {
+	var out []store.AuthScheme
+	var cur *store.AuthScheme
+
+	for _, part := range splitParams(header) {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		// A part that starts with a bare token begins a new challenge;
+		// anything else is an auth-param of the current one.
+		token, rest, _ := strings.Cut(part, " ")
+		if !strings.Contains(token, "=") {
+			out = append(out, challengeScheme(token))
+			cur = &out[len(out)-1]
+			part = strings.TrimSpace(rest)
+			if part == "" {
+				continue
+			}
+		}
+		if cur == nil {
+			continue
+		}
+
+		k, v, ok := strings.Cut(part, "=")
+		if !ok {
+			continue
+		}
+		v = strings.Trim(strings.TrimSpace(v), `"`)
+		switch strings.ToLower(strings.TrimSpace(k)) {
+		case "realm":
+			cur.Realm = v
+		case "scope":
+			cur.Scopes = strings.Fields(v)
+		case "authorization_uri":
+			cur.AuthorizationURL = v
+		case "token_uri":
+			cur.TokenURL = v
+		}
+	}
+	return out
+}
+
+func challengeScheme(token string) store.AuthScheme {
+	name := strings.ToLower(token)
+	s := store.AuthScheme{ID: name, Type: "http", Scheme: name, Source: "www-authenticate"}
+	if name == "apikey" || name == "api-key" || name == "x-api-key" {
+		s = store.AuthScheme{ID: "apiKey", Type: "apiKey", In: "header", Name: "X-API-Key", Source: "www-authenticate"}
+	}
+	return s
+}
+
+// splitParams splits on commas outside double quotes.
+func splitParams(s string) []string {
+	var out []string
+	var b strings.Builder
+	quoted := false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '\\' && quoted && i+1 < len(s):
+			b.WriteByte(c)
+			i++
+			b.WriteByte(s[i])
+			continue
+		case c == '"':
+			quoted = !quoted
+		case c == ',' && !quoted:
+			out = append(out, b.String())
+			b.Reset()
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return append(out, b.String())
+}
@@ -0,0 +1,252 @@
+package auth
+
+import (
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+
+	"github.com/bspippi1337/restless/internal/store"
+)
+
+const (
+	Public    = "public"
+	Protected = "protected"
+	Mixed     = "mixed"
+)
+
+// Evidence is everything discovery gathered about authentication besides
+// the status codes already stored on each endpoint.
+type Evidence struct {
+	// Challenges maps endpoint path to the WWW-Authenticate it answered with.
+	Challenges map[string]string
+	// Sent maps endpoint path to the scheme captured requests to it
+	// carried in their credentials, where no challenge was seen.
+	Sent map[string]string
+
+	OIDC    *OIDCConfig
+	OIDCURL string
+
+	Spec *openapi3.T
+}
+
+var (
+	oauthTokenPath = regexp.MustCompile(`(?i)(^|/)(access_?)?token$`)
+	loginPath      = regexp.MustCompile(`(?i)(^|/)(login|signin|sign_in|sign-in|authenticate|sessions?)$`)
+)
+
+// TokenKind recognises credential-issuing endpoints by path and, when a
+// response schema was learned, by the token fields it returns.
+func TokenKind(ep store.Endpoint) string {
+	if ep.Schema != nil && ep.Schema.Properties != nil {
+		if _, ok := ep.Schema.Properties["access_token"]; ok {
+			return "oauth2"
+		}
+		for _, k := range []string{"token", "jwt", "id_token", "accessToken"} {
+			if _, ok := ep.Schema.Properties[k]; ok {
+				return "login"
+			}
+		}
+	}
+	switch {
+	case oauthTokenPath.MatchString(ep.Path):
+		return "oauth2"
+	case loginPath.MatchString(ep.Path):
+		return "login"
+	}
+	return ""
+}
+
+// Classify fills api.Auth with the schemes found in the evidence and marks
+// each endpoint public, protected or mixed with the schemes it accepts.
+// Spec requirements win over observed status codes.
+func Classify(api *store.API, ev Evidence) {
+	a := &store.Auth{}
+	if api.Auth != nil {
+		a.Schemes = append(a.Schemes, api.Auth.Schemes...)
+		a.Issuer = api.Auth.Issuer
+	}
+	add := func(s store.AuthScheme) {
+		for i := range a.Schemes {
+			if a.Schemes[i].ID == s.ID {
+				a.Schemes[i] = s
+				return
+			}
+		}
+		a.Schemes = append(a.Schemes, s)
+	}
+
+	specSchemes, ops := FromOpenAPI(ev.Spec)
+	for _, s := range specSchemes {
+		add(s)
+	}
+
+	if ev.OIDC != nil {
+		add(ev.OIDC.Scheme(ev.OIDCURL))
+		a.Issuer = ev.OIDC.Issuer
+	}
+
+	// Schemes seen only in requests go first, so a challenge for the same
+	// scheme replaces them with what the server itself said.
+	challenged := map[string][]string{}
+	for _, src := range []struct {
+		headers map[string]string
+		source  string
+	}{{ev.Sent, "request-header"}, {ev.Challenges, "www-authenticate"}} {
+		for path, h := range src.headers {
+			for _, s := range ParseChallenges(h) {
+				s.Source = src.source
+				add(s)
+				if !contains(challenged[path], s.ID) {
+					challenged[path] = append(challenged[path], s.ID)
+				}
+			}
+		}
+	}
+
+	hasLogin := false
+	for _, ep := range api.Endpoints {
+		switch TokenKind(ep) {
+		case "oauth2":
+			a.TokenEndpoints = append(a.TokenEndpoints, ep.Path)
+			if !hasType(a.Schemes, "oauth2") && !hasType(a.Schemes, "openIdConnect") {
+				add(store.AuthScheme{ID: "oauth2", Type: "oauth2", TokenURL: api.BaseURL + ep.Path, Source: "token-endpoint"})
+			}
+		case "login":
+			a.TokenEndpoints = append(a.TokenEndpoints, ep.Path)
+			hasLogin = true
+		}
+	}
+
+	for i := range api.Endpoints {
+		ep := &api.Endpoints[i]
+		ep.Access, ep.Auth = "", nil
+
+		if access, ids, ok := fromSpec(ep.Path, ops); ok {
+			ep.Access, ep.Auth = access, ids
+			continue
+		}
+
+		ep.Access = fromStatuses(ep.Statuses)
+		// Captured traffic is authenticated: a credential header on the
+		// request means the 2xx it got says nothing about public access.
+		if ep.Access == Public && (contains(ep.Headers, "authorization") || contains(ep.Headers, "x-api-key")) {
+			ep.Access = Protected
+		}
+		if TokenKind(*ep) != "" && ep.Access == "" {
+			ep.Access = Public
+		}
+		if ep.Access == Protected || ep.Access == Mixed {
+			ep.Auth = challenged[ep.Path]
+		}
+	}
+
+	// A login endpoint with protected routes and no stated scheme almost
+	// always hands out bearer tokens.
+	if hasLogin && len(a.Schemes) == 0 && anyProtected(api) {
+		add(store.AuthScheme{ID: "bearer", Type: "http", Scheme: "bearer", Source: "token-endpoint"})
+	}
+
+	// With a single scheme there is no doubt which credential is meant.
+	if len(a.Schemes) == 1 {
+		for i := range api.Endpoints {
+			ep := &api.Endpoints[i]
+			if (ep.Access == Protected || ep.Access == Mixed) && len(ep.Auth) == 0 {
+				ep.Auth = []string{a.Schemes[0].ID}
+			}
+		}
+	}
+
+	sort.Slice(a.Schemes, func(i, j int) bool { return a.Schemes[i].ID < a.Schemes[j].ID })
+	sort.Strings(a.TokenEndpoints)
+
+	if len(a.Schemes) == 0 && len(a.TokenEndpoints) == 0 && a.Issuer == "" {
+		api.Auth = nil
+		return
+	}
+	api.Auth = a
+}
+
+// fromStatuses reads access from unauthenticated probes: 401/403 means a
+// credential is needed, 2xx/3xx means it is not.
+func fromStatuses(statuses map[string][]int) string {
+	var pub, prot bool
+	for _, codes := range statuses {
+		for _, c := range codes {
+			switch {
+			case c == http.StatusUnauthorized || c == http.StatusForbidden:
+				prot = true
+			case c >= 200 && c < 400:
+				pub = true
+			}
+		}
+	}
+	switch {
+	case pub && prot:
+		return Mixed
+	case prot:
+		return Protected
+	case pub:
+		return Public
+	}
+	return ""
+}
+
+func fromSpec(path string, ops []OpSecurity) (string, []string, bool) {
+	var pub, prot, found bool
+	var ids []string
+	for _, op := range ops {
+		if normalize(op.Path) != normalize(path) {
+			continue
+		}
+		found = true
+		if op.Public {
+			pub = true
+		} else {
+			prot = true
+		}
+		for _, id := range op.Schemes {
+			if !contains(ids, id) {
+				ids = append(ids, id)
+			}
+		}
+	}
+	if !found {
+		return "", nil, false
+	}
+	sort.Strings(ids)
+	switch {
+	case pub && prot:
+		return Mixed, ids, true
+	case prot:
+		return Protected, ids, true
+	}
+	return Public, ids, true
+}
+
+var param = regexp.MustCompile(`\{[^/]+\}`)
+
+// normalize makes /users/{id} and /users/{userId} compare equal.
+func normalize(tpl string) string {
+	return param.ReplaceAllString(strings.TrimRight(tpl, "/"), "{}")
+}
+
+func hasType(ss []store.AuthScheme, typ string) bool {
+	for _, s := range ss {
+		if s.Type == typ {
+			return true
+		}
+	}
+	return false
+}
+
+func anyProtected(api *store.API) bool {
+	for _, ep := range api.Endpoints {
+		if ep.Access == Protected || ep.Access == Mixed {
+			return true
+		}
+	}
+	return false
+}
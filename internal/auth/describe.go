@@ -0,0 +1,113 @@
+package auth
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/bspippi1337/restless/internal/store"
+)
+
+// Describe says, in one line, what credential a scheme wants and where it
+// goes, e.g. "bearer token (Authorization: Bearer <token>)".
+func Describe(s store.AuthScheme) string {
+	switch s.Type {
+	case "http":
+		switch s.Scheme {
+		case "basic":
+			return "basic auth (Authorization: Basic <base64 user:password>)" + realm(s)
+		case "bearer":
+			f := "token"
+			if s.BearerFormat != "" {
+				f = s.BearerFormat
+			}
+			return "bearer " + strings.ToLower(f) + " (Authorization: Bearer <" + strings.ToLower(f) + ">)" + realm(s)
+		case "digest":
+			return "digest auth" + realm(s)
+		}
+		return s.Scheme + " auth (Authorization: " + titleCase(s.Scheme) + " ...)" + realm(s)
+	case "apiKey":
+		switch s.In {
+		case "query":
+			return "API key in query (?" + s.Name + "=<key>)"
+		case "cookie":
+			return "API key in cookie (" + s.Name + "=<key>)"
+		}
+		return "API key header (" + s.Name + ": <key>)"
+	case "oauth2":
+		if s.TokenURL != "" {
+			return "OAuth2 access token from " + s.TokenURL + " (Authorization: Bearer <token>)"
+		}
+		return "OAuth2 access token (Authorization: Bearer <token>)"
+	case "openIdConnect":
+		if s.TokenURL != "" {
+			return "OpenID Connect token from " + s.TokenURL + " (Authorization: Bearer <token>)"
+		}
+		return "OpenID Connect token (" + s.OpenIDConnectURL + ")"
+	case "mutualTLS":
+		return "client TLS certificate"
+	}
+	return s.ID
+}
+
+// Needs describes the credentials an endpoint accepts, or "" if public.
+func Needs(api *store.API, ep store.Endpoint) string {
+	if ep.Access != Protected && ep.Access != Mixed {
+		return ""
+	}
+	var parts []string
+	for _, id := range ep.Auth {
+		if s, ok := api.Auth.Scheme(id); ok {
+			parts = append(parts, Describe(s))
+		} else {
+			parts = append(parts, id)
+		}
+	}
+	if len(parts) == 0 {
+		parts = []string{"a credential (scheme unknown)"}
+	}
+	out := strings.Join(parts, " or ")
+	if ep.Access == Mixed {
+		out += ", for some methods"
+	}
+	return out
+}
+
+func realm(s store.AuthScheme) string {
+	if s.Realm == "" {
+		return ""
+	}
+	return ", realm " + s.Realm
+}
+
+func titleCase(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+// Hint is what to tell a user who called path and got status: the
+// credential the endpoint needs, or, after a 401/403 on an endpoint not
+// known to be protected, whatever schemes the API offers. A successful
+// status needs no hint; pass 0 to ask before calling.
+func Hint(api *store.API, path string, status int) string {
+	if status >= 200 && status < 400 {
+		return ""
+	}
+	if ep, ok := api.Lookup(path); ok {
+		if n := Needs(api, ep); n != "" {
+			return ep.Access + " — needs " + n
+		}
+	}
+	if status != http.StatusUnauthorized && status != http.StatusForbidden {
+		return ""
+	}
+	if api.Auth == nil || len(api.Auth.Schemes) == 0 {
+		return "credential required — no scheme known yet (re-run restless learn)"
+	}
+	var parts []string
+	for _, s := range api.Auth.Schemes {
+		parts = append(parts, Describe(s))
+	}
+	return "credential required — the API accepts " + strings.Join(parts, " or ")
+}
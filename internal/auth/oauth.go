@@ -0,0 +1,267 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Grants supported by Config.Grant.
+const (
+	ClientCredentials = "client_credentials"
+	DeviceCode        = "device_code"
+
+	deviceGrantType = "urn:ietf:params:oauth:grant-type:device_code"
+)
+
+// Config is one OAuth 2.0 client registration.
+type Config struct {
+	Grant         string
+	TokenURL      string
+	DeviceAuthURL string `json:",omitempty"`
+	ClientID      string
+	ClientSecret  string   `json:",omitempty"`
+	Scopes        []string `json:",omitempty"`
+	Audience      string   `json:",omitempty"`
+
+	// BaseURL limits injection to one origin so tokens never leak to
+	// third-party hosts. Empty means every request.
+	BaseURL string `json:",omitempty"`
+}
+
+// Token is an access token as issued by the token endpoint.
+type Token struct {
+	AccessToken  string
+	TokenType    string
+	RefreshToken string    `json:",omitempty"`
+	Expiry       time.Time `json:",omitzero"`
+	Scope        string    `json:",omitempty"`
+}
+
+// expirySkew refreshes tokens a little early so they don't expire in flight.
+const expirySkew = 30 * time.Second
+
+// Valid reports whether the token can still be used at now.
+func (t *Token) Valid(now time.Time) bool {
+	if t == nil || t.AccessToken == "" {
+		return false
+	}
+	return t.Expiry.IsZero() || now.Add(expirySkew).Before(t.Expiry)
+}
+
+// Header is the Authorization header value for the token.
+func (t *Token) Header() string {
+	typ := t.TokenType
+	if typ == "" || strings.EqualFold(typ, "bearer") {
+		typ = "Bearer"
+	}
+	return typ + " " + t.AccessToken
+}
+
+// Error is an RFC 6749 §5.2 error response.
+type Error struct {
+	Status      int
+	Code        string
+	Description string
+}
+
+func (e *Error) Error() string {
+	if e.Description != "" {
+		return fmt.Sprintf("oauth2: %s: %s", e.Code, e.Description)
+	}
+	if e.Code != "" {
+		return "oauth2: " + e.Code
+	}
+	return fmt.Sprintf("oauth2: token endpoint returned %d", e.Status)
+}
+
+// ClientCredentialsToken runs the client-credentials grant.
+func ClientCredentialsToken(ctx context.Context, hc *http.Client, c Config) (*Token, error) {
+	form := url.Values{"grant_type": {"client_credentials"}}
+	c.scopes(form)
+	return c.token(ctx, hc, form)
+}
+
+// RefreshToken trades a refresh token for a new access token. The old
+// refresh token is kept if the server does not rotate it.
+func RefreshToken(ctx context.Context, hc *http.Client, c Config, refresh string) (*Token, error) {
+	form := url.Values{"grant_type": {"refresh_token"}, "refresh_token": {refresh}}
+	t, err := c.token(ctx, hc, form)
+	if err != nil {
+		return nil, err
+	}
+	if t.RefreshToken == "" {
+		t.RefreshToken = refresh
+	}
+	return t, nil
+}
+
+// DeviceAuth is the device authorization response (RFC 8628 §3.2).
+type DeviceAuth struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete,omitempty"`
+	ExpiresIn               int    `json:"expires_in"`
+	Interval                int    `json:"interval,omitempty"`
+}
+
+// StartDevice asks the server for a user code to show the user.
+func StartDevice(ctx context.Context, hc *http.Client, c Config) (*DeviceAuth, error) {
+	if c.DeviceAuthURL == "" {
+		return nil, errors.New("oauth2: no device authorization URL configured")
+	}
+	form := url.Values{}
+	c.scopes(form)
+	body, err := c.post(ctx, hc, c.DeviceAuthURL, form)
+	if err != nil {
+		return nil, err
+	}
+	var d DeviceAuth
+	if err := json.Unmarshal(body, &d); err != nil {
+		return nil, fmt.Errorf("oauth2: device authorization response: %w", err)
+	}
+	if d.DeviceCode == "" {
+		return nil, errors.New("oauth2: device authorization response has no device_code")
+	}
+	return &d, nil
+}
+
+// PollDevice polls the token endpoint until the user approves the device,
+// honouring authorization_pending and slow_down (RFC 8628 §3.5).
+func PollDevice(ctx context.Context, hc *http.Client, c Config, d *DeviceAuth) (*Token, error) {
+	interval := time.Duration(d.Interval) * time.Second
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	if d.ExpiresIn > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(d.ExpiresIn)*time.Second)
+		defer cancel()
+	}
+
+	form := url.Values{"grant_type": {deviceGrantType}, "device_code": {d.DeviceCode}}
+	for {
+		t, err := c.token(ctx, hc, form)
+		var oe *Error
+		switch {
+		case err == nil:
+			return t, nil
+		case errors.As(err, &oe) && oe.Code == "authorization_pending":
+		case errors.As(err, &oe) && oe.Code == "slow_down":
+			interval += 5 * time.Second
+		default:
+			return nil, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("oauth2: device login not completed: %w", ctx.Err())
+		case <-time.After(interval):
+		}
+	}
+}
+
+func (c Config) scopes(form url.Values) {
+	if len(c.Scopes) > 0 {
+		form.Set("scope", strings.Join(c.Scopes, " "))
+	}
+	if c.Audience != "" {
+		form.Set("audience", c.Audience)
+	}
+}
+
+func (c Config) token(ctx context.Context, hc *http.Client, form url.Values) (*Token, error) {
+	if c.TokenURL == "" {
+		return nil, errors.New("oauth2: no token URL configured")
+	}
+	body, err := c.post(ctx, hc, c.TokenURL, form)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw struct {
+		AccessToken  string          `json:"access_token"`
+		TokenType    string          `json:"token_type"`
+		RefreshToken string          `json:"refresh_token"`
+		ExpiresIn    json.RawMessage `json:"expires_in"`
+		Scope        string          `json:"scope"`
+	}
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, fmt.Errorf("oauth2: token response: %w", err)
+	}
+	if raw.AccessToken == "" {
+		return nil, errors.New("oauth2: token response has no access_token")
+	}
+
+	t := &Token{
+		AccessToken:  raw.AccessToken,
+		TokenType:    raw.TokenType,
+		RefreshToken: raw.RefreshToken,
+		Scope:        raw.Scope,
+	}
+	if n := seconds(raw.ExpiresIn); n > 0 {
+		t.Expiry = time.Now().Add(time.Duration(n) * time.Second)
+	}
+	return t, nil
+}
+
+// seconds reads expires_in, which some servers send as a string.
+func seconds(raw json.RawMessage) int64 {
+	var n int64
+	if json.Unmarshal(raw, &n) == nil {
+		return n
+	}
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		n, _ = strconv.ParseInt(s, 10, 64)
+	}
+	return n
+}
+
+// post sends a form with client authentication: HTTP Basic when there is
+// a secret (RFC 6749 §2.3.1), client_id in the body otherwise.
+func (c Config) post(ctx context.Context, hc *http.Client, endpoint string, form url.Values) ([]byte, error) {
+	if c.ClientSecret == "" {
+		form.Set("client_id", c.ClientID)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if c.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(c.ClientID), url.QueryEscape(c.ClientSecret))
+	}
+
+	resp, err := hc.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode >= 400 {
+		e := &Error{Status: resp.StatusCode}
+		var raw struct {
+			Error       string `json:"error"`
+			Description string `json:"error_description"`
+		}
+		if json.Unmarshal(body, &raw) == nil {
+			e.Code, e.Description = raw.Error, raw.Description
+		}
+		return nil, e
+	}
+	return body, nil
+}
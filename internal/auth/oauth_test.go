@@ -0,0 +1,146 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// oauthServer is a minimal authorization server: client credentials for
+// id/secret, refresh tokens, and a device flow approved on the second poll.
+func oauthServer(t *testing.T, issued *int32) *httptest.Server {
+	var polls int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/device", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{
+			"device_code": "dev-1", "user_code": "ABCD-EFGH",
+			"verification_uri": "https://example.test/activate", "expires_in": 60, "interval": 1,
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		fail := func(code string) {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": code})
+		}
+		switch r.Form.Get("grant_type") {
+		case "client_credentials":
+			if id, secret, ok := r.BasicAuth(); !ok || id != "id" || secret != "s3cret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				json.NewEncoder(w).Encode(map[string]string{"error": "invalid_client"})
+				return
+			}
+		case "refresh_token":
+			if r.Form.Get("refresh_token") != "r-1" {
+				fail("invalid_grant")
+				return
+			}
+		case deviceGrantType:
+			if atomic.AddInt32(&polls, 1) < 2 {
+				fail("authorization_pending")
+				return
+			}
+		default:
+			fail("unsupported_grant_type")
+			return
+		}
+		n := atomic.AddInt32(issued, 1)
+		json.NewEncoder(w).Encode(map[string]any{
+			"access_token": "at-" + string(rune('0'+n)), "token_type": "bearer",
+			"expires_in": "3600", "refresh_token": "r-1",
+		})
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestSourceCachesAndRefreshes(t *testing.T) {
+	var issued int32
+	srv := oauthServer(t, &issued)
+	st := NewStore(t.TempDir())
+	cfg := Config{Grant: ClientCredentials, TokenURL: srv.URL + "/token", ClientID: "id", ClientSecret: "s3cret"}
+	if err := st.SaveConfig("p", cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	src, err := NewSource(st, "p")
+	if err != nil || src == nil {
+		t.Fatalf("source: %v", err)
+	}
+	src.Now = func() time.Time { return now }
+
+	tok, err := src.Token(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tok.AccessToken != "at-1" || tok.Header() != "Bearer at-1" {
+		t.Fatalf("first token: %+v", tok)
+	}
+
+	// A new source (a later command) reuses the cached token.
+	src2, _ := NewSource(st, "p")
+	src2.Now = src.Now
+	if tok, _ := src2.Token(context.Background()); tok.AccessToken != "at-1" || issued != 1 {
+		t.Fatalf("cached token not reused: %+v, issued %d", tok, issued)
+	}
+
+	// Close to expiry it refreshes.
+	now = now.Add(time.Hour - 10*time.Second)
+	if tok, err := src2.Token(context.Background()); err != nil || tok.AccessToken != "at-2" {
+		t.Fatalf("refresh: %+v %v", tok, err)
+	}
+}
+
+func TestDeviceFlow(t *testing.T) {
+	var issued int32
+	srv := oauthServer(t, &issued)
+	cfg := Config{Grant: DeviceCode, TokenURL: srv.URL + "/token", DeviceAuthURL: srv.URL + "/device", ClientID: "cli"}
+
+	// Without a prompt the source refuses to start an interactive login.
+	if _, err := (&Source{Config: cfg}).Token(context.Background()); err == nil {
+		t.Fatal("want device login required error")
+	}
+
+	var code string
+	src := &Source{Config: cfg, Prompt: func(d *DeviceAuth) { code = d.UserCode }}
+	tok, err := src.Token(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code != "ABCD-EFGH" || tok.AccessToken == "" {
+		t.Fatalf("code %q token %+v", code, tok)
+	}
+}
+
+func TestTransportScopesToBaseURL(t *testing.T) {
+	var issued int32
+	srv := oauthServer(t, &issued)
+	var got string
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get("Authorization")
+	}))
+	defer api.Close()
+
+	src := &Source{Config: Config{TokenURL: srv.URL + "/token", ClientID: "id", ClientSecret: "s3cret", BaseURL: api.URL}}
+	hc := &http.Client{Transport: &Transport{Source: src}}
+
+	if _, err := hc.Get(api.URL + "/me"); err != nil {
+		t.Fatal(err)
+	}
+	if got != "Bearer at-1" {
+		t.Fatalf("Authorization = %q", got)
+	}
+
+	src.Config.BaseURL = "https://elsewhere.example"
+	got = ""
+	hc.Get(api.URL + "/me")
+	if got != "" {
+		t.Fatalf("token sent to foreign origin: %q", got)
+	}
+}
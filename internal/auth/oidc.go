@@ -0,0 +1,55 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/bspippi1337/restless/internal/store"
+)
+
+// WellKnownPaths are the discovery documents of OpenID Connect and plain
+// OAuth 2.0 authorization servers (RFC 8414).
+var WellKnownPaths = []string{
+	"/.well-known/openid-configuration",
+	"/.well-known/oauth-authorization-server",
+}
+
+type OIDCConfig struct {
+	Issuer                      string   `json:"issuer"`
+	AuthorizationEndpoint       string   `json:"authorization_endpoint,omitempty"`
+	TokenEndpoint               string   `json:"token_endpoint,omitempty"`
+	DeviceAuthorizationEndpoint string   `json:"device_authorization_endpoint,omitempty"`
+	JWKSURI                     string   `json:"jwks_uri,omitempty"`
+	GrantTypesSupported         []string `json:"grant_types_supported,omitempty"`
+	ScopesSupported             []string `json:"scopes_supported,omitempty"`
+}
+
+func ParseOIDC(body []byte) (*OIDCConfig, error) {
+	var c OIDCConfig
+	if err := json.Unmarshal(body, &c); err != nil {
+		return nil, err
+	}
+	if c.Issuer == "" || c.TokenEndpoint == "" && c.AuthorizationEndpoint == "" {
+		return nil, errors.New("auth: not an authorization server metadata document")
+	}
+	return &c, nil
+}
+
+// Scheme describes the server as an openIdConnect (or oauth2) scheme.
+func (c *OIDCConfig) Scheme(configURL string) store.AuthScheme {
+	s := store.AuthScheme{
+		ID:                     "oidc",
+		Type:                   "openIdConnect",
+		OpenIDConnectURL:       configURL,
+		AuthorizationURL:       c.AuthorizationEndpoint,
+		TokenURL:               c.TokenEndpoint,
+		DeviceAuthorizationURL: c.DeviceAuthorizationEndpoint,
+		Scopes:                 c.ScopesSupported,
+		Source:                 "oidc",
+	}
+	if !strings.HasSuffix(configURL, "/openid-configuration") {
+		s.ID, s.Type, s.OpenIDConnectURL = "oauth2", "oauth2", ""
+	}
+	return s
+}
@@ -0,0 +1,126 @@
+package auth
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+
+	"github.com/bspippi1337/restless/internal/store"
+)
+
+// OpSecurity is the effective security requirement of one operation.
+type OpSecurity struct {
+	Method  string
+	Path    string
+	Schemes []string // IDs any of which satisfies the operation
+	Public  bool     // no requirement, or an explicit empty one ({})
+}
+
+// FromOpenAPI reads components.securitySchemes and resolves each
+// operation's requirement (its own security, else the document's).
+func FromOpenAPI(doc *openapi3.T) ([]store.AuthScheme, []OpSecurity) {
+	if doc == nil {
+		return nil, nil
+	}
+
+	var schemes []store.AuthScheme
+	if doc.Components != nil {
+		names := make([]string, 0, len(doc.Components.SecuritySchemes))
+		for n := range doc.Components.SecuritySchemes {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		for _, n := range names {
+			ref := doc.Components.SecuritySchemes[n]
+			if ref == nil || ref.Value == nil {
+				continue
+			}
+			schemes = append(schemes, specScheme(n, ref.Value))
+		}
+	}
+
+	var ops []OpSecurity
+	if doc.Paths == nil {
+		return schemes, nil
+	}
+	for path, item := range doc.Paths.Map() {
+		for method, op := range item.Operations() {
+			req := doc.Security
+			if op.Security != nil {
+				req = *op.Security
+			}
+			ops = append(ops, opSecurity(strings.ToUpper(method), path, req))
+		}
+	}
+	sort.Slice(ops, func(i, j int) bool {
+		if ops[i].Path != ops[j].Path {
+			return ops[i].Path < ops[j].Path
+		}
+		return ops[i].Method < ops[j].Method
+	})
+	return schemes, ops
+}
+
+func opSecurity(method, path string, req openapi3.SecurityRequirements) OpSecurity {
+	o := OpSecurity{Method: method, Path: path}
+	if len(req) == 0 {
+		o.Public = true
+		return o
+	}
+	for _, r := range req {
+		if len(r) == 0 {
+			o.Public = true
+			continue
+		}
+		for id := range r {
+			if !contains(o.Schemes, id) {
+				o.Schemes = append(o.Schemes, id)
+			}
+		}
+	}
+	sort.Strings(o.Schemes)
+	return o
+}
+
+func specScheme(id string, ss *openapi3.SecurityScheme) store.AuthScheme {
+	s := store.AuthScheme{
+		ID:               id,
+		Type:             ss.Type,
+		Scheme:           strings.ToLower(ss.Scheme),
+		BearerFormat:     ss.BearerFormat,
+		In:               ss.In,
+		Name:             ss.Name,
+		OpenIDConnectURL: ss.OpenIdConnectUrl,
+		Source:           "openapi",
+	}
+	if f := ss.Flows; f != nil {
+		for _, flow := range []*openapi3.OAuthFlow{f.ClientCredentials, f.AuthorizationCode, f.Password, f.Implicit} {
+			if flow == nil {
+				continue
+			}
+			if s.TokenURL == "" {
+				s.TokenURL = flow.TokenURL
+			}
+			if s.AuthorizationURL == "" {
+				s.AuthorizationURL = flow.AuthorizationURL
+			}
+			for scope := range flow.Scopes {
+				if !contains(s.Scopes, scope) {
+					s.Scopes = append(s.Scopes, scope)
+				}
+			}
+		}
+		sort.Strings(s.Scopes)
+	}
+	return s
+}
+
+func contains(in []string, v string) bool {
+	for _, x := range in {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
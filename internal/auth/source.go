@@ -0,0 +1,244 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultProfile names the client registration used when no API context
+// is selected.
+const DefaultProfile = "default"
+
+// Store keeps client registrations and cached tokens under <root>/auth,
+// readable only by the owner.
+type Store struct {
+	Dir string
+}
+
+func NewStore(root string) *Store {
+	return &Store{Dir: filepath.Join(root, "auth")}
+}
+
+func (s *Store) ConfigPath(profile string) string {
+	return filepath.Join(s.Dir, profile+".json")
+}
+
+func (s *Store) TokenPath(profile string) string {
+	return filepath.Join(s.Dir, profile+".token.json")
+}
+
+// LoadConfig returns the registration for profile, or nil if there is none.
+// An empty ClientSecret is filled from RESTLESS_CLIENT_SECRET so secrets
+// need not be written to disk.
+func (s *Store) LoadConfig(profile string) (*Config, error) {
+	var c Config
+	if err := readJSON(s.ConfigPath(profile), &c); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if c.ClientSecret == "" {
+		c.ClientSecret = os.Getenv("RESTLESS_CLIENT_SECRET")
+	}
+	return &c, nil
+}
+
+func (s *Store) SaveConfig(profile string, c Config) error {
+	return writeJSON(s.ConfigPath(profile), c)
+}
+
+// LoadToken returns the cached token for profile, or nil if there is none.
+func (s *Store) LoadToken(profile string) (*Token, error) {
+	var t Token
+	if err := readJSON(s.TokenPath(profile), &t); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (s *Store) SaveToken(profile string, t *Token) error {
+	return writeJSON(s.TokenPath(profile), t)
+}
+
+// Forget removes the cached token and, with config, the registration too.
+func (s *Store) Forget(profile string, config bool) error {
+	paths := []string{s.TokenPath(profile)}
+	if config {
+		paths = append(paths, s.ConfigPath(profile))
+	}
+	for _, p := range paths {
+		if err := os.Remove(p); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+// Source hands out a valid token for one profile: the cached one while it
+// lasts, then a refreshed one, then a fresh grant. It is safe for
+// concurrent use.
+type Source struct {
+	Profile string
+	Config  Config
+	Store   *Store
+	HTTP    *http.Client
+
+	// Prompt shows the user code of a device login. Without it a device
+	// profile whose refresh token is gone fails instead of blocking.
+	Prompt func(*DeviceAuth)
+
+	Now func() time.Time
+
+	mu  sync.Mutex
+	tok *Token
+}
+
+// NewSource loads profile from store. It returns nil, nil when the
+// profile has no registration.
+func NewSource(store *Store, profile string) (*Source, error) {
+	c, err := store.LoadConfig(profile)
+	if err != nil || c == nil {
+		return nil, err
+	}
+	return &Source{Profile: profile, Config: *c, Store: store}, nil
+}
+
+// Token returns a token valid for at least the next few seconds.
+func (s *Source) Token(ctx context.Context) (*Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now
+	if s.Now != nil {
+		now = s.Now
+	}
+	hc := s.HTTP
+	if hc == nil {
+		hc = &http.Client{Timeout: 30 * time.Second}
+	}
+
+	if s.tok == nil && s.Store != nil {
+		t, err := s.Store.LoadToken(s.Profile)
+		if err != nil {
+			return nil, err
+		}
+		s.tok = t
+	}
+	if s.tok.Valid(now()) {
+		return s.tok, nil
+	}
+
+	var t *Token
+	var err error
+	if s.tok != nil && s.tok.RefreshToken != "" {
+		t, err = RefreshToken(ctx, hc, s.Config, s.tok.RefreshToken)
+	}
+	if t == nil {
+		t, err = s.grant(ctx, hc, err)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	s.tok = t
+	if s.Store != nil {
+		if err := s.Store.SaveToken(s.Profile, t); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+func (s *Source) grant(ctx context.Context, hc *http.Client, refreshErr error) (*Token, error) {
+	switch s.Config.Grant {
+	case ClientCredentials, "":
+		return ClientCredentialsToken(ctx, hc, s.Config)
+	case DeviceCode:
+		if s.Prompt == nil {
+			msg := "auth: device login required. Run: restless auth login"
+			if refreshErr != nil {
+				msg += " (refresh failed: " + refreshErr.Error() + ")"
+			}
+			return nil, errors.New(msg)
+		}
+		d, err := StartDevice(ctx, hc, s.Config)
+		if err != nil {
+			return nil, err
+		}
+		s.Prompt(d)
+		return PollDevice(ctx, hc, s.Config, d)
+	}
+	return nil, fmt.Errorf("auth: unknown grant %q", s.Config.Grant)
+}
+
+// Applies reports whether requests to rawURL should carry the token.
+func (s *Source) Applies(rawURL string) bool {
+	if s.Config.BaseURL == "" {
+		return true
+	}
+	base, err := url.Parse(s.Config.BaseURL)
+	if err != nil {
+		return false
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(u.Scheme, base.Scheme) && strings.EqualFold(u.Host, base.Host)
+}
+
+// Transport injects the token into requests for clients that do not run
+// through core/app, such as the discovery scanners.
+type Transport struct {
+	Source *Source
+	Base   http.RoundTripper
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if req.Header.Get("Authorization") != "" || !t.Source.Applies(req.URL.String()) {
+		return base.RoundTrip(req)
+	}
+	tok, err := t.Source.Token(req.Context())
+	if err != nil {
+		return nil, err
+	}
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", tok.Header())
+	return base.RoundTrip(req)
+}
+
+func readJSON(path string, v any) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+func writeJSON(path string, v any) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, b, 0600)
+}
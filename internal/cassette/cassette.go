@@ -0,0 +1,131 @@
+package cassette
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/bspippi1337/restless/internal/redact"
+)
+
+// Version of the on-disk cassette format.
+const Version = 1
+
+// Cassette is an ordered list of recorded HTTP interactions that can be
+// replayed without the upstream API.
+type Cassette struct {
+	Version      int           `json:"version"`
+	Interactions []Interaction `json:"interactions"`
+
+	mu   sync.Mutex
+	used []bool // replayed interactions, by index
+}
+
+type Interaction struct {
+	RecordedAt time.Time `json:"recorded_at"`
+	Request    Request   `json:"request"`
+	Response   Response  `json:"response"`
+}
+
+type Request struct {
+	Method  string      `json:"method"`
+	URL     string      `json:"url"`
+	Headers http.Header `json:"headers,omitempty"`
+	Body    Body        `json:"body,omitzero"`
+}
+
+type Response struct {
+	Status     int         `json:"status"`
+	Headers    http.Header `json:"headers,omitempty"`
+	Body       Body        `json:"body,omitzero"`
+	DurationMs int64       `json:"duration_ms,omitempty"`
+}
+
+// Body keeps text bodies readable and falls back to base64 for binary.
+type Body struct {
+	Text   string `json:"text,omitempty"`
+	Base64 bool   `json:"base64,omitempty"`
+}
+
+func NewBody(b []byte) Body {
+	if utf8.Valid(b) {
+		return Body{Text: string(b)}
+	}
+	return Body{Text: base64.StdEncoding.EncodeToString(b), Base64: true}
+}
+
+func (b Body) Bytes() []byte {
+	if b.Base64 {
+		out, err := base64.StdEncoding.DecodeString(b.Text)
+		if err == nil {
+			return out
+		}
+	}
+	return []byte(b.Text)
+}
+
+// redacted is the request as it is stored, and as replay compares it.
+func (r Request) redacted() Request {
+	r.URL = redact.URL(r.URL)
+	r.Body = NewBody(redact.Body(r.Headers.Get("Content-Type"), r.Body.Bytes()))
+	r.Headers = redact.Headers(r.Headers)
+	return r
+}
+
+func New() *Cassette {
+	return &Cassette{Version: Version}
+}
+
+// Add appends an interaction with secrets in headers, query strings and
+// bodies redacted.
+func (c *Cassette) Add(it Interaction) {
+	it.Request = it.Request.redacted()
+	it.Response.Body = NewBody(redact.Body(it.Response.Headers.Get("Content-Type"), it.Response.Body.Bytes()))
+	it.Response.Headers = redact.Headers(it.Response.Headers)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.Interactions = append(c.Interactions, it)
+}
+
+func (c *Cassette) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.Interactions)
+}
+
+func Load(path string) (*Cassette, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	c := New()
+	if err := json.Unmarshal(b, c); err != nil {
+		return nil, err
+	}
+	if c.Version > Version {
+		return nil, errors.New("cassette: unsupported version")
+	}
+	return c, nil
+}
+
+func (c *Cassette) Save(path string) error {
+	c.mu.Lock()
+	b, err := json.MarshalIndent(c, "", "  ")
+	c.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+	return os.WriteFile(path, b, 0644)
+}
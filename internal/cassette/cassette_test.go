@@ -0,0 +1,81 @@
+package cassette
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRecordThenReplay(t *testing.T) {
+	n := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n++
+		b, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"n":`+string(rune('0'+n))+`,"echo":`+string(b)+`,"access_token":"live-secret"}`)
+	}))
+
+	rec := &Transport{Mode: Record, Cassette: New(), Match: DefaultMatch}
+	hc := &http.Client{Transport: rec}
+	post := func(hc *http.Client, body string) (*http.Response, error) {
+		req, _ := http.NewRequest("POST", srv.URL+"/items?api_key=k1&b=2&a=1", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer live")
+		return hc.Do(req)
+	}
+	for _, body := range []string{`{"x":1}`, `{"x":1}`, `{"x":2}`} {
+		resp, err := post(hc, body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	srv.Close()
+
+	path := filepath.Join(t.TempDir(), "c.json")
+	if err := rec.Cassette.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	c, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	it := c.Interactions[0]
+	if strings.Contains(it.Request.URL, "k1") || it.Request.Headers.Get("Authorization") != "REDACTED" ||
+		strings.Contains(it.Response.Body.Text, "live-secret") {
+		t.Fatalf("secrets recorded: %+v", it)
+	}
+
+	hc = &http.Client{Transport: &Transport{Mode: Replay, Cassette: c, Match: DefaultMatch}}
+	want := []string{`"n":1`, `"n":2`, `"n":2`}
+	for i, body := range []string{`{"x":1}`, `{ "x": 1 }`, `{"x":1}`} {
+		resp, err := post(hc, body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, _ := io.ReadAll(resp.Body)
+		if !strings.Contains(string(b), want[i]) {
+			t.Fatalf("replay %d: %s", i, b)
+		}
+	}
+	if resp, err := post(hc, `{"x":2}`); err != nil || resp.StatusCode != 200 {
+		t.Fatalf("body match: %v", err)
+	}
+	if _, err := post(hc, `{"x":3}`); !errors.Is(err, ErrNoMatch) {
+		t.Fatalf("want ErrNoMatch, got %v", err)
+	}
+}
+
+func TestParseMatch(t *testing.T) {
+	m, err := ParseMatch("method, url, header:x-tenant")
+	if err != nil || !m.Method || !m.URL || m.Body || len(m.Headers) != 1 || m.Headers[0] != "X-Tenant" {
+		t.Fatalf("got %+v, %v", m, err)
+	}
+	if _, err := ParseMatch("method,cookies"); err == nil {
+		t.Fatal("want error for unknown match")
+	}
+}
@@ -0,0 +1,213 @@
+package cassette
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Modes of a Transport.
+const (
+	Record = "record"
+	Replay = "replay"
+)
+
+// ErrNoMatch is returned in replay mode for a request the cassette has no
+// interaction for.
+var ErrNoMatch = errors.New("cassette: no recorded interaction matches")
+
+// Match selects which parts of a request must agree for a recorded
+// interaction to be replayed.
+type Match struct {
+	Method  bool
+	URL     bool // scheme, host, path and query, query order ignored
+	Body    bool // SHA-256 of the body, JSON compared after re-encoding
+	Headers []string
+}
+
+var DefaultMatch = Match{Method: true, URL: true, Body: true}
+
+// ParseMatch reads a comma-separated list such as
+// "method,url,body,header:X-Tenant".
+func ParseMatch(spec string) (Match, error) {
+	var m Match
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		switch {
+		case part == "":
+		case strings.EqualFold(part, "method"):
+			m.Method = true
+		case strings.EqualFold(part, "url"):
+			m.URL = true
+		case strings.EqualFold(part, "body"):
+			m.Body = true
+		case strings.HasPrefix(strings.ToLower(part), "header:"):
+			m.Headers = append(m.Headers, http.CanonicalHeaderKey(part[len("header:"):]))
+		default:
+			return Match{}, fmt.Errorf("cassette: unknown match %q (want method, url, body or header:<name>)", part)
+		}
+	}
+	return m, nil
+}
+
+func (m Match) key(r Request) string {
+	var b strings.Builder
+	if m.Method {
+		b.WriteString(strings.ToUpper(r.Method))
+	}
+	b.WriteByte('|')
+	if m.URL {
+		b.WriteString(canonicalURL(r.URL))
+	}
+	b.WriteByte('|')
+	if m.Body {
+		b.WriteString(BodyHash(r.Headers.Get("Content-Type"), r.Body.Bytes()))
+	}
+	for _, h := range m.Headers {
+		b.WriteByte('|')
+		b.WriteString(strings.Join(r.Headers.Values(h), ","))
+	}
+	return b.String()
+}
+
+// BodyHash is the hex SHA-256 of a body; JSON bodies are re-encoded first
+// so key order and whitespace do not matter.
+func BodyHash(contentType string, body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+	if strings.Contains(strings.ToLower(contentType), "json") {
+		var v any
+		if json.Unmarshal(body, &v) == nil {
+			if b, err := json.Marshal(v); err == nil {
+				body = b
+			}
+		}
+	}
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+func canonicalURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+	u.Host = strings.ToLower(u.Host)
+	u.RawQuery = u.Query().Encode()
+	u.Fragment = ""
+	return u.String()
+}
+
+// Find returns the recorded response for req. Identical requests replay
+// their recordings in order; once those run out the last one repeats.
+func (c *Cassette) Find(req Request, m Match) (Response, bool) {
+	key := m.key(req.redacted())
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.used) != len(c.Interactions) {
+		c.used = make([]bool, len(c.Interactions))
+	}
+
+	last := -1
+	for i, it := range c.Interactions {
+		if m.key(it.Request) != key {
+			continue
+		}
+		if !c.used[i] {
+			c.used[i] = true
+			return it.Response, true
+		}
+		last = i
+	}
+	if last >= 0 {
+		return c.Interactions[last].Response, true
+	}
+	return Response{}, false
+}
+
+// Transport records exchanges into, or replays them from, a cassette.
+// Install it under any http.Client, including the one behind
+// engine.HTTPRunner, to make a run offline and deterministic.
+type Transport struct {
+	Mode     string
+	Cassette *Cassette
+	Match    Match
+	Base     http.RoundTripper
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		b, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		body = b
+		req = req.Clone(req.Context())
+		req.Body = io.NopCloser(bytes.NewReader(b))
+	}
+	recReq := Request{
+		Method:  req.Method,
+		URL:     req.URL.String(),
+		Headers: req.Header.Clone(),
+		Body:    NewBody(body),
+	}
+
+	if t.Mode == Replay {
+		resp, ok := t.Cassette.Find(recReq, t.Match)
+		if !ok {
+			return nil, fmt.Errorf("%w: %s %s", ErrNoMatch, req.Method, recReq.redacted().URL)
+		}
+		b := resp.Body.Bytes()
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", resp.Status, http.StatusText(resp.Status)),
+			StatusCode:    resp.Status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        resp.Headers.Clone(),
+			Body:          io.NopCloser(bytes.NewReader(b)),
+			ContentLength: int64(len(b)),
+			Request:       req,
+		}, nil
+	}
+
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	start := time.Now()
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	t.Cassette.Add(Interaction{
+		RecordedAt: start.UTC(),
+		Request:    recReq,
+		Response: Response{
+			Status:     resp.StatusCode,
+			Headers:    resp.Header.Clone(),
+			Body:       NewBody(respBody),
+			DurationMs: time.Since(start).Milliseconds(),
+		},
+	})
+	return resp, nil
+}
@@ -0,0 +1,304 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bspippi1337/restless/internal/auth"
+	"github.com/bspippi1337/restless/internal/core/app"
+	"github.com/bspippi1337/restless/internal/modules/oauth"
+	"github.com/bspippi1337/restless/internal/store"
+)
+
+func NewAuthCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "auth",
+		Short: "Obtain and cache OAuth2 tokens for protected APIs",
+		Long: `Register an OAuth2 client once with "restless auth login"; call, flow,
+shell, learn and gql then send a cached access token, refreshing it
+before it expires. Profiles follow --api (default: "default").`,
+	}
+
+	cmd.AddCommand(newAuthLoginCmd())
+	cmd.AddCommand(newAuthTokenCmd())
+	cmd.AddCommand(newAuthStatusCmd())
+	cmd.AddCommand(newAuthLogoutCmd())
+	return cmd
+}
+
+func newAuthLoginCmd() *cobra.Command {
+	var c auth.Config
+	var grant string
+	var timeout time.Duration
+
+	cmd := &cobra.Command{
+		Use:   "login",
+		Short: "Register an OAuth2 client and fetch a first token",
+		Long: `Register an OAuth2 client and fetch a first token.
+
+Token and device URLs default to what discovery found (see restless learn).
+The client secret may be given through RESTLESS_CLIENT_SECRET instead of
+--client-secret, in which case it is never written to disk.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			st, profile, root := authStore(cmd)
+			apiName, _ := cmd.Root().PersistentFlags().GetString("api")
+			if api, err := store.Read(root, apiName); err == nil {
+				defaultsFromAPI(&c, api)
+			}
+
+			switch grant {
+			case "":
+				c.Grant = auth.ClientCredentials
+				if c.ClientSecret == "" && os.Getenv("RESTLESS_CLIENT_SECRET") == "" && c.DeviceAuthURL != "" {
+					c.Grant = auth.DeviceCode
+				}
+			case "client-credentials", auth.ClientCredentials:
+				c.Grant = auth.ClientCredentials
+			case "device", auth.DeviceCode:
+				c.Grant = auth.DeviceCode
+			default:
+				return fmt.Errorf("unknown --grant %q (want client-credentials or device)", grant)
+			}
+			if c.TokenURL == "" {
+				return fmt.Errorf("no token URL known. Pass --token-url or run: restless learn <url>")
+			}
+			if c.ClientID == "" {
+				return fmt.Errorf("--client-id is required")
+			}
+
+			if err := st.SaveConfig(profile, c); err != nil {
+				return err
+			}
+			if err := st.Forget(profile, false); err != nil {
+				return err
+			}
+
+			src, err := auth.NewSource(st, profile)
+			if err != nil {
+				return err
+			}
+			src.Prompt = devicePrompt(cmd.ErrOrStderr())
+
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+			tok, err := src.Token(ctx)
+			if err != nil {
+				return err
+			}
+
+			out := cmd.OutOrStdout()
+			fmt.Fprintf(out, "Profile: %s (%s)\n", profile, c.Grant)
+			fmt.Fprintf(out, "Token: %s\n", tokenSummary(tok))
+			fmt.Fprintf(out, "Saved → %s\n", st.TokenPath(profile))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&grant, "grant", "", "client-credentials or device (default: device when there is no secret but a device URL)")
+	cmd.Flags().StringVar(&c.TokenURL, "token-url", "", "token endpoint")
+	cmd.Flags().StringVar(&c.DeviceAuthURL, "device-url", "", "device authorization endpoint")
+	cmd.Flags().StringVar(&c.ClientID, "client-id", "", "client id")
+	cmd.Flags().StringVar(&c.ClientSecret, "client-secret", "", "client secret (stored 0600; prefer RESTLESS_CLIENT_SECRET)")
+	cmd.Flags().StringSliceVar(&c.Scopes, "scope", nil, "scope to request (repeatable)")
+	cmd.Flags().StringVar(&c.Audience, "audience", "", "audience parameter, for servers that need one")
+	cmd.Flags().StringVar(&c.BaseURL, "base", "", "only send the token to this origin (default: the learned API)")
+	cmd.Flags().DurationVarP(&timeout, "timeout", "t", 10*time.Minute, "how long to wait for the token (device logins included)")
+
+	return cmd
+}
+
+func newAuthTokenCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "token",
+		Short: "Print a valid access token, refreshing it if needed",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			src, err := authSource(cmd)
+			if err != nil {
+				return err
+			}
+			if src == nil {
+				return fmt.Errorf("no OAuth2 client registered. Run: restless auth login")
+			}
+			tok, err := src.Token(context.Background())
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), tok.AccessToken)
+			return nil
+		},
+	}
+}
+
+func newAuthStatusCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "status",
+		Short: "Show the registered client and cached token",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			st, profile, _ := authStore(cmd)
+			c, err := st.LoadConfig(profile)
+			if err != nil {
+				return err
+			}
+			out := cmd.OutOrStdout()
+			if c == nil {
+				fmt.Fprintf(out, "Profile %s: no OAuth2 client registered\n", profile)
+				return nil
+			}
+			fmt.Fprintf(out, "Profile: %s\n", profile)
+			fmt.Fprintf(out, "Grant: %s\n", c.Grant)
+			fmt.Fprintf(out, "Token URL: %s\n", c.TokenURL)
+			if c.DeviceAuthURL != "" {
+				fmt.Fprintf(out, "Device URL: %s\n", c.DeviceAuthURL)
+			}
+			fmt.Fprintf(out, "Client: %s\n", c.ClientID)
+			if len(c.Scopes) > 0 {
+				fmt.Fprintf(out, "Scopes: %s\n", strings.Join(c.Scopes, " "))
+			}
+			if c.BaseURL != "" {
+				fmt.Fprintf(out, "Sent to: %s\n", c.BaseURL)
+			}
+
+			tok, err := st.LoadToken(profile)
+			if err != nil {
+				return err
+			}
+			if tok == nil {
+				fmt.Fprintln(out, "Token: none cached")
+				return nil
+			}
+			fmt.Fprintf(out, "Token: %s\n", tokenSummary(tok))
+			return nil
+		},
+	}
+}
+
+func newAuthLogoutCmd() *cobra.Command {
+	var all bool
+
+	cmd := &cobra.Command{
+		Use:   "logout",
+		Short: "Forget the cached token (and with --all, the client)",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			st, profile, _ := authStore(cmd)
+			if err := st.Forget(profile, all); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Logged out of %s\n", profile)
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&all, "all", false, "also remove the client registration")
+	return cmd
+}
+
+// authStore resolves the token store and profile for the current --api.
+func authStore(cmd *cobra.Command) (*auth.Store, string, string) {
+	cacheRoot, _ := cmd.Root().PersistentFlags().GetString("cache")
+	apiName, _ := cmd.Root().PersistentFlags().GetString("api")
+	cacheRoot, _ = store.DefaultRoot(cacheRoot)
+
+	profile := apiName
+	if profile == "" {
+		profile = auth.DefaultProfile
+	}
+	return auth.NewStore(cacheRoot), profile, cacheRoot
+}
+
+// authSource returns the token source of the current profile, or nil when
+// no OAuth2 client is registered.
+func authSource(cmd *cobra.Command) (*auth.Source, error) {
+	st, profile, _ := authStore(cmd)
+	src, err := auth.NewSource(st, profile)
+	if err != nil || src == nil {
+		return nil, err
+	}
+	src.Prompt = devicePrompt(cmd.ErrOrStderr())
+	return src, nil
+}
+
+// authModules is the oauth module for app.New, if a client is registered.
+func authModules(cmd *cobra.Command) ([]app.Module, error) {
+	src, err := authSource(cmd)
+	if err != nil || src == nil {
+		return nil, err
+	}
+	return []app.Module{oauth.New(src)}, nil
+}
+
+// authHTTPClient is an http.Client that sends the profile's token, for
+// code that does not run through core/app.
+func authHTTPClient(cmd *cobra.Command, timeout time.Duration) (*http.Client, error) {
+	c := &http.Client{Timeout: timeout}
+	src, err := authSource(cmd)
+	if err != nil {
+		return nil, err
+	}
+	if src != nil {
+		c.Transport = &auth.Transport{Source: src}
+	}
+	return c, nil
+}
+
+func defaultsFromAPI(c *auth.Config, api *store.API) {
+	if c.BaseURL == "" {
+		c.BaseURL = api.BaseURL
+	}
+	if api.Auth == nil {
+		return
+	}
+	for _, s := range api.Auth.Schemes {
+		if s.TokenURL == "" {
+			continue
+		}
+		if c.TokenURL == "" {
+			c.TokenURL = s.TokenURL
+		}
+		if c.DeviceAuthURL == "" {
+			c.DeviceAuthURL = s.DeviceAuthorizationURL
+		}
+	}
+}
+
+func devicePrompt(w io.Writer) func(*auth.DeviceAuth) {
+	return func(d *auth.DeviceAuth) {
+		fmt.Fprintln(w, "To sign in, open:")
+		if d.VerificationURIComplete != "" {
+			fmt.Fprintf(w, "  %s\n", d.VerificationURIComplete)
+		} else {
+			fmt.Fprintf(w, "  %s\n", d.VerificationURI)
+		}
+		fmt.Fprintf(w, "and enter the code: %s\n", d.UserCode)
+		fmt.Fprintln(w, "Waiting for approval...")
+	}
+}
+
+func tokenSummary(t *auth.Token) string {
+	s := t.TokenType
+	if s == "" {
+		s = "bearer"
+	}
+	if t.Expiry.IsZero() {
+		s += ", no expiry"
+	} else if d := time.Until(t.Expiry).Round(time.Second); d > 0 {
+		s += ", expires in " + d.String()
+	} else {
+		s += ", expired"
+	}
+	if t.RefreshToken != "" {
+		s += ", refreshable"
+	}
+	return s
+}
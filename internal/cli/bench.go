@@ -0,0 +1,76 @@
+package cli
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bspippi1337/restless/internal/core/engine"
+	"github.com/bspippi1337/restless/internal/core/types"
+	"github.com/bspippi1337/restless/internal/modules/bench"
+)
+
+func NewBenchCmd() *cobra.Command {
+	var method string
+	var data string
+	var headers []string
+	var concurrency int
+	var duration time.Duration
+	var metricsAddr string
+
+	cmd := &cobra.Command{
+		Use:   "bench <url>",
+		Short: "Send one request repeatedly and report latency percentiles",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			stopMetrics, err := startMetrics(cmd, metricsAddr)
+			if err != nil {
+				return err
+			}
+			defer stopMetrics()
+
+			target := args[0]
+			if !strings.HasPrefix(target, "http://") && !strings.HasPrefix(target, "https://") {
+				target = "https://" + target
+			}
+			req := types.Request{
+				Method:  strings.ToUpper(method),
+				URL:     target,
+				Headers: http.Header{},
+				Body:    []byte(data),
+			}
+			for k, v := range parseHeaders(headers) {
+				req.Headers.Set(k, v)
+			}
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			fmt.Fprintf(cmd.ErrOrStderr(), "Benchmarking %s %s for %s with %d workers\n", req.Method, req.URL, duration, concurrency)
+			res, err := bench.Run(ctx, engine.NewHTTPRunner(nil), bench.Config{
+				Concurrency: concurrency,
+				Duration:    duration,
+				Request:     req,
+			})
+			if err != nil {
+				return err
+			}
+			bench.PrintTable(res)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&method, "method", "X", http.MethodGet, "HTTP method")
+	cmd.Flags().StringVarP(&data, "data", "d", "", "request body")
+	cmd.Flags().StringArrayVarP(&headers, "header", "H", nil, "header to send (repeatable)")
+	cmd.Flags().IntVar(&concurrency, "concurrency", 4, "requests in flight")
+	cmd.Flags().DurationVar(&duration, "duration", 10*time.Second, "how long to run")
+	addMetricsFlag(cmd, &metricsAddr)
+	return cmd
+}
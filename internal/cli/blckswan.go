@@ -10,8 +10,12 @@ import (
 	"strings"
 	"time"
 
+	"github.com/bspippi1337/restless/internal/audit"
+	"github.com/bspippi1337/restless/internal/council"
 	"github.com/bspippi1337/restless/internal/recon"
+	"github.com/bspippi1337/restless/internal/store"
 	"github.com/bspippi1337/restless/internal/topology"
+	"github.com/bspippi1337/restless/internal/version"
 	"github.com/spf13/cobra"
 )
 
@@ -24,6 +28,7 @@ type BlckswanReport struct {
 	GraphQL   *recon.GraphQLInfo `json:"graphql,omitempty"`
 	Notes     []string           `json:"notes,omitempty"`
 	RateHints map[string]string  `json:"rate_hints,omitempty"`
+	Security  []audit.Finding    `json:"security,omitempty"`
 	Topology  string             `json:"topology_ascii"`
 }
 
@@ -34,6 +39,7 @@ func NewBlckswanCmd() *cobra.Command {
 	var wordlist string
 	var header []string
 	var noGraphQL bool
+	var noAudit bool
 
 	cmd := &cobra.Command{
 		Use:   "blckswan <url>",
@@ -79,20 +85,38 @@ func NewBlckswanCmd() *cobra.Command {
 				}
 			}
 
+			sensitive := map[string]bool{}
+			if !noAudit {
+				for _, p := range audit.SensitiveSeeds() {
+					sensitive[p] = true
+					seeds = append(seeds, p)
+				}
+			}
+
 			ctx := context.Background()
+			sec := audit.New()
 			found := map[string]bool{}
 			openapiPaths := map[string]bool{}
 			var rateHints map[string]string
 			var notes []string
+			var evidence []council.Finding
+			saw := func(engine, method, path, how string, conf float64) {
+				evidence = append(evidence, council.Finding{
+					Engine: engine, Kind: council.KindEndpoint, Target: path, Method: method,
+					Evidence: how, Confidence: conf, Timestamp: time.Now(),
+				})
+			}
 
 			// Root probe for same-host URL harvesting
 			if rootResp, err := e.Request(ctx, "GET", target, nil); err == nil {
+				sec.Observe(auditObservation(rootResp, ""))
 				if len(rootResp.Headers) > 0 {
 					rateHints = rootResp.Headers
 				}
 				if recon.LooksJSON(rootResp.ContentType, rootResp.Body) {
 					for _, p := range recon.ExtractSameHostPaths(u.Host, rootResp.Body) {
 						found[p] = true
+						saw(council.Hypermedia, "", p, "linked from the root document", 0.6)
 					}
 				}
 			}
@@ -109,8 +133,15 @@ func NewBlckswanCmd() *cobra.Command {
 				if err != nil {
 					continue
 				}
+				sec.Observe(auditObservation(resp, ""))
+				if sensitive[p] {
+					// Audit-only seeds stay out of the map; exposures
+					// surface as security findings instead.
+					continue
+				}
 				if resp.Status < 500 {
 					found[p] = true
+					saw(council.Wordlist, "GET", p, fmt.Sprintf("GET → %d", resp.Status), council.StatusConfidence(resp.Status))
 					if recon.LooksJSON(resp.ContentType, resp.Body) {
 						paths := recon.TryExtractOpenAPIPaths(resp.Body)
 						if len(paths) > 0 {
@@ -118,19 +149,34 @@ func NewBlckswanCmd() *cobra.Command {
 							for _, op := range paths {
 								openapiPaths[op] = true
 								found[op] = true
+								saw(council.OpenAPI, "", op, "declared in "+p, 1)
 							}
 						}
 					}
 				}
 			}
 
+			// One preflight from a foreign origin shows whether CORS
+			// reflects whatever origin asks.
+			if !noAudit {
+				pe := *e
+				pe.Headers = map[string]string{"Origin": audit.ProbeOrigin, "Access-Control-Request-Method": "GET"}
+				for k, v := range e.Headers {
+					pe.Headers[k] = v
+				}
+				if resp, err := pe.Request(ctx, "OPTIONS", target, nil); err == nil {
+					sec.Observe(auditObservation(resp, audit.ProbeOrigin))
+				}
+			}
+
 			var gql *recon.GraphQLInfo
 			if !noGraphQL {
 				g, _ := recon.TryGraphQLIntrospection(ctx, e, u)
 				if g != nil && (g.Introspection || g.Note != "not-found") {
 					gql = g
 					if g.Introspection {
-						found["/graphql"] = true
+						found[g.Endpoint] = true
+						saw(council.Wordlist, "POST", g.Endpoint, "answers GraphQL introspection", 0.9)
 					}
 				}
 			}
@@ -160,9 +206,19 @@ func NewBlckswanCmd() *cobra.Command {
 				GraphQL:   gql,
 				Notes:     notes,
 				RateHints: rateHints,
+				Security:  sec.Findings(),
 				Topology:  ascii,
 			}
 
+			cacheRoot, _ := cmd.Root().PersistentFlags().GetString("cache")
+			cacheRoot, err = store.DefaultRoot(cacheRoot)
+			if err != nil {
+				return err
+			}
+			if err := council.Record(council.EvidencePath(cacheRoot, target), evidence); err != nil {
+				return err
+			}
+
 			if outDir == "" {
 				outDir = "dist"
 			}
@@ -177,24 +233,39 @@ func NewBlckswanCmd() *cobra.Command {
 			asciiPath := filepath.Join(outDir, base+".topology.txt")
 			svgPath := filepath.Join(outDir, base+".map.svg")
 			mdPath := filepath.Join(outDir, base+".summary.md")
+			sarifPath := filepath.Join(outDir, base+".security.sarif")
 
 			b, _ := json.MarshalIndent(rep, "", "  ")
 			_ = os.WriteFile(jsonPath, b, 0o644)
 			_ = os.WriteFile(asciiPath, []byte(ascii+"\n"), 0o644)
 			_ = os.WriteFile(svgPath, []byte(svg), 0o644)
 			_ = os.WriteFile(mdPath, []byte(buildSummaryMarkdown(rep, filepath.Base(svgPath))), 0o644)
+			if !noAudit {
+				if sarif, err := audit.ToSARIF(version.String(), rep.Security); err == nil {
+					_ = os.WriteFile(sarifPath, sarif, 0o644)
+				}
+			}
 
 			fmt.Println("target:", target)
 			if gql != nil {
-				fmt.Println("graphql:", gql.Note, "introspection:", gql.Introspection, "types:", gql.Types)
+				fmt.Println("graphql:", gql.Endpoint, gql.Note, "introspection:", gql.Introspection, "types:", gql.Types,
+					"queries:", gql.Queries, "mutations:", gql.Mutations, "subscriptions:", gql.Subscriptions)
 			}
 			fmt.Println()
 			fmt.Print(ascii)
 			fmt.Println()
+			if !noAudit {
+				fmt.Println("SECURITY")
+				fmt.Println("--------")
+				fmt.Print(audit.PrintHuman(rep.Security))
+			}
 			fmt.Println("report:", jsonPath)
 			fmt.Println("topology:", asciiPath)
 			fmt.Println("map:", svgPath)
 			fmt.Println("summary:", mdPath)
+			if !noAudit {
+				fmt.Println("sarif:", sarifPath)
+			}
 
 			return nil
 		},
@@ -206,6 +277,7 @@ func NewBlckswanCmd() *cobra.Command {
 	cmd.Flags().StringVar(&wordlist, "wordlist", "", "extra paths (one per line)")
 	cmd.Flags().StringArrayVar(&header, "header", nil, "extra header (repeatable), e.g. --header 'Authorization: Bearer ...'")
 	cmd.Flags().BoolVar(&noGraphQL, "no-graphql", false, "skip GraphQL introspection probe")
+	cmd.Flags().BoolVar(&noAudit, "no-audit", false, "skip the passive security audit and its sensitive-path seeds")
 
 	return cmd
 }
@@ -236,7 +308,7 @@ func buildSummaryMarkdown(rep BlckswanReport, svgFile string) string {
 
 	if rep.GraphQL != nil {
 		b.WriteString("## GraphQL\n\n")
-		b.WriteString("- endpoint: `/graphql`\n")
+		b.WriteString("- endpoint: `" + rep.GraphQL.Endpoint + "`\n")
 		b.WriteString("- introspection: ")
 		if rep.GraphQL.Introspection {
 			b.WriteString("yes\n")
@@ -246,6 +318,18 @@ func buildSummaryMarkdown(rep BlckswanReport, svgFile string) string {
 		b.WriteString("- note: `" + rep.GraphQL.Note + "`\n\n")
 	}
 
+	if len(rep.Security) > 0 {
+		b.WriteString("## Security\n\n")
+		for _, f := range rep.Security {
+			line := "- **" + string(f.Severity) + "** " + f.Title
+			if f.Detail != "" {
+				line += " — `" + f.Detail + "`"
+			}
+			b.WriteString(line + "\n")
+		}
+		b.WriteString("\n")
+	}
+
 	b.WriteString("## Next moves\n\n")
 	b.WriteString("- Rerun with auth header if needed.\n")
 	b.WriteString("- Provide a wordlist to expand discovery.\n")
@@ -253,3 +337,14 @@ func buildSummaryMarkdown(rep BlckswanReport, svgFile string) string {
 
 	return b.String()
 }
+
+func auditObservation(r *recon.Response, origin string) audit.Observation {
+	return audit.Observation{
+		Method: r.Method,
+		URL:    r.URL,
+		Status: r.Status,
+		Header: r.Header,
+		Body:   r.Body,
+		Origin: origin,
+	}
+}
@@ -1,18 +1,24 @@
 package cli
 
 import (
+	"bufio"
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
+	"net/http"
 	"os"
 	"strings"
-	"text/tabwriter"
 	"time"
 
-	"github.com/bspippi1337/restless/internal/httpx"
+	"github.com/bspippi1337/restless/internal/auth"
+	"github.com/bspippi1337/restless/internal/core/app"
+	"github.com/bspippi1337/restless/internal/core/types"
+	"github.com/bspippi1337/restless/internal/modules/openapi"
+	"github.com/bspippi1337/restless/internal/output"
+	"github.com/bspippi1337/restless/internal/pagination"
 	"github.com/bspippi1337/restless/internal/store"
 	"github.com/bspippi1337/restless/internal/util"
+	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/spf13/cobra"
 )
 
@@ -20,6 +26,12 @@ func NewCallCmd() *cobra.Command {
 
 	var timeout time.Duration
 	var table bool
+	var spec string
+	var noValidate bool
+	var strict bool
+	var allPages bool
+	var maxPages int
+	var out output.Options
 
 	cmd := &cobra.Command{
 		Use:   "call <METHOD> <PATH>",
@@ -30,6 +42,12 @@ func NewCallCmd() *cobra.Command {
 
 			method := strings.ToUpper(args[0])
 			path := args[1]
+			if table && out.Format == "" {
+				out.Format = output.Table
+			}
+			if err := out.Check(); err != nil {
+				return err
+			}
 
 			apiName, _ := cmd.Root().PersistentFlags().GetString("api")
 			cacheRoot, _ := cmd.Root().PersistentFlags().GetString("cache")
@@ -43,83 +61,160 @@ func NewCallCmd() *cobra.Command {
 
 			url := util.JoinURL(api.BaseURL, path)
 
-			ctx, cancel := context.WithTimeout(context.Background(), timeout)
-
-			defer cancel()
-			client := httpx.New()
-
-			res, err := client.Do(ctx, method, url, nil)
+			mods, err := authModules(cmd)
 			if err != nil {
 				return err
 			}
-
-			body, err := io.ReadAll(res.Body)
-			res.Body.Close()
+			if !noValidate {
+				pf, err := preflight(cmd, spec)
+				if err != nil {
+					return err
+				}
+				mods = append(mods, pf)
+			}
+			contract := contractModule(cmd)
+			a, err := app.New(append(mods, contract))
 			if err != nil {
 				return err
 			}
 
-			fmt.Println(method, url)
-			fmt.Println(res.Status)
+			if allPages {
+				if method != http.MethodGet {
+					return fmt.Errorf("--all-pages pages GET requests, not %s", method)
+				}
+				var pf *openapi.Preflight
+				for _, m := range mods {
+					if p, ok := m.(*openapi.Preflight); ok {
+						pf = p
+					}
+				}
+				if err := callAllPages(cmd, a, url, pagingFor(api, path, pf, url), maxPages, timeout, out); err != nil {
+					return err
+				}
+				reportDrift(cmd, contract)
+				return strictDrift(contract, strict)
+			}
 
-			if table {
-				return renderTable(body)
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			res, err := a.RunOnce(ctx, types.Request{Method: method, URL: url})
+			if err != nil {
+				return err
 			}
+			body := res.Body
 
-			return renderJSON(body)
+			w := statusOut(cmd, out)
+			fmt.Fprintln(w, method, url)
+			fmt.Fprintln(w, res.StatusCode, http.StatusText(res.StatusCode))
+			if hint := auth.Hint(api, path, res.StatusCode); hint != "" {
+				fmt.Fprintln(os.Stderr, "Auth:", hint)
+			}
+
+			if err := output.Render(cmd.OutOrStdout(), body, out); err != nil {
+				return err
+			}
+			reportDrift(cmd, contract)
+			return strictDrift(contract, strict)
 		},
 	}
 
 	cmd.Flags().DurationVarP(&timeout, "timeout", "t", 10*time.Second, "timeout")
-	cmd.Flags().BoolVar(&table, "table", false, "render JSON array as table")
+	cmd.Flags().BoolVar(&table, "table", false, "same as -o table")
+	cmd.Flags().BoolVar(&allPages, "all-pages", false, "follow the pagination and print the items of every page as JSON lines")
+	cmd.Flags().IntVar(&maxPages, "max-pages", 100, "with --all-pages, stop after this many pages (0: no limit)")
+	cmd.Flags().StringVar(&spec, "spec", "", "OpenAPI spec to check the call against (file, URL or imported spec); default: the imported spec for the API's base URL")
+	addOutputFlags(cmd, &out)
+	addNoValidateFlag(cmd, &noValidate)
+	addStrictFlag(cmd, &strict)
 
 	return cmd
 }
 
-func renderJSON(body []byte) error {
-
-	var v interface{}
-
-	if json.Unmarshal(body, &v) != nil {
-		fmt.Println(string(body))
-		return nil
+// pagingFor is how the endpoint at path pages: as discovery recorded it,
+// or as its OpenAPI operation describes it. nil leaves it to detection
+// on the first page.
+func pagingFor(api *store.API, path string, pf *openapi.Preflight, url string) *store.Pagination {
+	if ep, ok := api.Lookup(path); ok && ep.Pagination != nil {
+		return ep.Pagination
+	}
+	var doc *openapi3.T
+	if pf != nil {
+		doc = pf.Doc
+	}
+	if op, params, ok := openapi.OperationFor(doc, http.MethodGet, url); ok {
+		return pagination.FromOperation(op, params)
 	}
-
-	pretty, _ := json.MarshalIndent(v, "", "  ")
-	fmt.Println(string(pretty))
 	return nil
 }
 
-func renderTable(body []byte) error {
-
-	var rows []map[string]interface{}
-
-	if json.Unmarshal(body, &rows) != nil {
-		return renderJSON(body)
+// callAllPages walks every page from url. Items are printed as JSON
+// lines as they arrive, or gathered into one document for the other
+// output formats; --query applies to each item.
+func callAllPages(cmd *cobra.Command, a *app.App, url string, p *store.Pagination, maxPages int, timeout time.Duration, o output.Options) error {
+	fetch := func(ctx context.Context, u string) (types.Response, error) {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		return a.RunOnce(ctx, types.Request{Method: http.MethodGet, URL: u})
 	}
 
-	if len(rows) == 0 {
+	var q *output.Query
+	if o.Query != "" {
+		var err error
+		if q, err = output.Compile(o.Query); err != nil {
+			return err
+		}
+	}
+	stream := o.Format == "" || o.Format == output.NDJSON
+	w := bufio.NewWriter(cmd.OutOrStdout())
+	var rows []any
+	emit := func(item json.RawMessage) error {
+		v, err := output.Decode(item)
+		if err != nil {
+			v = string(item)
+		}
+		results := []any{v}
+		if q != nil {
+			if results, err = q.Run(v); err != nil {
+				return err
+			}
+		}
+		if !stream {
+			rows = append(rows, results...)
+			return nil
+		}
+		for _, r := range results {
+			if _, err := w.Write(append(output.Encode(r, ""), '\n')); err != nil {
+				return err
+			}
+		}
 		return nil
 	}
 
-	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-
-	var headers []string
-	for k := range rows[0] {
-		headers = append(headers, k)
+	res, err := pagination.Walk(cmd.Context(), url, p, maxPages, fetch, emit)
+	if ferr := w.Flush(); err == nil {
+		err = ferr
 	}
-
-	fmt.Fprintln(w, strings.Join(headers, "\t"))
-
-	for _, r := range rows {
-		var line []string
-		for _, h := range headers {
-			line = append(line, fmt.Sprintf("%v", r[h]))
+	if err != nil {
+		return err
+	}
+	if !stream {
+		if rows == nil {
+			rows = []any{}
+		}
+		o.Query = ""
+		if err := output.Render(cmd.OutOrStdout(), output.Encode(rows, ""), o); err != nil {
+			return err
 		}
-		fmt.Fprintln(w, strings.Join(line, "\t"))
 	}
 
-	w.Flush()
-
+	style := res.Style
+	if style == "" {
+		style = "single page"
+	}
+	fmt.Fprintf(cmd.ErrOrStderr(), "%d pages, %d items (%s)\n", res.Pages, res.Items, style)
+	if res.Truncated {
+		fmt.Fprintf(cmd.ErrOrStderr(), "Stopped at --max-pages %d; there are more pages.\n", maxPages)
+	}
 	return nil
 }
@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bspippi1337/restless/internal/cassette"
+)
+
+// cassetteRun is the record/replay session of the current command, if any.
+type cassetteRun struct {
+	mode string
+	path string
+	c    *cassette.Cassette
+	prev http.RoundTripper
+}
+
+var activeCassette *cassetteRun
+
+// startCassette installs a record/replay transport as http.DefaultTransport
+// so every client that doesn't bring its own transport — call, flow,
+// validate, guard, discovery — records to or replays from the cassette.
+// Swapping the global from the root's PersistentPreRunE is the whole
+// mechanism: core/engine.NewHTTPRunner, httpx.New and the other client
+// constructors are not handed a transport, and must keep leaving
+// http.Client.Transport nil for recording to see their requests.
+// RESTLESS_RECORD and RESTLESS_REPLAY stand in for the flags in CI.
+func startCassette(cmd *cobra.Command) error {
+	flags := cmd.Root().PersistentFlags()
+	record, _ := flags.GetString("record")
+	replay, _ := flags.GetString("replay")
+	matchSpec, _ := flags.GetString("match")
+	if record == "" && replay == "" {
+		record, replay = os.Getenv("RESTLESS_RECORD"), os.Getenv("RESTLESS_REPLAY")
+	}
+	if record == "" && replay == "" {
+		return nil
+	}
+	if record != "" && replay != "" {
+		return fmt.Errorf("--record and --replay are mutually exclusive")
+	}
+
+	m, err := cassette.ParseMatch(matchSpec)
+	if err != nil {
+		return err
+	}
+
+	run := &cassetteRun{mode: cassette.Record, path: record, c: cassette.New(), prev: http.DefaultTransport}
+	if replay != "" {
+		run.mode, run.path = cassette.Replay, replay
+		if run.c, err = cassette.Load(replay); err != nil {
+			return err
+		}
+	}
+
+	http.DefaultTransport = &cassette.Transport{Mode: run.mode, Cassette: run.c, Match: m, Base: run.prev}
+	activeCassette = run
+	return nil
+}
+
+// finishCassette restores the real transport and saves a recording.
+func finishCassette() error {
+	run := activeCassette
+	if run == nil {
+		return nil
+	}
+	activeCassette = nil
+	http.DefaultTransport = run.prev
+
+	if run.mode != cassette.Record {
+		return nil
+	}
+	if err := run.c.Save(run.path); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Cassette → %s (%d interactions)\n", run.path, run.c.Len())
+	return nil
+}
@@ -1,24 +1,134 @@
 package cli
 
 import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
 	"github.com/spf13/cobra"
 
-	"github.com/bspippi1337/restless/internal/app"
 	"github.com/bspippi1337/restless/internal/council"
+	"github.com/bspippi1337/restless/internal/store"
 )
 
 func NewCouncilCmd() *cobra.Command {
+	var flags councilFlags
+	var verbose bool
 
 	cmd := &cobra.Command{
-		Use:   "council",
-		Short: "run engine council consensus meeting",
-		Run: func(cmd *cobra.Command, args []string) {
+		Use:   "council [target]",
+		Short: "Rank endpoints by how strongly the discovery engines agree",
+		Long: `Every discovery engine records what it saw: "restless discover" runs
+openapi, crawl, hypermedia and wordlist, "restless blckswan" adds its
+seeds and detected specs, "restless learn" what the root document
+links to, and "restless learn --har" and "restless proxy" the traffic
+they saw. The council weighs that evidence per engine, lets old
+evidence decay, and combines what the engines say about each endpoint
+into one confidence.
+
+Without a target it uses the API of the last discovery.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cacheRoot, _ := cmd.Root().PersistentFlags().GetString("cache")
+			cacheRoot, _ = store.DefaultRoot(cacheRoot)
+
+			opt, err := flags.options()
+			if err != nil {
+				return err
+			}
 
-			c := council.NewCouncil(app.GlobalBlackboard)
-			c.Convene()
+			target := ""
+			if len(args) == 1 {
+				target = strings.TrimRight(args[0], "/")
+				if !strings.Contains(target, "://") {
+					target = "https://" + target
+				}
+			} else if api, err := store.Read(cacheRoot, ""); err == nil {
+				target = api.BaseURL
+			}
+			if target == "" {
+				return fmt.Errorf("no target given and no API learned yet; run: restless discover <target>")
+			}
 
+			evidence, err := council.Load(council.EvidencePath(cacheRoot, target))
+			if err != nil {
+				return err
+			}
+			verdicts := council.NewCouncil(council.NewBlackboard(evidence...)).Convene(opt)
+
+			out := cmd.OutOrStdout()
+			fmt.Fprintf(out, "Target  %s\n\n", target)
+			council.Render(out, verdicts, verbose)
+			return nil
 		},
 	}
 
+	flags.register(cmd)
+	cmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "show what each engine saw")
 	return cmd
 }
+
+// councilFlags are the weighing knobs shared by discover and council.
+type councilFlags struct {
+	weights  []string
+	halfLife time.Duration
+}
+
+func (f *councilFlags) register(cmd *cobra.Command) {
+	cmd.Flags().StringArrayVar(&f.weights, "weight", nil, "engine weight as engine=w, 0..1 (repeatable), e.g. --weight wordlist=0.3")
+	cmd.Flags().DurationVar(&f.halfLife, "half-life", council.DefaultHalfLife, "age at which evidence counts half; negative disables decay")
+}
+
+func (f *councilFlags) options() (council.Options, error) {
+	opt := council.Options{HalfLife: f.halfLife, Weights: map[string]float64{}}
+	for _, kv := range f.weights {
+		engine, raw, ok := strings.Cut(kv, "=")
+		w, err := strconv.ParseFloat(raw, 64)
+		if !ok || err != nil || w < 0 || w > 1 {
+			return opt, fmt.Errorf("bad --weight %q: want engine=w with w between 0 and 1", kv)
+		}
+		opt.Weights[strings.TrimSpace(engine)] = w
+	}
+	return opt, nil
+}
+
+// publishAPI records what a learning command saw of api as evidence for
+// the council, as engine. how says where it was seen.
+func publishAPI(cacheRoot string, api *store.API, engine, how string) error {
+	if api == nil || api.BaseURL == "" {
+		return nil
+	}
+	now := time.Now()
+	var fs []council.Finding
+	for _, e := range api.Endpoints {
+		for _, m := range e.Methods {
+			conf, evidence := 0.9, how
+			if st := e.Statuses[m]; len(st) > 0 {
+				conf = 0
+				for _, code := range st {
+					conf = max(conf, council.StatusConfidence(code))
+				}
+				evidence = fmt.Sprintf("%s, %s → %s", how, m, joinInts(st))
+			}
+			fs = append(fs, council.Finding{
+				Engine:     engine,
+				Kind:       council.KindEndpoint,
+				Target:     e.Path,
+				Method:     m,
+				Evidence:   evidence,
+				Confidence: conf,
+				Timestamp:  now,
+			})
+		}
+	}
+	return council.Record(council.EvidencePath(cacheRoot, api.BaseURL), fs)
+}
+
+func joinInts(ns []int) string {
+	parts := make([]string, len(ns))
+	for i, n := range ns {
+		parts[i] = strconv.Itoa(n)
+	}
+	return strings.Join(parts, ",")
+}
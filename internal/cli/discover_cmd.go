@@ -1,27 +1,174 @@
 package cli
 
 import (
+	"context"
 	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
 
-	"github.com/bspippi1337/restless/internal/discoverwow"
 	"github.com/spf13/cobra"
+
+	"github.com/bspippi1337/restless/internal/council"
+	"github.com/bspippi1337/restless/internal/discoverwow"
+	"github.com/bspippi1337/restless/internal/discovery"
+	"github.com/bspippi1337/restless/internal/modules/openapi/guard/loader"
+	"github.com/bspippi1337/restless/internal/store"
 )
 
 func NewDiscoverCmd() *cobra.Command {
+	var flags councilFlags
+	var timeout time.Duration
+	var metricsAddr string
+
 	cmd := &cobra.Command{
 		Use:   "discover [target]",
 		Short: "Semantic API discovery engine",
-		Args:  cobra.ExactArgs(1),
+		Long: `Discover runs every discovery engine against the target: a published
+OpenAPI spec, a crawl that follows links, the links in the root
+document, and a short wordlist. The council weighs what each engine saw
+into one ranked endpoint list, which is shown and stored as the API.
+
+Evidence is kept between runs and decays with --half-life, so an
+endpoint no engine has seen for a while slowly loses confidence.`,
+		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
+			opt, err := flags.options()
+			if err != nil {
+				return err
+			}
+
+			stopMetrics, err := startMetrics(cmd, metricsAddr)
+			if err != nil {
+				return err
+			}
+			defer stopMetrics()
+
 			res, err := discoverwow.Discover(args[0])
 			if err != nil {
 				return err
 			}
 
+			board := council.NewBlackboard()
+			res.Publish(board)
+			runStrategies(res.Target, board, timeout)
+
+			cacheRoot, _ := cmd.Root().PersistentFlags().GetString("cache")
+			apiName, _ := cmd.Root().PersistentFlags().GetString("api")
+			cacheRoot, _ = store.DefaultRoot(cacheRoot)
+
+			evPath := council.EvidencePath(cacheRoot, res.Target)
+			prior, err := council.Load(evPath)
+			if err != nil {
+				return err
+			}
+			evidence := council.Prune(append(prior, board.List()...), opt)
+			if err := council.Save(evPath, evidence); err != nil {
+				return err
+			}
+			verdicts := council.NewCouncil(council.NewBlackboard(evidence...)).Convene(opt)
+
+			res.TopEndpoints = res.TopEndpoints[:0]
+			for _, v := range verdicts {
+				res.TopEndpoints = append(res.TopEndpoints, discoverwow.EndpointScore{
+					Path:   v.Target,
+					Score:  int(math.Round(v.Confidence * 100)),
+					Reason: v.Explain(),
+				})
+			}
 			fmt.Print(discoverwow.Render(res))
+
+			prev, _ := store.Read(cacheRoot, apiName)
+			path, err := store.Write(cacheRoot, councilAPI(prev, res.Target, verdicts))
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Saved → %s\n", path)
 			return nil
 		},
 	}
 
+	flags.register(cmd)
+	cmd.Flags().DurationVarP(&timeout, "timeout", "t", 20*time.Second, "how long the engines may run")
+	addMetricsFlag(cmd, &metricsAddr)
 	return cmd
 }
+
+// runStrategies runs the wordlist, crawl and spec engines side by side.
+// Engines still running at the deadline are abandoned; what they
+// published so far counts.
+func runStrategies(target string, board *council.Blackboard, timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	run := func(f func()) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			f()
+		}()
+	}
+
+	run(func() { _, _ = discovery.Discover(target, board) })
+	run(func() {
+		eng, err := discovery.NewEngine(target)
+		if err != nil {
+			return
+		}
+		eng.Board = board
+		eng.Discover(ctx)
+	})
+	run(func() {
+		specURL, ok := discovery.Find(target)
+		if !ok {
+			return
+		}
+		doc, err := loader.Load(ctx, specURL, loader.LoadOptions{})
+		if err != nil {
+			return
+		}
+		discovery.PublishSpec(board, specURL, doc)
+	})
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+	}
+}
+
+// councilAPI folds the council's verdicts into the stored API. The
+// council has the last word on where each endpoint came from and how
+// sure we are of it.
+func councilAPI(prev *store.API, baseURL string, verdicts []council.Verdict) *store.API {
+	now := time.Now()
+	next := &store.API{BaseURL: baseURL}
+	for _, v := range verdicts {
+		next.Endpoints = append(next.Endpoints, store.Endpoint{
+			Path:      v.Target,
+			Methods:   v.Methods,
+			FirstSeen: now,
+		})
+	}
+	api := store.Merge(prev, next)
+
+	byPath := map[string]council.Verdict{}
+	for _, v := range verdicts {
+		byPath[v.Target] = v
+	}
+	for i := range api.Endpoints {
+		e := &api.Endpoints[i]
+		if v, ok := byPath[e.Path]; ok {
+			e.Source = strings.Join(v.Engines, "+")
+			e.Confidence = v.Level()
+			e.Score = math.Round(v.Confidence*1000) / 1000
+		}
+	}
+	return api
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/bspippi1337/restless/internal/httpx"
+	"github.com/bspippi1337/restless/internal/output"
 	"github.com/bspippi1337/restless/internal/store"
 	"github.com/bspippi1337/restless/internal/util"
 )
@@ -35,6 +36,7 @@ func AddDynamicCommands(root *cobra.Command) error {
 		}
 
 		path := e.Path
+		var out output.Options
 
 		cmd := &cobra.Command{
 			Use:   name,
@@ -42,22 +44,29 @@ func AddDynamicCommands(root *cobra.Command) error {
 
 			RunE: func(cmd *cobra.Command, args []string) error {
 
+				if err := out.Check(); err != nil {
+					return err
+				}
 				url := util.JoinURL(api.BaseURL, path)
 
 				req, _ := http.NewRequest("GET", url, nil)
 
-				res, err := client.HTTP.Do(req)
+				hc, err := authHTTPClient(cmd, client.HTTP.Timeout)
 				if err != nil {
 					return err
 				}
 
-				body, _ := httpx.ReadBody(res, 10<<20)
+				res, err := hc.Do(req)
+				if err != nil {
+					return err
+				}
 
-				cmd.Println(string(body))
+				body, _ := httpx.ReadBody(res, 10<<20)
 
-				return nil
+				return output.Render(cmd.OutOrStdout(), body, out)
 			},
 		}
+		addOutputFlags(cmd, &out)
 
 		root.AddCommand(cmd)
 
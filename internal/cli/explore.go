@@ -0,0 +1,96 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bspippi1337/restless/internal/core/app"
+	"github.com/bspippi1337/restless/internal/core/state"
+	"github.com/bspippi1337/restless/internal/modules/session"
+	"github.com/bspippi1337/restless/internal/store"
+	"github.com/bspippi1337/restless/internal/tui"
+)
+
+func NewExploreCmd() *cobra.Command {
+
+	var timeout time.Duration
+	var vars []string
+	var fresh bool
+
+	cmd := &cobra.Command{
+		Use:   "explore",
+		Short: "Full-screen explorer for a learned API",
+		Long: `Full-screen explorer for a learned API.
+
+Endpoints are listed on the left, grouped by path and filtered as you
+type; the history of requests sent sits under them. On the right are the
+request editor (method, path params, query, headers, body) and the
+response, with foldable JSON and its headers. In the history, m marks an
+entry and d diffs the selected one against it.
+
+Tab moves between panes, ctrl+s sends from anywhere and ctrl+c quits.
+History and the last request are kept per API in the state directory,
+so the next launch picks up where this one stopped; credentials and
+cookies in them are saved as REDACTED.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			cacheRoot, _ := cmd.Root().PersistentFlags().GetString("cache")
+			apiName, _ := cmd.Root().PersistentFlags().GetString("api")
+			cacheRoot, _ = store.DefaultRoot(cacheRoot)
+
+			api, err := store.Read(cacheRoot, apiName)
+			if err != nil {
+				return err
+			}
+			if api == nil || api.BaseURL == "" {
+				return fmt.Errorf("no API loaded. Run: restless learn <url>")
+			}
+			if !stdinIsTerminal() {
+				return fmt.Errorf("explore needs a terminal; use restless shell or call in scripts")
+			}
+
+			sess := session.New()
+			for _, kv := range vars {
+				k, v, ok := strings.Cut(kv, "=")
+				if !ok {
+					return fmt.Errorf("invalid --var %q (want key=value)", kv)
+				}
+				sess.Set(strings.TrimSpace(k), v)
+			}
+			mods, err := authModules(cmd)
+			if err != nil {
+				return err
+			}
+			a, err := app.New(append([]app.Module{sess}, mods...))
+			if err != nil {
+				return err
+			}
+
+			file := ""
+			if dir := state.Dir(); dir != "" {
+				file = tui.SessionFile(dir, api.BaseURL)
+				if fresh {
+					if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+						return err
+					}
+				}
+			}
+
+			return tui.Explore(os.Stdin, os.Stdout, tui.ExploreOptions{
+				API:     api,
+				App:     a,
+				Timeout: timeout,
+				Session: file,
+			})
+		},
+	}
+
+	cmd.Flags().DurationVarP(&timeout, "timeout", "t", 12*time.Second, "request timeout")
+	cmd.Flags().StringArrayVar(&vars, "var", nil, "session variable key=value (repeatable)")
+	cmd.Flags().BoolVar(&fresh, "fresh", false, "forget the saved session for this API and start empty")
+	return cmd
+}
@@ -0,0 +1,176 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bspippi1337/restless/internal/findings"
+	"github.com/bspippi1337/restless/internal/store"
+)
+
+func NewFindingsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "findings",
+		Short: "List saved findings and export them as reproducers",
+		Long: `Fuzz runs and proxy --spec sessions save what they find, together with
+the exact request (and for stateful runs, every request before it) that
+reproduces it. Credentials are stored redacted.
+
+"restless findings export <id>" turns a finding into a standalone
+reproducer: a curl script, a Go test that needs only net/http, or a
+flow file. Scripts and tests fail while the problem is present and read
+redacted credentials from the environment.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return listFindings(cmd)
+		},
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "ls",
+		Short: "List saved findings, newest first",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return listFindings(cmd)
+		},
+	})
+	cmd.AddCommand(newFindingsShowCmd())
+	cmd.AddCommand(newFindingsExportCmd())
+	return cmd
+}
+
+func findingsRoot(cmd *cobra.Command) string {
+	cacheRoot, _ := cmd.Root().PersistentFlags().GetString("cache")
+	cacheRoot, _ = store.DefaultRoot(cacheRoot)
+	return cacheRoot
+}
+
+func listFindings(cmd *cobra.Command) error {
+	fs, err := findings.List(findingsRoot(cmd))
+	if err != nil {
+		return err
+	}
+	out := cmd.OutOrStdout()
+	if len(fs) == 0 {
+		fmt.Fprintln(out, "No findings saved. Run: restless fuzz --spec <spec> <url>")
+		return nil
+	}
+	for _, f := range fs {
+		fmt.Fprintf(out, "%s  %-8s %-8s %-16s %s %s\n", f.ID, strings.ToUpper(string(f.Severity)), f.Source, f.Kind, f.Method, f.Path)
+	}
+	return nil
+}
+
+func newFindingsShowCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "show <id>",
+		Short: "Show a finding and the requests that reproduce it",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			f, err := findings.Load(findingsRoot(cmd), args[0])
+			if err != nil {
+				return err
+			}
+			printFinding(cmd.OutOrStdout(), f)
+			return nil
+		},
+	}
+}
+
+func printFinding(w io.Writer, f findings.Finding) {
+	fmt.Fprintf(w, "ID:       %s\n", f.ID)
+	fmt.Fprintf(w, "Source:   %s\n", f.Source)
+	fmt.Fprintf(w, "Kind:     %s (%s)\n", f.Kind, f.Severity)
+	fmt.Fprintf(w, "Endpoint: %s %s\n", f.Method, f.Path)
+	if f.Status != 0 {
+		fmt.Fprintf(w, "Status:   %d\n", f.Status)
+	}
+	msg := f.Message
+	if f.JSONPath != "" && f.JSONPath != "$" {
+		msg = f.JSONPath + ": " + msg
+	}
+	fmt.Fprintf(w, "Message:  %s\n", msg)
+	if f.Expected != "" {
+		fmt.Fprintf(w, "Expected: %s\n", f.Expected)
+	}
+	fmt.Fprintf(w, "Found:    %s\n\n", f.Found.Local().Format("2006-01-02 15:04:05"))
+
+	fmt.Fprintln(w, "REQUESTS")
+	fmt.Fprintln(w, "--------")
+	for _, r := range f.Sequence {
+		printFindingRequest(w, r.Method, r.URL, r.As, r.Body)
+	}
+	if f.Request != nil {
+		printFindingRequest(w, f.Request.Method, f.Request.URL, f.Request.As, f.Request.Body)
+	}
+}
+
+func printFindingRequest(w io.Writer, method, url, as, body string) {
+	if as != "" {
+		as = "  (as " + as + ")"
+	}
+	if len(url) > 160 {
+		url = url[:160] + "…"
+	}
+	fmt.Fprintf(w, "%s %s%s\n", method, url, as)
+	if body != "" {
+		if len(body) > 160 {
+			body = body[:160] + "…"
+		}
+		fmt.Fprintf(w, "  %s\n", body)
+	}
+}
+
+func newFindingsExportCmd() *cobra.Command {
+	var as string
+	var outFile string
+	var pkg string
+
+	cmd := &cobra.Command{
+		Use:   "export <id>",
+		Short: "Export a finding as a curl script, Go test or flow file",
+		Long: `Export a finding as a standalone reproducer.
+
+  --as curl     POSIX sh script; exits 1 while the problem is present
+  --as go-test  Go test using net/http, ready to drop into a service repo
+  --as flow     flow file for "restless flow"
+
+Set BASE_URL to point the script or test at another server. Credentials
+that were redacted are read from environment variables named after the
+header or field (AUTHORIZATION, or BOB_AUTHORIZATION for identity bob);
+in a flow they are variables to pass with --var.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			f, err := findings.Load(findingsRoot(cmd), args[0])
+			if err != nil {
+				return err
+			}
+			b, err := findings.Export(f, as, findings.Options{Package: pkg})
+			if err != nil {
+				return err
+			}
+			if outFile == "" {
+				_, err := cmd.OutOrStdout().Write(b)
+				return err
+			}
+			mode := os.FileMode(0o644)
+			if as == findings.Curl {
+				mode = 0o755
+			}
+			if err := os.WriteFile(outFile, b, mode); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Saved → %s\n", outFile)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&as, "as", findings.Curl, "format: "+strings.Join(findings.Formats, ", "))
+	cmd.Flags().StringVarP(&outFile, "output", "o", "", "write to a file instead of stdout")
+	cmd.Flags().StringVar(&pkg, "package", "repro", "package clause of the go-test export")
+	return cmd
+}
@@ -0,0 +1,85 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bspippi1337/restless/internal/core/app"
+	"github.com/bspippi1337/restless/internal/core/types"
+	"github.com/bspippi1337/restless/internal/modules/session"
+)
+
+func NewFlowCmd() *cobra.Command {
+
+	var vars []string
+	var timeout time.Duration
+	var spec string
+	var noValidate bool
+	var strict bool
+
+	cmd := &cobra.Command{
+		Use:   "flow <file>",
+		Short: "Replay a flow file (request collection) step by step",
+		Args:  cobra.ExactArgs(1),
+
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			steps, err := session.LoadFlow(args[0])
+			if err != nil {
+				return err
+			}
+
+			sess := session.New()
+			for _, kv := range vars {
+				k, v, ok := strings.Cut(kv, "=")
+				if !ok {
+					return fmt.Errorf("invalid --var %q (want key=value)", kv)
+				}
+				sess.Set(strings.TrimSpace(k), v)
+			}
+
+			mods, err := authModules(cmd)
+			if err != nil {
+				return err
+			}
+			mods = append([]app.Module{sess}, mods...)
+			if !noValidate {
+				pf, err := preflight(cmd, spec)
+				if err != nil {
+					return err
+				}
+				mods = append(mods, pf)
+			}
+			contract := contractModule(cmd)
+			a, err := app.New(append(mods, contract))
+			if err != nil {
+				return err
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			out := cmd.OutOrStdout()
+			err = session.RunFlowWith(ctx, a, steps, sess, func(i int, s session.FlowStep, resp types.Response) {
+				fmt.Fprintf(out, "[%d/%d] %s %s -> %d (%dms)\n", i+1, len(steps), s.Method, s.URL, resp.StatusCode, resp.DurationMs)
+				reportDrift(cmd, contract)
+			})
+			if err != nil {
+				return err
+			}
+			return strictDrift(contract, strict)
+		},
+	}
+
+	cmd.Flags().StringArrayVar(&vars, "var", nil, "session variable key=value (repeatable)")
+	cmd.Flags().DurationVarP(&timeout, "timeout", "t", 2*time.Minute, "timeout for the whole flow")
+	cmd.Flags().StringVar(&spec, "spec", "", "OpenAPI spec to check each step against (file, URL or imported spec); default: the imported spec for the step's URL")
+	addNoValidateFlag(cmd, &noValidate)
+	addStrictFlag(cmd, &strict)
+
+	return cmd
+}
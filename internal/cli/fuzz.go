@@ -1,31 +1,410 @@
 package cli
 
 import (
+	"bufio"
+	"encoding/json"
 	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
 
-	"github.com/bspippi1337/restless/internal/core/fuzz"
+	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/spf13/cobra"
+
+	"github.com/bspippi1337/restless/internal/auth"
+	"github.com/bspippi1337/restless/internal/core/fuzz"
+	"github.com/bspippi1337/restless/internal/findings"
+	"github.com/bspippi1337/restless/internal/modules/openapi/guard/loader"
+	"github.com/bspippi1337/restless/internal/modules/openapi/guard/report"
+	"github.com/bspippi1337/restless/internal/specfuzz"
+	"github.com/bspippi1337/restless/internal/store"
+	"github.com/bspippi1337/restless/internal/version"
 )
 
 func NewFuzzCmd() *cobra.Command {
 
+	var specRef string
+	var grep string
+	var headers []string
+	var maxCases int
+	var seed int64
+	var timeout time.Duration
+	var writes bool
+	var yes bool
+	var sarifOut string
+	var stateful bool
+	var sequences int
+	var maxSteps int
+	var identities []string
+	var depsOnly bool
+
 	cmd := &cobra.Command{
-		Use:  "fuzz <url>",
-		Args: cobra.ExactArgs(1),
+		Use:   "fuzz [url]",
+		Short: "Fuzz an API: path wordlist, or schema-aware with --spec",
+		Long: `Without --spec, probe <url> for a short wordlist of sensitive paths.
+
+With --spec, build requests for every operation from its parameter and
+body schemas and mutate one value at a time: boundary values, wrong
+types, oversized strings, missing required fields, unicode and
+injection payloads. Responses are classified as crashes (5xx),
+schema violations or timeouts; failures are re-sent to confirm them
+and shrunk to the smallest request that still fails.
+
+With --stateful, operations are chained instead: ids returned by
+creates (POST /orders -> id) feed the operations that take them
+(GET/DELETE /orders/{orderId}), matched by field name and type.
+Random sequences drawn from --seed look for deleted resources that are
+still served, creates that succeed twice, and resources a second
+--identity can reach. --deps prints the inferred graph and exits.
+
+Only GET and HEAD operations are fuzzed unless --writes or --stateful
+is given, which ask for confirmation first (skip with --yes).`,
+		Args: cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 
-			res, err := fuzz.Run(args[0])
+			if specRef == "" {
+				if len(args) == 0 {
+					return fmt.Errorf("missing <url> (or --spec)")
+				}
+				res, err := fuzz.Run(args[0])
+				if err != nil {
+					return err
+				}
+
+				for _, r := range res {
+					fmt.Printf("FOUND %s\n", r)
+				}
+
+				return nil
+			}
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			ref, err := resolveSpecRef(cmd, specRef)
+			if err != nil {
+				return err
+			}
+			doc, err := loader.Load(ctx, ref, loader.LoadOptions{})
+			if err != nil {
+				return fmt.Errorf("load spec: %w", err)
+			}
+
+			if depsOnly {
+				printDeps(cmd.OutOrStdout(), specfuzz.Dependencies(doc))
+				return nil
+			}
+
+			base := ""
+			if len(args) == 1 {
+				base = args[0]
+			} else if len(doc.Servers) > 0 {
+				base = doc.Servers[0].URL
+			}
+			if !strings.HasPrefix(base, "http://") && !strings.HasPrefix(base, "https://") {
+				return fmt.Errorf("missing target: pass <url> (the spec has no absolute server URL)")
+			}
+
+			client, err := authHTTPClient(cmd, 0)
+			if err != nil {
+				return err
+			}
+			ids, err := parseIdentities(cmd, identities)
 			if err != nil {
 				return err
 			}
+			if len(ids) > 0 {
+				// Each identity brings its own credentials; the workspace
+				// token must not be slipped into the other's requests.
+				client = &http.Client{}
+			}
+			opt := specfuzz.Options{
+				BaseURL:    base,
+				Client:     client,
+				Timeout:    timeout,
+				MaxCases:   maxCases,
+				Seed:       seed,
+				Header:     http.Header{},
+				Identities: ids,
+				Sequences:  sequences,
+				MaxSteps:   maxSteps,
+			}
+			for k, v := range parseHeaders(headers) {
+				opt.Header.Set(k, v)
+			}
+			if grep != "" {
+				opt.Include = func(op specfuzz.Operation) bool { return strings.Contains(op.Path, grep) }
+			}
+
+			out := cmd.OutOrStdout()
+			if writes || stateful {
+				ok, err := confirmWrites(cmd, doc, opt, yes)
+				if err != nil || !ok {
+					return err
+				}
+				opt.Methods = []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE"}
+			}
+
+			fmt.Fprintln(out, "RESTLESS FUZZ")
+			fmt.Fprintf(out, "Target: %s\n", base)
+			fmt.Fprintf(out, "Spec:   %s\n\n", specRef)
+
+			last := ""
+			opt.Progress = func(c specfuzz.Case, status int, err error) {
+				if key := c.Op.Method + " " + c.Op.Path; key != last {
+					last = key
+					fmt.Fprintf(out, "  %s\n", key)
+				}
+			}
+
+			run := specfuzz.Run
+			if stateful {
+				run = specfuzz.RunStateful
+			}
+			rep, err := run(ctx, doc, opt)
+			if rep == nil {
+				return err
+			}
+			if err != nil && ctx.Err() == nil {
+				return err
+			}
+
+			fmt.Fprintln(out)
+			printFuzzReport(out, rep)
 
-			for _, r := range res {
-				fmt.Printf("FOUND %s\n", r)
+			cacheRoot, _ := cmd.Root().PersistentFlags().GetString("cache")
+			cacheRoot, _ = store.DefaultRoot(cacheRoot)
+			// Nanoseconds keep two runs started in the same second apart.
+			path := filepath.Join(cacheRoot, "fuzz", "fuzz-"+time.Now().UTC().Format("20060102-150405.000000000")+".json")
+			if err := writeJSONFile(path, rep); err != nil {
+				return err
 			}
+			fmt.Fprintf(out, "Saved → %s\n", path)
 
+			found := findings.FromFuzz(rep)
+			if err := findings.Save(cacheRoot, found); err != nil {
+				return err
+			}
+			if len(found) > 0 {
+				fmt.Fprintf(out, "Findings → %s  (restless findings export %s --as curl|go-test|flow)\n", findingIDs(found), found[0].ID)
+			}
+
+			if sarifOut != "" {
+				b, err := fuzzSARIF(rep)
+				if err != nil {
+					return err
+				}
+				if err := os.WriteFile(sarifOut, b, 0o644); err != nil {
+					return err
+				}
+				fmt.Fprintf(out, "Saved → %s\n", sarifOut)
+			}
 			return nil
 		},
 	}
 
+	cmd.Flags().StringVar(&specRef, "spec", "", "OpenAPI spec (file, URL or imported spec ID) to generate requests from")
+	cmd.Flags().StringVar(&grep, "grep", "", "only fuzz paths containing this text")
+	cmd.Flags().StringArrayVarP(&headers, "header", "H", nil, "header to send with every request (repeatable)")
+	cmd.Flags().IntVar(&maxCases, "max-cases", 60, "cases per operation (0 = all)")
+	cmd.Flags().Int64Var(&seed, "seed", 1, "seed for case sampling and --stateful sequences; same seed, same run")
+	cmd.Flags().DurationVarP(&timeout, "timeout", "t", 10*time.Second, "per-request timeout; slower answers are findings")
+	cmd.Flags().BoolVar(&writes, "writes", false, "also fuzz POST, PUT, PATCH and DELETE operations")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "do not ask before fuzzing write operations")
+	cmd.Flags().StringVar(&sarifOut, "sarif", "", "write findings as SARIF")
+	cmd.Flags().BoolVar(&stateful, "stateful", false, "fuzz sequences of dependent operations instead of single requests")
+	cmd.Flags().IntVar(&sequences, "sequences", 20, "sequences to run with --stateful")
+	cmd.Flags().IntVar(&maxSteps, "max-steps", 8, "requests per sequence with --stateful")
+	cmd.Flags().StringArrayVar(&identities, "identity", nil, "identity for --stateful as name='Header: value' or name=@auth-profile (repeatable; the second probes the first's resources)")
+	cmd.Flags().BoolVar(&depsOnly, "deps", false, "print the inferred producer/consumer graph and exit")
+
 	return cmd
 }
+
+// confirmWrites lists the write operations about to be fuzzed and asks.
+func confirmWrites(cmd *cobra.Command, doc *openapi3.T, opt specfuzz.Options, yes bool) (bool, error) {
+	ops := specfuzz.Writes(doc, opt.Include)
+	if len(ops) == 0 || yes {
+		return true, nil
+	}
+	w := cmd.ErrOrStderr()
+	fmt.Fprintf(w, "About to send malformed writes to %s:\n", opt.BaseURL)
+	for _, op := range ops {
+		fmt.Fprintf(w, "  %s %s\n", op.Method, op.Path)
+	}
+	fmt.Fprint(w, "These may create, change or delete data. Continue? [y/N] ")
+
+	line, err := bufio.NewReader(cmd.InOrStdin()).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return false, err
+	}
+	switch strings.ToLower(strings.TrimSpace(line)) {
+	case "y", "yes":
+		return true, nil
+	}
+	fmt.Fprintln(w, "Aborted.")
+	return false, nil
+}
+
+func printFuzzReport(w io.Writer, rep *specfuzz.Report) {
+	fmt.Fprintln(w, "FINDINGS")
+	fmt.Fprintln(w, "--------")
+	if len(rep.Findings) == 0 {
+		if rep.Sequences > 0 {
+			fmt.Fprintf(w, "None in %d sequences (%d requests) over %d operations.\n\n", rep.Sequences, rep.Requests, rep.Operations)
+			return
+		}
+		fmt.Fprintf(w, "None in %d cases over %d operations.\n\n", rep.Cases, rep.Operations)
+		return
+	}
+	for _, f := range rep.Findings {
+		input := "baseline"
+		switch {
+		case len(f.Sequence) > 0:
+			input = ""
+		case f.Target != "":
+			input = f.Target + "=" + f.Mutation
+		}
+		fmt.Fprintf(w, "[%s] %s %s %s  %s\n", strings.ToUpper(string(f.Severity)), f.Kind, f.Method, f.Path, input)
+		if f.JSONPath != "" && f.JSONPath != "$" {
+			fmt.Fprintf(w, "  %s: %s\n", f.JSONPath, f.Message)
+		} else {
+			fmt.Fprintf(w, "  %s\n", f.Message)
+		}
+		state := "flaky, did not reproduce"
+		switch {
+		case len(f.Sequence) > 0:
+			state = fmt.Sprintf("after a sequence of %d requests", len(f.Sequence))
+			if f.Request.As != "" {
+				state += ", sent as " + f.Request.As
+			}
+		case f.Minimized:
+			state = "reproduced, minimized"
+		case f.Reproduced:
+			state = "reproduced"
+		}
+		u := f.Request.URL
+		if len(u) > 160 {
+			u = u[:160] + "…"
+		}
+		fmt.Fprintf(w, "  %s %s  (%s)\n\n", f.Request.Method, u, state)
+	}
+	if rep.Sequences > 0 {
+		fmt.Fprintf(w, "%d findings in %d sequences (%d requests) over %d operations.\n\n", len(rep.Findings), rep.Sequences, rep.Requests, rep.Operations)
+		return
+	}
+	fmt.Fprintf(w, "%d findings in %d cases over %d operations.\n\n", len(rep.Findings), rep.Cases, rep.Operations)
+}
+
+func printDeps(w io.Writer, deps []specfuzz.Dependency) {
+	fmt.Fprintln(w, "DEPENDENCIES")
+	fmt.Fprintln(w, "------------")
+	if len(deps) == 0 {
+		fmt.Fprintln(w, "No operation consumes another's output.")
+		return
+	}
+	for _, d := range deps {
+		note := ""
+		if d.Convention {
+			note = "  (by convention)"
+		}
+		fmt.Fprintf(w, "%s.%s -> %s {%s}%s\n", d.Producer, d.Field, d.Consumer, d.Param, note)
+	}
+}
+
+// parseIdentities reads --identity values. Several values with the same
+// name add headers to one identity; name=@profile sends the token of
+// that auth profile.
+func parseIdentities(cmd *cobra.Command, specs []string) ([]specfuzz.Identity, error) {
+	var out []specfuzz.Identity
+	index := map[string]int{}
+	for _, s := range specs {
+		name, val, ok := strings.Cut(s, "=")
+		name, val = strings.TrimSpace(name), strings.TrimSpace(val)
+		if !ok || name == "" || val == "" {
+			return nil, fmt.Errorf("bad --identity %q: want name='Header: value' or name=@profile", s)
+		}
+		i, seen := index[name]
+		if !seen {
+			i = len(out)
+			index[name] = i
+			out = append(out, specfuzz.Identity{Name: name, Header: http.Header{}})
+		}
+
+		if profile, isProfile := strings.CutPrefix(val, "@"); isProfile {
+			st, _, _ := authStore(cmd)
+			src, err := auth.NewSource(st, profile)
+			if err != nil {
+				return nil, err
+			}
+			if src == nil {
+				return nil, fmt.Errorf("no OAuth2 client in profile %q. Run: restless auth login -a %s", profile, profile)
+			}
+			src.Prompt = devicePrompt(cmd.ErrOrStderr())
+			tok, err := src.Token(cmd.Context())
+			if err != nil {
+				return nil, err
+			}
+			out[i].Header.Set("Authorization", tok.Header())
+			continue
+		}
+		k, v, ok := strings.Cut(val, ":")
+		if !ok {
+			return nil, fmt.Errorf("bad --identity %q: header needs a colon", s)
+		}
+		out[i].Header.Set(strings.TrimSpace(k), strings.TrimSpace(v))
+	}
+	if len(out) > 2 {
+		return nil, fmt.Errorf("--identity: at most two identities (owner and intruder)")
+	}
+	return out, nil
+}
+
+func fuzzSARIF(rep *specfuzz.Report) ([]byte, error) {
+	rules := []report.Rule{
+		{ID: specfuzz.Crash, Description: "Server error or dropped connection on fuzzed input"},
+		{ID: specfuzz.Schema, Description: "Response does not match the OpenAPI schema"},
+		{ID: specfuzz.Timeout, Description: "No response within the timeout"},
+		{ID: specfuzz.UseAfterDelete, Description: "A deleted resource is still served"},
+		{ID: specfuzz.DoubleCreate, Description: "Replaying a create is accepted"},
+		{ID: specfuzz.AuthzLeak, Description: "Another identity reaches the resource"},
+	}
+	var results []report.Result
+	for _, f := range rep.Findings {
+		msg := fmt.Sprintf("%s %s: %s", f.Method, f.Path, f.Message)
+		if f.Target != "" {
+			msg = fmt.Sprintf("%s %s with %s=%s: %s", f.Method, f.Path, f.Target, f.Mutation, f.Message)
+		}
+		results = append(results, report.Result{
+			RuleID:   f.Kind,
+			Severity: f.Severity,
+			Message:  msg,
+			URI:      f.Request.URL,
+		})
+	}
+	return report.SARIF("restless-fuzz", version.String(), rules, results)
+}
+
+func writeJSONFile(path string, v any) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, b, 0o644)
+}
+
+func findingIDs(fs []findings.Finding) string {
+	ids := make([]string, len(fs))
+	for i, f := range fs {
+		ids[i] = f.ID
+	}
+	return strings.Join(ids, " ")
+}
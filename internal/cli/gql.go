@@ -0,0 +1,413 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bspippi1337/restless/internal/graphql"
+	"github.com/bspippi1337/restless/internal/output"
+	"github.com/bspippi1337/restless/internal/store"
+)
+
+func NewGQLCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "gql",
+		Short: "GraphQL introspection, query building and schema diffing",
+	}
+
+	cmd.AddCommand(newGQLIntrospectCmd())
+	cmd.AddCommand(newGQLOpsCmd())
+	cmd.AddCommand(newGQLBuildCmd())
+	cmd.AddCommand(newGQLRunCmd())
+	cmd.AddCommand(newGQLDiffCmd())
+	return cmd
+}
+
+func newGQLIntrospectCmd() *cobra.Command {
+	var headers []string
+	var output string
+	var timeout time.Duration
+
+	cmd := &cobra.Command{
+		Use:   "introspect <url>",
+		Short: "Find the GraphQL endpoint, introspect it and save its operations",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			target := args[0]
+			if !strings.Contains(target, "://") {
+				target = "https://" + target
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			c, err := gqlClient(cmd, headers)
+			if err != nil {
+				return err
+			}
+			endpoint, s, err := c.Discover(ctx, target)
+			if err != nil {
+				return err
+			}
+
+			cacheRoot, _ := cmd.Root().PersistentFlags().GetString("cache")
+			apiName, _ := cmd.Root().PersistentFlags().GetString("api")
+			cacheRoot, err = store.DefaultRoot(cacheRoot)
+			if err != nil {
+				return err
+			}
+
+			u, _ := url.Parse(endpoint)
+			snapshot := filepath.Join(cacheRoot, "graphql", strings.ReplaceAll(u.Host, ":", "_")+".json")
+			if err := os.MkdirAll(filepath.Dir(snapshot), 0755); err != nil {
+				return err
+			}
+			if err := s.Save(snapshot); err != nil {
+				return err
+			}
+			if output != "" {
+				if err := s.Save(output); err != nil {
+					return err
+				}
+			}
+
+			learned := gqlWorkspace(u, snapshot, s, time.Now().UTC())
+			prev, _ := store.Read(cacheRoot, apiName)
+			path, err := store.Write(cacheRoot, store.Merge(prev, learned))
+			if err != nil {
+				return err
+			}
+
+			out := cmd.OutOrStdout()
+			fmt.Fprintln(out, "RESTLESS GQL")
+			fmt.Fprintf(out, "Endpoint: %s\n", endpoint)
+			fmt.Fprintf(out, "Types: %d\n\n", len(s.UserTypes()))
+			printGQLOps(out, s.Operations(), "")
+			fmt.Fprintln(out)
+			fmt.Fprintf(out, "Snapshot → %s\n", snapshot)
+			if output != "" {
+				fmt.Fprintf(out, "Snapshot → %s\n", output)
+			}
+			fmt.Fprintf(out, "Saved → %s\n", path)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringArrayVarP(&headers, "header", "H", nil, "extra header (repeatable), e.g. -H 'Authorization: Bearer ...'")
+	cmd.Flags().StringVarP(&output, "output", "o", "", "also write the introspection snapshot here (for gql diff)")
+	cmd.Flags().DurationVarP(&timeout, "timeout", "t", 30*time.Second, "timeout")
+	return cmd
+}
+
+func newGQLOpsCmd() *cobra.Command {
+	var kind string
+
+	cmd := &cobra.Command{
+		Use:   "ops",
+		Short: "List GraphQL operations of the loaded API",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			_, s, err := loadGQL(cmd)
+			if err != nil {
+				return err
+			}
+			printGQLOps(cmd.OutOrStdout(), s.Operations(), kind)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&kind, "kind", "", "only query, mutation or subscription")
+	return cmd
+}
+
+func newGQLBuildCmd() *cobra.Command {
+	var depth int
+
+	cmd := &cobra.Command{
+		Use:   "build <operation>",
+		Short: "Print a ready-to-run document for an operation",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			_, s, err := loadGQL(cmd)
+			if err != nil {
+				return err
+			}
+			op, ok := s.Operation(args[0])
+			if !ok {
+				return fmt.Errorf("unknown operation %q (see: restless gql ops)", args[0])
+			}
+			fmt.Fprint(cmd.OutOrStdout(), graphql.Build(s, op, depth))
+			return nil
+		},
+	}
+
+	cmd.Flags().IntVar(&depth, "depth", graphql.DefaultDepth, "object levels to select")
+	return cmd
+}
+
+func newGQLRunCmd() *cobra.Command {
+	var query string
+	var file string
+	var vars []string
+	var varsJSON string
+	var headers []string
+	var endpoint string
+	var depth int
+	var timeout time.Duration
+	var out output.Options
+
+	cmd := &cobra.Command{
+		Use:   "run [operation]",
+		Short: "Run an operation (built from the schema) or a raw query",
+		Long: `Run a GraphQL operation against the introspected endpoint.
+
+Name an operation to have the document built from the schema, or pass
+--query / --file for a handwritten one. Variables come from --vars (a JSON
+object) and --var name=value; values are parsed as JSON when they can be.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			doc := query
+			if file != "" {
+				b, err := os.ReadFile(file)
+				if err != nil {
+					return err
+				}
+				doc = string(b)
+			}
+
+			if endpoint == "" || len(args) == 1 {
+				gq, s, err := loadGQL(cmd)
+				if err != nil {
+					return err
+				}
+				if endpoint == "" {
+					endpoint = gq.Endpoint
+				}
+				if len(args) == 1 {
+					op, ok := s.Operation(args[0])
+					if !ok {
+						return fmt.Errorf("unknown operation %q (see: restless gql ops)", args[0])
+					}
+					doc = graphql.Build(s, op, depth)
+				}
+			}
+			if doc == "" {
+				return fmt.Errorf("nothing to run: name an operation or pass --query/--file")
+			}
+
+			variables := map[string]any{}
+			if varsJSON != "" {
+				if err := json.Unmarshal([]byte(varsJSON), &variables); err != nil {
+					return fmt.Errorf("invalid --vars: %w", err)
+				}
+			}
+			for _, kv := range vars {
+				k, v, ok := strings.Cut(kv, "=")
+				if !ok {
+					return fmt.Errorf("invalid --var %q (want name=value)", kv)
+				}
+				variables[strings.TrimSpace(k)] = gqlValue(v)
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			c, err := gqlClient(cmd, headers)
+			if err != nil {
+				return err
+			}
+			res, err := c.Execute(ctx, endpoint, doc, variables)
+			if err != nil {
+				return err
+			}
+
+			w := statusOut(cmd, out)
+			fmt.Fprintln(w, "POST", endpoint)
+			fmt.Fprintln(w, res.Status, http.StatusText(res.Status))
+			if err := output.Render(cmd.OutOrStdout(), res.Body, out); err != nil {
+				return err
+			}
+
+			var env struct {
+				Errors []json.RawMessage `json:"errors"`
+			}
+			if json.Unmarshal(res.Body, &env) == nil && len(env.Errors) > 0 {
+				return fmt.Errorf("graphql returned %d error(s)", len(env.Errors))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&query, "query", "q", "", "GraphQL document to run")
+	cmd.Flags().StringVarP(&file, "file", "f", "", "read the GraphQL document from a file")
+	cmd.Flags().StringArrayVar(&vars, "var", nil, "variable name=value (repeatable)")
+	cmd.Flags().StringVar(&varsJSON, "vars", "", "variables as a JSON object")
+	cmd.Flags().StringArrayVarP(&headers, "header", "H", nil, "extra header (repeatable)")
+	cmd.Flags().StringVar(&endpoint, "endpoint", "", "GraphQL endpoint (default: the introspected one)")
+	cmd.Flags().IntVar(&depth, "depth", graphql.DefaultDepth, "object levels to select when building")
+	cmd.Flags().DurationVarP(&timeout, "timeout", "t", 30*time.Second, "timeout")
+	addOutputFlags(cmd, &out)
+	return cmd
+}
+
+func newGQLDiffCmd() *cobra.Command {
+	var failOnBreaking bool
+
+	cmd := &cobra.Command{
+		Use:   "diff <old.json> <new.json>",
+		Short: "Compare two introspection snapshots and flag breaking changes",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			oldS, err := graphql.Load(args[0])
+			if err != nil {
+				return err
+			}
+			newS, err := graphql.Load(args[1])
+			if err != nil {
+				return err
+			}
+
+			res := graphql.Diff(oldS, newS)
+
+			out := cmd.OutOrStdout()
+			fmt.Fprintf(out, "Recommended bump: %s\n\n", res.RecommendedBump)
+			if len(res.Breaking) == 0 && len(res.NonBreaking) == 0 {
+				fmt.Fprintln(out, "No schema changes.")
+				return nil
+			}
+			if len(res.Breaking) > 0 {
+				fmt.Fprintln(out, "Breaking")
+				fmt.Fprintln(out, "--------")
+				for _, c := range res.Breaking {
+					fmt.Fprintf(out, "  ✗ %s\n", c)
+				}
+				fmt.Fprintln(out)
+			}
+			if len(res.NonBreaking) > 0 {
+				fmt.Fprintln(out, "Non-breaking")
+				fmt.Fprintln(out, "------------")
+				for _, c := range res.NonBreaking {
+					fmt.Fprintf(out, "  + %s\n", c)
+				}
+			}
+
+			if failOnBreaking && len(res.Breaking) > 0 {
+				return fmt.Errorf("%d breaking change(s)", len(res.Breaking))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&failOnBreaking, "fail-on-breaking", false, "exit non-zero when breaking changes are found")
+	return cmd
+}
+
+func gqlClient(cmd *cobra.Command, headers []string) (*graphql.Client, error) {
+	h := http.Header{}
+	for k, v := range parseHeaders(headers) {
+		h.Set(k, v)
+	}
+	hc, err := authHTTPClient(cmd, 30*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	return &graphql.Client{HTTP: hc, Headers: h}, nil
+}
+
+// loadGQL returns the workspace GraphQL record and its schema snapshot.
+func loadGQL(cmd *cobra.Command) (*store.GraphQL, *graphql.Schema, error) {
+	cacheRoot, _ := cmd.Root().PersistentFlags().GetString("cache")
+	apiName, _ := cmd.Root().PersistentFlags().GetString("api")
+	cacheRoot, _ = store.DefaultRoot(cacheRoot)
+
+	api, err := store.Read(cacheRoot, apiName)
+	if err != nil || api.GraphQL == nil || api.GraphQL.Snapshot == "" {
+		return nil, nil, fmt.Errorf("no GraphQL schema loaded. Run: restless gql introspect <url>")
+	}
+	s, err := graphql.Load(api.GraphQL.Snapshot)
+	if err != nil {
+		return nil, nil, err
+	}
+	return api.GraphQL, s, nil
+}
+
+// gqlWorkspace turns an introspection into the workspace model: the
+// endpoint itself plus one operation per root field.
+func gqlWorkspace(u *url.URL, snapshot string, s *graphql.Schema, now time.Time) *store.API {
+	gq := &store.GraphQL{
+		Endpoint:     u.String(),
+		Snapshot:     snapshot,
+		Introspected: now,
+	}
+	for _, op := range s.Operations() {
+		o := store.Operation{
+			Kind:       op.Kind,
+			Name:       op.Name,
+			Returns:    op.Returns.String(),
+			Deprecated: op.Deprecated,
+		}
+		for _, a := range op.Args {
+			o.Args = append(o.Args, a.Name+": "+a.Type.String())
+		}
+		gq.Operations = append(gq.Operations, o)
+	}
+
+	path := u.Path
+	if path == "" {
+		path = "/"
+	}
+	return &store.API{
+		BaseURL: u.Scheme + "://" + u.Host,
+		Endpoints: []store.Endpoint{{
+			Path:       path,
+			Methods:    []string{http.MethodPost},
+			Statuses:   map[string][]int{http.MethodPost: {http.StatusOK}},
+			Source:     "graphql-introspection",
+			Confidence: "high",
+			FirstSeen:  now,
+		}},
+		GraphQL: gq,
+	}
+}
+
+func printGQLOps(out io.Writer, ops []graphql.Operation, kind string) {
+	for _, op := range ops {
+		if kind != "" && op.Kind != kind {
+			continue
+		}
+		line := fmt.Sprintf("  %-12s %s", op.Kind, op.Signature())
+		if op.Deprecated {
+			line += "  [deprecated" + gqlReason(op.Reason) + "]"
+		}
+		fmt.Fprintln(out, line)
+	}
+}
+
+func gqlReason(r string) string {
+	if r == "" {
+		return ""
+	}
+	return ": " + r
+}
+
+// gqlValue parses a --var value as JSON when possible, so numbers, booleans
+// and objects keep their type; anything else is a string.
+func gqlValue(s string) any {
+	var v any
+	if err := json.Unmarshal([]byte(s), &v); err == nil {
+		return v
+	}
+	return s
+}
@@ -1,18 +1,32 @@
 package cli
 
 import (
+	"encoding/json"
 	"fmt"
+	"strings"
 
 	"github.com/bspippi1337/restless/internal/discovery"
+	"github.com/bspippi1337/restless/internal/store"
 	"github.com/spf13/cobra"
 )
 
 func NewInspectCmd() *cobra.Command {
+	var showSchema bool
+
 	cmd := &cobra.Command{
 		Use:   "inspect <target>",
 		Short: "Fingerprint and inspect a target",
-		Args:  cobra.ExactArgs(1),
+		Args: func(cmd *cobra.Command, args []string) error {
+			if showSchema {
+				return cobra.MaximumNArgs(1)(cmd, args)
+			}
+			return cobra.ExactArgs(1)(cmd, args)
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if showSchema {
+				return printSchemas(cmd, args)
+			}
+
 			target := args[0]
 
 			fmt.Println("Restless API Discovery Engine")
@@ -74,5 +88,47 @@ func NewInspectCmd() *cobra.Command {
 		},
 	}
 
+	cmd.Flags().BoolVar(&showSchema, "schema", false, "show inferred response schemas of learned endpoints (optionally one path)")
+
 	return cmd
 }
+
+func printSchemas(cmd *cobra.Command, args []string) error {
+	cacheRoot, _ := cmd.Root().PersistentFlags().GetString("cache")
+	apiName, _ := cmd.Root().PersistentFlags().GetString("api")
+	cacheRoot, _ = store.DefaultRoot(cacheRoot)
+
+	api, err := store.Read(cacheRoot, apiName)
+	if err != nil {
+		return fmt.Errorf("no API loaded. Run: restless learn <url>")
+	}
+
+	out := cmd.OutOrStdout()
+	printed := 0
+
+	for _, e := range api.Endpoints {
+		if len(args) == 1 && e.Path != args[0] {
+			continue
+		}
+		if e.Schema == nil {
+			continue
+		}
+
+		b, err := json.MarshalIndent(e.Schema, "", "  ")
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(out, "%s %s\n", strings.Join(e.Methods, ","), e.Path)
+		fmt.Fprintln(out, string(b))
+		fmt.Fprintln(out)
+		printed++
+	}
+
+	if printed == 0 {
+		fmt.Fprintln(out, "No inferred schema recorded.")
+		fmt.Fprintln(out, "Run: restless learn <url>")
+	}
+
+	return nil
+}
@@ -2,25 +2,58 @@ package cli
 
 import (
 	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
 
+	"github.com/bspippi1337/restless/internal/audit"
+	"github.com/bspippi1337/restless/internal/auth"
+	"github.com/bspippi1337/restless/internal/council"
+	"github.com/bspippi1337/restless/internal/har"
+	"github.com/bspippi1337/restless/internal/modules/session"
 	"github.com/bspippi1337/restless/internal/restlesscore"
+	"github.com/bspippi1337/restless/internal/store"
+	"github.com/bspippi1337/restless/internal/version"
 )
 
 func NewLearnCmd() *cobra.Command {
 
 	var timeout time.Duration
+	var harFile string
+	var collection string
+	var sarifOut string
+	var noAudit bool
 
 	cmd := &cobra.Command{
 		Use:   "learn <host>",
 		Short: "Adaptive endpoint learner",
-		Args:  cobra.ExactArgs(1),
+		Args: func(cmd *cobra.Command, args []string) error {
+			if harFile != "" {
+				return cobra.MaximumNArgs(1)(cmd, args)
+			}
+			return cobra.ExactArgs(1)(cmd, args)
+		},
 
 		RunE: func(cmd *cobra.Command, args []string) error {
 
-			r, err := restlesscore.Scan(args[0], timeout)
+			if harFile != "" {
+				host := ""
+				if len(args) == 1 {
+					host = args[0]
+				}
+				return learnHAR(cmd, harFile, host, collection)
+			}
+
+			client, err := authHTTPClient(cmd, timeout)
+			if err != nil {
+				return err
+			}
+			r, err := restlesscore.ScanWithOptions(args[0], client, restlesscore.Options{NoAudit: noAudit})
 			if err != nil {
 				return err
 			}
@@ -30,6 +63,44 @@ func NewLearnCmd() *cobra.Command {
 				restlesscore.Render("RESTLESS LEARN", r),
 			)
 
+			if len(r.Security) > 0 {
+				fmt.Fprintln(cmd.OutOrStdout(), "SECURITY")
+				fmt.Fprintln(cmd.OutOrStdout(), "--------")
+				fmt.Fprint(cmd.OutOrStdout(), audit.PrintHuman(r.Security))
+			}
+			if sarifOut != "" {
+				b, err := audit.ToSARIF(version.String(), r.Security)
+				if err != nil {
+					return err
+				}
+				if err := os.WriteFile(sarifOut, b, 0644); err != nil {
+					return err
+				}
+			}
+
+			cacheRoot, _ := cmd.Root().PersistentFlags().GetString("cache")
+			cacheRoot, err = store.DefaultRoot(cacheRoot)
+			if err != nil {
+				return err
+			}
+
+			apiName, _ := cmd.Root().PersistentFlags().GetString("api")
+			prev, _ := store.Read(cacheRoot, apiName)
+
+			api := learnedAPI(r, time.Now().UTC())
+			store.CarryFirstSeen(prev, api)
+			store.CarrySchemas(prev, api)
+			printAuth(cmd.OutOrStdout(), api)
+
+			path, err := store.Write(cacheRoot, api)
+			if err != nil {
+				return err
+			}
+			if err := publishAPI(cacheRoot, api, council.Hypermedia, "linked from the root document"); err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "Saved → %s\n", path)
 			return nil
 		},
 	}
@@ -40,6 +111,144 @@ func NewLearnCmd() *cobra.Command {
 		7*time.Second,
 		"HTTP timeout",
 	)
+	cmd.Flags().StringVar(&harFile, "har", "", "learn from a HAR capture instead of probing (host optional)")
+	cmd.Flags().StringVar(&collection, "collection", "", "with --har: also write the calls as a flow file")
+	cmd.Flags().StringVar(&sarifOut, "sarif", "", "write security audit findings as SARIF")
+	cmd.Flags().BoolVar(&noAudit, "no-audit", false, "skip the security audit and its probes of sensitive paths (/.env, /.git/config, ...)")
 
 	return cmd
 }
+
+func learnHAR(cmd *cobra.Command, file, host, collection string) error {
+	h, err := har.Load(file)
+	if err != nil {
+		return err
+	}
+
+	res, err := har.Learn(h, har.Options{Host: host})
+	if err != nil {
+		return err
+	}
+
+	cacheRoot, _ := cmd.Root().PersistentFlags().GetString("cache")
+	apiName, _ := cmd.Root().PersistentFlags().GetString("api")
+	cacheRoot, err = store.DefaultRoot(cacheRoot)
+	if err != nil {
+		return err
+	}
+
+	prev, _ := store.Read(cacheRoot, apiName)
+	store.CarryFirstSeen(prev, res.API)
+
+	path, err := store.Write(cacheRoot, res.API)
+	if err != nil {
+		return err
+	}
+	if err := publishAPI(cacheRoot, res.API, council.Traffic, "seen in "+filepath.Base(file)); err != nil {
+		return err
+	}
+
+	out := cmd.OutOrStdout()
+	fmt.Fprintln(out, "RESTLESS LEARN (HAR)")
+	fmt.Fprintf(out, "Base: %s\n", res.API.BaseURL)
+	fmt.Fprintf(out, "Calls: %d  skipped: %d\n\n", res.Calls, res.Skipped)
+	for _, e := range res.API.Endpoints {
+		fmt.Fprintf(out, "  %-28s %s\n", strings.Join(e.Methods, ","), e.Path)
+	}
+	fmt.Fprintln(out)
+	printAuth(out, res.API)
+	fmt.Fprintf(out, "Saved → %s\n", path)
+
+	if collection != "" {
+		if err := session.SaveFlow(collection, res.Collection); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Collection → %s (replay: restless flow %s)\n", collection, collection)
+	}
+
+	return nil
+}
+
+// learnedAPI keeps the endpoints that actually answered, one entry per path,
+// and classifies which of them need credentials.
+func learnedAPI(r *restlesscore.ScanResult, now time.Time) *store.API {
+	api := &store.API{BaseURL: r.BaseURL}
+	index := map[string]int{}
+	challenges := map[string]string{}
+
+	for _, ep := range r.Confirmed {
+		if ep.Status == 0 || ep.Status == http.StatusNotFound {
+			continue
+		}
+
+		i, ok := index[ep.Path]
+		if !ok {
+			i = len(api.Endpoints)
+			index[ep.Path] = i
+			api.Endpoints = append(api.Endpoints, store.Endpoint{
+				Path:       ep.Path,
+				Statuses:   map[string][]int{},
+				Source:     ep.Source,
+				Confidence: ep.Confidence,
+				FirstSeen:  now,
+			})
+		}
+
+		e := &api.Endpoints[i]
+		e.Methods = append(e.Methods, ep.Method)
+		e.Statuses[ep.Method] = append(e.Statuses[ep.Method], ep.Status)
+		e.Allow = ep.Allow
+		if ep.Schema != nil && ep.Status < 400 {
+			e.Schema = ep.Schema
+		}
+		if ep.Pagination != nil {
+			e.Pagination = ep.Pagination
+		}
+		if ep.Challenge != "" {
+			challenges[ep.Path] = ep.Challenge
+		}
+	}
+
+	auth.Classify(api, auth.Evidence{
+		Challenges: challenges,
+		OIDC:       r.AuthServer,
+		OIDCURL:    r.AuthServerURL,
+	})
+
+	return api
+}
+
+// printAuth lists the credential schemes found and which endpoints need them.
+func printAuth(out io.Writer, api *store.API) {
+	protected := false
+	for _, e := range api.Endpoints {
+		protected = protected || e.Access == auth.Protected || e.Access == auth.Mixed
+	}
+	if api.Auth == nil && !protected {
+		return
+	}
+	fmt.Fprintln(out, "AUTH")
+	fmt.Fprintln(out, "----")
+	if a := api.Auth; a != nil {
+		for _, s := range a.Schemes {
+			fmt.Fprintf(out, "  %-12s %s  [%s]\n", s.ID, auth.Describe(s), s.Source)
+		}
+		if a.Issuer != "" {
+			fmt.Fprintf(out, "  issuer: %s\n", a.Issuer)
+		}
+		for _, p := range a.TokenEndpoints {
+			fmt.Fprintf(out, "  token endpoint: %s\n", p)
+		}
+	}
+	for _, e := range api.Endpoints {
+		if e.Access == "" {
+			continue
+		}
+		label := e.Access
+		if len(e.Auth) > 0 {
+			label += " (" + strings.Join(e.Auth, ", ") + ")"
+		}
+		fmt.Fprintf(out, "  %-24s %s\n", label, e.Path)
+	}
+	fmt.Fprintln(out)
+}
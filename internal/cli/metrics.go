@@ -0,0 +1,35 @@
+package cli
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bspippi1337/restless/internal/metrics"
+)
+
+// addMetricsFlag gives a long-running command --metrics-addr.
+func addMetricsFlag(cmd *cobra.Command, addr *string) {
+	cmd.Flags().StringVar(addr, "metrics-addr", "", "serve OpenMetrics on this address at /metrics (e.g. :9090)")
+}
+
+// startMetrics serves the metrics registry on addr and times every
+// request sent through http.DefaultTransport. stop undoes both; with
+// no addr it does nothing.
+func startMetrics(cmd *cobra.Command, addr string) (stop func(), err error) {
+	if addr == "" {
+		return func() {}, nil
+	}
+	srv, at, err := metrics.Serve(addr, metrics.Default)
+	if err != nil {
+		return nil, fmt.Errorf("metrics: %w", err)
+	}
+	prev := http.DefaultTransport
+	http.DefaultTransport = &metrics.Transport{Base: prev}
+	fmt.Fprintf(cmd.ErrOrStderr(), "Metrics → http://%s/metrics\n", at)
+	return func() {
+		http.DefaultTransport = prev
+		_ = srv.Close()
+	}, nil
+}
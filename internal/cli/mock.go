@@ -0,0 +1,150 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/spf13/cobra"
+
+	"github.com/bspippi1337/restless/internal/metrics"
+	"github.com/bspippi1337/restless/internal/mock"
+	"github.com/bspippi1337/restless/internal/modules/openapi/guard/loader"
+	"github.com/bspippi1337/restless/internal/modules/openapi/infer"
+	"github.com/bspippi1337/restless/internal/store"
+)
+
+func NewMockCmd() *cobra.Command {
+
+	var specRef string
+	var listen string
+	var opt mock.Options
+	var metricsAddr string
+
+	cmd := &cobra.Command{
+		Use:   "mock [--spec openapi.yaml]",
+		Short: "Serve a mock API from an OpenAPI spec or the learned API",
+		Long: `Serve every operation of a spec with its examples, or with values
+synthesized from its schemas when there are none.
+
+Without --spec the mock is built from the API learned into the
+workspace. Requests are validated against the spec (400 on mismatch),
+simple collections such as /pets + /pets/{id} keep state in memory so
+POST, GET, PUT, PATCH and DELETE behave, and --latency / --error-rate
+inject slowness and failures. Send "Prefer: code=404" to get a
+specific declared response.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			doc, source, err := mockSpec(ctx, cmd, specRef)
+			if err != nil {
+				return err
+			}
+			if opt.ErrorRate < 0 || opt.ErrorRate > 1 {
+				return fmt.Errorf("--error-rate must be between 0 and 1")
+			}
+			if opt.ErrorStatus < 100 || opt.ErrorStatus > 599 {
+				return fmt.Errorf("--error-status must be between 100 and 599")
+			}
+
+			out := cmd.OutOrStdout()
+			opt.Log = out
+			srv, err := mock.New(doc, opt)
+			if err != nil {
+				return err
+			}
+
+			stopMetrics, err := startMetrics(cmd, metricsAddr)
+			if err != nil {
+				return err
+			}
+			defer stopMetrics()
+
+			ln, err := net.Listen("tcp", listen)
+			if err != nil {
+				return err
+			}
+			hs := &http.Server{Handler: metrics.Handler(srv), ReadHeaderTimeout: 10 * time.Second}
+
+			ops := srv.Operations()
+			fmt.Fprintln(out, "RESTLESS MOCK")
+			fmt.Fprintf(out, "Source: %s\n", source)
+			fmt.Fprintf(out, "Mocking %d operations on http://%s\n", len(ops), ln.Addr())
+			for _, op := range ops {
+				fmt.Fprintf(out, "  %s\n", op)
+			}
+			if colls := srv.Collections(); len(colls) > 0 {
+				fmt.Fprintf(out, "Stateful: %v\n", colls)
+			}
+			fmt.Fprintln(out, "Ctrl-C to stop.")
+			fmt.Fprintln(out)
+
+			errc := make(chan error, 1)
+			go func() { errc <- hs.Serve(ln) }()
+
+			select {
+			case <-ctx.Done():
+			case err := <-errc:
+				if !errors.Is(err, http.ErrServerClosed) {
+					return err
+				}
+			}
+
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			return hs.Shutdown(shutdownCtx)
+		},
+	}
+
+	cmd.Flags().StringVar(&specRef, "spec", "", "OpenAPI spec (file, URL or imported spec ID); default is the learned API")
+	cmd.Flags().StringVar(&listen, "listen", ":4010", "address to listen on")
+	cmd.Flags().DurationVar(&opt.Latency, "latency", 0, "delay added to every response (e.g. 200ms)")
+	cmd.Flags().DurationVar(&opt.Jitter, "jitter", 0, "random extra delay up to this much")
+	cmd.Flags().Float64Var(&opt.ErrorRate, "error-rate", 0, "fraction of requests that fail (0..1)")
+	cmd.Flags().IntVar(&opt.ErrorStatus, "error-status", http.StatusInternalServerError, "status returned for injected failures")
+	cmd.Flags().Int64Var(&opt.Seed, "seed", 0, "seed for jitter and error injection (0 = random)")
+	cmd.Flags().BoolVar(&opt.NoValidate, "no-validate", false, "serve requests that do not match the spec")
+	cmd.Flags().BoolVar(&opt.Stateless, "stateless", false, "always answer with examples, keep no state")
+	addMetricsFlag(cmd, &metricsAddr)
+
+	return cmd
+}
+
+// mockSpec loads --spec, or infers a spec from the workspace.
+func mockSpec(ctx context.Context, cmd *cobra.Command, ref string) (*openapi3.T, string, error) {
+	if ref != "" {
+		path, err := resolveSpecRef(cmd, ref)
+		if err != nil {
+			return nil, "", err
+		}
+		doc, err := loader.Load(ctx, path, loader.LoadOptions{})
+		if err != nil {
+			return nil, "", fmt.Errorf("load spec: %w", err)
+		}
+		return doc, ref, nil
+	}
+
+	cacheRoot, _ := cmd.Root().PersistentFlags().GetString("cache")
+	apiName, _ := cmd.Root().PersistentFlags().GetString("api")
+	cacheRoot, _ = store.DefaultRoot(cacheRoot)
+
+	api, err := store.Read(cacheRoot, apiName)
+	if err != nil {
+		return nil, "", fmt.Errorf("no API loaded. Run: restless learn <url>, or pass --spec")
+	}
+	doc, err := infer.Build(api, infer.Options{})
+	if err != nil {
+		return nil, "", err
+	}
+	return doc, "learned API " + api.BaseURL, nil
+}
@@ -0,0 +1,437 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bspippi1337/restless/internal/core/app"
+	"github.com/bspippi1337/restless/internal/modules/openapi"
+	"github.com/bspippi1337/restless/internal/output"
+	"github.com/bspippi1337/restless/internal/store"
+)
+
+func NewOpenAPICmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "openapi",
+		Short: "Import OpenAPI specs into an offline catalog and call their operations",
+		Long: `Imported specs are kept in the workspace, so they can be browsed and
+called without the network that served them. Specs are named by ID;
+any unique prefix of it works, as does the file or URL imported.
+
+The --spec flag of fuzz, mock and proxy also accepts a catalog ID.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			useSpecCatalog(cmd)
+			return openapi.ListCached(cmd.OutOrStdout())
+		},
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "ls",
+		Short: "List imported specs, newest first",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			useSpecCatalog(cmd)
+			return openapi.ListCached(cmd.OutOrStdout())
+		},
+	})
+	cmd.AddCommand(newOpenAPIImportCmd())
+	cmd.AddCommand(newOpenAPIShowCmd())
+	cmd.AddCommand(newOpenAPIEndpointsCmd())
+	cmd.AddCommand(newOpenAPIRunCmd())
+	cmd.AddCommand(newOpenAPIRmCmd())
+	return cmd
+}
+
+// useSpecCatalog points the spec catalog at the --cache workspace.
+func useSpecCatalog(cmd *cobra.Command) {
+	cacheRoot, _ := cmd.Root().PersistentFlags().GetString("cache")
+	cacheRoot, _ = store.DefaultRoot(cacheRoot)
+	openapi.Root = cacheRoot
+}
+
+// resolveSpecRef lets --spec name an imported spec: anything that is
+// not a URL or an existing file is looked up in the catalog.
+func resolveSpecRef(cmd *cobra.Command, ref string) (string, error) {
+	if ref == "" || strings.HasPrefix(ref, "http://") || strings.HasPrefix(ref, "https://") {
+		return ref, nil
+	}
+	if _, err := os.Stat(ref); err == nil {
+		return ref, nil
+	}
+	useSpecCatalog(cmd)
+	idx, err := openapi.Resolve(ref)
+	if err != nil {
+		return "", fmt.Errorf("spec %s: not a file, URL or imported spec", ref)
+	}
+	return idx.RawPath, nil
+}
+
+func newOpenAPIImportCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "import <file|url>...",
+		Short: "Import specs into the catalog (importing again refreshes them)",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			useSpecCatalog(cmd)
+			out := cmd.OutOrStdout()
+			for _, src := range args {
+				idx, err := openapi.Import(src)
+				if err != nil {
+					return err
+				}
+				eps, _ := openapi.ListEndpoints(idx.ID)
+				fmt.Fprintf(out, "Imported %s  %s %s  (%d operations)\n", idx.ShortID(), idx.Title, idx.Version, len(eps))
+				fmt.Fprintf(out, "Saved → %s\n", idx.RawPath)
+			}
+			return nil
+		},
+	}
+}
+
+func newOpenAPIShowCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "show <id> [<METHOD> <path>]",
+		Short: "Show an imported spec, or what one of its operations takes",
+		Long: `Show an imported spec. Given an operation, show its parameters, a
+request body to start from and the responses it declares.
+
+  restless openapi show 3f2a
+  restless openapi show 3f2a POST /pets`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 && len(args) != 3 {
+				return fmt.Errorf("accepts <id> or <id> <METHOD> <path>, received %d args", len(args))
+			}
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			useSpecCatalog(cmd)
+			idx, err := openapi.Resolve(args[0])
+			if err != nil {
+				return err
+			}
+			if len(args) == 3 {
+				spec, err := openapi.LoadSpecFromFile(idx.RawPath)
+				if err != nil {
+					return err
+				}
+				method := strings.ToUpper(args[1])
+				tpl, _, err := openapi.MatchEndpoint(spec, method, args[2])
+				if err != nil {
+					return fmt.Errorf("%w (see: restless openapi endpoints %s)", err, idx.ShortID())
+				}
+				return openapi.PrintOperation(cmd.OutOrStdout(), spec, method, tpl)
+			}
+
+			eps, err := openapi.ListEndpoints(idx.ID)
+			if err != nil {
+				return err
+			}
+
+			out := cmd.OutOrStdout()
+			fmt.Fprintf(out, "ID:         %s\n", idx.ID)
+			fmt.Fprintf(out, "Title:      %s\n", idx.Title)
+			fmt.Fprintf(out, "Version:    %s\n", idx.Version)
+			fmt.Fprintf(out, "Base:       %s\n", idx.BaseURL)
+			fmt.Fprintf(out, "Source:     %s\n", idx.Source)
+			fmt.Fprintf(out, "Imported:   %s\n", time.Unix(idx.Imported, 0).Format("2006-01-02 15:04:05"))
+			fmt.Fprintf(out, "File:       %s\n", idx.RawPath)
+			fmt.Fprintf(out, "Operations: %d\n", len(eps))
+
+			tags := map[string]int{}
+			for _, e := range eps {
+				for _, t := range e.Tags {
+					tags[t]++
+				}
+			}
+			if len(tags) > 0 {
+				names := make([]string, 0, len(tags))
+				for t := range tags {
+					names = append(names, t)
+				}
+				sort.Strings(names)
+				fmt.Fprintln(out)
+				fmt.Fprintln(out, "TAGS")
+				fmt.Fprintln(out, "----")
+				for _, t := range names {
+					fmt.Fprintf(out, "  %-24s %d\n", t, tags[t])
+				}
+			}
+			return nil
+		},
+	}
+}
+
+func newOpenAPIEndpointsCmd() *cobra.Command {
+	var tag string
+	var grep string
+
+	cmd := &cobra.Command{
+		Use:   "endpoints <id>",
+		Short: "List the operations of an imported spec",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			useSpecCatalog(cmd)
+			eps, err := openapi.ListEndpoints(args[0])
+			if err != nil {
+				return err
+			}
+			openapi.PrintEndpoints(cmd.OutOrStdout(), openapi.FilterEndpoints(eps, tag, grep))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&tag, "tag", "", "only operations with this tag")
+	cmd.Flags().StringVar(&grep, "grep", "", "only operations whose method, path, summary or operation ID contains this")
+	return cmd
+}
+
+func newOpenAPIRunCmd() *cobra.Command {
+	var params []string
+	var query []string
+	var headers []string
+	var data string
+	var base string
+	var curl bool
+	var noValidate bool
+	var strict bool
+	var out output.Options
+	var timeout time.Duration
+
+	cmd := &cobra.Command{
+		Use:   "run <id> <METHOD> <path>",
+		Short: "Call an operation of an imported spec",
+		Long: `Call an operation of an imported spec. The path is either the declared
+template, with --param filling it, or a concrete path such as /pets/42.
+
+  restless openapi run 3f2a GET /pets/{petId} --param petId=42
+  restless openapi run 3f2a POST /pets --data @pet.json
+  restless openapi run 3f2a GET /pets --query limit=5 --curl
+
+Parameters and JSON bodies are checked against the spec before anything
+is sent (--no-validate skips this); see what an operation takes with:
+restless openapi show <id> <METHOD> <path>`,
+		Args: cobra.ExactArgs(3),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			useSpecCatalog(cmd)
+			idx, err := openapi.Resolve(args[0])
+			if err != nil {
+				return err
+			}
+			spec, err := openapi.LoadSpecFromFile(idx.RawPath)
+			if err != nil {
+				return err
+			}
+
+			method := strings.ToUpper(args[1])
+			tpl, bound, err := openapi.MatchEndpoint(spec, method, args[2])
+			if err != nil {
+				return fmt.Errorf("%w (see: restless openapi endpoints %s)", err, idx.ShortID())
+			}
+
+			ra := openapi.RunArgs{
+				ID:           idx.ID,
+				Method:       method,
+				Path:         tpl,
+				BaseOverride: base,
+				PathParams:   bound,
+				Headers:      parseHeaders(headers),
+				ShowCurl:     curl,
+			}
+			if ra.PathParams == nil {
+				ra.PathParams = map[string]string{}
+			}
+			if ra.PathParams, err = keyValues("--param", params, ra.PathParams); err != nil {
+				return err
+			}
+			if ra.QueryParams, err = keyValues("--query", query, nil); err != nil {
+				return err
+			}
+			if err := openapi.ValidatePathParams(tpl, ra.PathParams); err != nil {
+				return fmt.Errorf("%w (pass --param name=value)", err)
+			}
+			op, _, err := openapi.Operation(spec, method, tpl)
+			if err != nil {
+				return err
+			}
+			if ra.Body, err = requestBody(data); err != nil {
+				return err
+			}
+			ct, _, _ := openapi.BodyTemplate(op)
+			if len(ra.Body) > 0 && !hasHeader(ra.Headers, "Content-Type") {
+				switch {
+				case ct != "":
+					ra.Headers["Content-Type"] = ct
+				case json.Valid(ra.Body):
+					ra.Headers["Content-Type"] = "application/json"
+				}
+			}
+
+			req, snippet, err := openapi.BuildRequest(idx, spec, ra)
+			if err != nil {
+				return err
+			}
+			if !noValidate {
+				if err := openapi.NewPreflight(spec).Check(req.Method, req.URL, req.Headers, req.Body); err != nil {
+					return fmt.Errorf("%w\n(see: restless openapi show %s %s %s)", err, idx.ShortID(), method, tpl)
+				}
+			}
+			if curl {
+				fmt.Fprintln(cmd.OutOrStdout(), snippet)
+				return nil
+			}
+
+			mods, err := authModules(cmd)
+			if err != nil {
+				return err
+			}
+			contract := contractModule(cmd)
+			a, err := app.New(append(mods, contract))
+			if err != nil {
+				return err
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			res, err := a.RunOnce(ctx, req)
+			if err != nil {
+				return err
+			}
+
+			w := statusOut(cmd, out)
+			fmt.Fprintln(w, req.Method, req.URL)
+			fmt.Fprintln(w, res.StatusCode, http.StatusText(res.StatusCode))
+			if err := output.Render(cmd.OutOrStdout(), res.Body, out); err != nil {
+				return err
+			}
+			reportDrift(cmd, contract)
+			return strictDrift(contract, strict)
+		},
+	}
+
+	cmd.Flags().StringArrayVar(&params, "param", nil, "path parameter name=value (repeatable)")
+	cmd.Flags().StringArrayVar(&query, "query", nil, "query parameter name=value (repeatable)")
+	cmd.Flags().StringArrayVarP(&headers, "header", "H", nil, "header 'Name: value' (repeatable)")
+	cmd.Flags().StringVarP(&data, "data", "d", "", "request body, or @file to read it from a file")
+	cmd.Flags().StringVar(&base, "base", "", "base URL instead of the spec's first server")
+	cmd.Flags().BoolVar(&curl, "curl", false, "print the request as a curl command instead of sending it")
+	addNoValidateFlag(cmd, &noValidate)
+	addStrictFlag(cmd, &strict)
+	cmd.Flags().DurationVarP(&timeout, "timeout", "t", 10*time.Second, "timeout")
+	addOutputFlags(cmd, &out)
+	return cmd
+}
+
+func newOpenAPIRmCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "rm <id>...",
+		Short: "Remove imported specs",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			useSpecCatalog(cmd)
+			for _, id := range args {
+				idx, err := openapi.Resolve(id)
+				if err != nil {
+					return err
+				}
+				if err := openapi.Remove(idx); err != nil {
+					return err
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "Removed %s  %s\n", idx.ShortID(), idx.Title)
+			}
+			return nil
+		},
+	}
+}
+
+func addNoValidateFlag(cmd *cobra.Command, noValidate *bool) {
+	cmd.Flags().BoolVar(noValidate, "no-validate", false, "send requests that break the OpenAPI contract instead of refusing them")
+}
+
+func addStrictFlag(cmd *cobra.Command, strict *bool) {
+	cmd.Flags().BoolVar(strict, "strict", os.Getenv("RESTLESS_STRICT") == "1", "exit non-zero when a response breaks the OpenAPI contract (default from RESTLESS_STRICT=1)")
+}
+
+// contractModule checks responses against the spec imported into the
+// --cache workspace for their URL, or one discovered on the host.
+func contractModule(cmd *cobra.Command) *openapi.Module {
+	useSpecCatalog(cmd)
+	return openapi.New()
+}
+
+// reportDrift prints what the contract module found in the responses
+// since it last reported.
+func reportDrift(cmd *cobra.Command, m *openapi.Module) {
+	openapi.PrintDrift(cmd.ErrOrStderr(), m.Take())
+}
+
+// strictDrift fails a --strict run whose responses broke the contract.
+func strictDrift(m *openapi.Module, strict bool) error {
+	if n := m.Violations(); strict && n > 0 {
+		return fmt.Errorf("responses break the OpenAPI contract (%d findings)", n)
+	}
+	return nil
+}
+
+// preflight is the contract check requests are run through: against
+// --spec, or else the imported spec whose base URL a request is under.
+func preflight(cmd *cobra.Command, specRef string) (*openapi.Preflight, error) {
+	if specRef == "" {
+		useSpecCatalog(cmd)
+		return openapi.NewPreflight(nil), nil
+	}
+	ref, err := resolveSpecRef(cmd, specRef)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := openapi.LoadSpecFromFile(ref)
+	if err != nil {
+		return nil, err
+	}
+	return openapi.NewPreflight(doc), nil
+}
+
+// keyValues adds name=value flags to into.
+func keyValues(flag string, kvs []string, into map[string]string) (map[string]string, error) {
+	if into == nil {
+		into = map[string]string{}
+	}
+	for _, kv := range kvs {
+		k, v, ok := strings.Cut(kv, "=")
+		if !ok || k == "" {
+			return nil, fmt.Errorf("bad %s %q: want name=value", flag, kv)
+		}
+		into[k] = v
+	}
+	return into, nil
+}
+
+// requestBody reads --data, which may name a file as @path.
+func requestBody(data string) ([]byte, error) {
+	if path, ok := strings.CutPrefix(data, "@"); ok {
+		if path == "-" {
+			return io.ReadAll(os.Stdin)
+		}
+		return os.ReadFile(path)
+	}
+	return []byte(data), nil
+}
+
+func hasHeader(h map[string]string, name string) bool {
+	for k := range h {
+		if strings.EqualFold(k, name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,41 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bspippi1337/restless/internal/output"
+)
+
+// addOutputFlags adds -o, --columns and --query to a command that prints
+// API data. Where --query already means something else (openapi run's
+// query parameters, gql's document) the expression is --filter.
+func addOutputFlags(cmd *cobra.Command, o *output.Options) {
+	cmd.Flags().StringVarP(&o.Format, "output", "o", "", "output format: "+strings.Join(output.Formats, "|")+" (default json)")
+	cmd.Flags().StringSliceVar(&o.Columns, "columns", nil, "table and csv columns in order, by dotted path (id,user.name)")
+	name := "query"
+	if cmd.Flags().Lookup(name) != nil {
+		name = "filter"
+	}
+	cmd.Flags().StringVar(&o.Query, name, "", "select and filter the response, e.g. '.items[] | select(.age > 30) | {id, name}'")
+}
+
+// statusOut is where a command's request and status lines go: stdout,
+// unless the body is printed for another program to read.
+func statusOut(cmd *cobra.Command, o output.Options) io.Writer {
+	if o.Piped() {
+		return cmd.ErrOrStderr()
+	}
+	return cmd.OutOrStdout()
+}
+
+// printResponse writes the request and status lines and the body.
+func printResponse(cmd *cobra.Command, o output.Options, method, url, status string, body []byte) error {
+	w := statusOut(cmd, o)
+	fmt.Fprintln(w, method, url)
+	fmt.Fprintln(w, status)
+	return output.Render(cmd.OutOrStdout(), body, o)
+}
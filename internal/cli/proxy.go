@@ -0,0 +1,183 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bspippi1337/restless/internal/council"
+	"github.com/bspippi1337/restless/internal/findings"
+	"github.com/bspippi1337/restless/internal/metrics"
+	"github.com/bspippi1337/restless/internal/modules/openapi/guard/loader"
+	"github.com/bspippi1337/restless/internal/modules/openapi/guard/model"
+	"github.com/bspippi1337/restless/internal/modules/openapi/guard/report"
+	gruntime "github.com/bspippi1337/restless/internal/modules/openapi/guard/runtime"
+	"github.com/bspippi1337/restless/internal/proxy"
+	"github.com/bspippi1337/restless/internal/store"
+)
+
+func NewProxyCmd() *cobra.Command {
+
+	var listen string
+	var upstream string
+	var cassetteFile string
+	var specRef string
+	var metricsAddr string
+
+	cmd := &cobra.Command{
+		Use:   "proxy --upstream <url>",
+		Short: "Recording proxy that learns an API from live traffic",
+		Long: `Forward traffic to --upstream and learn from every exchange.
+
+Point a browser, app or test suite at the listen address. On exit
+(Ctrl-C) the learned endpoints are merged into the workspace and the
+exchanges are written to a cassette with credentials redacted.
+With --spec, every JSON response is checked against the contract.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			if upstream == "" {
+				return fmt.Errorf("missing --upstream")
+			}
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			cacheRoot, _ := cmd.Root().PersistentFlags().GetString("cache")
+			cacheRoot, err := store.DefaultRoot(cacheRoot)
+			if err != nil {
+				return err
+			}
+			if cassetteFile == "" {
+				cassetteFile = filepath.Join(cacheRoot, "cassettes", "proxy-"+time.Now().UTC().Format("20060102-150405")+".json")
+			}
+
+			out := cmd.OutOrStdout()
+			opt := proxy.Options{Upstream: upstream, Log: out}
+			if specRef != "" {
+				ref, err := resolveSpecRef(cmd, specRef)
+				if err != nil {
+					return err
+				}
+				doc, err := loader.Load(ctx, ref, loader.LoadOptions{})
+				if err != nil {
+					return fmt.Errorf("load spec: %w", err)
+				}
+				opt.Spec = doc
+			}
+
+			rec, err := proxy.New(opt)
+			if err != nil {
+				return err
+			}
+
+			stopMetrics, err := startMetrics(cmd, metricsAddr)
+			if err != nil {
+				return err
+			}
+			defer stopMetrics()
+
+			ln, err := net.Listen("tcp", listen)
+			if err != nil {
+				return err
+			}
+			srv := &http.Server{Handler: metrics.Handler(rec), ReadHeaderTimeout: 10 * time.Second}
+
+			fmt.Fprintln(out, "RESTLESS PROXY")
+			fmt.Fprintf(out, "Listening: http://%s → %s\n", ln.Addr(), upstream)
+			fmt.Fprintln(out, "Ctrl-C to stop and save.")
+			fmt.Fprintln(out)
+
+			errc := make(chan error, 1)
+			go func() { errc <- srv.Serve(ln) }()
+
+			select {
+			case <-ctx.Done():
+			case err := <-errc:
+				if !errors.Is(err, http.ErrServerClosed) {
+					return err
+				}
+			}
+
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			_ = srv.Shutdown(shutdownCtx)
+
+			return saveProxySession(cmd, rec, cacheRoot, cassetteFile, specRef)
+		},
+	}
+
+	cmd.Flags().StringVar(&listen, "listen", ":8099", "address to listen on")
+	cmd.Flags().StringVar(&upstream, "upstream", "", "API to forward to (e.g. https://api.example.com)")
+	cmd.Flags().StringVar(&cassetteFile, "cassette", "", "cassette file (default ~/.restless/cassettes/proxy-<time>.json)")
+	cmd.Flags().StringVar(&specRef, "spec", "", "OpenAPI spec (file, URL or imported spec ID) to validate responses against")
+	addMetricsFlag(cmd, &metricsAddr)
+
+	return cmd
+}
+
+func saveProxySession(cmd *cobra.Command, rec *proxy.Recorder, cacheRoot, cassetteFile, specRef string) error {
+	out := cmd.OutOrStdout()
+	fmt.Fprintln(out)
+
+	if rec.Calls() == 0 {
+		fmt.Fprintln(out, "No traffic recorded.")
+		return nil
+	}
+
+	apiName, _ := cmd.Root().PersistentFlags().GetString("api")
+	prev, _ := store.Read(cacheRoot, apiName)
+
+	learned := rec.API()
+	api := store.Merge(prev, learned)
+
+	path, err := store.Write(cacheRoot, api)
+	if err != nil {
+		return err
+	}
+	if err := publishAPI(cacheRoot, learned, council.Traffic, "recorded by the proxy"); err != nil {
+		return err
+	}
+
+	if err := rec.Cassette().Save(cassetteFile); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "Calls: %d  endpoints: %d\n\n", rec.Calls(), len(learned.Endpoints))
+	for _, e := range learned.Endpoints {
+		fmt.Fprintf(out, "  %-28s %s\n", strings.Join(e.Methods, ","), e.Path)
+	}
+	fmt.Fprintln(out)
+	printAuth(out, learned)
+	fmt.Fprintf(out, "Saved → %s\n", path)
+	fmt.Fprintf(out, "Cassette → %s\n", cassetteFile)
+
+	if specRef != "" {
+		found := rec.Findings()
+		fmt.Fprintln(out)
+		fmt.Fprint(out, report.PrintHuman(model.GuardResult{
+			SpecRef:  specRef,
+			Findings: found,
+			CDI:      gruntime.ComputeCDI(found, gruntime.DefaultWeights()),
+		}))
+		saved := findings.FromGuard(found, time.Now().UTC())
+		if err := findings.Save(cacheRoot, saved); err != nil {
+			return err
+		}
+		if len(saved) > 0 {
+			fmt.Fprintf(out, "Findings → %s  (restless findings export <id>)\n", findingIDs(saved))
+		}
+	}
+
+	return nil
+}
@@ -20,23 +20,38 @@ and now also supports reactive filesystem execution workflows.
 Restless is designed as a composable Unix-native runtime layer.`,
 		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 			maybeAutopilot(args)
-			return nil
+			return startCassette(cmd)
 		},
 	}
 
 	cmd.PersistentFlags().StringP("api", "a", "", "API context")
 	cmd.PersistentFlags().StringP("cache", "c", "", "cache directory")
+	cmd.PersistentFlags().String("record", "", "record all HTTP traffic to a cassette file")
+	cmd.PersistentFlags().String("replay", "", "serve HTTP traffic from a cassette file instead of the network")
+	cmd.PersistentFlags().String("match", "method,url,body", "replay matching: method, url, body, header:<name>")
 
 	cmd.AddCommand(NewScanCmd())
 	cmd.AddCommand(NewDiscoverCmd())
 	cmd.AddCommand(NewLearnCmd())
+	cmd.AddCommand(NewAuthCmd())
 	cmd.AddCommand(NewTeachCmd())
 	cmd.AddCommand(NewCallCmd())
 	cmd.AddCommand(NewShellCmd())
+	cmd.AddCommand(NewExploreCmd())
 	cmd.AddCommand(NewMapCmd())
 	cmd.AddCommand(NewGraphCmd())
 	cmd.AddCommand(NewInspectCmd())
+	cmd.AddCommand(NewSpecCmd())
+	cmd.AddCommand(NewOpenAPICmd())
+	cmd.AddCommand(NewSuggestCmd())
+	cmd.AddCommand(NewGenCmd())
+	cmd.AddCommand(NewFlowCmd())
+	cmd.AddCommand(NewProxyCmd())
+	cmd.AddCommand(NewMockCmd())
+	cmd.AddCommand(NewBenchCmd())
+	cmd.AddCommand(NewGQLCmd())
 	cmd.AddCommand(NewFuzzCmd())
+	cmd.AddCommand(NewFindingsCmd())
 	cmd.AddCommand(NewCouncilCmd())
 	cmd.AddCommand(NewEngineCmd())
 	cmd.AddCommand(NewCopilotCmd())
@@ -57,7 +72,11 @@ Restless is designed as a composable Unix-native runtime layer.`,
 
 func Execute() {
 	root := NewRootCmd()
-	if err := root.Execute(); err != nil {
+	err := root.Execute()
+	if cerr := finishCassette(); err == nil {
+		err = cerr
+	}
+	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
@@ -6,14 +6,23 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"net/http"
 	"os"
+	"path/filepath"
+	"regexp"
 	"sort"
 	"strings"
 	"time"
 
+	prompt "github.com/c-bata/go-prompt"
 	"github.com/spf13/cobra"
 
-	"github.com/bspippi1337/restless/internal/httpx"
+	"github.com/bspippi1337/restless/internal/auth"
+	"github.com/bspippi1337/restless/internal/core/app"
+	"github.com/bspippi1337/restless/internal/core/state"
+	"github.com/bspippi1337/restless/internal/core/types"
+	"github.com/bspippi1337/restless/internal/modules/session"
+	"github.com/bspippi1337/restless/internal/output"
 	"github.com/bspippi1337/restless/internal/store"
 	"github.com/bspippi1337/restless/internal/util"
 )
@@ -21,12 +30,24 @@ import (
 func NewShellCmd() *cobra.Command {
 
 	var timeout time.Duration
+	var opts output.Options
+	var vars []string
 
 	cmd := &cobra.Command{
 		Use:   "shell",
 		Short: "Interactive API shell (learned endpoints become commands)",
+		Long: `Interactive API shell. Learned endpoints become commands, and Tab
+completes commands, endpoint names, methods, paths, IDs seen in earlier
+responses and {{vars}}. History is kept in the state directory.
+
+Requests run through the session module: "set id=42" makes {{id}} usable
+in paths, bodies and headers, and "extract id .data[0].id" takes it from
+the last response. "save file.json" writes the requests so far as a flow.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 
+			if err := opts.Check(); err != nil {
+				return err
+			}
 			cacheRoot, _ := cmd.Root().PersistentFlags().GetString("cache")
 			apiName, _ := cmd.Root().PersistentFlags().GetString("api")
 			cacheRoot, _ = store.DefaultRoot(cacheRoot)
@@ -42,126 +63,638 @@ func NewShellCmd() *cobra.Command {
 				return fmt.Errorf("no endpoints discovered. Run: restless learn <url>")
 			}
 
-			epByName := map[string]string{}
-			names := make([]string, 0, len(api.Endpoints))
-
-			for _, e := range api.Endpoints {
-				n := endpointName(e.Path)
-				if n == "" {
-					continue
-				}
-				if _, exists := epByName[n]; !exists {
-					epByName[n] = e.Path
-					names = append(names, n)
+			sess := session.New()
+			for _, kv := range vars {
+				k, v, ok := strings.Cut(kv, "=")
+				if !ok {
+					return fmt.Errorf("invalid --var %q (want key=value)", kv)
 				}
+				sess.Set(strings.TrimSpace(k), v)
 			}
-			sort.Strings(names)
+			mods, err := authModules(cmd)
+			if err != nil {
+				return err
+			}
+			a, err := app.New(append([]app.Module{sess}, mods...))
+			if err != nil {
+				return err
+			}
+
+			sh := newShell(cmd.OutOrStdout(), api, a, sess, timeout, opts)
 
 			out := cmd.OutOrStdout()
 			fmt.Fprintf(out, "RESTLESS SHELL\n")
 			fmt.Fprintf(out, "Base: %s\n", api.BaseURL)
 			fmt.Fprintf(out, "Type: help\n\n")
 
-			client := httpx.New()
-			in := bufio.NewScanner(os.Stdin)
-
-			for {
-				fmt.Fprint(out, "> ")
-				if !in.Scan() {
-					fmt.Fprintln(out)
-					return nil
-				}
-				line := strings.TrimSpace(in.Text())
-				if line == "" {
-					continue
-				}
-
-				parts := splitArgs(line)
-				head := parts[0]
-				tail := parts[1:]
-
-				switch head {
-				case "exit", "quit":
-					return nil
-				case "help":
-					printShellHelp(out, names)
-					continue
-				case "endpoints", "eps":
-					for _, n := range names {
-						fmt.Fprintf(out, "- %-18s %s\n", n, epByName[n])
-					}
-					continue
-				case "base":
-					fmt.Fprintln(out, api.BaseURL)
-					continue
-				case "call":
-					// passthrough: call GET /path [seg...]
-					if len(tail) < 2 {
-						fmt.Fprintln(out, "usage: call <METHOD> <PATH> [seg...]")
-						continue
-					}
-					method := strings.ToUpper(tail[0])
-					path := tail[1]
-					if len(tail) > 2 {
-						path = appendPath(path, tail[2:])
-					}
-					if err := doRequest(out, client, api.BaseURL, method, path, timeout); err != nil {
-						fmt.Fprintln(out, "error:", err)
+			if !stdinIsTerminal() {
+				in := bufio.NewScanner(os.Stdin)
+				for !sh.quit {
+					fmt.Fprint(out, "> ")
+					if !in.Scan() {
+						fmt.Fprintln(out)
+						return nil
 					}
-					continue
-				default:
-					path, ok := epByName[head]
-					if !ok {
-						fmt.Fprintln(out, "unknown command:", head)
-						fmt.Fprintln(out, "type: help")
-						continue
-					}
-					if len(tail) > 0 {
-						path = appendPath(path, tail)
-					}
-					if err := doRequest(out, client, api.BaseURL, "GET", path, timeout); err != nil {
-						fmt.Fprintln(out, "error:", err)
-					}
-					continue
+					sh.exec(in.Text())
 				}
+				return nil
 			}
+
+			sh.historyFile = filepath.Join(state.Dir(), "shell_history")
+			prompt.New(
+				func(line string) {
+					sh.remember(line)
+					sh.exec(line)
+				},
+				sh.complete,
+				prompt.OptionPrefix("> "),
+				prompt.OptionTitle("restless shell"),
+				prompt.OptionHistory(loadHistory(sh.historyFile, 1000)),
+				prompt.OptionSetExitCheckerOnInput(func(string, bool) bool { return sh.quit }),
+			).Run()
+			return nil
 		},
 	}
 
 	cmd.Flags().DurationVarP(&timeout, "timeout", "t", 12*time.Second, "request timeout")
+	cmd.Flags().StringArrayVar(&vars, "var", nil, "session variable key=value (repeatable)")
+	addOutputFlags(cmd, &opts)
 	return cmd
 }
 
-func doRequest(out io.Writer, client *httpx.Client, base, method, path string, timeout time.Duration) error {
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+// shell is one interactive session against a learned API.
+type shell struct {
+	out     io.Writer
+	api     *store.API
+	app     *app.App
+	sess    *session.Module
+	timeout time.Duration
+	opts    output.Options
+
+	names  []string          // endpoint commands, sorted
+	byName map[string]string // endpoint command → path
+
+	ids   []prompt.Suggest // IDs seen in responses, newest first
+	last  *types.Response
+	steps []session.FlowStep
+	quit  bool
+
+	historyFile string
+}
+
+func newShell(out io.Writer, api *store.API, a *app.App, sess *session.Module, timeout time.Duration, opts output.Options) *shell {
+	sh := &shell{out: out, api: api, app: a, sess: sess, timeout: timeout, opts: opts, byName: map[string]string{}}
+	for _, e := range api.Endpoints {
+		n := endpointName(e.Path)
+		if n == "" {
+			continue
+		}
+		if _, exists := sh.byName[n]; !exists {
+			sh.byName[n] = e.Path
+			sh.names = append(sh.names, n)
+		}
+	}
+	sort.Strings(sh.names)
+	return sh
+}
+
+// exec runs one shell line.
+func (sh *shell) exec(line string) {
+	out := sh.out
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return
+	}
+
+	parts, lineOpts, err := shellOutput(splitArgs(line), sh.opts)
+	if err != nil {
+		fmt.Fprintln(out, "error:", err)
+		return
+	}
+	if len(parts) == 0 {
+		return
+	}
+	head := parts[0]
+	tail := parts[1:]
+
+	switch head {
+	case "exit", "quit":
+		sh.quit = true
+	case "help":
+		printShellHelp(out, sh.names)
+	case "endpoints", "eps":
+		for _, n := range sh.names {
+			ep, _ := sh.api.Lookup(sh.byName[n])
+			fmt.Fprintf(out, "- %-18s %-28s %s\n", n, sh.byName[n], ep.Access)
+		}
+	case "auth":
+		// auth [name|path]: what credential an endpoint needs
+		if len(tail) == 0 {
+			if sh.api.Auth == nil {
+				fmt.Fprintln(out, "no auth schemes recorded")
+			}
+			printAuth(out, sh.api)
+			return
+		}
+		path, ok := sh.byName[tail[0]]
+		if !ok {
+			path = tail[0]
+		}
+		if hint := auth.Hint(sh.api, path, 0); hint != "" {
+			fmt.Fprintln(out, hint)
+		} else {
+			fmt.Fprintln(out, "no credential known to be needed")
+		}
+	case "base":
+		fmt.Fprintln(out, sh.api.BaseURL)
+	case "output":
+		// output [format]: show or set how responses print
+		if len(tail) == 0 {
+			fmt.Fprintln(out, shellFormat(sh.opts))
+			return
+		}
+		next := sh.opts
+		next.Format = tail[0]
+		if err := next.Check(); err != nil {
+			fmt.Fprintln(out, "error:", err)
+			return
+		}
+		sh.opts = next
+	case "set":
+		// set name=value ...: session vars for {{name}}
+		if len(tail) == 0 {
+			sh.printVars()
+			return
+		}
+		for _, kv := range tail {
+			k, v, ok := strings.Cut(kv, "=")
+			if !ok || strings.TrimSpace(k) == "" {
+				fmt.Fprintln(out, "usage: set name=value")
+				return
+			}
+			sh.sess.Set(strings.TrimSpace(k), v)
+		}
+	case "vars":
+		sh.printVars()
+	case "extract":
+		// extract name EXPR: a session var from the last response
+		if len(tail) < 2 {
+			fmt.Fprintln(out, "usage: extract <name> <query>   (example: extract id .data[0].id)")
+			return
+		}
+		if err := sh.extract(tail[0], strings.Join(tail[1:], " ")); err != nil {
+			fmt.Fprintln(out, "error:", err)
+		}
+	case "last":
+		// last [EXPR]: the last response again, through a query
+		if sh.last == nil {
+			fmt.Fprintln(out, "no response yet")
+			return
+		}
+		if len(tail) > 0 {
+			lineOpts.Query = strings.Join(tail, " ")
+		}
+		if err := output.Render(out, sh.last.Body, lineOpts); err != nil {
+			fmt.Fprintln(out, "error:", err)
+		}
+	case "save":
+		// save FILE: the requests so far as a flow
+		if len(tail) != 1 {
+			fmt.Fprintln(out, "usage: save <flow.json>")
+			return
+		}
+		sh.save(tail[0])
+	case "call":
+		// passthrough: call GET /path [seg...] [-d body] [-H 'Name: value']
+		rest, body, headers, err := shellRequest(tail)
+		if err != nil {
+			fmt.Fprintln(out, "error:", err)
+			return
+		}
+		if len(rest) < 2 {
+			fmt.Fprintln(out, "usage: call <METHOD> <PATH> [seg...] [-d body] [-H 'Name: value']")
+			return
+		}
+		method := strings.ToUpper(rest[0])
+		path := rest[1]
+		if len(rest) > 2 {
+			path = appendPath(path, rest[2:])
+		}
+		if body != "" && json.Valid([]byte(sh.sess.Apply(body))) && !hasHeader(headers, "Content-Type") {
+			if headers == nil {
+				headers = map[string]string{}
+			}
+			headers["Content-Type"] = "application/json"
+		}
+		if err := sh.request(method, path, body, headers, lineOpts); err != nil {
+			fmt.Fprintln(out, "error:", err)
+		}
+	default:
+		path, ok := sh.byName[head]
+		if !ok {
+			fmt.Fprintln(out, "unknown command:", head)
+			fmt.Fprintln(out, "type: help")
+			return
+		}
+		if len(tail) > 0 {
+			path = appendPath(path, tail)
+		}
+		if err := sh.request("GET", path, "", nil, lineOpts); err != nil {
+			fmt.Fprintln(out, "error:", err)
+		}
+	}
+}
+
+func (sh *shell) request(method, path, body string, headers map[string]string, opts output.Options) error {
+	ctx, cancel := context.WithTimeout(context.Background(), sh.timeout)
 	defer cancel()
-	url := util.JoinURL(base, path)
+	url := util.JoinURL(sh.api.BaseURL, path)
 
-	res, err := client.Do(ctx, method, url, nil)
+	h := http.Header{}
+	for k, v := range headers {
+		h.Set(k, v)
+	}
+	res, err := sh.app.RunOnce(ctx, types.Request{Method: method, URL: url, Headers: h, Body: []byte(body)})
 	if err != nil {
 		return err
 	}
+	sh.last = &res
+	sh.steps = append(sh.steps, session.FlowStep{Method: method, URL: url, Headers: headers, Body: body})
+	sh.seeIDs(path, res.Body)
 
-	body, err := httpx.ReadBody(res, 10<<20)
+	out := sh.out
+	fmt.Fprintf(out, "%s %s\n", method, sh.sess.Apply(url))
+	fmt.Fprintf(out, "%d %s\n", res.StatusCode, http.StatusText(res.StatusCode))
+	if hint := auth.Hint(sh.api, sh.sess.Apply(path), res.StatusCode); hint != "" {
+		fmt.Fprintln(out, "Auth:", hint)
+	}
+	if err := output.Render(out, res.Body, opts); err != nil {
+		return err
+	}
+	fmt.Fprintln(out)
+	return nil
+}
+
+// extract sets a session var from the last response. Plain field paths
+// are also recorded on the last request, so a saved flow extracts it too.
+func (sh *shell) extract(name, expr string) error {
+	if sh.last == nil {
+		return fmt.Errorf("no response yet")
+	}
+	q, err := output.Compile(expr)
+	if err != nil {
+		return err
+	}
+	v, err := output.Decode(sh.last.Body)
+	if err != nil {
+		return fmt.Errorf("the last response is not JSON")
+	}
+	res, err := q.Run(v)
 	if err != nil {
 		return err
 	}
+	if len(res) != 1 || res[0] == nil {
+		return fmt.Errorf("%s gives %d values, want one", expr, len(res))
+	}
+	val := output.Text(res[0])
+	sh.sess.Set(name, val)
+	fmt.Fprintf(sh.out, "%s = %s\n", name, val)
 
-	fmt.Fprintf(out, "%s %s\n", method, url)
-	fmt.Fprintf(out, "%s\n", res.Status)
-	printMaybeJSON(out, body)
-	fmt.Fprintln(out)
+	step := &sh.steps[len(sh.steps)-1]
+	if dot, ok := dotPath(expr); ok {
+		if step.Extract == nil {
+			step.Extract = map[string]string{}
+		}
+		step.Extract[name] = dot
+	} else {
+		fmt.Fprintf(sh.out, "(a saved flow cannot replay %s; only field paths like .data[0].id)\n", expr)
+	}
 	return nil
 }
 
-func printMaybeJSON(out io.Writer, body []byte) {
-	var v any
-	if json.Unmarshal(body, &v) != nil {
-		fmt.Fprintln(out, string(body))
+var dotPathRe = regexp.MustCompile(`^(\.[A-Za-z_][\w-]*|\[\d+\])+$`)
+
+// dotPath turns .data[0].id into the flow's data.0.id.
+func dotPath(expr string) (string, bool) {
+	expr = strings.TrimPrefix(strings.TrimSpace(expr), "$")
+	if !dotPathRe.MatchString(expr) {
+		return "", false
+	}
+	r := strings.NewReplacer("[", ".", "]", "")
+	return strings.TrimPrefix(r.Replace(expr), "."), true
+}
+
+func (sh *shell) save(file string) {
+	if len(sh.steps) == 0 {
+		fmt.Fprintln(sh.out, "nothing to save yet")
+		return
+	}
+	if err := session.SaveFlow(file, sh.steps); err != nil {
+		fmt.Fprintln(sh.out, "error:", err)
 		return
 	}
-	b, _ := json.MarshalIndent(v, "", "  ")
-	fmt.Fprintln(out, string(b))
+	fmt.Fprintf(sh.out, "Saved → %s (%d steps)\n", file, len(sh.steps))
+
+	// Vars the flow uses but does not extract itself.
+	extracted := map[string]bool{}
+	for _, s := range sh.steps {
+		for k := range s.Extract {
+			extracted[k] = true
+		}
+	}
+	var need []string
+	for k, v := range sh.sess.Vars() {
+		if !extracted[k] {
+			need = append(need, "--var "+k+"="+v)
+		}
+	}
+	if len(need) > 0 {
+		sort.Strings(need)
+		fmt.Fprintf(sh.out, "Run: restless flow %s %s\n", file, strings.Join(need, " "))
+	}
+}
+
+func (sh *shell) printVars() {
+	vars := sh.sess.Vars()
+	if len(vars) == 0 {
+		fmt.Fprintln(sh.out, "no vars set (set name=value)")
+		return
+	}
+	keys := make([]string, 0, len(vars))
+	for k := range vars {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(sh.out, "%s=%s\n", k, vars[k])
+	}
+}
+
+// seeIDs remembers the IDs in a response for completion.
+func (sh *shell) seeIDs(path string, body []byte) {
+	v, err := output.Decode(body)
+	if err != nil {
+		return
+	}
+	seen := map[string]bool{}
+	var found []prompt.Suggest
+	var walk func(v any, depth int)
+	walk = func(v any, depth int) {
+		if depth > 3 || len(found) >= 100 {
+			return
+		}
+		switch t := v.(type) {
+		case []any:
+			for _, e := range t {
+				walk(e, depth+1)
+			}
+		case *output.Object:
+			for _, k := range t.Keys {
+				switch x := t.Vals[k].(type) {
+				case *output.Object, []any:
+					walk(x, depth+1)
+				default:
+					id := output.Text(x)
+					if isIDKey(k) && id != "" && !strings.ContainsAny(id, " /") && !seen[id] {
+						seen[id] = true
+						found = append(found, prompt.Suggest{Text: id, Description: k + " from " + path})
+					}
+				}
+			}
+		}
+	}
+	walk(v, 0)
+
+	for _, s := range sh.ids {
+		if !seen[s.Text] && len(found) < 300 {
+			found = append(found, s)
+		}
+	}
+	sh.ids = found
+}
+
+func isIDKey(k string) bool {
+	l := strings.ToLower(k)
+	return l == "id" || l == "uuid" || l == "slug" || l == "login" || strings.HasSuffix(l, "_id") || strings.HasSuffix(k, "Id")
+}
+
+var shellCommands = []prompt.Suggest{
+	{Text: "call", Description: "raw request: call METHOD PATH"},
+	{Text: "endpoints", Description: "list learned endpoint commands"},
+	{Text: "set", Description: "set a session var: set name=value"},
+	{Text: "vars", Description: "list session vars"},
+	{Text: "extract", Description: "session var from the last response"},
+	{Text: "last", Description: "the last response, through a query"},
+	{Text: "save", Description: "save the requests so far as a flow"},
+	{Text: "output", Description: "show or set the output format"},
+	{Text: "auth", Description: "credential schemes"},
+	{Text: "base", Description: "print base URL"},
+	{Text: "help", Description: "show help"},
+	{Text: "exit", Description: "leave shell"},
+}
+
+var shellMethods = []prompt.Suggest{
+	{Text: "GET"}, {Text: "POST"}, {Text: "PUT"}, {Text: "PATCH"}, {Text: "DELETE"}, {Text: "HEAD"}, {Text: "OPTIONS"},
+}
+
+// complete suggests the next word for go-prompt.
+func (sh *shell) complete(d prompt.Document) []prompt.Suggest {
+	word := d.GetWordBeforeCursor()
+	if i := strings.LastIndex(word, "{{"); i >= 0 && !strings.Contains(word[i:], "}}") {
+		var s []prompt.Suggest
+		for k, v := range sh.sess.Vars() {
+			s = append(s, prompt.Suggest{Text: word[:i] + "{{" + k + "}}", Description: v})
+		}
+		sort.Slice(s, func(i, j int) bool { return s[i].Text < s[j].Text })
+		return prompt.FilterHasPrefix(s, word, false)
+	}
+
+	args := splitArgs(d.TextBeforeCursor())
+	if word != "" && len(args) > 0 {
+		args = args[:len(args)-1]
+	}
+	if len(args) == 0 {
+		s := append([]prompt.Suggest(nil), shellCommands...)
+		for _, n := range sh.names {
+			s = append(s, prompt.Suggest{Text: n, Description: "GET " + sh.byName[n]})
+		}
+		return prompt.FilterHasPrefix(s, word, true)
+	}
+
+	switch args[len(args)-1] {
+	case "-o", "--output":
+		return prompt.FilterHasPrefix(formatSuggestions(), word, true)
+	case "--query", "--columns", "-d", "-H":
+		return nil
+	}
+
+	var s []prompt.Suggest
+	switch head, n := args[0], len(args); {
+	case head == "call" && n == 1:
+		s = shellMethods
+	case head == "call" && n == 2:
+		for _, e := range sh.api.Endpoints {
+			s = append(s, prompt.Suggest{Text: e.Path, Description: strings.Join(e.Methods, " ")})
+		}
+	case head == "output":
+		s = formatSuggestions()
+	case head == "auth" && n == 1:
+		for _, name := range sh.names {
+			s = append(s, prompt.Suggest{Text: name})
+		}
+	case head == "set":
+		for k, v := range sh.sess.Vars() {
+			s = append(s, prompt.Suggest{Text: k + "=", Description: v})
+		}
+	case head == "extract" && n == 1:
+		for k, v := range sh.sess.Vars() {
+			s = append(s, prompt.Suggest{Text: k, Description: v})
+		}
+	case head == "call" || sh.byName[head] != "":
+		base := sh.byName[head]
+		segs := args[1:]
+		if head == "call" {
+			base, segs = args[2], args[3:]
+		}
+		s = append(sh.nextSegments(base, segs), sh.ids...)
+	}
+	return prompt.FilterHasPrefix(s, word, true)
+}
+
+// nextSegments suggests the literal path segment that follows base and
+// the segments typed after it, from the learned paths.
+func (sh *shell) nextSegments(base string, typed []string) []prompt.Suggest {
+	full := strings.Split(strings.Trim(appendPath(base, typed), "/"), "/")
+	seen := map[string]bool{}
+	var out []prompt.Suggest
+	for _, e := range sh.api.Endpoints {
+		segs := strings.Split(strings.Trim(e.Path, "/"), "/")
+		if len(segs) <= len(full) {
+			continue
+		}
+		match := true
+		for i, f := range full {
+			if segs[i] != f && !strings.HasPrefix(segs[i], "{") {
+				match = false
+				break
+			}
+		}
+		next := segs[len(full)]
+		if match && !strings.HasPrefix(next, "{") && !seen[next] {
+			seen[next] = true
+			out = append(out, prompt.Suggest{Text: next, Description: e.Path})
+		}
+	}
+	return out
+}
+
+func formatSuggestions() []prompt.Suggest {
+	s := make([]prompt.Suggest, len(output.Formats))
+	for i, f := range output.Formats {
+		s[i] = prompt.Suggest{Text: f}
+	}
+	return s
+}
+
+// remember appends a line to the history file.
+func (sh *shell) remember(line string) {
+	if strings.TrimSpace(line) == "" || sh.historyFile == "" {
+		return
+	}
+	_ = os.MkdirAll(filepath.Dir(sh.historyFile), 0700)
+	f, err := os.OpenFile(sh.historyFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	fmt.Fprintln(f, line)
+}
+
+// loadHistory reads the last max lines of the history file.
+func loadHistory(file string, max int) []string {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return nil
+	}
+	lines := strings.Split(strings.TrimRight(string(b), "\n"), "\n")
+	if len(lines) > max {
+		lines = lines[len(lines)-max:]
+	}
+	return lines
+}
+
+func stdinIsTerminal() bool {
+	fi, err := os.Stdin.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// shellOutput takes -o, --columns and --query off a shell line; they
+// override the shell's defaults for that line only.
+func shellOutput(args []string, def output.Options) ([]string, output.Options, error) {
+	opts := def
+	var rest []string
+	for i := 0; i < len(args); i++ {
+		name, val, inline := strings.Cut(args[i], "=")
+		switch name {
+		case "-o", "--output", "--columns", "--query":
+		default:
+			rest = append(rest, args[i])
+			continue
+		}
+		if !inline {
+			if i+1 >= len(args) {
+				return nil, opts, fmt.Errorf("%s needs a value", name)
+			}
+			i++
+			val = args[i]
+		}
+		switch name {
+		case "--columns":
+			opts.Columns = strings.Split(val, ",")
+		case "--query":
+			opts.Query = val
+		default:
+			opts.Format = val
+		}
+	}
+	return rest, opts, opts.Check()
+}
+
+// shellRequest takes a body (-d) and headers (-H) off a call line.
+func shellRequest(args []string) ([]string, string, map[string]string, error) {
+	var rest []string
+	var body string
+	var headers map[string]string
+	for i := 0; i < len(args); i++ {
+		if args[i] != "-d" && args[i] != "-H" {
+			rest = append(rest, args[i])
+			continue
+		}
+		if i+1 >= len(args) {
+			return nil, "", nil, fmt.Errorf("%s needs a value", args[i])
+		}
+		if args[i] == "-d" {
+			body = args[i+1]
+		} else {
+			k, v, ok := strings.Cut(args[i+1], ":")
+			if !ok {
+				return nil, "", nil, fmt.Errorf("bad header %q: want 'Name: value'", args[i+1])
+			}
+			if headers == nil {
+				headers = map[string]string{}
+			}
+			headers[strings.TrimSpace(k)] = strings.TrimSpace(v)
+		}
+		i++
+	}
+	return rest, body, headers, nil
+}
+
+func shellFormat(opts output.Options) string {
+	if opts.Format == "" {
+		return output.JSON
+	}
+	return opts.Format
 }
 
 func endpointName(path string) string {
@@ -241,7 +774,16 @@ func printShellHelp(out io.Writer, names []string) {
 	fmt.Fprintln(out, "  help  show this help")
 	fmt.Fprintln(out, "  endpoints | eps  list learned endpoint commands")
 	fmt.Fprintln(out, "  base  print base URL")
+	fmt.Fprintln(out, "  auth [name|path]  credential schemes, or what one endpoint needs")
 	fmt.Fprintln(out, "  call METHOD PATH ... raw call (example: call GET /users mojombo)")
+	fmt.Fprintln(out, "        -d BODY, -H 'Name: value'  request body and headers")
+	fmt.Fprintln(out, "  set name=value  session var, used as {{name}} in paths, bodies and headers")
+	fmt.Fprintln(out, "  vars  list session vars")
+	fmt.Fprintln(out, "  extract name QUERY  session var from the last response (example: extract id .data[0].id)")
+	fmt.Fprintln(out, "  last [QUERY]  the last response again (example: last .data[] | .name -o raw)")
+	fmt.Fprintln(out, "  save FILE  save the requests so far as a flow (run with: restless flow FILE)")
+	fmt.Fprintln(out, "  output [format]  show or set the output format ("+strings.Join(output.Formats, "|")+")")
+	fmt.Fprintln(out, "  ... -o FORMAT --columns a,b --query EXPR  format one response")
 	fmt.Fprintln(out, "  exit | quit  leave shell")
 	fmt.Fprintln(out, "")
 	fmt.Fprintln(out, "Endpoint commands:")
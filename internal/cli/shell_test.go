@@ -0,0 +1,322 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	prompt "github.com/c-bata/go-prompt"
+
+	"github.com/bspippi1337/restless/internal/modules/session"
+	"github.com/bspippi1337/restless/internal/output"
+	"github.com/bspippi1337/restless/internal/store"
+)
+
+func TestSplitArgs(t *testing.T) {
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{"", nil},
+		{"   ", nil},
+		{"users  42\tx", []string{"users", "42", "x"}},
+		{`call POST /users -d '{"name": "ada"}'`, []string{"call", "POST", "/users", "-d", `{"name": "ada"}`}},
+		{`-H "X-Note: say \"hi\""`, []string{"-H", `X-Note: say "hi"`}},
+		{`a"b c"d`, []string{"ab cd"}},
+		{`''`, nil},
+	}
+	for _, tt := range tests {
+		if got := splitArgs(tt.line); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitArgs(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestShellOutput(t *testing.T) {
+	def := output.Options{Format: output.Table}
+	tests := []struct {
+		args    []string
+		rest    []string
+		opts    output.Options
+		wantErr bool
+	}{
+		{[]string{"users"}, []string{"users"}, def, false},
+		{[]string{"users", "-o", "raw"}, []string{"users"}, output.Options{Format: output.Raw}, false},
+		{[]string{"users", "--output=yaml", "42"}, []string{"users", "42"}, output.Options{Format: output.YAML}, false},
+		{[]string{"users", "--columns", "id,name", "--query=.data"}, []string{"users"}, output.Options{Format: output.Table, Columns: []string{"id", "name"}, Query: ".data"}, false},
+		{[]string{"users", "-o"}, nil, def, true},
+		{[]string{"users", "-o", "xml"}, []string{"users"}, output.Options{Format: "xml"}, true},
+	}
+	for _, tt := range tests {
+		rest, opts, err := shellOutput(tt.args, def)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("shellOutput(%q) error = %v", tt.args, err)
+			continue
+		}
+		if tt.wantErr {
+			continue
+		}
+		if !reflect.DeepEqual(rest, tt.rest) || !reflect.DeepEqual(opts, tt.opts) {
+			t.Errorf("shellOutput(%q) = %q, %+v; want %q, %+v", tt.args, rest, opts, tt.rest, tt.opts)
+		}
+	}
+}
+
+func TestShellRequest(t *testing.T) {
+	tests := []struct {
+		args    []string
+		rest    []string
+		body    string
+		headers map[string]string
+		wantErr bool
+	}{
+		{[]string{"GET", "/users"}, []string{"GET", "/users"}, "", nil, false},
+		{[]string{"POST", "/users", "-d", `{"a":1}`, "-H", "X-Trace : abc"}, []string{"POST", "/users"}, `{"a":1}`, map[string]string{"X-Trace": "abc"}, false},
+		{[]string{"GET", "/users", "-H", "Accept: a", "-H", "Accept: b"}, []string{"GET", "/users"}, "", map[string]string{"Accept": "b"}, false},
+		{[]string{"POST", "/users", "-d"}, nil, "", nil, true},
+		{[]string{"GET", "/users", "-H", "no colon"}, nil, "", nil, true},
+	}
+	for _, tt := range tests {
+		rest, body, headers, err := shellRequest(tt.args)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("shellRequest(%q) error = %v", tt.args, err)
+			continue
+		}
+		if !reflect.DeepEqual(rest, tt.rest) || body != tt.body || !reflect.DeepEqual(headers, tt.headers) {
+			t.Errorf("shellRequest(%q) = %q, %q, %v", tt.args, rest, body, headers)
+		}
+	}
+}
+
+func TestDotPath(t *testing.T) {
+	tests := []struct {
+		expr string
+		want string
+		ok   bool
+	}{
+		{".id", "id", true},
+		{".data[0].id", "data.0.id", true},
+		{"$.data[12].owner-id", "data.12.owner-id", true},
+		{" .a.b ", "a.b", true},
+		{".data[] | .id", "", false},
+		{".data[-1]", "", false},
+		{"id", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := dotPath(tt.expr)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("dotPath(%q) = %q, %v; want %q, %v", tt.expr, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestAppendPath(t *testing.T) {
+	tests := []struct {
+		path string
+		seg  []string
+		want string
+	}{
+		{"/users", nil, "/users"},
+		{"/users/", []string{"42"}, "/users/42"},
+		{"users", []string{"/42/", "", "repos"}, "/users/42/repos"},
+		{"/", []string{"{{id}}"}, "/{{id}}"},
+	}
+	for _, tt := range tests {
+		if got := appendPath(tt.path, tt.seg); got != tt.want {
+			t.Errorf("appendPath(%q, %q) = %q, want %q", tt.path, tt.seg, got, tt.want)
+		}
+	}
+}
+
+func suggestions(sh *shell, line string) []string {
+	buf := prompt.NewBuffer()
+	buf.InsertText(line, false, true)
+	var out []string
+	for _, s := range sh.complete(*buf.Document()) {
+		out = append(out, s.Text)
+	}
+	return out
+}
+
+func TestComplete(t *testing.T) {
+	api := &store.API{BaseURL: "https://api.example.com", Endpoints: []store.Endpoint{
+		{Path: "/users", Methods: []string{"GET", "POST"}},
+		{Path: "/users/{id}", Methods: []string{"GET"}},
+		{Path: "/users/{id}/repos", Methods: []string{"GET"}},
+		{Path: "/users/{id}/keys", Methods: []string{"GET"}},
+		{Path: "/orgs", Methods: []string{"GET"}},
+	}}
+	sess := session.New()
+	sess.Set("uid", "7")
+	sh := newShell(io.Discard, api, nil, sess, 0, output.Options{})
+	sh.seeIDs("/users", []byte(`[{"id": 7, "login": "ada"}]`))
+
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{"us", []string{"users", "users_{id}", "users_{id}_keys", "users_{id}_repos"}},
+		{"ex", []string{"extract", "exit"}},
+		{"call ", []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS"}},
+		{"call GET /o", []string{"/orgs"}},
+		{"users 7 ", []string{"repos", "keys", "7", "ada"}},
+		{"users 7 r", []string{"repos"}},
+		{"call GET /users ", []string{"7", "ada"}},
+		{"users {{u", []string{"{{uid}}"}},
+		{"set ", []string{"uid="}},
+		{"users -o n", []string{"ndjson"}},
+		{"call POST /users -d ", nil},
+	}
+	for _, tt := range tests {
+		if got := suggestions(sh, tt.line); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("complete(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestNextSegments(t *testing.T) {
+	api := &store.API{Endpoints: []store.Endpoint{
+		{Path: "/repos/{owner}/{repo}/issues"},
+		{Path: "/repos/{owner}/{repo}/pulls"},
+		{Path: "/repos/{owner}/{repo}/pulls/{n}/files"},
+	}}
+	sh := newShell(io.Discard, api, nil, session.New(), 0, output.Options{})
+
+	tests := []struct {
+		base  string
+		typed []string
+		want  []string
+	}{
+		{"/repos", []string{"ada"}, nil},
+		{"/repos", []string{"ada", "tools"}, []string{"issues", "pulls"}},
+		{"/repos", []string{"ada", "tools", "pulls", "3"}, []string{"files"}},
+		{"/repos", []string{"ada", "tools", "issues", "3"}, nil},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, s := range sh.nextSegments(tt.base, tt.typed) {
+			got = append(got, s.Text)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("nextSegments(%q, %q) = %q, want %q", tt.base, tt.typed, got, tt.want)
+		}
+	}
+}
+
+func TestShellHistory(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "state", "shell_history")
+	if got := loadHistory(file, 10); got != nil {
+		t.Fatalf("missing history file: %q", got)
+	}
+
+	sh := &shell{historyFile: file}
+	for _, line := range []string{"users", "  ", "call GET /orgs -o raw", "", "exit"} {
+		sh.remember(line)
+	}
+
+	if got, want := loadHistory(file, 10), []string{"users", "call GET /orgs -o raw", "exit"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("history = %q, want %q", got, want)
+	}
+	if got, want := loadHistory(file, 2), []string{"call GET /orgs -o raw", "exit"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("last 2 = %q, want %q", got, want)
+	}
+}
+
+// TestShellFlow drives the shell over a pipe, the way scripts do: vars
+// must reach the server, and save must write the extract steps a flow
+// needs to replay them.
+func TestShellFlow(t *testing.T) {
+	var seen []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		seen = append(seen, r.Method+" "+r.URL.Path+" "+string(body))
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Method {
+		case http.MethodPost:
+			w.WriteHeader(http.StatusCreated)
+			io.WriteString(w, `{"data": {"id": 42}}`)
+		default:
+			io.WriteString(w, `{"id": 42, "name": "ada"}`)
+		}
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("XDG_STATE_HOME", filepath.Join(dir, "state"))
+	cache := filepath.Join(dir, "cache")
+	if _, err := store.Write(cache, &store.API{BaseURL: srv.URL, Endpoints: []store.Endpoint{
+		{Path: "/users", Methods: []string{"GET", "POST"}},
+	}}); err != nil {
+		t.Fatal(err)
+	}
+
+	flow := filepath.Join(dir, "flow.json")
+	script := strings.Join([]string{
+		"set team=core",
+		`call POST /users -d '{"team": "{{team}}"}'`,
+		"extract uid .data.id",
+		"users {{uid}} -o raw --query .name",
+		"save " + flow,
+		"exit",
+	}, "\n")
+	in := filepath.Join(dir, "script")
+	if err := os.WriteFile(in, []byte(script), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	stdin := os.Stdin
+	os.Stdin = f
+	defer func() { os.Stdin = stdin }()
+
+	var out bytes.Buffer
+	cmd := NewRootCmd()
+	cmd.SetOut(&out)
+	cmd.SetErr(&out)
+	cmd.SetArgs([]string{"--cache", cache, "shell"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("shell: %v\n%s", err, out.String())
+	}
+
+	want := []string{`POST /users {"team": "core"}`, "GET /users/42 "}
+	if !reflect.DeepEqual(seen, want) {
+		t.Fatalf("server saw %q, want %q\n%s", seen, want, out.String())
+	}
+	for _, s := range []string{"uid = 42", "\nada\n", "Saved → " + flow + " (2 steps)", "--var team=core"} {
+		if !strings.Contains(out.String(), s) {
+			t.Fatalf("output lacks %q:\n%s", s, out.String())
+		}
+	}
+	if strings.Contains(out.String(), "--var uid=") {
+		t.Fatalf("extracted var listed as needed:\n%s", out.String())
+	}
+
+	b, err := os.ReadFile(flow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var steps []session.FlowStep
+	if err := json.Unmarshal(b, &steps); err != nil {
+		t.Fatal(err)
+	}
+	if len(steps) != 2 {
+		t.Fatalf("flow has %d steps, want 2: %s", len(steps), b)
+	}
+	if got := steps[0].Extract; !reflect.DeepEqual(got, map[string]string{"uid": "data.id"}) {
+		t.Fatalf("first step extracts %v", got)
+	}
+	if steps[0].Body != `{"team": "{{team}}"}` || steps[1].URL != srv.URL+"/users/{{uid}}" {
+		t.Fatalf("flow lost its templates: %s", b)
+	}
+}
@@ -0,0 +1,97 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bspippi1337/restless/internal/modules/openapi/infer"
+	"github.com/bspippi1337/restless/internal/store"
+)
+
+func NewSpecCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "spec",
+		Short: "Work with API specifications",
+	}
+
+	cmd.AddCommand(newSpecInferCmd())
+	return cmd
+}
+
+func newSpecInferCmd() *cobra.Command {
+	var output string
+	var format string
+	var title string
+	var version string
+
+	cmd := &cobra.Command{
+		Use:   "infer",
+		Short: "Generate an OpenAPI 3.1 document from learned endpoints",
+		Long: `Generate an OpenAPI 3.1 document from learned endpoints.
+
+Each operation gets the response schema, request body and query
+parameters observed for its own method; endpoints learned without
+traffic only describe their GET response.
+
+Before writing, the document is checked with kin-openapi, which knows
+OpenAPI 3.0: 3.1 constructs such as type: [string, "null"] are projected
+onto 3.0 for the check. The 3.1 output is not itself validated as 3.1.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+
+			cacheRoot, _ := cmd.Root().PersistentFlags().GetString("cache")
+			apiName, _ := cmd.Root().PersistentFlags().GetString("api")
+			cacheRoot, _ = store.DefaultRoot(cacheRoot)
+
+			api, err := store.Read(cacheRoot, apiName)
+			if err != nil {
+				return fmt.Errorf("no API loaded. Run: restless learn <url>")
+			}
+
+			doc, err := infer.Build(api, infer.Options{Title: title, Version: version})
+			if err != nil {
+				return err
+			}
+
+			if err := infer.Validate(context.Background(), doc); err != nil {
+				return fmt.Errorf("inferred spec is invalid: %w", err)
+			}
+
+			if format == "" {
+				format = "yaml"
+				if strings.EqualFold(filepath.Ext(output), ".json") {
+					format = "json"
+				}
+			}
+
+			b, err := infer.Marshal(doc, format)
+			if err != nil {
+				return err
+			}
+
+			if output == "" || output == "-" {
+				_, err = cmd.OutOrStdout().Write(b)
+				return err
+			}
+
+			if err := os.WriteFile(output, b, 0644); err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cmd.ErrOrStderr(), "Wrote %d paths → %s\n", doc.Paths.Len(), output)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&output, "output", "o", "", "output file (default: stdout)")
+	cmd.Flags().StringVar(&format, "format", "", "yaml or json (default: from --output extension, else yaml)")
+	cmd.Flags().StringVar(&title, "title", "", "info.title of the generated spec")
+	cmd.Flags().StringVar(&version, "spec-version", "", "info.version of the generated spec")
+
+	return cmd
+}
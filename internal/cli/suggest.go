@@ -0,0 +1,133 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/bspippi1337/restless/internal/modules/openapi"
+	"github.com/bspippi1337/restless/internal/modules/openapi/suggest"
+)
+
+func NewSuggestCmd() *cobra.Command {
+	var minCount int
+	var outDir string
+	var apply string
+	var rewrite bool
+	var minConfidence float64
+
+	cmd := &cobra.Command{
+		Use:   "suggest <base-url>",
+		Short: "Suggest OpenAPI changes from the contract drift seen on a host",
+		Long: `Responses checked against a spec (call, openapi run, flow) leave their
+drift in a snapshot per host. suggest turns drift seen at least
+--min-count times into suggestions and writes them as a markdown and a
+JSON report.
+
+With --apply, the suggestions at or above --min-confidence become an
+RFC 6902 JSON Patch against that spec (or, with --rewrite, the spec
+with the patch applied): required fields that go missing become
+optional, types and enums widen to what was seen, and undeclared
+response codes get an entry. Changes land where a schema is defined, so
+a fix to a shared schema applies wherever it is used.
+
+  restless suggest https://api.example.com --min-count 3
+  restless suggest https://api.example.com --apply openapi.yaml
+  restless suggest https://api.example.com --apply openapi.yaml --rewrite`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			useSpecCatalog(cmd)
+			base := strings.TrimRight(args[0], "/")
+			report, err := suggest.Build(base, minCount)
+			if err != nil {
+				// The snapshot may be kept under the imported spec's base.
+				if alt := openapi.SnapshotBase(base); alt != base {
+					report, err = suggest.Build(alt, minCount)
+				}
+			}
+			if err != nil {
+				return err
+			}
+
+			out := cmd.OutOrStdout()
+			fmt.Fprintf(out, "SUGGESTIONS (%s)\n", report.BaseURL)
+			fmt.Fprintln(out, strings.Repeat("-", len(report.BaseURL)+14))
+			if len(report.Items) == 0 {
+				fmt.Fprintf(out, "No drift seen %d or more times.\n", minCount)
+			}
+			for _, it := range report.Items {
+				fmt.Fprintf(out, "  %.2f  %-14s %s %d %s  (seen %d×)\n",
+					it.Confidence, it.Action, it.Selector.OperationID, it.Selector.Status, it.Selector.JSONPath, it.Evidence.Count)
+			}
+
+			mdPath, jsonPath, planPath, err := suggest.Write(report, outDir)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(out)
+			fmt.Fprintf(out, "Saved → %s\n", mdPath)
+			fmt.Fprintf(out, "Saved → %s\n", jsonPath)
+			fmt.Fprintf(out, "Saved → %s\n", planPath)
+
+			if apply == "" {
+				return nil
+			}
+			ref, err := resolveSpecRef(cmd, apply)
+			if err != nil {
+				return err
+			}
+			spec, isYAML, err := suggest.LoadSpec(ref)
+			if err != nil {
+				return err
+			}
+			ops, skipped, err := suggest.Patch(spec, report, minConfidence)
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintln(out)
+			fmt.Fprintf(out, "Applied %d of %d suggestions to %s (%d operations)\n", len(report.Items)-len(skipped), len(report.Items), ref, len(ops))
+			for _, s := range skipped {
+				fmt.Fprintf(out, "  skipped %s %s %s: %s\n", s.Suggestion.Action, s.Suggestion.Selector.OperationID, s.Suggestion.Selector.JSONPath, s.Reason)
+			}
+
+			stem := strings.TrimSuffix(mdPath, ".md")
+			var path string
+			var b []byte
+			if rewrite {
+				src, err := os.ReadFile(ref)
+				if err != nil {
+					return err
+				}
+				if b, err = suggest.Rewrite(src, ops); err != nil {
+					return err
+				}
+				path = stem + ".spec.json"
+				if isYAML {
+					path = stem + ".spec.yaml"
+				}
+			} else {
+				if b, err = json.MarshalIndent(ops, "", "  "); err != nil {
+					return err
+				}
+				b = append(b, '\n')
+				path = stem + ".patch.json"
+			}
+			if err := os.WriteFile(path, b, 0o644); err != nil {
+				return err
+			}
+			fmt.Fprintf(out, "Saved → %s\n", path)
+			return nil
+		},
+	}
+
+	cmd.Flags().IntVar(&minCount, "min-count", 2, "only drift seen at least this many times")
+	cmd.Flags().StringVar(&outDir, "out", "suggestions", "directory for the reports")
+	cmd.Flags().StringVar(&apply, "apply", "", "spec to patch (file or imported spec)")
+	cmd.Flags().BoolVar(&rewrite, "rewrite", false, "with --apply, write the patched spec instead of the patch")
+	cmd.Flags().Float64Var(&minConfidence, "min-confidence", 0.6, "with --apply, only suggestions at least this confident")
+	return cmd
+}
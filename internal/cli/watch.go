@@ -2,11 +2,13 @@ package cli
 
 import (
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/spf13/cobra"
 
 	"github.com/bspippi1337/restless/internal/events"
+	"github.com/bspippi1337/restless/internal/metrics"
 	"github.com/bspippi1337/restless/internal/observe"
 	"github.com/bspippi1337/restless/internal/pipeline"
 	"github.com/bspippi1337/restless/internal/watch"
@@ -16,6 +18,7 @@ func NewWatchCmd() *cobra.Command {
 	var command string
 	var debounce int
 	var jsonMode bool
+	var metricsAddr string
 
 	cmd := &cobra.Command{
 		Use:   "watch <path>",
@@ -26,8 +29,16 @@ func NewWatchCmd() *cobra.Command {
 				return fmt.Errorf("missing --run command")
 			}
 
+			stopMetrics, err := startMetrics(cmd, metricsAddr)
+			if err != nil {
+				return err
+			}
+			defer stopMetrics()
+
 			return watch.Run(args[0], time.Duration(debounce)*time.Millisecond, func(ev events.Event) {
 				exec := pipeline.Run(ev, command)
+				metrics.WatchRuns.Inc(strconv.Itoa(exec.ExitCode))
+				metrics.WatchDuration.Observe(exec.FinishedAt.Sub(exec.StartedAt).Seconds())
 
 				if jsonMode {
 					_ = observe.PrintJSON(exec)
@@ -42,6 +53,7 @@ func NewWatchCmd() *cobra.Command {
 	cmd.Flags().StringVarP(&command, "run", "r", "", "command to execute")
 	cmd.Flags().IntVar(&debounce, "debounce", 250, "debounce in milliseconds")
 	cmd.Flags().BoolVar(&jsonMode, "json", false, "emit JSON runtime events")
+	addMetricsFlag(cmd, &metricsAddr)
 
 	return cmd
 }
@@ -19,7 +19,7 @@ func New(mods []Module) (*App, error) {
 	reg := NewRegistry(lg, runner)
 
 	for _, m := range mods {
-		reg.Log.Printf(logx.Info, "registering module: %s", m.Name())
+		reg.Log.Printf(logx.Debug, "registering module: %s", m.Name())
 		if err := m.Register(reg); err != nil {
 			return nil, err
 		}
@@ -66,6 +66,9 @@ func (a *App) RunOnce(ctx context.Context, req types.Request) (types.Response, e
 	}
 	// Apply response mutators
 	rsc := &ResponseContext{
+		Context:    ctx,
+		Method:     finalReq.Method,
+		URL:        finalReq.URL,
 		StatusCode: resp.StatusCode,
 		Body:       resp.Body,
 		Header:     map[string][]string{},
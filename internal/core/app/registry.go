@@ -1,6 +1,8 @@
 package app
 
 import (
+	"context"
+
 	"github.com/bspippi1337/restless/internal/core/engine"
 	"github.com/bspippi1337/restless/internal/core/logx"
 )
@@ -31,6 +33,13 @@ type RequestContext struct {
 }
 
 type ResponseContext struct {
+	// Context is the one the request was sent under.
+	Context context.Context
+
+	// The request answered, as it was sent.
+	Method string
+	URL    string
+
 	StatusCode int
 	Body       []byte
 	Header     map[string][]string
@@ -10,7 +10,9 @@ import (
 	"github.com/bspippi1337/restless/internal/core/types"
 )
 
-// DefaultClient returns a reasonably safe default HTTP client.
+// DefaultClient returns a reasonably safe default HTTP client. It leaves
+// Transport nil so requests go through http.DefaultTransport, which the
+// CLI replaces for --record and --replay.
 func DefaultClient() *http.Client {
 	return &http.Client{
 		Timeout: 30 * time.Second,
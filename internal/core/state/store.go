@@ -20,17 +20,28 @@ type State struct {
 	LastScan ScanResult `json:"last_scan"`
 }
 
-func path() string {
+// Dir is where restless keeps its state: $XDG_STATE_HOME/restless, or
+// ~/.local/state/restless.
+func Dir() string {
 	if xdg := os.Getenv("XDG_STATE_HOME"); xdg != "" {
-		return filepath.Join(xdg, "restless", "state.json")
+		return filepath.Join(xdg, "restless")
 	}
 
 	home, err := os.UserHomeDir()
 	if err != nil || home == "" {
+		return ""
+	}
+
+	return filepath.Join(home, ".local", "state", "restless")
+}
+
+func path() string {
+	dir := Dir()
+	if dir == "" {
 		return ".restless_state.json"
 	}
 
-	return filepath.Join(home, ".local", "state", "restless", "state.json")
+	return filepath.Join(dir, "state.json")
 }
 
 func legacyPath() string {
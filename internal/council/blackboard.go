@@ -7,8 +7,9 @@ type Blackboard struct {
 	Findings []Finding
 }
 
-func NewBlackboard() *Blackboard {
-	return &Blackboard{}
+// NewBlackboard returns a board, optionally holding earlier findings.
+func NewBlackboard(fs ...Finding) *Blackboard {
+	return &Blackboard{Findings: fs}
 }
 
 func (b *Blackboard) Publish(f Finding) {
@@ -0,0 +1,395 @@
+package council
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bspippi1337/restless/internal/pathtmpl"
+)
+
+// Engines that publish endpoint findings.
+const (
+	OpenAPI    = "openapi"    // declared in a published spec
+	Traffic    = "traffic"    // seen in recorded or proxied traffic
+	Crawl      = "crawl"      // reached by following links
+	Hypermedia = "hypermedia" // advertised by the root document
+	Wordlist   = "wordlist"   // guessed and answered
+)
+
+// DefaultWeights says how much each engine's word counts: a spec is
+// authoritative, a guessed path that answers is the weakest evidence.
+var DefaultWeights = map[string]float64{
+	OpenAPI:    1.0,
+	Traffic:    0.95,
+	Crawl:      0.85,
+	Hypermedia: 0.8,
+	Wordlist:   0.6,
+}
+
+// DefaultWeight applies to engines missing from the weights.
+const DefaultWeight = 0.5
+
+// DefaultHalfLife is how long evidence keeps half its weight.
+const DefaultHalfLife = 30 * 24 * time.Hour
+
+type Options struct {
+	// Weights by engine; unset engines use DefaultWeights, then
+	// DefaultWeight.
+	Weights map[string]float64
+
+	// HalfLife decays evidence by age; zero means DefaultHalfLife and a
+	// negative value turns decay off.
+	HalfLife time.Duration
+
+	// Now is when the council sits (default time.Now).
+	Now time.Time
+}
+
+func (o Options) weight(engine string) float64 {
+	if w, ok := o.Weights[engine]; ok {
+		return w
+	}
+	if w, ok := DefaultWeights[engine]; ok {
+		return w
+	}
+	return DefaultWeight
+}
+
+func (o Options) decay(t time.Time) float64 {
+	if o.HalfLife < 0 || t.IsZero() {
+		return 1
+	}
+	hl := o.HalfLife
+	if hl == 0 {
+		hl = DefaultHalfLife
+	}
+	age := o.now().Sub(t)
+	if age <= 0 {
+		return 1
+	}
+	return math.Pow(0.5, float64(age)/float64(hl))
+}
+
+func (o Options) now() time.Time {
+	if o.Now.IsZero() {
+		return time.Now()
+	}
+	return o.Now
+}
+
+// Verdict is the council's view of one endpoint.
+type Verdict struct {
+	Target  string
+	Methods []string `json:",omitempty"`
+
+	// Confidence is the chance the endpoint is real, combining each
+	// engine's strongest weighted, decayed evidence as independent
+	// votes: 1 - (1-a)(1-b)...
+	Confidence float64
+
+	// Engines that saw it, strongest first, and what each saw.
+	Engines  []string
+	Evidence []string
+
+	LastSeen time.Time
+}
+
+// Explain says who vouches for the endpoint: "seen by openapi+crawl".
+func (v Verdict) Explain() string {
+	return "seen by " + strings.Join(v.Engines, "+")
+}
+
+// Level buckets the confidence as high, medium or low.
+func (v Verdict) Level() string {
+	switch {
+	case v.Confidence >= 0.8:
+		return "high"
+	case v.Confidence >= 0.5:
+		return "medium"
+	}
+	return "low"
+}
+
+// Consensus folds endpoint findings into one verdict per endpoint,
+// most certain first. Concrete paths merge into a template another
+// engine reported (/users/42 into /users/{id}).
+func Consensus(fs []Finding, opt Options) []Verdict {
+	type vote struct {
+		score    float64
+		evidence string
+	}
+	type group struct {
+		target    string
+		weight    float64 // of the engine that named the target
+		methods   map[string]bool
+		votes     map[string]vote
+		lastSeen  time.Time
+		templated bool
+	}
+
+	groups := map[string]*group{}
+	var order []string
+	for _, f := range fs {
+		if f.Kind != "" && f.Kind != KindEndpoint {
+			continue
+		}
+		target := clean(f.Target)
+		if target == "" {
+			continue
+		}
+		tpl, _ := pathtmpl.Template(target)
+		key := paramRe.ReplaceAllString(tpl, "{}")
+		g, ok := groups[key]
+		if !ok {
+			g = &group{target: tpl, methods: map[string]bool{}, votes: map[string]vote{}}
+			groups[key] = g
+			order = append(order, key)
+		}
+		// A path written with named parameters beats one templated
+		// from ids; among those the strongest engine names it.
+		w := opt.weight(f.Engine)
+		named := strings.Contains(target, "{")
+		if named && !g.templated || named == g.templated && w > g.weight {
+			g.target, g.weight, g.templated = tpl, w, named
+		}
+		if f.Method != "" {
+			g.methods[strings.ToUpper(f.Method)] = true
+		}
+		s := clamp(w * f.Confidence * opt.decay(f.Timestamp))
+		// Equal evidence keeps the first word; decay alone separates it
+		// by rounding error.
+		if cur, ok := g.votes[f.Engine]; !ok || s > cur.score+1e-9 {
+			g.votes[f.Engine] = vote{score: s, evidence: f.Evidence}
+		}
+		if f.Timestamp.After(g.lastSeen) {
+			g.lastSeen = f.Timestamp
+		}
+	}
+
+	// Concrete paths that instantiate a template fold into it, unless
+	// a spec declares them (/users/me next to /users/{id}).
+	for _, key := range order {
+		g := groups[key]
+		if strings.Contains(key, "{") || g.votes[OpenAPI].evidence != "" {
+			continue
+		}
+		for _, other := range order {
+			t := groups[other]
+			if other == key || t == nil || !strings.Contains(other, "{") {
+				continue
+			}
+			if _, ok := pathtmpl.Match(t.target, g.target); !ok {
+				continue
+			}
+			for m := range g.methods {
+				t.methods[m] = true
+			}
+			for e, v := range g.votes {
+				if v.score > t.votes[e].score {
+					t.votes[e] = v
+				}
+			}
+			if g.lastSeen.After(t.lastSeen) {
+				t.lastSeen = g.lastSeen
+			}
+			delete(groups, key)
+			break
+		}
+	}
+
+	var out []Verdict
+	for _, key := range order {
+		g := groups[key]
+		if g == nil {
+			continue
+		}
+		v := Verdict{Target: g.target, LastSeen: g.lastSeen}
+		engines := make([]string, 0, len(g.votes))
+		miss := 1.0
+		for e, vt := range g.votes {
+			engines = append(engines, e)
+			miss *= 1 - vt.score
+		}
+		sort.Slice(engines, func(i, j int) bool {
+			a, b := g.votes[engines[i]].score, g.votes[engines[j]].score
+			if a != b {
+				return a > b
+			}
+			return engines[i] < engines[j]
+		})
+		v.Confidence = 1 - miss
+		v.Engines = engines
+		for _, e := range engines {
+			v.Evidence = append(v.Evidence, e+": "+g.votes[e].evidence)
+		}
+		for m := range g.methods {
+			v.Methods = append(v.Methods, m)
+		}
+		sort.Strings(v.Methods)
+		out = append(out, v)
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Confidence != out[j].Confidence {
+			return out[i].Confidence > out[j].Confidence
+		}
+		return out[i].Target < out[j].Target
+	})
+	return out
+}
+
+var (
+	paramRe    = regexp.MustCompile(`\{[^}]*\}`)
+	uriTmplRe  = regexp.MustCompile(`\{[?&/#][^}]*\}`)
+	multiSlash = regexp.MustCompile(`/{2,}`)
+)
+
+// clean reduces a reported target to a path: no scheme or host, no
+// query, no RFC 6570 expansions such as {?since}.
+func clean(target string) string {
+	t := strings.TrimSpace(target)
+	if i := strings.Index(t, "://"); i >= 0 {
+		t = t[i+3:]
+		j := strings.Index(t, "/")
+		if j < 0 {
+			return "/"
+		}
+		t = t[j:]
+	}
+	t = uriTmplRe.ReplaceAllString(t, "")
+	if i := strings.IndexAny(t, "?#"); i >= 0 {
+		t = t[:i]
+	}
+	if !strings.HasPrefix(t, "/") {
+		return ""
+	}
+	t = multiSlash.ReplaceAllString(t, "/")
+	if len(t) > 1 {
+		t = strings.TrimRight(t, "/")
+	}
+	return t
+}
+
+func clamp(x float64) float64 {
+	return math.Max(0, math.Min(1, x))
+}
+
+// StatusConfidence is how strongly an answer says a path exists: a
+// success or an auth challenge is a real route, a bare 4xx or 5xx may
+// be a catch-all.
+func StatusConfidence(code int) float64 {
+	switch {
+	case code >= 200 && code < 300:
+		return 0.9
+	case code == 401 || code == 403:
+		return 0.75
+	case code == 405:
+		return 0.7
+	case code >= 300 && code < 400:
+		return 0.5
+	}
+	return 0.3
+}
+
+// Prune drops evidence that has decayed below 1/64 of its weight and
+// keeps only the newest of identical findings, so stored evidence does
+// not grow without bound.
+func Prune(fs []Finding, opt Options) []Finding {
+	newest := map[string]int{}
+	var out []Finding
+	for _, f := range fs {
+		if opt.decay(f.Timestamp) < 1.0/64 {
+			continue
+		}
+		key := strings.Join([]string{f.Engine, f.Kind, f.Target, f.Method, f.Evidence}, "\x00")
+		if i, ok := newest[key]; ok {
+			if f.Timestamp.After(out[i].Timestamp) {
+				out[i] = f
+			}
+			continue
+		}
+		newest[key] = len(out)
+		out = append(out, f)
+	}
+	return out
+}
+
+// Load reads evidence saved by an earlier run; a missing file is empty.
+func Load(path string) ([]Finding, error) {
+	b, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var fs []Finding
+	if err := json.Unmarshal(b, &fs); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return fs, nil
+}
+
+func Save(path string, fs []Finding) error {
+	b, err := json.MarshalIndent(fs, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, b, 0o644)
+}
+
+// Record adds findings to the evidence kept at path, for commands that
+// learn an API outside of discover.
+func Record(path string, fs []Finding) error {
+	if len(fs) == 0 {
+		return nil
+	}
+	prior, err := Load(path)
+	if err != nil {
+		return err
+	}
+	return Save(path, Prune(append(prior, fs...), Options{}))
+}
+
+// EvidencePath is where evidence about baseURL is kept under root.
+func EvidencePath(root, baseURL string) string {
+	host := baseURL
+	if i := strings.Index(host, "://"); i >= 0 {
+		host = host[i+3:]
+	}
+	host = strings.Trim(strings.NewReplacer("/", "_", ":", "_").Replace(host), "_")
+	return filepath.Join(root, "council", host+".json")
+}
+
+// Render prints the ranked list; with evidence, what each engine saw.
+func Render(w io.Writer, vs []Verdict, evidence bool) {
+	fmt.Fprintln(w, "ENDPOINTS")
+	fmt.Fprintln(w, "---------")
+	if len(vs) == 0 {
+		fmt.Fprintln(w, "No evidence yet. Run: restless discover <target>")
+		return
+	}
+	for _, v := range vs {
+		methods := strings.Join(v.Methods, ",")
+		if methods == "" {
+			methods = "-"
+		}
+		fmt.Fprintf(w, "  %.2f  %-6s %-12s %-40s %s\n", v.Confidence, v.Level(), methods, v.Target, v.Explain())
+		if evidence {
+			for _, e := range v.Evidence {
+				fmt.Fprintf(w, "          %s\n", e)
+			}
+		}
+	}
+	fmt.Fprintln(w)
+}
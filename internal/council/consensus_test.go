@@ -0,0 +1,85 @@
+package council
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestConsensus(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	fs := []Finding{
+		{Engine: OpenAPI, Kind: KindEndpoint, Target: "/users/{id}", Method: "get", Confidence: 1, Timestamp: now},
+		{Engine: Crawl, Kind: KindEndpoint, Target: "/users/42", Method: "GET", Confidence: 0.9, Timestamp: now},
+		{Engine: Wordlist, Kind: KindEndpoint, Target: "/health", Confidence: 0.9, Timestamp: now.Add(-DefaultHalfLife)},
+		{Engine: Hypermedia, Kind: KindEndpoint, Target: "https://h/search{?q}", Confidence: 0.6, Timestamp: now},
+		{Engine: Crawl, Kind: "other", Target: "/ignored", Confidence: 1, Timestamp: now},
+	}
+	vs := Consensus(fs, Options{Now: now})
+	if len(vs) != 3 {
+		t.Fatalf("verdicts: %+v", vs)
+	}
+
+	users := vs[0]
+	if users.Target != "/users/{id}" || users.Explain() != "seen by openapi+crawl" || len(users.Methods) != 1 {
+		t.Fatalf("users: %+v", users)
+	}
+	// 1 - (1-1)(1-0.765): the spec alone is certain.
+	if users.Confidence != 1 {
+		t.Fatalf("users confidence %v", users.Confidence)
+	}
+
+	// One half-life old: 0.6 * 0.9 / 2.
+	var health Verdict
+	for _, v := range vs {
+		if v.Target == "/health" {
+			health = v
+		}
+	}
+	if math.Abs(health.Confidence-0.27) > 1e-9 {
+		t.Fatalf("health: %+v", health)
+	}
+
+	vs = Consensus(fs, Options{Now: now, Weights: map[string]float64{OpenAPI: 0.5}, HalfLife: -1})
+	if c := vs[0].Confidence; math.Abs(c-(1-0.5*(1-0.85*0.9))) > 1e-9 {
+		t.Fatalf("weighted: %+v", vs[0])
+	}
+	if vs[len(vs)-1].Target != "/search" {
+		t.Fatalf("uri template not stripped: %+v", vs)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	now := time.Now()
+	f := Finding{Engine: Crawl, Kind: KindEndpoint, Target: "/a", Confidence: 1}
+	old, mid, fresh := f, f, f
+	old.Timestamp = now.Add(-7 * DefaultHalfLife)
+	mid.Timestamp = now.Add(-time.Hour)
+	fresh.Timestamp = now
+	out := Prune([]Finding{old, mid, fresh}, Options{Now: now})
+	if len(out) != 1 || !out[0].Timestamp.Equal(now) {
+		t.Fatalf("prune: %+v", out)
+	}
+}
+
+func TestRecord(t *testing.T) {
+	path := EvidencePath(t.TempDir(), "https://api.example.com/")
+	now := time.Now()
+	spec := Finding{Engine: OpenAPI, Kind: KindEndpoint, Target: "/a", Method: "GET", Confidence: 1, Timestamp: now}
+	seen := Finding{Engine: Traffic, Kind: KindEndpoint, Target: "/a", Method: "GET", Evidence: "recorded by the proxy", Confidence: 0.9, Timestamp: now}
+	if err := Save(path, []Finding{spec}); err != nil {
+		t.Fatal(err)
+	}
+	for range 2 {
+		if err := Record(path, []Finding{seen}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	fs, err := Load(path)
+	if err != nil || len(fs) != 2 || fs[1].Engine != Traffic {
+		t.Fatalf("evidence: %v %+v", err, fs)
+	}
+	if vs := Consensus(fs, Options{Now: now}); len(vs) != 1 || vs[0].Explain() != "seen by openapi+traffic" {
+		t.Fatalf("verdicts: %+v", vs)
+	}
+}
@@ -4,8 +4,6 @@ import telemetry "github.com/bspippi1337/restless/internal/telemetry"
 
 import status "github.com/bspippi1337/restless/internal/status"
 
-import "fmt"
-
 type Council struct {
 	Blackboard *Blackboard
 }
@@ -14,30 +12,16 @@ func NewCouncil(b *Blackboard) *Council {
 	return &Council{Blackboard: b}
 }
 
-func (c *Council) Convene() {
-
-	findings := c.Blackboard.List()
-
-	byTarget := map[string][]Finding{}
-
-	for _, f := range findings {
-		byTarget[f.Target] = append(byTarget[f.Target], f)
-	}
-
-	for target, group := range byTarget {
-
-		score := 0.0
-		engines := map[string]bool{}
-
-		for _, f := range group {
-			score += f.Confidence
-			engines[f.Engine] = true
-		}
-
-		if len(engines) > 1 && score > 1.2 {
-			fmt.Printf("council consensus: %s (score %.2f)\n", target, score)
+// Convene weighs everything on the blackboard and returns the ranked
+// endpoint list. Endpoints more than one engine agrees on count as
+// consensus.
+func (c *Council) Convene(opt Options) []Verdict {
+	verdicts := Consensus(c.Blackboard.List(), opt)
+	for _, v := range verdicts {
+		if len(v.Engines) > 1 {
 			telemetry.IncConsensus()
 			status.IncConsensus()
 		}
 	}
+	return verdicts
 }
@@ -2,10 +2,14 @@ package council
 
 import "time"
 
+// KindEndpoint is a finding that Target is an endpoint of the API.
+const KindEndpoint = "endpoint"
+
 type Finding struct {
 	Engine     string
 	Kind       string
 	Target     string
+	Method     string `json:",omitempty"`
 	Evidence   string
 	Confidence float64
 	Timestamp  time.Time
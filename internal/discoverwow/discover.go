@@ -12,6 +12,8 @@ import (
 	"strings"
 	"time"
 	"unicode"
+
+	"github.com/bspippi1337/restless/internal/council"
 )
 
 type EndpointScore struct {
@@ -214,6 +216,35 @@ func Discover(target string) (*Result, error) {
 	return res, nil
 }
 
+// Publish tells the board about the paths the root document links to,
+// more confidently for those whose sample answered.
+func (r *Result) Publish(board *council.Blackboard) {
+	fetched := map[string]bool{}
+
+	for _, f := range r.FieldIntel {
+		fetched[f.Path] = true
+	}
+
+	for _, path := range r.Traversal {
+		conf := 0.6
+		evidence := "linked from the root document"
+
+		if fetched[path] {
+			conf = 0.9
+			evidence += ", sample answered 200"
+		}
+
+		board.Publish(council.Finding{
+			Engine:     council.Hypermedia,
+			Kind:       council.KindEndpoint,
+			Target:     path,
+			Evidence:   evidence,
+			Confidence: conf,
+			Timestamp:  time.Now(),
+		})
+	}
+}
+
 func Render(r *Result) string {
 	var b strings.Builder
 
@@ -280,13 +311,21 @@ func renderEndpoints(
 
 		fmt.Fprintf(
 			b,
-			"  %2d  %-53s %s\n",
+			"  %3d  %-53s %s\n",
 			it.Score,
 			path,
 			it.Reason,
 		)
 	}
 
+	if len(items) > limit {
+		fmt.Fprintf(
+			b,
+			"  ... and %d more (restless council)\n",
+			len(items)-limit,
+		)
+	}
+
 	fmt.Fprintln(b)
 }
 
@@ -570,7 +609,7 @@ func normalizeTarget(raw string) (string, error) {
 		return "", fmt.Errorf(msg)
 	}
 
-	if _, err := net.LookupHost(host); err != nil {
+	if _, err := net.LookupHost(u.Hostname()); err != nil {
 		return "", fmt.Errorf(
 			"unable to resolve host %q",
 			host,
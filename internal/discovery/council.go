@@ -0,0 +1,57 @@
+package discovery
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+
+	"github.com/bspippi1337/restless/internal/council"
+)
+
+func publish(board *council.Blackboard, engine, method, path, evidence string, confidence float64) {
+	if board == nil {
+		return
+	}
+	board.Publish(council.Finding{
+		Engine:     engine,
+		Kind:       council.KindEndpoint,
+		Target:     path,
+		Method:     method,
+		Evidence:   evidence,
+		Confidence: confidence,
+		Timestamp:  time.Now(),
+	})
+}
+
+// PublishSpec tells the board about every operation a spec declares.
+// Paths get the path prefix of the spec's first server.
+func PublishSpec(board *council.Blackboard, specURL string, doc *openapi3.T) {
+	if doc == nil || doc.Paths == nil {
+		return
+	}
+	prefix := ""
+	if len(doc.Servers) > 0 {
+		if u, err := url.Parse(doc.Servers[0].URL); err == nil {
+			prefix = strings.TrimRight(u.Path, "/")
+		}
+	}
+	paths := doc.Paths.Map()
+	keys := make([]string, 0, len(paths))
+	for p := range paths {
+		keys = append(keys, p)
+	}
+	sort.Strings(keys)
+	for _, p := range keys {
+		for method := range paths[p].Operations() {
+			publish(board, council.OpenAPI, method, prefix+p, "declared in "+specURL, 1)
+		}
+	}
+}
+
+func evidenceFor(method string, status int) string {
+	return fmt.Sprintf("%s → %d", method, status)
+}
@@ -4,6 +4,8 @@ import (
 	"net/http"
 	"strings"
 	"time"
+
+	"github.com/bspippi1337/restless/internal/council"
 )
 
 type Result struct {
@@ -11,7 +13,10 @@ type Result struct {
 	Endpoints []Endpoint `json:"endpoints"`
 }
 
-func Discover(url string) (*Result, error) {
+// Discover probes a short list of well-known paths. Paths that answer
+// with anything but 404 are published to board (which may be nil) as
+// wordlist evidence.
+func Discover(url string, board *council.Blackboard) (*Result, error) {
 	client := &http.Client{
 		Timeout: 12 * time.Second,
 	}
@@ -52,6 +57,10 @@ func Discover(url string) (*Result, error) {
 			continue
 		}
 		r.Body.Close()
+		if r.StatusCode != http.StatusNotFound {
+			// HEAD answering implies GET (RFC 9110).
+			publish(board, council.Wordlist, http.MethodGet, req.URL.Path, evidenceFor(http.MethodHead, r.StatusCode), council.StatusConfidence(r.StatusCode))
+		}
 		if r.StatusCode > 0 && r.StatusCode < 500 {
 			endpoints = append(endpoints, Endpoint{Path: p})
 		}
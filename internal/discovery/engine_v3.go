@@ -12,6 +12,8 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/bspippi1337/restless/internal/council"
 )
 
 type Engine struct {
@@ -21,7 +23,11 @@ type Engine struct {
 	Endpoints map[string]*Endpoint
 	Workers   int
 	MaxDepth  int
-	mu        sync.Mutex
+
+	// Board, when set, hears about every path that answers.
+	Board *council.Blackboard
+
+	mu sync.Mutex
 }
 
 type Endpoint struct {
@@ -178,6 +184,13 @@ func (e *Engine) registerEndpoint(path, method string, status int, body []byte)
 
 	schema := detectSchema(body)
 	ep.Methods[method] = &MethodInfo{Status: status, Schema: schema}
+
+	m := method
+	if m != http.MethodGet {
+		m = "" // HEAD and OPTIONS say the path exists, not what it serves
+	}
+	publish(e.Board, council.Crawl, m, path, evidenceFor(method, status), council.StatusConfidence(status))
+
 	for k, v := range schema {
 		if _, ok := ep.Parameters[k]; !ok {
 			ep.Parameters[k] = v
@@ -0,0 +1,169 @@
+package findings
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/bspippi1337/restless/internal/specfuzz"
+)
+
+// A check is what the last response of a reproducer must satisfy; it
+// fails while the finding is still there.
+type check struct {
+	kind   checkKind
+	path   []any    // JSON location, for body checks
+	names  []string // types or property names
+	values []string // allowed values, or an operator and a bound
+	length bool     // compare the length instead of the value
+}
+
+type checkKind int
+
+const (
+	noServerError  checkKind = iota // status < 500
+	refused                         // not 2xx
+	declaredStatus                  // status is in values (OpenAPI keys)
+	not404
+	answers  // any response in time
+	hasType  // value at path is one of names
+	hasProps // names exist under path
+	noProps  // names do not exist under path
+	oneOf    // value at path is one of values
+	bound    // value at path compares with values[1] by values[0]
+	validJSON
+	present // fallback: value at path exists
+)
+
+var (
+	moreRe     = regexp.MustCompile(`\s*\(\+\d+ more\)$`)
+	typeRe     = regexp.MustCompile(`^expected (.+), but got \S+$`)
+	requiredRe = regexp.MustCompile(`^missing properties: (.+)$`)
+	extraRe    = regexp.MustCompile(`^additionalProperties (.+) not allowed$`)
+	enumRe     = regexp.MustCompile(`^value must be (?:one of )?(.+)$`)
+	boundRe    = regexp.MustCompile(`^(length )?must be (>=|<=|>|<) (\S+?),? but (?:found|got)`)
+	itemsRe    = regexp.MustCompile(`^(minimum|maximum) (\d+) items required`)
+	quotedRe   = regexp.MustCompile(`'((?:[^'\\]|\\.)*)'`)
+	pathRe     = regexp.MustCompile(`\.([^.\[]+)|\[(\d+)\]`)
+)
+
+// checkFor picks the assertion that tells whether f is fixed.
+func checkFor(f Finding) check {
+	switch f.Kind {
+	case specfuzz.Crash:
+		return check{kind: noServerError}
+	case specfuzz.Timeout:
+		return check{kind: answers}
+	case specfuzz.UseAfterDelete, specfuzz.DoubleCreate, specfuzz.AuthzLeak:
+		return check{kind: refused}
+	case Drift:
+		if f.Expected == "" {
+			return check{kind: not404}
+		}
+		return check{kind: declaredStatus, values: strings.Split(f.Expected, ",")}
+	}
+	return schemaCheck(parsePath(f.JSONPath), moreRe.ReplaceAllString(f.Message, ""))
+}
+
+// schemaCheck reads a JSON Schema validation message back into the
+// rule that was broken.
+func schemaCheck(path []any, msg string) check {
+	if m := typeRe.FindStringSubmatch(msg); m != nil {
+		return check{kind: hasType, path: path, names: strings.Split(m[1], " or ")}
+	}
+	if m := requiredRe.FindStringSubmatch(msg); m != nil {
+		return check{kind: hasProps, path: path, names: quotedNames(m[1])}
+	}
+	if m := extraRe.FindStringSubmatch(msg); m != nil {
+		return check{kind: noProps, path: path, names: quotedNames(m[1])}
+	}
+	if m := boundRe.FindStringSubmatch(msg); m != nil {
+		if _, err := strconv.ParseFloat(m[3], 64); err == nil {
+			return check{kind: bound, path: path, values: []string{m[2], m[3]}, length: m[1] != ""}
+		}
+	}
+	if m := itemsRe.FindStringSubmatch(msg); m != nil {
+		op := ">="
+		if m[1] == "maximum" {
+			op = "<="
+		}
+		return check{kind: bound, path: path, values: []string{op, m[2]}, length: true}
+	}
+	if m := enumRe.FindStringSubmatch(msg); m != nil {
+		if vs, ok := goValues(m[1]); ok {
+			return check{kind: oneOf, path: path, values: vs}
+		}
+	}
+	if strings.Contains(msg, "not valid JSON") {
+		return check{kind: validJSON}
+	}
+	return check{kind: present, path: path}
+}
+
+// parsePath turns $.items[0].id into ["items", 0, "id"].
+func parsePath(jp string) []any {
+	var out []any
+	for _, m := range pathRe.FindAllStringSubmatch(strings.TrimPrefix(jp, "$"), -1) {
+		if m[2] != "" {
+			n, _ := strconv.Atoi(m[2])
+			out = append(out, n)
+			continue
+		}
+		out = append(out, m[1])
+	}
+	return out
+}
+
+func quotedNames(s string) []string {
+	var out []string
+	for _, m := range quotedRe.FindAllStringSubmatch(s, -1) {
+		out = append(out, strings.ReplaceAll(m[1], `\'`, `'`))
+	}
+	return out
+}
+
+// goValues reads a list of %#v values: "a", "b", 3, true. Numbers and
+// strings are compared by their text, so "1" and 1 are the same.
+func goValues(s string) ([]string, bool) {
+	var out []string
+	for s = strings.TrimSpace(s); s != ""; {
+		var v string
+		if s[0] == '"' {
+			q, err := strconv.QuotedPrefix(s)
+			if err != nil {
+				return nil, false
+			}
+			v, _ = strconv.Unquote(q)
+			s = s[len(q):]
+		} else {
+			end := strings.Index(s, ",")
+			if end < 0 {
+				end = len(s)
+			}
+			v = strings.TrimSpace(s[:end])
+			s = s[end:]
+		}
+		out = append(out, v)
+		s = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(s), ","))
+	}
+	return out, len(out) > 0
+}
+
+// jsonPath renders a parsed path for messages: $.items[0].id.
+func jsonPath(path []any) string {
+	var b strings.Builder
+	b.WriteString("$")
+	for _, p := range path {
+		switch x := p.(type) {
+		case int:
+			b.WriteString("[" + strconv.Itoa(x) + "]")
+		default:
+			b.WriteString("." + x.(string))
+		}
+	}
+	return b.String()
+}
+
+func child(path []any, name string) []any {
+	return append(append([]any(nil), path...), name)
+}
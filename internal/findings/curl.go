@@ -0,0 +1,198 @@
+package findings
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// curl renders the plan as a POSIX shell script. Earlier steps run for
+// their side effects; the last one is checked and sets the exit code.
+func (p *plan) curl() []byte {
+	c := checkFor(p.f)
+	var b strings.Builder
+	w := func(format string, args ...any) { fmt.Fprintf(&b, format+"\n", args...) }
+
+	w("#!/bin/sh")
+	w("# Reproduces restless finding %s (%s %s):", p.f.ID, p.f.Kind, p.f.Source)
+	w("# %s %s: %s", p.f.Method, p.f.Path, oneLine(p.f.Message))
+	w("#")
+	w("# Exits 1 while the problem is present. Set %s to run it against", BaseEnv)
+	w("# another server.")
+	if len(p.env) > 0 {
+		w("# Needs %s.", strings.Join(p.env, ", "))
+	}
+	w("set -eu")
+	w("")
+	w("%s=\"${%s:-%s}\"", BaseEnv, BaseEnv, shEscape(p.base))
+	usesJQ := false
+	switch c.kind {
+	case noServerError, refused, declaredStatus, not404, answers:
+	default:
+		usesJQ = true
+	}
+	for _, s := range p.steps {
+		if len(s.extract) > 0 {
+			usesJQ = true
+		}
+	}
+	if usesJQ {
+		w("command -v jq >/dev/null || { echo 'this script needs jq' >&2; exit 2; }")
+	}
+	for _, s := range p.steps {
+		if s.hasSecret() {
+			w("# Percent-encodes every byte, so secrets survive in a query string.")
+			w("urlencode() { printf %%s \"$1\" | od -An -tx1 -v | tr -d ' \\n' | sed 's/../%%&/g'; }")
+			break
+		}
+	}
+	w("out=$(mktemp)")
+	w("trap 'rm -f \"$out\"' EXIT")
+
+	for _, s := range p.steps {
+		w("")
+		args := p.curlArgs(s)
+		switch {
+		case s.last && c.kind == answers:
+			w("curl -sS -o /dev/null --max-time 10 %s", args)
+			w("echo ok")
+			return []byte(b.String())
+		case s.last:
+			w("status=$(curl -sS -o \"$out\" -w '%%{http_code}' %s)", args)
+		case len(s.extract) > 0:
+			w("curl -sS -o \"$out\" %s", args)
+			for _, name := range sortedKeys(s.extract) {
+				w("%s=$(jq -r %s \"$out\")", name, shQuote("getpath("+jqPath(dotPath(s.extract[name]))+")"))
+			}
+		default:
+			w("curl -sS -o /dev/null %s", args)
+		}
+	}
+
+	w("")
+	op := p.f.Method + " " + p.f.Path
+	fail := func(msg string) string {
+		return "{ echo " + shQuote("FAIL: "+msg) + " >&2; cat \"$out\" >&2; exit 1; }"
+	}
+	switch c.kind {
+	case noServerError:
+		w("[ \"$status\" -lt 500 ] || %s", fail(op+" answered a server error"))
+	case refused:
+		w("case \"$status\" in 2??) %s ;; esac", fail(op+" was not refused"))
+	case declaredStatus:
+		var pats []string
+		for _, v := range c.values {
+			v = strings.TrimSpace(strings.ToUpper(v))
+			if v == "DEFAULT" {
+				v = "*"
+			}
+			pats = append(pats, strings.ReplaceAll(v, "X", "?"))
+		}
+		w("case \"$status\" in %s) ;; *) %s ;; esac", strings.Join(pats, "|"), fail(op+" answered an undeclared status"))
+	case not404:
+		w("[ \"$status\" != 404 ] || %s", fail(op+" answered 404"))
+	default:
+		w("jq -e %s \"$out\" >/dev/null || %s", shQuote(jqCheck(c)), fail(oneLine(p.f.Message)))
+	}
+	w("echo \"ok: $status\"")
+	return []byte(b.String())
+}
+
+func (p *plan) curlArgs(s step) string {
+	args := []string{"-X " + s.method}
+	for _, h := range s.header {
+		args = append(args, "-H "+p.shString(template{{lit: h.name + ": "}}.concat(h.value)))
+	}
+	if len(s.body) > 0 {
+		args = append(args, "--data-binary "+p.shString(s.body))
+	}
+	u := p.shString(s.url)
+	if len(s.query) > 0 {
+		u = strings.TrimSuffix(u, `"`) + rawQuery(s.query, shEscape, func(name string) string {
+			return `$(urlencode "${` + name + `}")`
+		}) + `"`
+	}
+	args = append(args, u)
+	return strings.Join(args, " ")
+}
+
+func (t template) concat(u template) template {
+	return append(append(template(nil), t...), u...)
+}
+
+// shString renders a template as a double-quoted shell word.
+func (p *plan) shString(t template) string {
+	var b strings.Builder
+	b.WriteString(`"`)
+	for _, x := range t {
+		if x.tok == "" {
+			b.WriteString(shEscape(x.lit))
+			continue
+		}
+		b.WriteString("${" + x.tok + "}")
+	}
+	b.WriteString(`"`)
+	return b.String()
+}
+
+// shEscape escapes s for use inside double quotes.
+func shEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`, "`", "\\`").Replace(s)
+}
+
+func shQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// jqPath renders a path as a jq array for getpath.
+func jqPath(path []any) string {
+	if path == nil {
+		path = []any{}
+	}
+	b, _ := json.Marshal(path)
+	return string(b)
+}
+
+const jqPresent = `def present(p): p == [] or (try (getpath(p[:-1]) | has(p[-1])) catch false); `
+
+// jqCheck renders a body check as a jq filter that is true when the
+// response is right.
+func jqCheck(c check) string {
+	at := jqPath(c.path)
+	absent := "(present(" + at + ") | not)"
+	switch c.kind {
+	case hasType:
+		var tests []string
+		for _, t := range c.names {
+			switch t {
+			case "integer":
+				tests = append(tests, `(type == "number" and . == floor)`)
+			default:
+				tests = append(tests, `type == "`+t+`"`)
+			}
+		}
+		return jqPresent + absent + " or (getpath(" + at + ") | " + strings.Join(tests, " or ") + ")"
+	case hasProps, noProps:
+		var tests []string
+		for _, name := range c.names {
+			t := "present(" + jqPath(child(c.path, name)) + ")"
+			if c.kind == noProps {
+				t = "(" + t + " | not)"
+			}
+			tests = append(tests, t)
+		}
+		return jqPresent + strings.Join(tests, " and ")
+	case oneOf:
+		vals, _ := json.Marshal(c.values)
+		return jqPresent + absent + " or ((getpath(" + at + ") | tostring) as $v | " + string(vals) + " | any(. == $v))"
+	case bound:
+		get := "getpath(" + at + ")"
+		if c.length {
+			get += " | length"
+		}
+		return jqPresent + absent + " or ((" + get + ") " + c.values[0] + " " + c.values[1] + ")"
+	case validJSON:
+		return "true"
+	}
+	return jqPresent + "present(" + at + ")"
+}
@@ -0,0 +1,390 @@
+package findings
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bspippi1337/restless/internal/modules/openapi/guard/model"
+	"github.com/bspippi1337/restless/internal/modules/session"
+	"github.com/bspippi1337/restless/internal/redact"
+)
+
+// Formats an export can take.
+const (
+	Curl   = "curl"
+	GoTest = "go-test"
+	Flow   = "flow"
+)
+
+// Formats lists the export formats.
+var Formats = []string{Curl, GoTest, Flow}
+
+// BaseEnv overrides the recorded origin in curl and Go reproducers.
+const BaseEnv = "BASE_URL"
+
+type Options struct {
+	// Package names the Go test's package (default "repro").
+	Package string
+}
+
+// Export renders f as a standalone reproducer that fails while the
+// problem is present.
+func Export(f Finding, format string, opt Options) ([]byte, error) {
+	p, err := newPlan(f)
+	if err != nil {
+		return nil, err
+	}
+	switch format {
+	case Curl:
+		return p.curl(), nil
+	case GoTest:
+		if opt.Package == "" {
+			opt.Package = "repro"
+		}
+		return p.goTest(opt.Package)
+	case Flow:
+		return p.flow()
+	}
+	return nil, errors.New("unknown format " + format + ": want " + strings.Join(Formats, ", "))
+}
+
+// A template is text with placeholders: the base URL, a secret read
+// from the environment, or a value an earlier step returned.
+type template []part
+
+type part struct {
+	lit string
+	tok string
+}
+
+func (t template) add(lit string) template {
+	if lit == "" {
+		return t
+	}
+	if n := len(t); n > 0 && t[n-1].tok == "" {
+		out := append(template(nil), t...)
+		out[n-1].lit += lit
+		return out
+	}
+	return append(t, part{lit: lit})
+}
+
+func (t template) token(name string) template {
+	return append(t, part{tok: name})
+}
+
+// plan is a finding's request sequence with secrets and ids lifted out
+// of the recorded text.
+type plan struct {
+	f     Finding
+	base  string
+	steps []step
+	env   []string // secrets the reproducer needs
+}
+
+type step struct {
+	method  string
+	url     template // up to the query string
+	query   []queryParam
+	header  []headerValue
+	body    template
+	extract map[string]string // variable -> JSON dot path
+	last    bool
+}
+
+type headerValue struct {
+	name  string
+	value template
+}
+
+// queryParam is one name=value pair of a recorded query string. A value
+// that was redacted is read from the secret instead, and reproducers
+// escape it themselves: the secret may hold & or +.
+type queryParam struct {
+	raw    string // as recorded, escaped
+	rawKey string
+	key    string // unescaped
+	value  string
+	secret string
+}
+
+// hasSecret reports whether any query value is a secret.
+func (s step) hasSecret() bool {
+	for _, q := range s.query {
+		if q.secret != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// Headers the client sets itself; copying Accept-Encoding would also
+// turn off transparent decompression.
+var skipHeaders = map[string]bool{
+	"Accept-Encoding":   true,
+	"Connection":        true,
+	"Content-Length":    true,
+	"Host":              true,
+	"Keep-Alive":        true,
+	"Proxy-Connection":  true,
+	"Te":                true,
+	"Trailer":           true,
+	"Transfer-Encoding": true,
+	"Upgrade":           true,
+}
+
+func newPlan(f Finding) (*plan, error) {
+	if f.Request == nil || f.Request.URL == "" {
+		return nil, errors.New("finding " + f.ID + " has no recorded request")
+	}
+	reqs := append(append([]model.Request(nil), f.Sequence...), *f.Request)
+	u, err := url.Parse(f.Request.URL)
+	if err != nil {
+		return nil, err
+	}
+	p := &plan{f: f, base: u.Scheme + "://" + u.Host}
+
+	// Ids a response returned become variables wherever a later URL
+	// uses them as a path segment.
+	type chained struct{ name, value string }
+	var vars []chained
+	env := map[string]bool{}
+	for i, r := range reqs {
+		s := step{method: r.Method, last: i == len(reqs)-1}
+		s.url, s.query = p.url(r, func(seg string) string {
+			for j := len(vars) - 1; j >= 0; j-- {
+				if vars[j].value == seg {
+					return vars[j].name
+				}
+			}
+			return ""
+		}, env)
+		for _, k := range sortedHeaders(r.Header) {
+			for _, v := range r.Header[k] {
+				var t template
+				if v == redact.Placeholder {
+					name := envName(r.As, k)
+					env[name] = true
+					t = t.token(name)
+				} else {
+					t = t.add(v)
+				}
+				s.header = append(s.header, headerValue{name: k, value: t})
+			}
+		}
+		s.body = secretBody(r, env)
+		for _, path := range sortedKeys(r.Returned) {
+			v := chained{name: varName(path, i), value: r.Returned[path]}
+			if s.extract == nil {
+				s.extract = map[string]string{}
+			}
+			s.extract[v.name] = path
+			vars = append(vars, v)
+		}
+		p.steps = append(p.steps, s)
+	}
+
+	// Drop extractions nothing uses.
+	used := map[string]bool{}
+	for _, s := range p.steps {
+		for _, t := range s.url {
+			used[t.tok] = true
+		}
+	}
+	for _, s := range p.steps {
+		for name := range s.extract {
+			if !used[name] {
+				delete(s.extract, name)
+			}
+		}
+	}
+	p.env = sortedKeys(env)
+	return p, nil
+}
+
+// url splits the recorded URL into the base, path segments that name
+// earlier results, and the query string with the values that were
+// secrets.
+func (p *plan) url(r model.Request, lookup func(seg string) string, env map[string]bool) (template, []queryParam) {
+	var t template
+	rest := r.URL
+	if strings.HasPrefix(rest, p.base) {
+		t = t.token(BaseEnv)
+		rest = rest[len(p.base):]
+	}
+	path, query, hasQuery := strings.Cut(rest, "?")
+	segs := strings.Split(path, "/")
+	for i, seg := range segs {
+		if i > 0 {
+			t = t.add("/")
+		}
+		if v, err := url.PathUnescape(seg); err == nil && v != "" {
+			if name := lookup(v); name != "" {
+				t = t.token(name)
+				continue
+			}
+		}
+		t = t.add(seg)
+	}
+	if !hasQuery {
+		return t, nil
+	}
+	var qs []queryParam
+	for _, kv := range strings.Split(query, "&") {
+		k, v, ok := strings.Cut(kv, "=")
+		q := queryParam{raw: kv, rawKey: k}
+		q.key, _ = url.QueryUnescape(k)
+		q.value, _ = url.QueryUnescape(v)
+		if ok && v == redact.Placeholder {
+			q.secret = envName(r.As, q.key)
+			env[q.secret] = true
+		}
+		qs = append(qs, q)
+	}
+	return t, qs
+}
+
+// rawQuery renders the query string as recorded, passing the recorded
+// text through lit and putting each secret in by secret(name).
+func rawQuery(qs []queryParam, lit, secret func(string) string) string {
+	parts := make([]string, len(qs))
+	for i, q := range qs {
+		parts[i] = lit(q.raw)
+		if q.secret != "" {
+			parts[i] = lit(q.rawKey+"=") + secret(q.secret)
+		}
+	}
+	return lit("?") + strings.Join(parts, lit("&"))
+}
+
+var (
+	jsonSecret = regexp.MustCompile(`"([^"\\]+)"\s*:\s*"` + redact.Placeholder + `"`)
+	formSecret = regexp.MustCompile(`(^|&)([^=&]+)=` + redact.Placeholder + `(&|$)`)
+)
+
+// secretBody lifts redacted JSON fields and form values into secrets.
+func secretBody(r model.Request, env map[string]bool) template {
+	re, key := jsonSecret, 1
+	if strings.Contains(r.Header.Get("Content-Type"), "form") {
+		re, key = formSecret, 2
+	}
+	var t template
+	body := r.Body
+	for {
+		m := re.FindStringSubmatchIndex(body)
+		if m == nil {
+			return t.add(body)
+		}
+		name := envName(r.As, body[m[2*key]:m[2*key+1]])
+		env[name] = true
+		at := strings.LastIndex(body[:m[1]], redact.Placeholder)
+		t = t.add(body[:at]).token(name)
+		body = body[at+len(redact.Placeholder):]
+	}
+}
+
+// envName is the variable a secret is read from: AUTHORIZATION, or
+// BOB_AUTHORIZATION when identity bob sent it.
+func envName(as, key string) string {
+	if as != "" {
+		key = as + "_" + key
+	}
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		}
+		return '_'
+	}, key)
+}
+
+// varName names the value at a dot path of step i's response: id_1.
+func varName(path string, i int) string {
+	name := path[strings.LastIndex(path, ".")+1:]
+	name = strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return '_'
+	}, name)
+	return strings.ToLower(name) + "_" + strconv.Itoa(i+1)
+}
+
+func (p *plan) isEnv(name string) bool {
+	for _, e := range p.env {
+		if e == name {
+			return true
+		}
+	}
+	return false
+}
+
+// flow renders the plan as a flow file. The base URL stays literal;
+// secrets are flow variables to pass with --var.
+func (p *plan) flow() ([]byte, error) {
+	var steps []session.FlowStep
+	render := func(t template) string {
+		var b strings.Builder
+		for _, x := range t {
+			switch x.tok {
+			case "":
+				b.WriteString(x.lit)
+			case BaseEnv:
+				b.WriteString(p.base)
+			default:
+				b.WriteString("{{" + x.tok + "}}")
+			}
+		}
+		return b.String()
+	}
+	for _, s := range p.steps {
+		u := render(s.url)
+		if len(s.query) > 0 {
+			u += rawQuery(s.query, func(x string) string { return x }, func(name string) string { return "{{" + name + "}}" })
+		}
+		fs := session.FlowStep{
+			Method:  s.method,
+			URL:     u,
+			Headers: map[string]string{},
+			Body:    render(s.body),
+			Extract: s.extract,
+		}
+		for _, h := range s.header {
+			fs.Headers[h.name] = render(h.value)
+		}
+		steps = append(steps, fs)
+	}
+	b, err := json.MarshalIndent(steps, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(b, '\n'), nil
+}
+
+func sortedHeaders(h http.Header) []string {
+	var out []string
+	for k := range h {
+		if !skipHeaders[http.CanonicalHeaderKey(k)] {
+			out = append(out, k)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	out := make([]string, 0, len(m))
+	for k := range m {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
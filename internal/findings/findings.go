@@ -0,0 +1,226 @@
+// Package findings keeps what fuzz, guard and validate runs found, each
+// with the request that reproduces it, and exports them as standalone
+// reproducers.
+package findings
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bspippi1337/restless/internal/modules/openapi/guard/model"
+	"github.com/bspippi1337/restless/internal/specfuzz"
+	"github.com/bspippi1337/restless/internal/validate"
+)
+
+// Sources of findings.
+const (
+	Fuzz     = "fuzz"
+	Guard    = "guard"
+	Validate = "validate"
+)
+
+// Drift is the kind of a validate finding: the live status code is not
+// one the spec declares.
+const Drift = "drift"
+
+type Finding struct {
+	ID       string
+	Source   string
+	Kind     string
+	Severity model.FindingSeverity
+	Method   string
+	Path     string
+	Status   int `json:",omitempty"`
+	Message  string
+	JSONPath string `json:",omitempty"`
+	Expected string `json:",omitempty"`
+	Actual   string `json:",omitempty"`
+
+	// Request reproduces the finding. Sequence holds the requests that
+	// must be sent before it, in order.
+	Request  *model.Request
+	Sequence []model.Request `json:",omitempty"`
+
+	Found time.Time
+}
+
+// FromFuzz converts the findings of a fuzz report.
+func FromFuzz(rep *specfuzz.Report) []Finding {
+	var out []Finding
+	for _, f := range rep.Findings {
+		req := f.Request
+		x := Finding{
+			Source:   Fuzz,
+			Kind:     f.Kind,
+			Severity: f.Severity,
+			Method:   f.Method,
+			Path:     f.Path,
+			Status:   f.Status,
+			Message:  f.Message,
+			JSONPath: f.JSONPath,
+			Request:  &req,
+			Found:    rep.FinishedAt,
+		}
+		if n := len(f.Sequence); n > 1 {
+			x.Sequence = f.Sequence[:n-1]
+		}
+		out = append(out, x.withID())
+	}
+	return out
+}
+
+// FromGuard converts response validation findings. Findings without a
+// captured request, and the root "doesn't validate" summary that always
+// accompanies a more specific one, are dropped.
+func FromGuard(fs []model.Finding, at time.Time) []Finding {
+	var out []Finding
+	for _, f := range fs {
+		if f.Request == nil || (f.JSONPath == "$" && strings.HasPrefix(f.Message, "doesn't validate with")) {
+			continue
+		}
+		out = append(out, Finding{
+			Source:   Guard,
+			Kind:     string(f.Kind),
+			Severity: f.Severity,
+			Method:   f.Method,
+			Path:     f.Path,
+			Status:   f.Status,
+			Message:  f.Message,
+			JSONPath: f.JSONPath,
+			Expected: f.Expected,
+			Actual:   f.Actual,
+			Request:  f.Request,
+			Found:    at,
+		}.withID())
+	}
+	return out
+}
+
+// FromValidate converts the drift findings of a validate run.
+func FromValidate(rep validate.Report, at time.Time) []Finding {
+	var out []Finding
+	for _, f := range rep.Findings {
+		if f.Request == nil {
+			continue
+		}
+		out = append(out, Finding{
+			Source:   Validate,
+			Kind:     Drift,
+			Severity: model.SevMedium,
+			Method:   f.Method,
+			Path:     f.Path,
+			Status:   f.ActualCode,
+			Message:  f.Problem,
+			Expected: f.ExpectedCodes,
+			Actual:   fmt.Sprint(f.ActualCode),
+			Request:  f.Request,
+			Found:    at,
+		}.withID())
+	}
+	return out
+}
+
+// withID sets the ID from what was found and how, so the same finding
+// seen twice keeps its ID.
+func (f Finding) withID() Finding {
+	h := sha256.New()
+	for _, s := range []string{f.Source, f.Kind, f.Method, f.Path, f.JSONPath, f.Message, f.Request.Method, f.Request.URL, f.Request.Body} {
+		h.Write([]byte(s))
+		h.Write([]byte{0})
+	}
+	f.ID = hex.EncodeToString(h.Sum(nil))[:8]
+	return f
+}
+
+func dir(root string) string {
+	return filepath.Join(root, "findings")
+}
+
+// Save writes each finding to root/findings/<id>.json.
+func Save(root string, fs []Finding) error {
+	if len(fs) == 0 {
+		return nil
+	}
+	if err := os.MkdirAll(dir(root), 0o755); err != nil {
+		return err
+	}
+	for _, f := range fs {
+		b, err := json.MarshalIndent(f, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(dir(root), f.ID+".json"), b, 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// List returns the saved findings, newest first.
+func List(root string) ([]Finding, error) {
+	entries, err := os.ReadDir(dir(root))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var out []Finding
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".json" {
+			continue
+		}
+		f, err := read(filepath.Join(dir(root), e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, f)
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if !out[i].Found.Equal(out[j].Found) {
+			return out[i].Found.After(out[j].Found)
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out, nil
+}
+
+// Load reads the finding whose ID starts with id.
+func Load(root, id string) (Finding, error) {
+	entries, err := os.ReadDir(dir(root))
+	if err != nil && !os.IsNotExist(err) {
+		return Finding{}, err
+	}
+	var match []string
+	for _, e := range entries {
+		if name := e.Name(); id != "" && strings.HasPrefix(name, id) && filepath.Ext(name) == ".json" {
+			match = append(match, name)
+		}
+	}
+	switch len(match) {
+	case 0:
+		return Finding{}, fmt.Errorf("no finding %q. Run: restless findings ls", id)
+	case 1:
+		return read(filepath.Join(dir(root), match[0]))
+	}
+	return Finding{}, fmt.Errorf("finding %q is ambiguous: %d match", id, len(match))
+}
+
+func read(path string) (Finding, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return Finding{}, err
+	}
+	var f Finding
+	if err := json.Unmarshal(b, &f); err != nil {
+		return Finding{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return f, nil
+}
@@ -0,0 +1,104 @@
+package findings
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bspippi1337/restless/internal/modules/openapi/guard/model"
+	"github.com/bspippi1337/restless/internal/modules/session"
+	"github.com/bspippi1337/restless/internal/specfuzz"
+)
+
+func TestSaveLoad(t *testing.T) {
+	root := t.TempDir()
+	fs := FromFuzz(&specfuzz.Report{
+		FinishedAt: time.Now(),
+		Findings: []specfuzz.Finding{
+			{Kind: specfuzz.Crash, Method: "GET", Path: "/a", Status: 500, Request: model.Request{Method: "GET", URL: "http://x/a"}},
+			{Kind: specfuzz.Crash, Method: "GET", Path: "/b", Status: 500, Request: model.Request{Method: "GET", URL: "http://x/b"}},
+		},
+	})
+	if err := Save(root, fs); err != nil {
+		t.Fatal(err)
+	}
+	list, err := List(root)
+	if err != nil || len(list) != 2 {
+		t.Fatalf("list: %v %+v", err, list)
+	}
+	f, err := Load(root, fs[1].ID[:4])
+	if err != nil || f.Path != "/b" {
+		t.Fatalf("load: %v %+v", err, f)
+	}
+	if _, err := Load(root, "zz"); err == nil {
+		t.Fatal("loaded a finding that does not exist")
+	}
+}
+
+func TestExportSchema(t *testing.T) {
+	f := Finding{
+		ID: "abcd1234", Source: Fuzz, Kind: specfuzz.Schema, Method: "GET", Path: "/search",
+		Message:  "expected integer, but got string (+1 more)",
+		JSONPath: "$.items[0].total",
+		Request: &model.Request{
+			Method: "GET",
+			URL:    "http://127.0.0.1:8080/search?limit=0&api_key=REDACTED",
+			Header: http.Header{"Authorization": {"REDACTED"}, "Accept-Encoding": {"gzip"}},
+		},
+	}
+	src, err := Export(f, GoTest, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"func TestSchemaabcd1234(t *testing.T)",
+		`base+"/search?"+url.Values{"limit": {"0"}, "api_key": {os.Getenv("API_KEY")}}.Encode()`,
+		`"net/url"`,
+		`"Authorization": {os.Getenv("AUTHORIZATION")}`,
+		`lookup(body, "items", 0, "total"); ok && !isType(v, "integer")`,
+	} {
+		if !strings.Contains(string(src), want) {
+			t.Fatalf("go test lacks %q:\n%s", want, src)
+		}
+	}
+	if strings.Contains(string(src), "Accept-Encoding") {
+		t.Fatalf("copied Accept-Encoding:\n%s", src)
+	}
+
+	sh, _ := Export(f, Curl, Options{})
+	if !strings.Contains(string(sh), `"${BASE_URL}/search?limit=0&api_key=$(urlencode "${API_KEY}")"`) ||
+		!strings.Contains(string(sh), "urlencode() {") ||
+		!strings.Contains(string(sh), `(type == "number" and . == floor)`) {
+		t.Fatalf("curl:\n%s", sh)
+	}
+}
+
+func TestExportSequence(t *testing.T) {
+	post := model.Request{Method: "POST", URL: "http://h/orders", Body: `{"code":"c"}`, Returned: map[string]string{"data.id": "17"}}
+	f := Finding{
+		ID: "00ff00ff", Source: Fuzz, Kind: specfuzz.UseAfterDelete, Method: "GET", Path: "/orders/{orderId}",
+		Sequence: []model.Request{post, {Method: "DELETE", URL: "http://h/orders/17"}},
+		Request:  &model.Request{Method: "GET", URL: "http://h/orders/17"},
+	}
+	b, err := Export(f, Flow, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var steps []session.FlowStep
+	if err := json.Unmarshal(b, &steps); err != nil {
+		t.Fatal(err)
+	}
+	if len(steps) != 3 || steps[0].Extract["id_1"] != "data.id" || steps[2].URL != "http://h/orders/{{id_1}}" {
+		t.Fatalf("flow: %s", b)
+	}
+
+	src, err := Export(f, GoTest, Options{Package: "orders"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(src), `base+"/orders/"+vars["id_1"]`) || !strings.Contains(string(src), "status/100 == 2") {
+		t.Fatalf("go test:\n%s", src)
+	}
+}
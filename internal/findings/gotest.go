@@ -0,0 +1,424 @@
+package findings
+
+import (
+	"fmt"
+	"go/format"
+	"strconv"
+	"strings"
+)
+
+// goTest renders the plan as a _test.go file that only needs net/http.
+func (p *plan) goTest(pkg string) ([]byte, error) {
+	c := checkFor(p.f)
+	need := map[string]bool{}
+	var pre, body strings.Builder
+	w := func(format string, args ...any) { fmt.Fprintf(&pre, format+"\n", args...) }
+
+	w("base := os.Getenv(%q)", BaseEnv)
+	w("if base == \"\" {")
+	w("base = %q", p.base)
+	w("}")
+	for _, name := range p.env {
+		w("if os.Getenv(%q) == \"\" {", name)
+		w("t.Skip(%q)", "set "+name+" to the credential the finding was recorded with")
+		w("}")
+	}
+	w("client := &http.Client{Timeout: 10 * time.Second}")
+	chained := false
+	for _, s := range p.steps {
+		if len(s.extract) > 0 {
+			chained = true
+		}
+	}
+	if chained {
+		w("vars := map[string]string{}")
+	}
+	w("")
+	w("send := func(method, url, body string, header http.Header) (int, []byte) {")
+	w("t.Helper()")
+	w("req, err := http.NewRequest(method, url, strings.NewReader(body))")
+	w("if err != nil {")
+	w("t.Fatal(err)")
+	w("}")
+	w("req.Header = header")
+	w("resp, err := client.Do(req)")
+	w("if err != nil {")
+	w("t.Fatalf(\"%%s %%s: %%v\", method, url, err)")
+	w("}")
+	w("defer resp.Body.Close()")
+	w("b, err := io.ReadAll(resp.Body)")
+	w("if err != nil {")
+	w("t.Fatal(err)")
+	w("}")
+	w("return resp.StatusCode, b")
+	w("}")
+
+	w = func(format string, args ...any) { fmt.Fprintf(&body, format+"\n", args...) }
+
+	for i, s := range p.steps {
+		w("")
+		if s.hasSecret() {
+			need["url"] = true
+		}
+		call := fmt.Sprintf("send(%q, %s, %s, %s)", s.method, p.goURL(s), p.goExpr(s.body), p.goHeader(s.header))
+		switch {
+		case s.last && c.kind == answers:
+			w("// The client gives up after 10s; getting past this means the server answered.")
+			w("%s", call)
+		case s.last && (c.kind == refused || c.kind == declaredStatus || c.kind == not404):
+			w("status, _ := %s", call)
+		case s.last && c.kind == noServerError:
+			w("status, body := %s", call)
+		case s.last:
+			w("_, body := %s", call)
+		case len(s.extract) > 0:
+			w("_, out%d := %s", i+1, call)
+			for _, name := range sortedKeys(s.extract) {
+				need["lookup"], need["fmt"] = true, true
+				w("if v, ok := lookup(out%d, %s); ok {", i+1, goPath(dotPath(s.extract[name])))
+				w("vars[%q] = fmt.Sprint(v)", name)
+				w("}")
+			}
+		default:
+			w("%s", call)
+		}
+	}
+	w("")
+	p.goCheck(w, c, need)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "package %s\n\n", pkg)
+	imports := map[string]bool{"io": true, "net/http": true, "os": true, "strings": true, "testing": true, "time": true}
+	imports["encoding/json"] = need["lookup"] || need["jsonType"] || need["number"] || need["json"]
+	imports["fmt"] = need["fmt"] || need["oneOf"] || need["statusIn"]
+	imports["math"] = need["jsonType"]
+	imports["net/url"] = need["url"]
+	b.WriteString("import (\n")
+	for _, imp := range sortedKeys(imports) {
+		if imports[imp] {
+			fmt.Fprintf(&b, "%q\n", imp)
+		}
+	}
+	b.WriteString(")\n\n")
+	fmt.Fprintf(&b, "// Test%s reproduces restless finding %s (%s %s):\n", goName(p.f), p.f.ID, p.f.Kind, p.f.Source)
+	fmt.Fprintf(&b, "// %s %s: %s\n", p.f.Method, p.f.Path, oneLine(p.f.Message))
+	b.WriteString("//\n")
+	fmt.Fprintf(&b, "// It fails while the problem is present. Set %s to run it against\n// another server.\n", BaseEnv)
+	fmt.Fprintf(&b, "func Test%s(t *testing.T) {\n", goName(p.f))
+	b.WriteString(pre.String())
+	// Helpers are closures so several reproducers can share a package.
+	for _, name := range []string{"lookup", "jsonType", "isType", "oneOf", "number", "length", "statusIn"} {
+		if need[name] {
+			b.WriteString("\n" + goHelpers[name])
+		}
+	}
+	b.WriteString(body.String())
+	b.WriteString("}\n")
+
+	out, err := format.Source([]byte(b.String()))
+	if err != nil {
+		return nil, fmt.Errorf("render go test: %w", err)
+	}
+	return out, nil
+}
+
+func (p *plan) goCheck(w func(string, ...any), c check, need map[string]bool) {
+	op := p.f.Method + " " + p.f.Path
+	loc := jsonPath(c.path)
+	switch c.kind {
+	case noServerError:
+		w("if status >= 500 {")
+		w("t.Fatalf(\"%%s answered %%d: %%s\", %q, status, body)", op)
+		w("}")
+	case refused:
+		w("if status/100 == 2 {")
+		w("t.Fatalf(\"%%s answered %%d; want it refused\", %q, status)", op)
+		w("}")
+	case declaredStatus:
+		need["statusIn"] = true
+		w("if !statusIn(status, %q) {", strings.Join(c.values, ","))
+		w("t.Fatalf(\"%%s answered %%d; the spec declares %%s\", %q, status, %q)", op, strings.Join(c.values, ","))
+		w("}")
+	case not404:
+		w("if status == http.StatusNotFound {")
+		w("t.Fatalf(\"%%s answered 404 but the spec declares it\", %q)", op)
+		w("}")
+	case answers:
+	case hasType:
+		need["lookup"], need["jsonType"], need["isType"] = true, true, true
+		w("if v, ok := lookup(body, %s); ok && !isType(v, %s) {", goPath(c.path), goStrings(c.names))
+		w("t.Fatalf(\"%%s is %%s, want %%s\", %q, jsonType(v), %q)", loc, strings.Join(c.names, " or "))
+		w("}")
+	case hasProps, noProps:
+		need["lookup"] = true
+		for _, name := range c.names {
+			if c.kind == hasProps {
+				w("if _, ok := lookup(body, %s); !ok {", goPath(child(c.path, name)))
+				w("t.Fatalf(\"%%s is missing\", %q)", jsonPath(child(c.path, name)))
+			} else {
+				w("if _, ok := lookup(body, %s); ok {", goPath(child(c.path, name)))
+				w("t.Fatalf(\"%%s is not in the schema\", %q)", jsonPath(child(c.path, name)))
+			}
+			w("}")
+		}
+	case oneOf:
+		need["lookup"], need["oneOf"] = true, true
+		w("if v, ok := lookup(body, %s); ok && !oneOf(v, %s) {", goPath(c.path), goStrings(c.values))
+		w("t.Fatalf(\"%%s is %%v, want one of %%s\", %q, v, %q)", loc, strings.Join(c.values, ", "))
+		w("}")
+	case bound:
+		need["lookup"] = true
+		get, what := "number(v)", loc
+		if c.length {
+			need["length"] = true
+			get, what = "length(v)", "length of "+loc
+		} else {
+			need["number"] = true
+		}
+		w("if v, ok := lookup(body, %s); ok && !(%s %s %s) {", goPath(c.path), get, c.values[0], c.values[1])
+		w("t.Fatalf(\"%%s is %%v, want %s %s\", %q, %s)", c.values[0], c.values[1], what, get)
+		w("}")
+	case validJSON:
+		w("if !json.Valid(body) {")
+		w("t.Fatalf(\"%%s: response is not JSON: %%s\", %q, body)", op)
+		w("}")
+		need["json"] = true
+	default:
+		need["lookup"] = true
+		w("// restless reported %q; this only checks the value is there.", oneLine(p.f.Message))
+		w("if _, ok := lookup(body, %s); !ok {", goPath(c.path))
+		w("t.Fatalf(\"%%s is missing\", %q)", loc)
+		w("}")
+	}
+}
+
+// goExpr renders a template as a Go string expression.
+func (p *plan) goExpr(t template) string {
+	if len(t) == 0 {
+		return `""`
+	}
+	var parts []string
+	for _, x := range t {
+		switch {
+		case x.tok == "":
+			parts = append(parts, strconv.Quote(x.lit))
+		case x.tok == BaseEnv:
+			parts = append(parts, "base")
+		case p.isEnv(x.tok):
+			parts = append(parts, fmt.Sprintf("os.Getenv(%q)", x.tok))
+		default:
+			parts = append(parts, fmt.Sprintf("vars[%q]", x.tok))
+		}
+	}
+	return strings.Join(parts, " + ")
+}
+
+// goURL renders the step URL. A query string with secrets is built with
+// url.Values so the secrets are escaped; the rest stays as recorded.
+func (p *plan) goURL(s step) string {
+	if !s.hasSecret() {
+		if len(s.query) == 0 {
+			return p.goExpr(s.url)
+		}
+		return p.goExpr(s.url.add(rawQuery(s.query, func(x string) string { return x }, nil)))
+	}
+	var keys []string
+	vals := map[string][]string{}
+	for _, q := range s.query {
+		if _, ok := vals[q.key]; !ok {
+			keys = append(keys, q.key)
+		}
+		v := strconv.Quote(q.value)
+		if q.secret != "" {
+			v = fmt.Sprintf("os.Getenv(%q)", q.secret)
+		}
+		vals[q.key] = append(vals[q.key], v)
+	}
+	var b strings.Builder
+	b.WriteString("url.Values{")
+	for i, k := range keys {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		fmt.Fprintf(&b, "%q: {%s}", k, strings.Join(vals[k], ", "))
+	}
+	b.WriteString("}.Encode()")
+	return p.goExpr(s.url.add("?")) + " + " + b.String()
+}
+
+func (p *plan) goHeader(hs []headerValue) string {
+	if len(hs) == 0 {
+		return "http.Header{}"
+	}
+	var b strings.Builder
+	b.WriteString("http.Header{\n")
+	for i := 0; i < len(hs); {
+		j := i
+		var vs []string
+		for ; j < len(hs) && hs[j].name == hs[i].name; j++ {
+			vs = append(vs, p.goExpr(hs[j].value))
+		}
+		fmt.Fprintf(&b, "%q: {%s},\n", hs[i].name, strings.Join(vs, ", "))
+		i = j
+	}
+	b.WriteString("}")
+	return b.String()
+}
+
+func goPath(path []any) string {
+	var parts []string
+	for _, p := range path {
+		switch x := p.(type) {
+		case int:
+			parts = append(parts, strconv.Itoa(x))
+		default:
+			parts = append(parts, strconv.Quote(x.(string)))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+func goStrings(ss []string) string {
+	var parts []string
+	for _, s := range ss {
+		parts = append(parts, strconv.Quote(s))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// dotPath splits a flow extract path: data.id.
+func dotPath(s string) []any {
+	var out []any
+	for _, p := range strings.Split(s, ".") {
+		out = append(out, p)
+	}
+	return out
+}
+
+// goName is the test's name: Crash3f2a1b9c.
+func goName(f Finding) string {
+	var b strings.Builder
+	up := true
+	for _, r := range f.Kind {
+		switch {
+		case r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9':
+			if up && r >= 'a' && r <= 'z' {
+				r -= 'a' - 'A'
+			}
+			b.WriteRune(r)
+			up = false
+		default:
+			up = true
+		}
+	}
+	return b.String() + f.ID
+}
+
+func oneLine(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if len(s) > 200 {
+		s = s[:200] + "..."
+	}
+	return s
+}
+
+var goHelpers = map[string]string{
+	"lookup": `// lookup returns the JSON value at path in body.
+lookup := func(body []byte, path ...any) (any, bool) {
+	t.Helper()
+	dec := json.NewDecoder(strings.NewReader(string(body)))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		t.Fatalf("response is not JSON: %v: %s", err, body)
+	}
+	for _, p := range path {
+		switch k := p.(type) {
+		case string:
+			m, ok := v.(map[string]any)
+			if !ok {
+				return nil, false
+			}
+			if v, ok = m[k]; !ok {
+				return nil, false
+			}
+		case int:
+			a, ok := v.([]any)
+			if !ok || k >= len(a) {
+				return nil, false
+			}
+			v = a[k]
+		}
+	}
+	return v, true
+}
+`,
+	"jsonType": `jsonType := func(v any) string {
+	switch x := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case json.Number:
+		if f, err := x.Float64(); err == nil && f == math.Trunc(f) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	}
+	return "object"
+}
+`,
+	"isType": `isType := func(v any, types ...string) bool {
+	got := jsonType(v)
+	for _, want := range types {
+		if got == want || (want == "number" && got == "integer") {
+			return true
+		}
+	}
+	return false
+}
+`,
+	"oneOf": `oneOf := func(v any, values ...string) bool {
+	for _, want := range values {
+		if fmt.Sprint(v) == want {
+			return true
+		}
+	}
+	return false
+}
+`,
+	"number": `number := func(v any) float64 {
+	n, _ := v.(json.Number)
+	f, _ := n.Float64()
+	return f
+}
+`,
+	"length": `length := func(v any) int {
+	switch x := v.(type) {
+	case string:
+		return len([]rune(x))
+	case []any:
+		return len(x)
+	case map[string]any:
+		return len(x)
+	}
+	return 0
+}
+`,
+	"statusIn": `// statusIn reports whether status matches one of the OpenAPI response
+// keys in codes: "200,404", "2XX" or "default".
+statusIn := func(status int, codes string) bool {
+	for _, c := range strings.Split(codes, ",") {
+		c = strings.ToUpper(strings.TrimSpace(c))
+		if c == "DEFAULT" || c == fmt.Sprint(status) || (len(c) == 3 && strings.HasSuffix(c, "XX") && c[0] == byte('0'+status/100)) {
+			return true
+		}
+	}
+	return false
+}
+`,
+}
@@ -0,0 +1,109 @@
+package graphql
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultDepth is how many object levels Build selects below the root field.
+const DefaultDepth = 2
+
+// Build writes a ready-to-run document for an operation. Every argument
+// becomes a variable; the selection set takes scalar fields and descends
+// into object fields up to depth levels.
+func Build(s *Schema, op Operation, depth int) string {
+	if depth < 1 {
+		depth = DefaultDepth
+	}
+
+	var b strings.Builder
+	b.WriteString(op.Kind + " " + opName(op))
+	if len(op.Args) > 0 {
+		b.WriteString("(")
+		for i, a := range op.Args {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			fmt.Fprintf(&b, "$%s: %s", a.Name, a.Type.String())
+		}
+		b.WriteString(")")
+	}
+	b.WriteString(" {\n  " + op.Name)
+	if len(op.Args) > 0 {
+		b.WriteString("(")
+		for i, a := range op.Args {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			fmt.Fprintf(&b, "%s: $%s", a.Name, a.Name)
+		}
+		b.WriteString(")")
+	}
+	selection(&b, s, op.Returns.Named(), depth, 1)
+	b.WriteString("\n}\n")
+	return b.String()
+}
+
+func opName(op Operation) string {
+	if op.Name == "" {
+		return strings.ToUpper(op.Kind[:1]) + op.Kind[1:]
+	}
+	return strings.ToUpper(op.Name[:1]) + op.Name[1:]
+}
+
+func selection(b *strings.Builder, s *Schema, typeName string, depth, indent int) {
+	t := s.Type(typeName)
+	if t == nil {
+		return
+	}
+
+	pad := strings.Repeat("  ", indent+1)
+	switch t.Kind {
+	case "OBJECT", "INTERFACE":
+		b.WriteString(" {")
+		n := 0
+		for _, f := range t.Fields {
+			if requiredArgs(f) {
+				continue
+			}
+			ft := s.Type(f.Type.Named())
+			if ft == nil {
+				continue
+			}
+			if leaf(ft) {
+				b.WriteString("\n" + pad + f.Name)
+				n++
+				continue
+			}
+			if depth > 1 {
+				b.WriteString("\n" + pad + f.Name)
+				selection(b, s, ft.Name, depth-1, indent+1)
+				n++
+			}
+		}
+		if n == 0 {
+			b.WriteString("\n" + pad + "__typename")
+		}
+		b.WriteString("\n" + strings.Repeat("  ", indent) + "}")
+	case "UNION":
+		b.WriteString(" {\n" + pad + "__typename")
+		for _, pt := range t.PossibleTypes {
+			b.WriteString("\n" + pad + "... on " + pt.Named())
+			selection(b, s, pt.Named(), 1, indent+1)
+		}
+		b.WriteString("\n" + strings.Repeat("  ", indent) + "}")
+	}
+}
+
+func leaf(t *Type) bool {
+	return t.Kind == "SCALAR" || t.Kind == "ENUM"
+}
+
+func requiredArgs(f Field) bool {
+	for _, a := range f.Args {
+		if a.Type.NonNull() && a.DefaultValue == nil {
+			return true
+		}
+	}
+	return false
+}
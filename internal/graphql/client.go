@@ -0,0 +1,143 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Paths are the endpoint locations probed, most common first.
+var Paths = []string{
+	"/graphql",
+	"/api/graphql",
+	"/graphql/v1",
+	"/v1/graphql",
+	"/api/v1/graphql",
+	"/gql",
+	"/query",
+	"/graphiql",
+}
+
+// ErrAuthRequired is returned when an endpoint exists but refuses
+// introspection without credentials.
+var ErrAuthRequired = errors.New("graphql: endpoint requires auth")
+
+type Doer interface {
+	Do(*http.Request) (*http.Response, error)
+}
+
+type Client struct {
+	HTTP    Doer
+	Headers http.Header
+}
+
+type Result struct {
+	Status int
+	Body   []byte
+}
+
+// Request encodes a GraphQL-over-HTTP POST body.
+func Request(query string, vars map[string]any) []byte {
+	payload := map[string]any{"query": query}
+	if len(vars) > 0 {
+		payload["variables"] = vars
+	}
+	b, _ := json.Marshal(payload)
+	return b
+}
+
+// IntrospectionRequest is the POST body for IntrospectionQuery.
+func IntrospectionRequest() []byte {
+	return Request(IntrospectionQuery, nil)
+}
+
+func (c *Client) Execute(ctx context.Context, endpoint, query string, vars map[string]any) (*Result, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(Request(query, vars)))
+	if err != nil {
+		return nil, err
+	}
+	for k, vv := range c.Headers {
+		for _, v := range vv {
+			req.Header.Add(k, v)
+		}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if req.Header.Get("Accept") == "" {
+		req.Header.Set("Accept", "application/graphql-response+json, application/json")
+	}
+
+	hc := c.HTTP
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	b, err := io.ReadAll(io.LimitReader(resp.Body, 32<<20))
+	if err != nil {
+		return nil, err
+	}
+	return &Result{Status: resp.StatusCode, Body: b}, nil
+}
+
+// Introspect runs the full introspection query against one endpoint.
+func (c *Client) Introspect(ctx context.Context, endpoint string) (*Schema, error) {
+	res, err := c.Execute(ctx, endpoint, IntrospectionQuery, nil)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case res.Status == http.StatusUnauthorized || res.Status == http.StatusForbidden:
+		return nil, fmt.Errorf("%w: %s (%d)", ErrAuthRequired, endpoint, res.Status)
+	case res.Status == http.StatusNotFound:
+		return nil, fmt.Errorf("graphql: %s not found", endpoint)
+	}
+	return Parse(res.Body)
+}
+
+// Discover probes Paths under base and introspects the first endpoint that
+// answers. A base that already points at an endpoint is tried first.
+func (c *Client) Discover(ctx context.Context, base string) (string, *Schema, error) {
+	u, err := url.Parse(base)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var candidates []string
+	if p := strings.TrimRight(u.Path, "/"); p != "" {
+		candidates = append(candidates, u.String())
+	}
+	for _, p := range Paths {
+		v := *u
+		v.Path = p
+		v.RawQuery = ""
+		candidates = append(candidates, v.String())
+	}
+
+	var last error
+	for _, ep := range candidates {
+		s, err := c.Introspect(ctx, ep)
+		if err == nil {
+			return ep, s, nil
+		}
+		if ctx.Err() != nil {
+			return "", nil, ctx.Err()
+		}
+		if last == nil || !errors.Is(last, ErrAuthRequired) {
+			last = err
+		}
+	}
+	if last == nil {
+		last = errors.New("graphql: no endpoint found")
+	}
+	return "", nil, last
+}
@@ -0,0 +1,175 @@
+package graphql
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/bspippi1337/restless/internal/modules/openapi/guard/model"
+)
+
+// Diff compares two introspection snapshots. Removals, type changes and
+// new required arguments break clients; additions and deprecations do not.
+func Diff(oldS, newS *Schema) *model.DiffResult {
+	var breaking, nonBreaking []string
+
+	oldTypes := typeIndex(oldS)
+	newTypes := typeIndex(newS)
+
+	for name, ot := range oldTypes {
+		nt, ok := newTypes[name]
+		if !ok {
+			breaking = append(breaking, fmt.Sprintf("removed type: %s", name))
+			continue
+		}
+		if ot.Kind != nt.Kind {
+			breaking = append(breaking, fmt.Sprintf("type %s changed kind: %s -> %s", name, ot.Kind, nt.Kind))
+			continue
+		}
+		b, nb := compareType(ot, nt)
+		breaking = append(breaking, b...)
+		nonBreaking = append(nonBreaking, nb...)
+	}
+	for name := range newTypes {
+		if _, ok := oldTypes[name]; !ok {
+			nonBreaking = append(nonBreaking, fmt.Sprintf("added type: %s", name))
+		}
+	}
+
+	for _, root := range []struct {
+		kind     string
+		old, new *Named
+	}{
+		{"query", oldS.QueryType, newS.QueryType},
+		{"mutation", oldS.MutationType, newS.MutationType},
+		{"subscription", oldS.SubscriptionType, newS.SubscriptionType},
+	} {
+		switch {
+		case root.old != nil && root.new == nil:
+			breaking = append(breaking, fmt.Sprintf("removed %s root", root.kind))
+		case root.old == nil && root.new != nil:
+			nonBreaking = append(nonBreaking, fmt.Sprintf("added %s root", root.kind))
+		}
+	}
+
+	sort.Strings(breaking)
+	sort.Strings(nonBreaking)
+
+	return &model.DiffResult{
+		Breaking:        breaking,
+		NonBreaking:     nonBreaking,
+		RecommendedBump: recommend(breaking, nonBreaking),
+	}
+}
+
+func compareType(ot, nt Type) (breaking, nonBreaking []string) {
+	newFields := map[string]Field{}
+	for _, f := range nt.Fields {
+		newFields[f.Name] = f
+	}
+	for _, of := range ot.Fields {
+		nf, ok := newFields[of.Name]
+		where := ot.Name + "." + of.Name
+		if !ok {
+			breaking = append(breaking, "removed field: "+where)
+			continue
+		}
+		if of.Type.String() != nf.Type.String() {
+			breaking = append(breaking, fmt.Sprintf("field %s changed type: %s -> %s", where, of.Type, nf.Type))
+		}
+		if !of.IsDeprecated && nf.IsDeprecated {
+			nonBreaking = append(nonBreaking, "deprecated field: "+where+reason(nf.DeprecationReason))
+		}
+		b, nb := compareArgs(where, of.Args, nf.Args)
+		breaking = append(breaking, b...)
+		nonBreaking = append(nonBreaking, nb...)
+	}
+	oldFields := map[string]bool{}
+	for _, f := range ot.Fields {
+		oldFields[f.Name] = true
+	}
+	for _, f := range nt.Fields {
+		if !oldFields[f.Name] {
+			nonBreaking = append(nonBreaking, "added field: "+nt.Name+"."+f.Name)
+		}
+	}
+
+	b, nb := compareArgs(ot.Name, ot.InputFields, nt.InputFields)
+	breaking = append(breaking, b...)
+	nonBreaking = append(nonBreaking, nb...)
+
+	newEnum := map[string]EnumValue{}
+	for _, v := range nt.EnumValues {
+		newEnum[v.Name] = v
+	}
+	oldEnum := map[string]bool{}
+	for _, v := range ot.EnumValues {
+		oldEnum[v.Name] = true
+		nv, ok := newEnum[v.Name]
+		if !ok {
+			breaking = append(breaking, fmt.Sprintf("removed enum value: %s.%s", ot.Name, v.Name))
+			continue
+		}
+		if !v.IsDeprecated && nv.IsDeprecated {
+			nonBreaking = append(nonBreaking, fmt.Sprintf("deprecated enum value: %s.%s%s", ot.Name, v.Name, reason(nv.DeprecationReason)))
+		}
+	}
+	for _, v := range nt.EnumValues {
+		if !oldEnum[v.Name] {
+			nonBreaking = append(nonBreaking, fmt.Sprintf("added enum value: %s.%s", nt.Name, v.Name))
+		}
+	}
+
+	return breaking, nonBreaking
+}
+
+// compareArgs handles field arguments and input object fields alike.
+func compareArgs(where string, oldArgs, newArgs []InputValue) (breaking, nonBreaking []string) {
+	old := map[string]InputValue{}
+	for _, a := range oldArgs {
+		old[a.Name] = a
+	}
+	for _, na := range newArgs {
+		oa, ok := old[na.Name]
+		if !ok {
+			if na.Type.NonNull() && na.DefaultValue == nil {
+				breaking = append(breaking, fmt.Sprintf("added required argument: %s(%s)", where, na.Name))
+			} else {
+				nonBreaking = append(nonBreaking, fmt.Sprintf("added optional argument: %s(%s)", where, na.Name))
+			}
+			continue
+		}
+		if oa.Type.String() != na.Type.String() {
+			breaking = append(breaking, fmt.Sprintf("argument %s(%s) changed type: %s -> %s", where, na.Name, oa.Type, na.Type))
+		}
+		delete(old, na.Name)
+	}
+	for name := range old {
+		breaking = append(breaking, fmt.Sprintf("removed argument: %s(%s)", where, name))
+	}
+	return breaking, nonBreaking
+}
+
+func typeIndex(s *Schema) map[string]Type {
+	out := map[string]Type{}
+	for _, t := range s.UserTypes() {
+		out[t.Name] = t
+	}
+	return out
+}
+
+func reason(r string) string {
+	if r == "" {
+		return ""
+	}
+	return " (" + r + ")"
+}
+
+func recommend(breaking, nonBreaking []string) model.SemverBump {
+	if len(breaking) > 0 {
+		return model.BumpMajor
+	}
+	if len(nonBreaking) > 0 {
+		return model.BumpMinor
+	}
+	return model.BumpNone
+}
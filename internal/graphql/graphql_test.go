@@ -0,0 +1,77 @@
+package graphql
+
+import (
+	"strings"
+	"testing"
+)
+
+const introspection = `{"data":{"__schema":{
+ "queryType":{"name":"Query"},"mutationType":{"name":"Mutation"},"subscriptionType":null,
+ "types":[
+  {"kind":"OBJECT","name":"Query","fields":[
+    {"name":"user","args":[{"name":"id","type":{"kind":"NON_NULL","ofType":{"kind":"SCALAR","name":"ID"}}}],
+     "type":{"kind":"OBJECT","name":"User"},"isDeprecated":false},
+    {"name":"me","args":[],"type":{"kind":"OBJECT","name":"User"},"isDeprecated":true,"deprecationReason":"use user"}]},
+  {"kind":"OBJECT","name":"Mutation","fields":[
+    {"name":"rename","args":[{"name":"name","type":{"kind":"SCALAR","name":"String"}}],
+     "type":{"kind":"NON_NULL","ofType":{"kind":"OBJECT","name":"User"}},"isDeprecated":false}]},
+  {"kind":"OBJECT","name":"User","fields":[
+    {"name":"id","args":[],"type":{"kind":"NON_NULL","ofType":{"kind":"SCALAR","name":"ID"}},"isDeprecated":false},
+    {"name":"name","args":[],"type":{"kind":"SCALAR","name":"String"},"isDeprecated":false},
+    {"name":"friends","args":[],"type":{"kind":"LIST","ofType":{"kind":"OBJECT","name":"User"}},"isDeprecated":false}]},
+  {"kind":"SCALAR","name":"ID"},{"kind":"SCALAR","name":"String"},
+  {"kind":"OBJECT","name":"__Type","fields":[]}
+ ]}}}`
+
+func TestOperationsAndBuild(t *testing.T) {
+	s, err := Parse([]byte(introspection))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+
+	ops := s.Operations()
+	if len(ops) != 3 || ops[0].Name != "me" || ops[2].Kind != "mutation" {
+		t.Fatalf("unexpected operations: %+v", ops)
+	}
+	if !ops[0].Deprecated {
+		t.Fatalf("expected me to be deprecated")
+	}
+	if got := ops[1].Signature(); got != "user(id: ID!): User" {
+		t.Fatalf("unexpected signature: %s", got)
+	}
+
+	op, _ := s.Operation("query.user")
+	doc := Build(s, op, 2)
+	for _, want := range []string{"query User($id: ID!)", "user(id: $id)", "friends {", "      id"} {
+		if !strings.Contains(doc, want) {
+			t.Fatalf("document missing %q:\n%s", want, doc)
+		}
+	}
+}
+
+func TestDiffFlagsBreakingChanges(t *testing.T) {
+	oldS, _ := Parse([]byte(introspection))
+
+	changed := strings.Replace(introspection,
+		`{"name":"name","args":[],"type":{"kind":"SCALAR","name":"String"},"isDeprecated":false},`, "", 1)
+	changed = strings.Replace(changed,
+		`"args":[{"name":"name","type":{"kind":"SCALAR","name":"String"}}]`,
+		`"args":[{"name":"name","type":{"kind":"SCALAR","name":"String"}},{"name":"reason","type":{"kind":"NON_NULL","ofType":{"kind":"SCALAR","name":"String"}}}]`, 1)
+	newS, err := Parse([]byte(changed))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+
+	res := Diff(oldS, newS)
+	if res.RecommendedBump != "major" {
+		t.Fatalf("expected major bump, got %s", res.RecommendedBump)
+	}
+	want := []string{"added required argument: Mutation.rename(reason)", "removed field: User.name"}
+	if strings.Join(res.Breaking, "|") != strings.Join(want, "|") {
+		t.Fatalf("unexpected breaking changes: %v", res.Breaking)
+	}
+
+	if res := Diff(oldS, oldS); len(res.Breaking)+len(res.NonBreaking) != 0 {
+		t.Fatalf("expected no changes, got %+v", res)
+	}
+}
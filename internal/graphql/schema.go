@@ -0,0 +1,264 @@
+package graphql
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"sort"
+	"strings"
+)
+
+// IntrospectionQuery is the standard full introspection query, including
+// deprecated fields and enum values so they can be flagged.
+const IntrospectionQuery = `query IntrospectionQuery {
+  __schema {
+    queryType { name }
+    mutationType { name }
+    subscriptionType { name }
+    types { ...FullType }
+  }
+}
+fragment FullType on __Type {
+  kind name description
+  fields(includeDeprecated: true) {
+    name description
+    args { ...InputValue }
+    type { ...TypeRef }
+    isDeprecated deprecationReason
+  }
+  inputFields { ...InputValue }
+  interfaces { ...TypeRef }
+  enumValues(includeDeprecated: true) { name description isDeprecated deprecationReason }
+  possibleTypes { ...TypeRef }
+}
+fragment InputValue on __InputValue {
+  name description
+  type { ...TypeRef }
+  defaultValue
+}
+fragment TypeRef on __Type {
+  kind name
+  ofType { kind name ofType { kind name ofType { kind name ofType { kind name ofType { kind name ofType { kind name } } } } } }
+}`
+
+type Schema struct {
+	QueryType        *Named `json:"queryType"`
+	MutationType     *Named `json:"mutationType"`
+	SubscriptionType *Named `json:"subscriptionType"`
+	Types            []Type `json:"types"`
+}
+
+type Named struct {
+	Name string `json:"name"`
+}
+
+type Type struct {
+	Kind          string       `json:"kind"`
+	Name          string       `json:"name"`
+	Description   string       `json:"description,omitempty"`
+	Fields        []Field      `json:"fields,omitempty"`
+	InputFields   []InputValue `json:"inputFields,omitempty"`
+	Interfaces    []TypeRef    `json:"interfaces,omitempty"`
+	EnumValues    []EnumValue  `json:"enumValues,omitempty"`
+	PossibleTypes []TypeRef    `json:"possibleTypes,omitempty"`
+}
+
+type Field struct {
+	Name              string       `json:"name"`
+	Description       string       `json:"description,omitempty"`
+	Args              []InputValue `json:"args"`
+	Type              TypeRef      `json:"type"`
+	IsDeprecated      bool         `json:"isDeprecated"`
+	DeprecationReason string       `json:"deprecationReason,omitempty"`
+}
+
+type InputValue struct {
+	Name         string  `json:"name"`
+	Description  string  `json:"description,omitempty"`
+	Type         TypeRef `json:"type"`
+	DefaultValue *string `json:"defaultValue,omitempty"`
+}
+
+type EnumValue struct {
+	Name              string `json:"name"`
+	Description       string `json:"description,omitempty"`
+	IsDeprecated      bool   `json:"isDeprecated"`
+	DeprecationReason string `json:"deprecationReason,omitempty"`
+}
+
+type TypeRef struct {
+	Kind   string   `json:"kind"`
+	Name   string   `json:"name,omitempty"`
+	OfType *TypeRef `json:"ofType,omitempty"`
+}
+
+// String renders the reference in SDL notation, e.g. [User!]!.
+func (t TypeRef) String() string {
+	switch t.Kind {
+	case "NON_NULL":
+		if t.OfType != nil {
+			return t.OfType.String() + "!"
+		}
+	case "LIST":
+		if t.OfType != nil {
+			return "[" + t.OfType.String() + "]"
+		}
+	}
+	return t.Name
+}
+
+// Named unwraps lists and non-null wrappers down to the named type.
+func (t TypeRef) Named() string {
+	for r := &t; r != nil; r = r.OfType {
+		if r.Name != "" {
+			return r.Name
+		}
+	}
+	return ""
+}
+
+func (t TypeRef) NonNull() bool { return t.Kind == "NON_NULL" }
+
+// Parse extracts the schema from an introspection response body.
+func Parse(body []byte) (*Schema, error) {
+	var env struct {
+		Data struct {
+			Schema *Schema `json:"__schema"`
+		} `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(body, &env); err != nil {
+		return nil, err
+	}
+	if env.Data.Schema == nil {
+		if len(env.Errors) > 0 {
+			return nil, errors.New("graphql: " + env.Errors[0].Message)
+		}
+		return nil, errors.New("graphql: response has no __schema")
+	}
+	return env.Data.Schema, nil
+}
+
+// Load reads a snapshot written by Save (or a raw introspection response).
+func Load(path string) (*Schema, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if s, err := Parse(b); err == nil {
+		return s, nil
+	}
+	var s Schema
+	if err := json.Unmarshal(b, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (s *Schema) Save(path string) error {
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, b, 0644)
+}
+
+// Type returns the named type, or nil.
+func (s *Schema) Type(name string) *Type {
+	for i := range s.Types {
+		if s.Types[i].Name == name {
+			return &s.Types[i]
+		}
+	}
+	return nil
+}
+
+// UserTypes are the types declared by the API, without __ built-ins.
+func (s *Schema) UserTypes() []Type {
+	var out []Type
+	for _, t := range s.Types {
+		if !strings.HasPrefix(t.Name, "__") {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// Operation is one root field of the query, mutation or subscription type.
+type Operation struct {
+	Kind       string // query, mutation, subscription
+	Name       string
+	Args       []InputValue
+	Returns    TypeRef
+	Deprecated bool
+	Reason     string
+}
+
+// Signature renders name(arg: Type, ...): Return.
+func (o Operation) Signature() string {
+	var b strings.Builder
+	b.WriteString(o.Name)
+	if len(o.Args) > 0 {
+		b.WriteString("(")
+		for i, a := range o.Args {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			b.WriteString(a.Name + ": " + a.Type.String())
+		}
+		b.WriteString(")")
+	}
+	b.WriteString(": " + o.Returns.String())
+	return b.String()
+}
+
+// Operations lists every root field, queries first, each kind sorted by name.
+func (s *Schema) Operations() []Operation {
+	var out []Operation
+	roots := []struct {
+		kind string
+		ref  *Named
+	}{
+		{"query", s.QueryType},
+		{"mutation", s.MutationType},
+		{"subscription", s.SubscriptionType},
+	}
+	for _, r := range roots {
+		if r.ref == nil {
+			continue
+		}
+		t := s.Type(r.ref.Name)
+		if t == nil {
+			continue
+		}
+		fields := append([]Field(nil), t.Fields...)
+		sort.Slice(fields, func(i, j int) bool { return fields[i].Name < fields[j].Name })
+		for _, f := range fields {
+			out = append(out, Operation{
+				Kind:       r.kind,
+				Name:       f.Name,
+				Args:       f.Args,
+				Returns:    f.Type,
+				Deprecated: f.IsDeprecated,
+				Reason:     f.DeprecationReason,
+			})
+		}
+	}
+	return out
+}
+
+// Operation finds a root field by name, optionally qualified as kind.name.
+func (s *Schema) Operation(name string) (Operation, bool) {
+	kind := ""
+	if k, n, ok := strings.Cut(name, "."); ok {
+		kind, name = k, n
+	}
+	for _, op := range s.Operations() {
+		if op.Name == name && (kind == "" || op.Kind == kind) {
+			return op, true
+		}
+	}
+	return Operation{}, false
+}
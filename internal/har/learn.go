@@ -0,0 +1,173 @@
+package har
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/bspippi1337/restless/internal/auth"
+	"github.com/bspippi1337/restless/internal/modules/session"
+	"github.com/bspippi1337/restless/internal/redact"
+	"github.com/bspippi1337/restless/internal/store"
+	"github.com/bspippi1337/restless/internal/traffic"
+)
+
+type Options struct {
+	// Host restricts learning to one host. Empty picks the host with
+	// the most API calls in the archive.
+	Host string
+}
+
+type Result struct {
+	API        *store.API
+	Collection []session.FlowStep
+	Calls      int
+	Skipped    int
+}
+
+var staticExt = map[string]bool{
+	".js": true, ".mjs": true, ".css": true, ".map": true,
+	".png": true, ".jpg": true, ".jpeg": true, ".gif": true, ".svg": true, ".ico": true, ".webp": true,
+	".woff": true, ".woff2": true, ".ttf": true, ".eot": true,
+	".html": true, ".htm": true,
+}
+
+// Learn collapses same-host API calls into templated endpoints and
+// builds a replayable request collection from them.
+func Learn(h *HAR, opt Options) (*Result, error) {
+	if h == nil {
+		return nil, errors.New("har: nil archive")
+	}
+
+	host := opt.Host
+	if host == "" {
+		host = busiestHost(h)
+	}
+	if host == "" {
+		return nil, errors.New("har: no API calls found")
+	}
+	host = hostOf(host)
+
+	res := &Result{}
+	l := traffic.NewLearner("har")
+
+	for _, e := range h.Log.Entries {
+		u, err := url.Parse(e.Request.URL)
+		if err != nil || u.Host != host || !isAPICall(e, u) {
+			res.Skipped++
+			continue
+		}
+		res.Calls++
+		l.Add(exchange(e, u))
+		res.Collection = append(res.Collection, flowStep(e))
+	}
+
+	if res.Calls == 0 {
+		return nil, errors.New("har: no API calls for host " + host)
+	}
+	res.API = l.API()
+	auth.Classify(res.API, auth.Evidence{Challenges: l.Challenges(), Sent: l.SentSchemes()})
+
+	return res, nil
+}
+
+// exchange converts a HAR entry into the shape traffic.Learner expects.
+// HAR keeps mime types beside the headers, so they are folded back in.
+func exchange(e Entry, u *url.URL) traffic.Exchange {
+	x := traffic.Exchange{
+		Time:           e.StartedDateTime,
+		Method:         e.Request.Method,
+		URL:            u,
+		RequestHeader:  http.Header{},
+		Status:         e.Response.Status,
+		ResponseHeader: http.Header{},
+		ResponseBody:   e.Response.Content.Body(),
+	}
+	for _, hv := range e.Request.Headers {
+		x.RequestHeader.Add(hv.Name, hv.Value)
+	}
+	if pd := e.Request.PostData; pd != nil {
+		x.RequestHeader.Set("Content-Type", pd.MimeType)
+		x.RequestBody = []byte(pd.Text)
+	}
+	for _, hv := range e.Response.Headers {
+		x.ResponseHeader.Add(hv.Name, hv.Value)
+	}
+	if mt := e.Response.Content.MimeType; mt != "" {
+		x.ResponseHeader.Set("Content-Type", mt)
+	}
+	return x
+}
+
+// flowStep turns an entry into a replayable step. Secret headers are
+// replaced by {{header-name}} so they can be supplied as session vars.
+func flowStep(e Entry) session.FlowStep {
+	step := session.FlowStep{
+		Method:  strings.ToUpper(e.Request.Method),
+		URL:     e.Request.URL,
+		Headers: map[string]string{},
+	}
+	for _, hv := range e.Request.Headers {
+		n := strings.ToLower(hv.Name)
+		if traffic.IsNoise(n) || n == "cookie" {
+			continue
+		}
+		if redact.SecretHeader(n) {
+			step.Headers[hv.Name] = "{{" + n + "}}"
+			continue
+		}
+		step.Headers[hv.Name] = hv.Value
+	}
+	if e.Request.PostData != nil {
+		step.Body = e.Request.PostData.Text
+	}
+	return step
+}
+
+func busiestHost(h *HAR) string {
+	counts := map[string]int{}
+	best := ""
+	for _, e := range h.Log.Entries {
+		u, err := url.Parse(e.Request.URL)
+		if err != nil || u.Host == "" || !isAPICall(e, u) {
+			continue
+		}
+		counts[u.Host]++
+		if counts[u.Host] > counts[best] || (counts[u.Host] == counts[best] && u.Host < best) {
+			best = u.Host
+		}
+	}
+	return best
+}
+
+func isAPICall(e Entry, u *url.URL) bool {
+	switch strings.ToLower(e.ResourceType) {
+	case "xhr", "fetch":
+		return true
+	case "", "other":
+	default:
+		return false
+	}
+	if staticExt[strings.ToLower(path.Ext(u.Path))] {
+		return false
+	}
+	if traffic.IsJSON(e.Response.Content.MimeType) {
+		return true
+	}
+	if e.Request.PostData != nil && traffic.IsJSON(e.Request.PostData.MimeType) {
+		return true
+	}
+	m := strings.ToUpper(e.Request.Method)
+	return m != "GET" && m != "HEAD" && m != "OPTIONS"
+}
+
+func hostOf(s string) string {
+	if strings.Contains(s, "://") {
+		if u, err := url.Parse(s); err == nil {
+			return u.Host
+		}
+	}
+	return strings.TrimRight(s, "/")
+}
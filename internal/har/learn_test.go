@@ -0,0 +1,73 @@
+package har
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+const sample = `{"log":{"version":"1.2","entries":[
+{"startedDateTime":"2026-01-02T10:00:00Z","_resourceType":"fetch",
+ "request":{"method":"GET","url":"https://api.example.com/users/1?expand=org","headers":[{"name":"Authorization","value":"Bearer s3cret"},{"name":"User-Agent","value":"x"}],"queryString":[{"name":"expand","value":"org"}]},
+ "response":{"status":200,"headers":[],"content":{"mimeType":"application/json","text":"{\"id\":1,\"name\":\"a\"}"}}},
+{"startedDateTime":"2026-01-02T09:00:00Z","_resourceType":"xhr",
+ "request":{"method":"GET","url":"https://api.example.com/users/2","headers":[]},
+ "response":{"status":200,"headers":[],"content":{"mimeType":"application/json","text":"{\"id\":2}"}}},
+{"startedDateTime":"2026-01-02T11:00:00Z","_resourceType":"fetch",
+ "request":{"method":"POST","url":"https://api.example.com/users","headers":[],"postData":{"mimeType":"application/json","text":"{\"name\":\"c\"}"}},
+ "response":{"status":201,"headers":[],"content":{"mimeType":"application/json","text":"{\"id\":3}"}}},
+{"startedDateTime":"2026-01-02T11:00:00Z","_resourceType":"script",
+ "request":{"method":"GET","url":"https://api.example.com/app.js","headers":[]},
+ "response":{"status":200,"headers":[],"content":{"mimeType":"application/javascript","text":""}}},
+{"startedDateTime":"2026-01-02T11:00:00Z","_resourceType":"fetch",
+ "request":{"method":"GET","url":"https://cdn.example.net/x.json","headers":[]},
+ "response":{"status":200,"headers":[],"content":{"mimeType":"application/json","text":"{}"}}}
+]}}`
+
+func TestLearnCollapsesSameHostCalls(t *testing.T) {
+	var h HAR
+	if err := json.Unmarshal([]byte(sample), &h); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+
+	res, err := Learn(&h, Options{})
+	if err != nil {
+		t.Fatalf("learn: %v", err)
+	}
+
+	if res.API.BaseURL != "https://api.example.com" {
+		t.Fatalf("unexpected base: %s", res.API.BaseURL)
+	}
+	if res.Calls != 3 || res.Skipped != 2 {
+		t.Fatalf("calls=%d skipped=%d", res.Calls, res.Skipped)
+	}
+	if len(res.API.Endpoints) != 2 {
+		t.Fatalf("expected 2 endpoints, got %+v", res.API.Endpoints)
+	}
+
+	users := res.API.Endpoints[0]
+	item := res.API.Endpoints[1]
+	if users.Path != "/users" || item.Path != "/users/{userId}" {
+		t.Fatalf("unexpected paths: %s %s", users.Path, item.Path)
+	}
+	if users.RequestSchema == nil || !users.RequestSchema.Is("object") {
+		t.Fatalf("expected request schema on POST /users")
+	}
+	if item.Schema == nil || item.Schema.Samples != 2 {
+		t.Fatalf("expected merged response schema, got %#v", item.Schema)
+	}
+	if len(item.Query) != 1 || item.Query[0] != "expand" {
+		t.Fatalf("unexpected query: %v", item.Query)
+	}
+	if item.FirstSeen.Hour() != 9 {
+		t.Fatalf("expected earliest first-seen, got %s", item.FirstSeen)
+	}
+
+	if got := res.Collection[0].Headers["Authorization"]; got != "{{authorization}}" {
+		t.Fatalf("secret header not templated: %q", got)
+	}
+
+	s, ok := res.API.Auth.Scheme("bearer")
+	if !ok || s.Source != "request-header" {
+		t.Fatalf("expected bearer scheme from the request header, got %+v", res.API.Auth)
+	}
+}
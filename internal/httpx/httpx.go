@@ -11,6 +11,8 @@ type Client struct {
 	HTTP *http.Client
 }
 
+// New returns a client on http.DefaultTransport, which the CLI replaces
+// for --record and --replay.
 func New() *Client {
 	return &Client{
 		HTTP: &http.Client{
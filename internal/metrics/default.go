@@ -0,0 +1,57 @@
+package metrics
+
+import (
+	"github.com/bspippi1337/restless/internal/status"
+	"github.com/bspippi1337/restless/internal/telemetry"
+)
+
+// Default is the registry the CLI serves with --metrics-addr.
+var Default = NewRegistry()
+
+var (
+	// HTTPDuration is the time to response headers, by side (client
+	// for requests restless sends, server for requests it answers).
+	HTTPDuration = Default.NewHistogram("restless_http_request_duration_seconds", "seconds",
+		"Time from sending an HTTP request to its response headers.", DefBuckets, "side", "method")
+	HTTPResponses = Default.NewCounter("restless_http_responses",
+		"HTTP responses by status code.", "side", "code")
+	HTTPErrors = Default.NewCounter("restless_http_request_errors",
+		"HTTP requests that got no response.", "side")
+
+	WatchRuns = Default.NewCounter("restless_watch_runs",
+		"Commands run by watch, by exit code.", "exit_code")
+	WatchDuration = Default.NewHistogram("restless_watch_run_duration_seconds", "seconds",
+		"How long commands run by watch took.", DefBuckets)
+)
+
+func init() {
+	Default.Collect(writeTrackers)
+}
+
+// writeTrackers exports the telemetry and status counters, labelled by
+// which of the two kept them.
+func writeTrackers(e *Encoder) {
+	t, s := telemetry.Snapshot(), status.Snapshot()
+	counters := []struct {
+		name, help string
+		t, s       int
+	}{
+		{"restless_requests", "Requests counted by the discovery engines.", t.Requests, s.Requests},
+		{"restless_endpoints", "Endpoints found by the discovery engines.", t.Endpoints, s.Endpoints},
+		{"restless_probes", "Probes sent by the discovery engines.", t.Probes, s.Probes},
+		{"restless_consensus", "Endpoints more than one engine agreed on.", t.Consensus, s.Consensus},
+		{"restless_errors", "Errors counted by the discovery engines.", t.Errors, s.Errors},
+	}
+	for _, c := range counters {
+		e.Family(c.name, "counter", "", c.help)
+		e.Sample(c.name+"_total", float64(c.t), "tracker", "telemetry")
+		e.Sample(c.name+"_total", float64(c.s), "tracker", "status")
+	}
+
+	e.Family("restless_queue_depth", "gauge", "", "Paths waiting in the crawl queue.")
+	e.Sample("restless_queue_depth", float64(t.Queue))
+	e.Family("restless_workers", "gauge", "", "Crawl workers running.")
+	e.Sample("restless_workers", float64(t.Workers))
+	e.Family("restless_uptime_seconds", "gauge", "seconds", "Seconds since the process started.")
+	e.Sample("restless_uptime_seconds", t.Uptime.Seconds())
+}
@@ -0,0 +1,105 @@
+package metrics
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	Client = "client"
+	Server = "server"
+)
+
+// ContentType is the OpenMetrics text format.
+const ContentType = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+
+// Transport records latency and status codes of outgoing requests on
+// the Default registry. Latency is measured to the response headers.
+type Transport struct {
+	Base http.RoundTripper // required; http.DefaultTransport may point here
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.Base.RoundTrip(req)
+	if err != nil {
+		HTTPErrors.Inc(Client)
+		return resp, err
+	}
+	HTTPDuration.Observe(time.Since(start).Seconds(), Client, method(req.Method))
+	HTTPResponses.Inc(Client, strconv.Itoa(resp.StatusCode))
+	return resp, nil
+}
+
+// Handler records latency and status codes of the requests next answers.
+func Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		start := time.Now()
+		sw := &statusWriter{ResponseWriter: w}
+		next.ServeHTTP(sw, req)
+		if sw.code == 0 {
+			sw.code = http.StatusOK
+		}
+		HTTPDuration.Observe(time.Since(start).Seconds(), Server, method(req.Method))
+		HTTPResponses.Inc(Server, strconv.Itoa(sw.code))
+	})
+}
+
+type statusWriter struct {
+	http.ResponseWriter
+	code int
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	if w.code == 0 {
+		w.code = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	if w.code == 0 {
+		w.code = http.StatusOK
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *statusWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// method keeps the label set small: anything unusual is OTHER.
+func method(m string) string {
+	switch m = strings.ToUpper(m); m {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut,
+		http.MethodPatch, http.MethodDelete, http.MethodOptions:
+		return m
+	}
+	return "OTHER"
+}
+
+// Serve exposes reg on http://addr/metrics in the background and
+// returns the server with the address it listens on.
+func Serve(addr string, reg *Registry) (*http.Server, net.Addr, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, nil, err
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", ContentType)
+		_, _ = reg.WriteTo(w)
+	})
+	srv := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go srv.Serve(ln)
+	return srv, ln.Addr(), nil
+}
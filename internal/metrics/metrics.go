@@ -0,0 +1,242 @@
+// Package metrics keeps counters and latency histograms for long-running
+// commands and serves them, together with the telemetry and status
+// counters, in the OpenMetrics text format.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefBuckets are latency bucket bounds in seconds.
+var DefBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30}
+
+// Registry holds metric families in the order they were declared.
+type Registry struct {
+	mu       sync.Mutex
+	families []func(e *Encoder)
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Collect adds a family written fresh on every scrape, for values kept
+// elsewhere (telemetry, status).
+func (r *Registry) Collect(f func(e *Encoder)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.families = append(r.families, f)
+}
+
+// WriteTo writes every family followed by the terminating # EOF.
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	families := append([]func(e *Encoder){}, r.families...)
+	r.mu.Unlock()
+
+	cw := &countWriter{w: w}
+	e := &Encoder{w: bufio.NewWriter(cw)}
+	for _, f := range families {
+		f(e)
+	}
+	e.w.WriteString("# EOF\n")
+	err := e.w.Flush()
+	return cw.n, err
+}
+
+type countWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// Encoder writes OpenMetrics text: a family header, then its samples.
+type Encoder struct {
+	w *bufio.Writer
+}
+
+// Family starts a metric family of type counter, gauge or histogram.
+// unit may be empty; when set, name must end in _<unit>.
+func (e *Encoder) Family(name, typ, unit, help string) {
+	fmt.Fprintf(e.w, "# TYPE %s %s\n", name, typ)
+	if unit != "" {
+		fmt.Fprintf(e.w, "# UNIT %s %s\n", name, unit)
+	}
+	fmt.Fprintf(e.w, "# HELP %s %s\n", name, escapeHelp(help))
+}
+
+// Sample writes one sample; labels alternate name and value.
+func (e *Encoder) Sample(name string, v float64, labels ...string) {
+	e.w.WriteString(name)
+	if len(labels) > 0 {
+		e.w.WriteByte('{')
+		for i := 0; i+1 < len(labels); i += 2 {
+			if i > 0 {
+				e.w.WriteByte(',')
+			}
+			fmt.Fprintf(e.w, "%s=\"%s\"", labels[i], escapeLabel(labels[i+1]))
+		}
+		e.w.WriteByte('}')
+	}
+	e.w.WriteByte(' ')
+	e.w.WriteString(formatFloat(v))
+	e.w.WriteByte('\n')
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	s := strconv.FormatFloat(v, 'g', -1, 64)
+	if !strings.ContainsAny(s, ".eE") {
+		s += ".0"
+	}
+	return s
+}
+
+func escapeHelp(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(s)
+}
+
+func escapeLabel(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
+
+// key identifies a series by its label values.
+func key(values []string) string {
+	return strings.Join(values, "\x00")
+}
+
+func pairs(names []string, k string) []string {
+	if len(names) == 0 {
+		return nil
+	}
+	values := strings.Split(k, "\x00")
+	out := make([]string, 0, 2*len(names))
+	for i, n := range names {
+		out = append(out, n, values[i])
+	}
+	return out
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Counter is a family of monotonically increasing values.
+type Counter struct {
+	name, help string
+	labels     []string
+
+	mu     sync.Mutex
+	series map[string]float64
+}
+
+// NewCounter declares a counter; name is without the _total suffix.
+func (r *Registry) NewCounter(name, help string, labels ...string) *Counter {
+	c := &Counter{name: name, help: help, labels: labels, series: map[string]float64{}}
+	r.Collect(c.write)
+	return c
+}
+
+// Inc adds one to the series with the given label values.
+func (c *Counter) Inc(values ...string) {
+	c.Add(1, values...)
+}
+
+func (c *Counter) Add(v float64, values ...string) {
+	if len(values) != len(c.labels) {
+		panic(fmt.Sprintf("metrics: %s wants %d label values, got %d", c.name, len(c.labels), len(values)))
+	}
+	c.mu.Lock()
+	c.series[key(values)] += v
+	c.mu.Unlock()
+}
+
+func (c *Counter) write(e *Encoder) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e.Family(c.name, "counter", "", c.help)
+	for _, k := range sortedKeys(c.series) {
+		e.Sample(c.name+"_total", c.series[k], pairs(c.labels, k)...)
+	}
+}
+
+// Histogram counts observations into cumulative buckets.
+type Histogram struct {
+	name, unit, help string
+	bounds           []float64
+	labels           []string
+
+	mu     sync.Mutex
+	series map[string]*histSeries
+}
+
+type histSeries struct {
+	counts []uint64 // per bound, not cumulative; the last is +Inf
+	sum    float64
+}
+
+// NewHistogram declares a histogram. With a unit, name must end in
+// _<unit> (restless_http_request_duration_seconds).
+func (r *Registry) NewHistogram(name, unit, help string, bounds []float64, labels ...string) *Histogram {
+	h := &Histogram{name: name, unit: unit, help: help, bounds: bounds, labels: labels, series: map[string]*histSeries{}}
+	r.Collect(h.write)
+	return h
+}
+
+func (h *Histogram) Observe(v float64, values ...string) {
+	if len(values) != len(h.labels) {
+		panic(fmt.Sprintf("metrics: %s wants %d label values, got %d", h.name, len(h.labels), len(values)))
+	}
+	i := sort.SearchFloat64s(h.bounds, v)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s, ok := h.series[key(values)]
+	if !ok {
+		s = &histSeries{counts: make([]uint64, len(h.bounds)+1)}
+		h.series[key(values)] = s
+	}
+	s.counts[i]++
+	s.sum += v
+}
+
+func (h *Histogram) write(e *Encoder) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	e.Family(h.name, "histogram", h.unit, h.help)
+	for _, k := range sortedKeys(h.series) {
+		s := h.series[k]
+		labels := pairs(h.labels, k)
+		var cum uint64
+		for i, b := range append(append([]float64(nil), h.bounds...), math.Inf(1)) {
+			cum += s.counts[i]
+			e.Sample(h.name+"_bucket", float64(cum), append(labels[:len(labels):len(labels)], "le", formatFloat(b))...)
+		}
+		e.Sample(h.name+"_count", float64(cum), labels...)
+		e.Sample(h.name+"_sum", s.sum, labels...)
+	}
+}
@@ -0,0 +1,61 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWriteTo(t *testing.T) {
+	reg := NewRegistry()
+	c := reg.NewCounter("x_responses", "Responses.", "code")
+	h := reg.NewHistogram("x_latency_seconds", "seconds", "Latency.", []float64{0.1, 1})
+	c.Inc("200")
+	c.Inc("200")
+	c.Inc("500")
+	h.Observe(0.05)
+	h.Observe(0.5)
+	h.Observe(3)
+
+	var b strings.Builder
+	if _, err := reg.WriteTo(&b); err != nil {
+		t.Fatal(err)
+	}
+	want := `# TYPE x_responses counter
+# HELP x_responses Responses.
+x_responses_total{code="200"} 2.0
+x_responses_total{code="500"} 1.0
+# TYPE x_latency_seconds histogram
+# UNIT x_latency_seconds seconds
+# HELP x_latency_seconds Latency.
+x_latency_seconds_bucket{le="0.1"} 1.0
+x_latency_seconds_bucket{le="1.0"} 2.0
+x_latency_seconds_bucket{le="+Inf"} 3.0
+x_latency_seconds_count 3.0
+x_latency_seconds_sum 3.55
+# EOF
+`
+	if b.String() != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", b.String(), want)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	h := Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("BREW", "/", nil))
+
+	var b strings.Builder
+	Default.WriteTo(&b)
+	for _, want := range []string{
+		`restless_http_responses_total{side="server",code="418"} 1.0`,
+		`restless_http_request_duration_seconds_count{side="server",method="OTHER"} 1.0`,
+		`restless_requests_total{tracker="telemetry"}`,
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Fatalf("missing %q in:\n%s", want, b.String())
+		}
+	}
+}
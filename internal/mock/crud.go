@@ -0,0 +1,296 @@
+package mock
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// collection is the in-memory state behind a /things + /things/{id} pair,
+// seeded from the list operation's example.
+type collection struct {
+	list, item     string // path templates
+	listPI, itemPI *openapi3.PathItem
+	param          string // the {id} of the item template
+	idField        string
+	listKey        string // set when the list is wrapped, e.g. {"items": [...]}
+	numericID      bool
+
+	mu     sync.Mutex
+	seeded bool
+	items  map[string]map[string]any
+	order  []string
+	next   int
+}
+
+// collections pairs every template with a child template that adds one
+// path parameter, e.g. /pets and /pets/{petId}.
+func collections(doc *openapi3.T) map[string]*collection {
+	out := map[string]*collection{}
+	if doc == nil || doc.Paths == nil {
+		return out
+	}
+	paths := doc.Paths.Map()
+	var pairs []*collection
+	for tpl := range paths {
+		parent, last := splitLast(tpl)
+		if !isParam(last) || parent == "" {
+			continue
+		}
+		if _, ok := paths[parent]; !ok {
+			continue
+		}
+		c := &collection{
+			list: parent, item: tpl,
+			listPI: paths[parent], itemPI: paths[tpl],
+			param: strings.Trim(last, "{}"),
+			items: map[string]map[string]any{},
+		}
+		c.inspect(paths[parent], paths[tpl])
+		pairs = append(pairs, c)
+	}
+	// A template that is both an item and a list (/a/{id} under /a and
+	// over /a/{id}/{sub}) stays an item.
+	for _, c := range pairs {
+		out[c.item] = c
+	}
+	for _, c := range pairs {
+		if _, taken := out[c.list]; !taken {
+			out[c.list] = c
+		}
+	}
+	return out
+}
+
+func (c *collection) inspect(list, item *openapi3.PathItem) {
+	var itemSchema *openapi3.Schema
+	if op := item.Get; op != nil {
+		itemSchema = successSchema(op)
+	}
+	if itemSchema == nil && list.Post != nil && list.Post.RequestBody != nil && list.Post.RequestBody.Value != nil {
+		if _, mt := pickContent(list.Post.RequestBody.Value.Content); mt != nil && mt.Schema != nil {
+			itemSchema = mt.Schema.Value
+		}
+	}
+
+	c.idField = "id"
+	if itemSchema != nil {
+		props := properties(itemSchema)
+		for _, name := range []string{c.param, "id"} {
+			if p, ok := props[name]; ok {
+				c.idField = name
+				c.numericID = p.Value != nil && p.Value.Type != nil && (p.Value.Type.Is("integer") || p.Value.Type.Is("number"))
+				break
+			}
+		}
+	}
+
+	if list.Get != nil {
+		if s := successSchema(list.Get); s != nil && typeOf(s) != "array" {
+			for name, p := range properties(s) {
+				if p.Value != nil && typeOf(p.Value) == "array" {
+					c.listKey = name
+					break
+				}
+			}
+		}
+	}
+}
+
+// seed loads the list example once, keeping objects that carry an id.
+func (c *collection) seed(list *openapi3.Operation) {
+	if c.seeded {
+		return
+	}
+	c.seeded = true
+	if list == nil {
+		return
+	}
+	_, body, ok := response(list, 0)
+	if !ok {
+		return
+	}
+	arr, _ := body.([]any)
+	if m, isObj := body.(map[string]any); isObj && c.listKey != "" {
+		arr, _ = m[c.listKey].([]any)
+	}
+	for _, v := range arr {
+		obj, ok := v.(map[string]any)
+		if !ok || obj[c.idField] == nil {
+			continue
+		}
+		c.put(fmt.Sprint(obj[c.idField]), clone(obj))
+	}
+}
+
+func (c *collection) put(id string, obj map[string]any) {
+	if _, ok := c.items[id]; !ok {
+		c.order = append(c.order, id)
+	}
+	c.items[id] = obj
+	if n, err := strconv.Atoi(id); err == nil && n >= c.next {
+		c.next = n + 1
+	}
+}
+
+func (c *collection) newID() any {
+	if c.next == 0 {
+		c.next = 1
+	}
+	id := c.next
+	c.next++
+	if c.numericID {
+		return id
+	}
+	return strconv.Itoa(id)
+}
+
+// serve handles a request against the collection. ok is false when the
+// method is not one the collection models, so the caller falls back to
+// example responses.
+func (c *collection) serve(tpl, method, path string, body any) (status int, out any, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.seed(c.listPI.Get)
+
+	if tpl == c.list {
+		op := c.listPI.GetOperation(method)
+		switch method {
+		case http.MethodGet:
+			list := make([]any, 0, len(c.order))
+			for _, id := range c.order {
+				list = append(list, c.items[id])
+			}
+			if c.listKey != "" {
+				return successStatus(op, http.StatusOK), map[string]any{c.listKey: list}, true
+			}
+			return successStatus(op, http.StatusOK), list, true
+		case http.MethodPost:
+			obj, isObj := body.(map[string]any)
+			if !isObj {
+				return 0, nil, false
+			}
+			obj = clone(obj)
+			if obj[c.idField] == nil {
+				obj[c.idField] = c.newID()
+			}
+			c.put(fmt.Sprint(obj[c.idField]), obj)
+			return successStatus(op, http.StatusCreated), obj, true
+		}
+		return 0, nil, false
+	}
+
+	op := c.itemPI.GetOperation(method)
+	_, id := splitLast(path)
+	cur, exists := c.items[id]
+	switch method {
+	case http.MethodGet:
+		if !exists {
+			return http.StatusNotFound, notFound(), true
+		}
+		return successStatus(op, http.StatusOK), cur, true
+	case http.MethodPut, http.MethodPatch:
+		obj, isObj := body.(map[string]any)
+		if !isObj {
+			return 0, nil, false
+		}
+		if method == http.MethodPatch {
+			if !exists {
+				return http.StatusNotFound, notFound(), true
+			}
+			merged := clone(cur)
+			for k, v := range obj {
+				merged[k] = v
+			}
+			obj = merged
+		} else {
+			obj = clone(obj)
+		}
+		obj[c.idField] = typedID(id, c.numericID)
+		c.put(id, obj)
+		return successStatus(op, http.StatusOK), obj, true
+	case http.MethodDelete:
+		if !exists {
+			return http.StatusNotFound, notFound(), true
+		}
+		delete(c.items, id)
+		for i, x := range c.order {
+			if x == id {
+				c.order = append(c.order[:i], c.order[i+1:]...)
+				break
+			}
+		}
+		return successStatus(op, http.StatusNoContent), nil, true
+	}
+	return 0, nil, false
+}
+
+// successStatus is the lowest 2xx the operation declares, or def.
+func successStatus(op *openapi3.Operation, def int) int {
+	if op == nil || op.Responses == nil {
+		return def
+	}
+	var codes []int
+	for k := range op.Responses.Map() {
+		if n, err := strconv.Atoi(k); err == nil && n >= 200 && n < 300 {
+			codes = append(codes, n)
+		}
+	}
+	if len(codes) == 0 {
+		return def
+	}
+	sort.Ints(codes)
+	return codes[0]
+}
+
+func successSchema(op *openapi3.Operation) *openapi3.Schema {
+	if op.Responses == nil {
+		return nil
+	}
+	rr := op.Responses.Status(successStatus(op, http.StatusOK))
+	if rr == nil || rr.Value == nil {
+		return nil
+	}
+	if _, mt := pickContent(rr.Value.Content); mt != nil && mt.Schema != nil {
+		return mt.Schema.Value
+	}
+	return nil
+}
+
+func typedID(id string, numeric bool) any {
+	if numeric {
+		if n, err := strconv.Atoi(id); err == nil {
+			return n
+		}
+	}
+	return id
+}
+
+func notFound() map[string]any {
+	return map[string]any{"error": "not found"}
+}
+
+func clone(m map[string]any) map[string]any {
+	out := make(map[string]any, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
+
+func splitLast(p string) (string, string) {
+	i := strings.LastIndex(p, "/")
+	if i < 0 {
+		return "", p
+	}
+	return p[:i], p[i+1:]
+}
+
+func isParam(seg string) bool {
+	return strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}")
+}
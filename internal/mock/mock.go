@@ -0,0 +1,333 @@
+package mock
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+
+	gruntime "github.com/bspippi1337/restless/internal/modules/openapi/guard/runtime"
+)
+
+type Options struct {
+	// Latency is added to every response, plus up to Jitter at random.
+	Latency time.Duration
+	Jitter  time.Duration
+
+	// ErrorRate is the fraction (0..1) of requests answered with
+	// ErrorStatus (default 500) instead of a real response.
+	ErrorRate   float64
+	ErrorStatus int
+
+	// Seed makes jitter and error injection reproducible. Zero seeds from
+	// the clock.
+	Seed int64
+
+	// NoValidate serves requests that do not match the spec.
+	NoValidate bool
+
+	// Stateless disables in-memory CRUD; every call gets the example.
+	Stateless bool
+
+	// Log receives one line per request when set.
+	Log io.Writer
+}
+
+// Server serves every operation of an OpenAPI document.
+type Server struct {
+	doc   *openapi3.T
+	opt   Options
+	colls map[string]*collection
+
+	mu  sync.Mutex
+	rnd *rand.Rand
+}
+
+// New builds the server for doc. It fails on an error rate outside 0..1
+// or an error status that is not an HTTP status code.
+func New(doc *openapi3.T, opt Options) (*Server, error) {
+	if opt.ErrorStatus == 0 {
+		opt.ErrorStatus = http.StatusInternalServerError
+	}
+	if opt.ErrorRate < 0 || opt.ErrorRate > 1 {
+		return nil, fmt.Errorf("error rate %v is not between 0 and 1", opt.ErrorRate)
+	}
+	if opt.ErrorStatus < 100 || opt.ErrorStatus > 599 {
+		return nil, fmt.Errorf("error status %d is not between 100 and 599", opt.ErrorStatus)
+	}
+	seed := opt.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	s := &Server{doc: doc, opt: opt, rnd: rand.New(rand.NewSource(seed))}
+	if !opt.Stateless {
+		s.colls = collections(doc)
+	}
+	return s, nil
+}
+
+// Operations lists "METHOD /template" for every operation served.
+func (s *Server) Operations() []string {
+	var out []string
+	if s.doc == nil || s.doc.Paths == nil {
+		return out
+	}
+	for tpl, item := range s.doc.Paths.Map() {
+		for m := range item.Operations() {
+			out = append(out, strings.ToUpper(m)+" "+tpl)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		pi, pj := out[i][strings.Index(out[i], " ")+1:], out[j][strings.Index(out[j], " ")+1:]
+		if pi != pj {
+			return pi < pj
+		}
+		return out[i] < out[j]
+	})
+	return out
+}
+
+// Collections lists the list templates backed by in-memory state.
+func (s *Server) Collections() []string {
+	var out []string
+	for tpl, c := range s.colls {
+		if c.list == tpl {
+			out = append(out, tpl)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	status, tpl := s.serve(w, r)
+	if s.opt.Log != nil {
+		if tpl == "" {
+			tpl = "-"
+		}
+		fmt.Fprintf(s.opt.Log, "%s %s -> %d  %s (%dms)\n", r.Method, r.URL.RequestURI(), status, tpl, time.Since(start).Milliseconds())
+	}
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) (int, string) {
+	// Frontends call mocks straight from the browser.
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	s.delay()
+
+	tpl, ok := gruntime.MatchPathTemplate(s.doc, r.URL.Path)
+	if !ok {
+		return writeJSON(w, http.StatusNotFound, map[string]any{"error": "no such path in spec", "path": r.URL.Path}), ""
+	}
+	item := s.doc.Paths.Value(tpl)
+
+	if r.Method == http.MethodOptions && item.Options == nil {
+		w.Header().Set("Access-Control-Allow-Methods", strings.Join(allowed(item), ", "))
+		w.Header().Set("Access-Control-Allow-Headers", "*")
+		w.WriteHeader(http.StatusNoContent)
+		return http.StatusNoContent, tpl
+	}
+
+	op := item.GetOperation(r.Method)
+	if op == nil {
+		w.Header().Set("Allow", strings.Join(allowed(item), ", "))
+		return writeJSON(w, http.StatusMethodNotAllowed, map[string]any{"error": "method not allowed"}), tpl
+	}
+
+	body, _ := io.ReadAll(io.LimitReader(r.Body, 8<<20))
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	if !s.opt.NoValidate {
+		if errs := s.validate(r, tpl, item, op); len(errs) > 0 {
+			return writeJSON(w, http.StatusBadRequest, map[string]any{"error": "request does not match the spec", "details": errs}), tpl
+		}
+	}
+
+	if s.inject() {
+		ct, v, ok := response(op, s.opt.ErrorStatus)
+		if !ok {
+			ct, v = "application/json", map[string]any{"error": "injected failure"}
+		}
+		return write(w, s.opt.ErrorStatus, ct, v), tpl
+	}
+
+	// Prefer: code=404 asks for a specific declared response.
+	if code := preferCode(r.Header.Get("Prefer")); code != 0 {
+		ct, v, _ := response(op, code)
+		return write(w, code, ct, v), tpl
+	}
+
+	if c := s.colls[tpl]; c != nil {
+		var in any
+		if len(body) > 0 {
+			_ = json.Unmarshal(body, &in)
+		}
+		if status, v, ok := c.serve(tpl, r.Method, r.URL.Path, in); ok {
+			if status == http.StatusCreated && tpl == c.list {
+				if obj, isObj := v.(map[string]any); isObj {
+					w.Header().Set("Location", strings.TrimRight(r.URL.Path, "/")+"/"+fmt.Sprint(obj[c.idField]))
+				}
+			}
+			return write(w, status, "application/json", v), tpl
+		}
+	}
+
+	status := successStatus(op, http.StatusOK)
+	ct, v, _ := response(op, status)
+	return write(w, status, ct, v), tpl
+}
+
+// validate checks params and body against the operation; security is not
+// enforced so any credential, or none, is accepted.
+func (s *Server) validate(r *http.Request, tpl string, item *openapi3.PathItem, op *openapi3.Operation) []string {
+	input := &openapi3filter.RequestValidationInput{
+		Request:    r,
+		PathParams: pathParams(tpl, r.URL.Path),
+		Route: &routers.Route{
+			Spec:      s.doc,
+			Path:      tpl,
+			PathItem:  item,
+			Method:    r.Method,
+			Operation: op,
+		},
+		Options: &openapi3filter.Options{
+			MultiError:         true,
+			AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+		},
+	}
+	err := openapi3filter.ValidateRequest(r.Context(), input)
+	if err == nil {
+		return nil
+	}
+	var me openapi3.MultiError
+	if errors.As(err, &me) {
+		out := make([]string, 0, len(me))
+		for _, e := range me {
+			out = append(out, e.Error())
+		}
+		return out
+	}
+	return []string{err.Error()}
+}
+
+// response builds the declared response for status (0 = first success):
+// its content type and example body.
+func response(op *openapi3.Operation, status int) (string, any, bool) {
+	if op.Responses == nil {
+		return "", nil, false
+	}
+	if status == 0 {
+		status = successStatus(op, http.StatusOK)
+	}
+	rr := op.Responses.Status(status)
+	if rr == nil {
+		rr = op.Responses.Map()[fmt.Sprintf("%dXX", status/100)]
+	}
+	if rr == nil {
+		rr = op.Responses.Default()
+	}
+	if rr == nil || rr.Value == nil {
+		return "", nil, false
+	}
+	ct, mt := pickContent(rr.Value.Content)
+	v, ok := inResponse.mediaExample(mt)
+	return ct, v, ok
+}
+
+func (s *Server) delay() {
+	d := s.opt.Latency
+	if s.opt.Jitter > 0 {
+		s.mu.Lock()
+		d += time.Duration(s.rnd.Int63n(int64(s.opt.Jitter)))
+		s.mu.Unlock()
+	}
+	if d > 0 {
+		time.Sleep(d)
+	}
+}
+
+func (s *Server) inject() bool {
+	if s.opt.ErrorRate <= 0 {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rnd.Float64() < s.opt.ErrorRate
+}
+
+func pathParams(tpl, path string) map[string]string {
+	out := map[string]string{}
+	ts := strings.Split(strings.Trim(tpl, "/"), "/")
+	ps := strings.Split(strings.Trim(path, "/"), "/")
+	for i := 0; i < len(ts) && i < len(ps); i++ {
+		if isParam(ts[i]) {
+			out[strings.Trim(ts[i], "{}")] = ps[i]
+		}
+	}
+	return out
+}
+
+func allowed(item *openapi3.PathItem) []string {
+	var out []string
+	for m := range item.Operations() {
+		out = append(out, strings.ToUpper(m))
+	}
+	sort.Strings(out)
+	return out
+}
+
+func preferCode(h string) int {
+	for _, part := range strings.Split(h, ",") {
+		k, v, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if ok && strings.EqualFold(k, "code") {
+			if n, err := strconv.Atoi(strings.Trim(v, `"`)); err == nil && n >= 100 && n < 600 {
+				return n
+			}
+		}
+	}
+	return 0
+}
+
+func write(w http.ResponseWriter, status int, contentType string, v any) int {
+	if v == nil || status == http.StatusNoContent || contentType == "" {
+		w.WriteHeader(status)
+		return status
+	}
+	if !strings.Contains(contentType, "json") {
+		if s, ok := v.(string); ok {
+			w.Header().Set("Content-Type", contentType)
+			w.WriteHeader(status)
+			io.WriteString(w, s)
+			return status
+		}
+	}
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return http.StatusInternalServerError
+	}
+	if !strings.Contains(contentType, "json") {
+		contentType = "application/json"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(status)
+	w.Write(append(b, '\n'))
+	return status
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) int {
+	return write(w, status, "application/json", v)
+}
@@ -0,0 +1,246 @@
+package mock
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+const petstore = `
+openapi: 3.0.3
+info: {title: pets, version: "1"}
+paths:
+  /pets:
+    get:
+      parameters:
+        - {name: limit, in: query, schema: {type: integer, maximum: 100}}
+      responses:
+        "200":
+          description: ok
+          content:
+            application/json:
+              example: [{id: 1, name: Rex}]
+    post:
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              type: object
+              required: [name]
+              properties:
+                name: {type: string}
+      responses:
+        "201": {description: created}
+  /pets/{petId}:
+    get:
+      parameters:
+        - {name: petId, in: path, required: true, schema: {type: integer}}
+      responses:
+        "200":
+          description: ok
+          content:
+            application/json:
+              schema: {$ref: "#/components/schemas/Pet"}
+        "404": {description: missing}
+    delete:
+      parameters:
+        - {name: petId, in: path, required: true, schema: {type: integer}}
+      responses:
+        "204": {description: gone}
+  /health:
+    get:
+      responses:
+        "200":
+          description: ok
+          content:
+            application/json:
+              schema:
+                type: object
+                properties:
+                  status: {type: string, enum: [up]}
+                  at: {type: string, format: date-time}
+components:
+  schemas:
+    Pet:
+      type: object
+      properties:
+        id: {type: integer}
+        name: {type: string}
+`
+
+func newServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	doc, err := openapi3.NewLoader().LoadFromData([]byte(petstore))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := doc.Validate(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	m, err := New(doc, Options{Seed: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(m)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func do(t *testing.T, method, url, body string) (int, any) {
+	t.Helper()
+	req, _ := http.NewRequest(method, url, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	b, _ := io.ReadAll(resp.Body)
+	var v any
+	_ = json.Unmarshal(b, &v)
+	return resp.StatusCode, v
+}
+
+func TestSynthesizedResponse(t *testing.T) {
+	srv := newServer(t)
+	status, v := do(t, "GET", srv.URL+"/health", "")
+	m, _ := v.(map[string]any)
+	if status != 200 || m["status"] != "up" || m["at"] != "2024-01-01T00:00:00Z" {
+		t.Fatalf("got %d %v", status, v)
+	}
+}
+
+func TestValidationAndRouting(t *testing.T) {
+	srv := newServer(t)
+	if status, _ := do(t, "GET", srv.URL+"/pets?limit=1000", ""); status != 400 {
+		t.Fatalf("limit over maximum: %d", status)
+	}
+	if status, _ := do(t, "GET", srv.URL+"/pets/abc", ""); status != 400 {
+		t.Fatalf("non-integer id: %d", status)
+	}
+	if status, _ := do(t, "POST", srv.URL+"/pets", `{"tag":"x"}`); status != 400 {
+		t.Fatalf("missing required name: %d", status)
+	}
+	if status, _ := do(t, "PUT", srv.URL+"/pets/1", `{}`); status != 405 {
+		t.Fatalf("undeclared method: %d", status)
+	}
+	if status, _ := do(t, "GET", srv.URL+"/nope", ""); status != 404 {
+		t.Fatalf("unknown path: %d", status)
+	}
+}
+
+func TestCollectionState(t *testing.T) {
+	srv := newServer(t)
+	if status, v := do(t, "GET", srv.URL+"/pets/1", ""); status != 200 || v.(map[string]any)["name"] != "Rex" {
+		t.Fatalf("seeded item: %d %v", status, v)
+	}
+	status, v := do(t, "POST", srv.URL+"/pets", `{"name":"Fido"}`)
+	if status != 201 || v.(map[string]any)["id"] != float64(2) {
+		t.Fatalf("create: %d %v", status, v)
+	}
+	if _, v := do(t, "GET", srv.URL+"/pets", ""); len(v.([]any)) != 2 {
+		t.Fatalf("list after create: %v", v)
+	}
+	if status, _ := do(t, "DELETE", srv.URL+"/pets/2", ""); status != 204 {
+		t.Fatalf("delete: %d", status)
+	}
+	if status, _ := do(t, "GET", srv.URL+"/pets/2", ""); status != 404 {
+		t.Fatalf("after delete: %d", status)
+	}
+}
+
+const allOfPets = `
+openapi: 3.0.3
+info: {title: pets, version: "1"}
+paths:
+  /pets:
+    get:
+      responses:
+        "200":
+          description: ok
+          content:
+            application/json:
+              schema: {type: array, items: {$ref: "#/components/schemas/Pet"}}
+    post:
+      requestBody:
+        content:
+          application/json:
+            schema: {$ref: "#/components/schemas/NewPet"}
+      responses:
+        "201":
+          description: created
+          content:
+            application/json:
+              schema: {$ref: "#/components/schemas/Pet"}
+  /pets/{id}:
+    get:
+      parameters:
+        - {name: id, in: path, required: true, schema: {type: integer}}
+      responses:
+        "200":
+          description: ok
+          content:
+            application/json:
+              schema: {$ref: "#/components/schemas/Pet"}
+components:
+  schemas:
+    NewPet:
+      type: object
+      required: [name]
+      properties:
+        name: {type: string}
+    Pet:
+      allOf:
+        - $ref: "#/components/schemas/NewPet"
+        - type: object
+          required: [id]
+          properties:
+            id: {type: integer}
+`
+
+func TestCollectionAllOfID(t *testing.T) {
+	doc, err := openapi3.NewLoader().LoadFromData([]byte(allOfPets))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := New(doc, Options{Seed: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(m)
+	defer srv.Close()
+
+	status, v := do(t, "POST", srv.URL+"/pets", `{"name":"Fido"}`)
+	id, ok := v.(map[string]any)["id"].(float64)
+	if status != 201 || !ok {
+		t.Fatalf("create: %d %v (id must be an integer, as the allOf schema says)", status, v)
+	}
+	if status, v := do(t, "GET", fmt.Sprintf("%s/pets/%d", srv.URL, int(id)), ""); status != 200 || v.(map[string]any)["name"] != "Fido" {
+		t.Fatalf("get created: %d %v", status, v)
+	}
+}
+
+func TestNewRejectsBadOptions(t *testing.T) {
+	doc, err := openapi3.NewLoader().LoadFromData([]byte(petstore))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, opt := range []Options{{ErrorRate: 1, ErrorStatus: 42}, {ErrorStatus: 600}, {ErrorRate: 1.5}} {
+		if _, err := New(doc, opt); err == nil {
+			t.Fatalf("%+v: no error", opt)
+		}
+	}
+	if _, err := New(doc, Options{ErrorRate: 1, ErrorStatus: 503}); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,263 @@
+package mock
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// maxDepth stops synthesis of recursive schemas.
+const maxDepth = 8
+
+// Example returns a value that satisfies s: its example or default if it
+// has one, otherwise one built from its type, format and bounds. The
+// result is deterministic so mocks answer the same way every run.
+func Example(s *openapi3.Schema) any {
+	return inResponse.synth(s, "", 0)
+}
+
+// RequestExample is like Example for a value to send: it leaves out
+// readOnly properties instead of writeOnly ones.
+func RequestExample(s *openapi3.Schema) any {
+	return inRequest.synth(s, "", 0)
+}
+
+// direction says which side of the exchange a value is for.
+type direction bool
+
+const (
+	inResponse direction = false
+	inRequest  direction = true
+)
+
+func (d direction) synth(s *openapi3.Schema, name string, depth int) any {
+	if s == nil || depth > maxDepth {
+		return nil
+	}
+	switch {
+	case s.Example != nil:
+		return s.Example
+	case s.Default != nil:
+		return s.Default
+	case len(s.Enum) > 0:
+		return s.Enum[0]
+	case len(s.AllOf) > 0:
+		out := map[string]any{}
+		for _, ref := range s.AllOf {
+			if m, ok := d.synth(ref.Value, name, depth+1).(map[string]any); ok {
+				for k, v := range m {
+					out[k] = v
+				}
+			}
+		}
+		return out
+	case len(s.OneOf) > 0:
+		return d.synth(s.OneOf[0].Value, name, depth+1)
+	case len(s.AnyOf) > 0:
+		return d.synth(s.AnyOf[0].Value, name, depth+1)
+	}
+
+	switch typeOf(s) {
+	case "object":
+		out := map[string]any{}
+		names := make([]string, 0, len(s.Properties))
+		for n := range s.Properties {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		for _, n := range names {
+			p := s.Properties[n]
+			if p == nil || p.Value == nil || d == inResponse && p.Value.WriteOnly || d == inRequest && p.Value.ReadOnly {
+				continue
+			}
+			out[n] = d.synth(p.Value, n, depth+1)
+		}
+		return out
+	case "array":
+		n := int(s.MinItems)
+		if n == 0 {
+			n = 1
+		}
+		out := make([]any, 0, n)
+		for i := 0; i < n; i++ {
+			var item *openapi3.Schema
+			if s.Items != nil {
+				item = s.Items.Value
+			}
+			out = append(out, d.synth(item, name, depth+1))
+		}
+		return out
+	case "integer":
+		return int64(number(s, 1))
+	case "number":
+		return number(s, 1.5)
+	case "boolean":
+		return true
+	case "null":
+		return nil
+	}
+	return str(s, name)
+}
+
+// properties is s's properties with those of its allOf parts merged in,
+// later parts winning as they do when synth merges allOf values. $refs
+// are resolved by the loader, so following Value is enough.
+func properties(s *openapi3.Schema) openapi3.Schemas {
+	out := openapi3.Schemas{}
+	var walk func(s *openapi3.Schema, depth int)
+	walk = func(s *openapi3.Schema, depth int) {
+		if s == nil || depth > maxDepth {
+			return
+		}
+		for name, p := range s.Properties {
+			out[name] = p
+		}
+		for _, ref := range s.AllOf {
+			if ref != nil {
+				walk(ref.Value, depth+1)
+			}
+		}
+	}
+	walk(s, 0)
+	return out
+}
+
+// typeOf picks the schema's type, inferring object/array from shape.
+func typeOf(s *openapi3.Schema) string {
+	if s.Type != nil {
+		for _, t := range s.Type.Slice() {
+			if t != "null" {
+				return t
+			}
+		}
+		if s.Type.Is("null") {
+			return "null"
+		}
+	}
+	switch {
+	case len(s.Properties) > 0:
+		return "object"
+	case s.Items != nil:
+		return "array"
+	}
+	return "string"
+}
+
+func number(s *openapi3.Schema, def float64) float64 {
+	v := def
+	if s.Min != nil {
+		v = *s.Min
+		if s.ExclusiveMin {
+			v++
+		}
+	}
+	if s.Max != nil && v > *s.Max {
+		v = *s.Max
+	}
+	if m := s.MultipleOf; m != nil && *m > 0 {
+		v = math.Ceil(v / *m) * *m
+		if s.Max != nil && v > *s.Max {
+			v = math.Floor(*s.Max / *m) * *m
+		}
+	}
+	return v
+}
+
+func str(s *openapi3.Schema, name string) string {
+	var v string
+	switch s.Format {
+	case "date-time":
+		v = "2024-01-01T00:00:00Z"
+	case "date":
+		v = "2024-01-01"
+	case "time":
+		v = "12:00:00"
+	case "email":
+		v = "user@example.com"
+	case "uuid":
+		v = "3fa85f64-5717-4562-b3fc-2c963f66afa6"
+	case "uri", "url":
+		v = "https://example.com"
+	case "hostname":
+		v = "example.com"
+	case "ipv4":
+		v = "192.0.2.1"
+	case "ipv6":
+		v = "2001:db8::1"
+	case "byte":
+		v = "c3RyaW5n"
+	default:
+		v = "string"
+		if name != "" {
+			v = name
+		}
+	}
+	for uint64(len(v)) < s.MinLength {
+		v += "x"
+	}
+	if s.MaxLength != nil && uint64(len(v)) > *s.MaxLength {
+		v = v[:*s.MaxLength]
+	}
+	return v
+}
+
+// mediaExample returns the example of a media type: its example, the
+// first of its named examples, or one synthesized from its schema.
+func (d direction) mediaExample(mt *openapi3.MediaType) (any, bool) {
+	if mt == nil {
+		return nil, false
+	}
+	if mt.Example != nil {
+		return mt.Example, true
+	}
+	if len(mt.Examples) > 0 {
+		names := make([]string, 0, len(mt.Examples))
+		for n := range mt.Examples {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		for _, n := range names {
+			if ex := mt.Examples[n]; ex != nil && ex.Value != nil && ex.Value.Value != nil {
+				return ex.Value.Value, true
+			}
+		}
+	}
+	if mt.Schema != nil && mt.Schema.Value != nil {
+		return d.synth(mt.Schema.Value, "", 0), true
+	}
+	return nil, false
+}
+
+// RequestBody returns a body to send for rb: its content type and an
+// example value.
+func RequestBody(rb *openapi3.RequestBody) (string, any, bool) {
+	if rb == nil {
+		return "", nil, false
+	}
+	ct, mt := pickContent(rb.Content)
+	v, ok := inRequest.mediaExample(mt)
+	return ct, v, ok
+}
+
+// pickContent prefers JSON, then anything else declared.
+func pickContent(c openapi3.Content) (string, *openapi3.MediaType) {
+	if mt := c.Get("application/json"); mt != nil {
+		return "application/json", mt
+	}
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if strings.Contains(k, "json") {
+			return k, c[k]
+		}
+	}
+	if len(keys) > 0 {
+		return keys[0], c[keys[0]]
+	}
+	return "", nil
+}
@@ -3,6 +3,7 @@ package bench
 import (
 	"context"
 	"errors"
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -50,7 +51,7 @@ func Run(ctx context.Context, r engine.Runner, cfg Config) (Result, error) {
 	for i := 0; i < cfg.Concurrency; i++ {
 		go func() {
 			defer wg.Done()
-			for time.Now().Before(deadline) {
+			for time.Now().Before(deadline) && ctx.Err() == nil {
 				start := time.Now()
 				_, err := r.Run(ctx, cfg.Request)
 				ms := time.Since(start).Milliseconds()
@@ -81,14 +82,7 @@ func percentiles(ms []int64) (p50, p95, p99 int64) {
 	if len(ms) == 0 {
 		return 0, 0, 0
 	}
-	// simple in-place sort (small enough), avoid extra deps
-	for i := 0; i < len(ms); i++ {
-		for j := i + 1; j < len(ms); j++ {
-			if ms[j] < ms[i] {
-				ms[i], ms[j] = ms[j], ms[i]
-			}
-		}
-	}
+	sort.Slice(ms, func(i, j int) bool { return ms[i] < ms[j] })
 	get := func(q float64) int64 {
 		if len(ms) == 0 {
 			return 0
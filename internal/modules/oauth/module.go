@@ -0,0 +1,49 @@
+package oauth
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/bspippi1337/restless/internal/auth"
+	"github.com/bspippi1337/restless/internal/core/app"
+)
+
+// Module injects an OAuth2 access token into every request bound for the
+// profile's API that does not already carry an Authorization header.
+type Module struct {
+	src     *auth.Source
+	timeout time.Duration
+}
+
+func New(src *auth.Source) *Module {
+	return &Module{src: src, timeout: 2 * time.Minute}
+}
+
+func (m *Module) Name() string { return "oauth" }
+
+func (m *Module) Register(r *app.Registry) error {
+	r.RequestMutators = append(r.RequestMutators, func(rc *app.RequestContext) error {
+		if m.src == nil || !m.src.Applies(rc.URL) || hasAuthorization(rc.Header) {
+			return nil
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), m.timeout)
+		defer cancel()
+		tok, err := m.src.Token(ctx)
+		if err != nil {
+			return err
+		}
+		rc.Header["Authorization"] = []string{tok.Header()}
+		return nil
+	})
+	return nil
+}
+
+func hasAuthorization(h map[string][]string) bool {
+	for k, vv := range h {
+		if len(vv) > 0 && strings.EqualFold(k, "Authorization") {
+			return true
+		}
+	}
+	return false
+}
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
 	"time"
 
@@ -27,8 +28,12 @@ type FindingStat struct {
 	LastSeen  time.Time  `json:"last_seen"`
 	LastCDI   float64    `json:"last_cdi"`
 	AvgCDI    float64    `json:"avg_cdi"`
+	// Values are distinct offending values seen (JSON), up to maxValues.
+	Values []string `json:"values,omitempty"`
 }
 
+const maxValues = 20
+
 type HostSnapshot struct {
 	BaseURL     string                  `json:"base_url"`
 	SpecRef     string                  `json:"spec_ref"`
@@ -114,6 +119,9 @@ func UpdateFromGuard(baseURL, specRef string, findings []model.Finding, cdi floa
 		st.LastSeen = now
 		st.LastCDI = cdi
 		st.AvgCDI = ((st.AvgCDI * float64(st.Count-1)) + cdi) / float64(st.Count)
+		if f.Actual != "" && len(st.Values) < maxValues && !slices.Contains(st.Values, f.Actual) {
+			st.Values = append(st.Values, f.Actual)
+		}
 		s.TotalEvents++
 	}
 
@@ -1,6 +1,7 @@
 package auto
 
 import (
+	"context"
 	"net/http"
 	"time"
 )
@@ -15,12 +16,20 @@ var commonPaths = []string{
 
 // TryDiscover tries common OpenAPI/Swagger endpoints under baseURL.
 // baseURL should be like: https://api.example.com (no trailing slash preferred).
-func TryDiscover(baseURL string) (string, bool) {
+// It gives up when ctx is done.
+func TryDiscover(ctx context.Context, baseURL string) (string, bool) {
 	client := http.Client{Timeout: 4 * time.Second}
 
 	for _, p := range commonPaths {
+		if ctx.Err() != nil {
+			return "", false
+		}
 		url := baseURL + p
-		resp, err := client.Get(url)
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return "", false
+		}
+		resp, err := client.Do(req)
 		if err != nil {
 			continue
 		}
@@ -26,6 +26,9 @@ var (
 	mu    sync.RWMutex
 	store = map[string]*cachedSpec{}
 	ttl   = 10 * time.Minute
+	// probed are the base URLs whose spec was looked for on the network
+	// already; a process looks only once.
+	probed = map[string]bool{}
 )
 
 func cacheDir() string {
@@ -40,19 +43,25 @@ func cachePath(baseURL string) string {
 	return filepath.Join(cacheDir(), hex.EncodeToString(h[:])+".json")
 }
 
-// Get returns an OpenAPI doc for baseURL using memory cache, then disk cache, then network refresh.
+// Get returns an OpenAPI doc for baseURL using memory cache, then network refresh, then disk cache.
+// The network is tried once per process for each baseURL, found or not.
 // If offline/unreachable, disk cache is used as fallback.
 func Get(ctx context.Context, baseURL string) (*openapi3.T, string, bool) {
 	// 1) memory
-	mu.RLock()
+	mu.Lock()
 	entry, exists := store[baseURL]
-	mu.RUnlock()
+	tried := probed[baseURL]
+	probed[baseURL] = true
+	mu.Unlock()
 
 	if exists && time.Since(entry.LoadedAt) < ttl {
 		return entry.Doc, entry.SpecRef, true
 	}
 	// 2) network refresh
-	if specRef, ok := auto.TryDiscover(baseURL); ok {
+	if tried {
+		return loadCached(baseURL)
+	}
+	if specRef, ok := auto.TryDiscover(ctx, baseURL); ok {
 		doc, err := gloader.Load(ctx, specRef, gloader.LoadOptions{AllowRemoteRefs: true})
 		if err == nil {
 			saveToDisk(baseURL, doc, specRef)
@@ -63,6 +72,10 @@ func Get(ctx context.Context, baseURL string) (*openapi3.T, string, bool) {
 		}
 	}
 	// 3) disk fallback
+	return loadCached(baseURL)
+}
+
+func loadCached(baseURL string) (*openapi3.T, string, bool) {
 	doc, specRef, ok := loadFromDisk(baseURL)
 	if ok {
 		mu.Lock()
@@ -111,6 +124,7 @@ func loadFromDisk(baseURL string) (*openapi3.T, string, bool) {
 func Invalidate(baseURL string) {
 	mu.Lock()
 	delete(store, baseURL)
+	delete(probed, baseURL)
 	mu.Unlock()
 	_ = os.Remove(cachePath(baseURL))
 }
@@ -2,9 +2,35 @@ package openapi
 
 import (
 	"fmt"
+	"io"
+	"time"
 )
 
-func ListCached() error {
-	fmt.Println("OpenAPI cache listing not yet implemented (v1 skeleton).")
+// ListCached prints the imported specs, newest first.
+func ListCached(w io.Writer) error {
+	specs, err := ListSpecs()
+	if err != nil {
+		return err
+	}
+	if len(specs) == 0 {
+		fmt.Fprintln(w, "No specs imported. Run: restless openapi import <file|url>")
+		return nil
+	}
+	for _, idx := range specs {
+		title := idx.Title
+		if title == "" {
+			title = "(no title)"
+		}
+		ver := idx.Version
+		if ver == "" {
+			ver = "(no version)"
+		}
+		base := idx.BaseURL
+		if base == "" {
+			base = "(no base url)"
+		}
+		imported := time.Unix(idx.Imported, 0).Format("2006-01-02 15:04")
+		fmt.Fprintf(w, "%s  %s  %s  base=%s  imported=%s\n", idx.ShortID(), title, ver, base, imported)
+	}
 	return nil
 }
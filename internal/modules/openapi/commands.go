@@ -1,53 +1,28 @@
 package openapi
 
 import (
+	"encoding/json"
 	"fmt"
-	"path/filepath"
+	"io"
 	"sort"
 	"strings"
-)
 
-func ListSpecs() error {
-	files, err := ListIndexFiles()
-	if err != nil {
-		return err
-	}
-	sort.Strings(files)
+	"github.com/getkin/kin-openapi/openapi3"
 
-	for _, p := range files {
-		id := strings.TrimSuffix(filepath.Base(p), ".json")
-		idx, err := LoadIndex(id)
-		if err != nil {
-			// best effort
-			fmt.Printf("%s  (failed to read index)\n", id)
-			continue
-		}
-		title := idx.Title
-		if title == "" {
-			title = "(no title)"
-		}
-		ver := idx.Version
-		if ver == "" {
-			ver = "(no version)"
-		}
-		base := idx.BaseURL
-		if base == "" {
-			base = "(no base url)"
-		}
-		fmt.Printf("%s  %s  %s  base=%s  src=%s\n", idx.ID, title, ver, base, idx.Source)
-	}
-	return nil
-}
+	"github.com/bspippi1337/restless/internal/mock"
+)
 
 type Endpoint struct {
 	Method      string
 	Path        string
 	Summary     string
 	OperationID string
+	Tags        []string
 }
 
+// ListEndpoints lists the operations of an imported spec, by path.
 func ListEndpoints(id string) ([]Endpoint, error) {
-	idx, err := LoadIndex(id)
+	idx, err := Resolve(id)
 	if err != nil {
 		return nil, err
 	}
@@ -57,13 +32,14 @@ func ListEndpoints(id string) ([]Endpoint, error) {
 	}
 
 	var out []Endpoint
-	for path, item := range spec.Paths {
-		for method, op := range item {
+	for path, item := range spec.Paths.Map() {
+		for method, op := range item.Operations() {
 			out = append(out, Endpoint{
-				Method:      strings.ToUpper(method),
+				Method:      method,
 				Path:        path,
 				Summary:     op.Summary,
 				OperationID: op.OperationID,
+				Tags:        op.Tags,
 			})
 		}
 	}
@@ -76,11 +52,35 @@ func ListEndpoints(id string) ([]Endpoint, error) {
 	return out, nil
 }
 
-func PrintEndpoints(id string) error {
-	eps, err := ListEndpoints(id)
-	if err != nil {
-		return err
+// FilterEndpoints keeps endpoints tagged tag (any case) whose method,
+// path, summary or operation ID contains grep (any case). Empty
+// arguments match everything.
+func FilterEndpoints(eps []Endpoint, tag, grep string) []Endpoint {
+	grep = strings.ToLower(grep)
+	var out []Endpoint
+	for _, e := range eps {
+		if tag != "" && !hasTag(e.Tags, tag) {
+			continue
+		}
+		hay := strings.ToLower(e.Method + " " + e.Path + " " + e.Summary + " " + e.OperationID)
+		if grep != "" && !strings.Contains(hay, grep) {
+			continue
+		}
+		out = append(out, e)
+	}
+	return out
+}
+
+func hasTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
 	}
+	return false
+}
+
+func PrintEndpoints(w io.Writer, eps []Endpoint) {
 	for _, e := range eps {
 		s := e.Summary
 		if s == "" {
@@ -90,7 +90,89 @@ func PrintEndpoints(id string) error {
 		if op == "" {
 			op = "-"
 		}
-		fmt.Printf("%-6s %-40s  %s  (op:%s)\n", e.Method, e.Path, s, op)
+		fmt.Fprintf(w, "%-6s %-40s  %s  (op:%s)\n", e.Method, e.Path, s, op)
+	}
+}
+
+// PrintOperation describes one operation: what it takes and a body to
+// start from.
+func PrintOperation(w io.Writer, doc *openapi3.T, method, tpl string) error {
+	op, params, err := Operation(doc, method, tpl)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "%s %s\n", strings.ToUpper(method), tpl)
+	if op.Summary != "" {
+		fmt.Fprintf(w, "  %s\n", op.Summary)
+	}
+	if op.OperationID != "" {
+		fmt.Fprintf(w, "  op:%s\n", op.OperationID)
+	}
+	if op.Deprecated {
+		fmt.Fprintln(w, "  deprecated")
+	}
+
+	if len(params) > 0 {
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "PARAMETERS")
+		fmt.Fprintln(w, "----------")
+		for _, ref := range params {
+			p := ref.Value
+			req := ""
+			if p.Required {
+				req = "required"
+			}
+			line := fmt.Sprintf("  %-7s %-20s %-16s %-8s  %s", p.In, p.Name, SchemaType(p.Schema), req, firstLine(p.Description))
+			fmt.Fprintln(w, strings.TrimRight(line, " "))
+		}
+	}
+
+	if op.RequestBody != nil && op.RequestBody.Value != nil {
+		ct, example, ok := BodyTemplate(op)
+		title := "REQUEST BODY (" + ct
+		if op.RequestBody.Value.Required {
+			title += ", required"
+		}
+		title += ")"
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, title)
+		fmt.Fprintln(w, strings.Repeat("-", len(title)))
+		if ok {
+			fmt.Fprintln(w, string(example))
+		}
+	}
+
+	if op.Responses != nil && op.Responses.Len() > 0 {
+		codes := make([]string, 0, op.Responses.Len())
+		for code := range op.Responses.Map() {
+			codes = append(codes, code)
+		}
+		sort.Strings(codes)
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "RESPONSES")
+		fmt.Fprintln(w, "---------")
+		for _, code := range codes {
+			desc := ""
+			if r := op.Responses.Value(code); r != nil && r.Value != nil && r.Value.Description != nil {
+				desc = firstLine(*r.Value.Description)
+			}
+			fmt.Fprintf(w, "  %-8s %s\n", code, desc)
+		}
 	}
 	return nil
 }
+
+// BodyTemplate returns an example body for the operation, with the
+// content type it is sent as.
+func BodyTemplate(op *openapi3.Operation) (string, []byte, bool) {
+	if op.RequestBody == nil || op.RequestBody.Value == nil {
+		return "", nil, false
+	}
+	ct, v, ok := mock.RequestBody(op.RequestBody.Value)
+	if !ok {
+		return ct, nil, false
+	}
+	b, err := json.MarshalIndent(v, "", "  ")
+	return ct, b, err == nil
+}
@@ -3,9 +3,13 @@ package openapi
 import (
 	"context"
 	"fmt"
+	"io"
+	"net/url"
 	"strings"
 	"time"
 
+	"github.com/getkin/kin-openapi/openapi3"
+
 	"github.com/bspippi1337/restless/internal/modules/openapi/ai"
 	"github.com/bspippi1337/restless/internal/modules/openapi/cache"
 	"github.com/bspippi1337/restless/internal/modules/openapi/guard/model"
@@ -13,57 +17,127 @@ import (
 	gruntime "github.com/bspippi1337/restless/internal/modules/openapi/guard/runtime"
 )
 
-// MaybeValidateResponse validates JSON responses against cached OpenAPI contracts.
-// Safe no-op if no spec is discoverable.
+// MaybeValidateResponse validates a JSON response to method rawURL
+// against the contract for its host: the imported spec it falls under,
+// else one discovered on the host. Findings are added to the host
+// snapshot (see SnapshotBase) and returned. Safe no-op if no spec is
+// discoverable. A Module does the same and looks each contract up once.
 func MaybeValidateResponse(
 	ctx context.Context,
-	baseURL string,
+	rawURL string,
 	method string,
-	path string,
 	status int,
 	contentType string,
 	body []byte,
-) {
+) []model.Finding {
+	return New().validate(ctx, rawURL, method, status, contentType, body)
+}
+
+func (m *Module) validate(ctx context.Context, rawURL, method string, status int, contentType string, body []byte) []model.Finding {
 	if ctx == nil {
 		ctx = context.Background()
 	}
 
 	ct := strings.ToLower(contentType)
 	if !strings.Contains(ct, "json") {
-		return
+		return nil
 	}
 	if len(body) == 0 {
-		return
+		return nil
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil
 	}
 
-	doc, specRef, ok := cache.Get(ctx, baseURL)
-	if !ok || doc == nil {
-		return
+	c := m.contract(ctx, rawURL)
+	if c.doc == nil {
+		return nil
 	}
-	// 12.5: accept concrete paths by mapping to template if possible.
-	if doc.Paths != nil && doc.Paths.Find(path) == nil {
-		if tpl, ok := gruntime.MatchPathTemplate(doc, path); ok {
-			path = tpl
-		}
+	// 12.5: accept concrete paths by mapping to template if possible; the
+	// path may carry the server's base path.
+	path, _, ok := matchSuffix(c.doc, method, u.Path)
+	if !ok {
+		return nil
 	}
 
-	v := gruntime.NewValidator(doc)
+	v := gruntime.NewValidator(c.doc)
 	findings, err := v.ValidateResponse(ctx, method, path, status, contentType, body)
 	if err != nil || len(findings) == 0 {
-		return
+		return nil
 	}
 
-	res := model.GuardResult{
-		TargetBaseURL: baseURL,
-		SpecRef:       specRef,
-		StartedAt:     time.Now(),
-		FinishedAt:    time.Now(),
-		Findings:      findings,
-	}
-	res.CDI = gruntime.ComputeCDI(findings, gruntime.DefaultWeights())
 	// 13: not-quite-steady-state snapshot (strictly observational).
-	_ = ai.UpdateFromGuard(baseURL, specRef, findings, res.CDI)
+	_ = ai.UpdateFromGuard(c.base, c.ref, findings, CDI(findings))
+	return findings
+}
+
+// contract is the spec for a base URL and where it came from. A nil doc
+// means there is none.
+type contract struct {
+	base string
+	doc  *openapi3.T
+	ref  string
+}
+
+// contract finds the spec for a request URL and the base URL its
+// snapshot is kept under. Each is looked up once, found or not.
+func (m *Module) contract(ctx context.Context, rawURL string) contract {
+	base := SnapshotBase(rawURL)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if c, ok := m.contracts[base]; ok {
+		return c
+	}
+	c := contractFor(ctx, rawURL)
+	m.contracts[base] = c
+	return c
+}
 
-	fmt.Printf("OpenAPI contract drift detected (CDI %.3f)\n", res.CDI)
-	fmt.Print(greport.PrintHuman(res))
+func contractFor(ctx context.Context, rawURL string) contract {
+	if idx, ok := ForURL(rawURL); ok {
+		doc, err := LoadSpecFromFile(idx.RawPath)
+		if err == nil {
+			return contract{base: idx.BaseURL, doc: doc, ref: idx.Source}
+		}
+	}
+	base := SnapshotBase(rawURL)
+	doc, specRef, ok := cache.Get(ctx, base)
+	if !ok || doc == nil {
+		return contract{}
+	}
+	return contract{base: base, doc: doc, ref: specRef}
+}
+
+// SnapshotBase is the base URL the drift snapshot for rawURL is kept
+// under: the base of the imported spec it falls under, else its origin.
+func SnapshotBase(rawURL string) string {
+	if idx, ok := ForURL(rawURL); ok {
+		return idx.BaseURL
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return strings.TrimRight(rawURL, "/")
+	}
+	return u.Scheme + "://" + u.Host
+}
+
+// CDI is the contract drift index of findings.
+func CDI(findings []model.Finding) float64 {
+	return gruntime.ComputeCDI(findings, gruntime.DefaultWeights())
+}
+
+// PrintDrift prints the findings of one response.
+func PrintDrift(w io.Writer, findings []model.Finding) {
+	if len(findings) == 0 {
+		return
+	}
+	res := model.GuardResult{
+		StartedAt:  time.Now(),
+		FinishedAt: time.Now(),
+		Findings:   findings,
+		CDI:        CDI(findings),
+	}
+	fmt.Fprintln(w, "OpenAPI contract drift detected")
+	fmt.Fprint(w, greport.PrintHuman(res))
 }
@@ -0,0 +1,607 @@
+// Package gen writes typed API clients from OpenAPI documents: models
+// from the component schemas, one method per operation, auth injection
+// and helpers that walk paginated lists. Clients use only the target
+// language's standard library, so they build offline.
+package gen
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+
+	"github.com/bspippi1337/restless/internal/modules/openapi"
+)
+
+// Langs are the languages Client writes.
+var Langs = []string{"go", "ts", "python"}
+
+type Options struct {
+	Lang string
+	// Package names the Go package; defaults to one derived from the
+	// spec's title.
+	Package string
+}
+
+// File is one generated source file, relative to the output directory.
+type File struct {
+	Name string
+	Data []byte
+}
+
+// Stats sums up what a client covers.
+type Stats struct {
+	Types      int
+	Operations int
+	Paginated  int
+}
+
+// Client writes a client for doc in opt.Lang.
+func Client(doc *openapi3.T, opt Options) ([]File, Stats, error) {
+	m := build(doc)
+	st := Stats{Types: len(m.decls), Operations: len(m.ops)}
+	for _, op := range m.ops {
+		if op.pager != nil {
+			st.Paginated++
+		}
+	}
+	var files []File
+	var err error
+	switch opt.Lang {
+	case "go":
+		pkg := opt.Package
+		if pkg == "" {
+			pkg = PackageName(doc)
+		}
+		files, err = goClient(m, pkg)
+	case "ts", "typescript":
+		files = tsClient(m)
+	case "python", "py":
+		files = pyClient(m)
+	default:
+		return nil, st, fmt.Errorf("unknown language %q (want %s)", opt.Lang, strings.Join(Langs, ", "))
+	}
+	return files, st, err
+}
+
+// PackageName is a package name derived from the spec's title.
+func PackageName(doc *openapi3.T) string {
+	name := ""
+	if doc.Info != nil {
+		for _, w := range words(doc.Info.Title) {
+			name += strings.ToLower(w)
+		}
+	}
+	if name == "" || !isIdent(name) || name[0] >= '0' && name[0] <= '9' {
+		return "client"
+	}
+	return name
+}
+
+// model is a spec reduced to what a client needs; the emitters turn it
+// into source.
+type model struct {
+	title, version string
+	baseURL        string
+	decls          []*decl
+	byName         map[string]*decl
+	ops            []*operation
+	schemes        []scheme
+
+	names namer
+	comps map[string]string // component schema → decl name
+}
+
+type kind int
+
+const (
+	kAny kind = iota
+	kNamed
+	kString
+	kInt
+	kInt32
+	kNumber
+	kBool
+	kArray
+	kMap
+	kUnion
+	kRaw // a non-JSON body, passed as is
+)
+
+// typ is a reference to a type: a primitive, a container or a decl.
+type typ struct {
+	kind     kind
+	name     string // kNamed
+	elem     *typ   // kArray, kMap
+	alts     []*typ // kUnion
+	nullable bool
+}
+
+type declKind int
+
+const (
+	dObject declKind = iota
+	dEnum
+	dAlias
+)
+
+type decl struct {
+	name   string
+	doc    string
+	kind   declKind
+	fields []field  // dObject
+	enum   []string // dEnum
+	alias  *typ     // dAlias
+}
+
+type field struct {
+	name     string // as on the wire
+	typ      *typ
+	required bool
+	doc      string
+}
+
+type param struct {
+	name     string // as on the wire
+	in       string
+	typ      *typ
+	required bool
+	doc      string
+}
+
+type operation struct {
+	name         string
+	method, path string
+	doc          string
+	pathParams   []param
+	params       []param // query and header
+	body         *typ
+	bodyRequired bool
+	contentType  string
+	result       *typ
+	pager        *pager
+}
+
+// paramsRequired reports whether the caller must pass the params.
+func (op *operation) paramsRequired() bool {
+	for _, p := range op.params {
+		if p.required {
+			return true
+		}
+	}
+	return false
+}
+
+type scheme struct {
+	name  string // as in components.securitySchemes
+	kind  string // bearer, basic or apiKey
+	in    string // apiKey: header, query or cookie
+	param string // apiKey: header, query or cookie name
+	doc   string
+}
+
+func build(doc *openapi3.T) *model {
+	m := &model{
+		baseURL: openapi.BaseURL(doc),
+		byName:  map[string]*decl{},
+		names:   namer{},
+		comps:   map[string]string{},
+	}
+	if doc.Info != nil {
+		m.title, m.version = doc.Info.Title, doc.Info.Version
+	}
+	// Runtime names the emitters use.
+	for _, n := range []string{"Client", "APIError", "ApiError", "BasicAuth", "ClientOptions"} {
+		m.names[n] = true
+	}
+
+	if doc.Components != nil {
+		comps := sortedKeys(doc.Components.Schemas)
+		for _, c := range comps {
+			m.comps[c] = m.names.unique(pascal(c))
+		}
+		for _, c := range comps {
+			ref := doc.Components.Schemas[c]
+			if ref == nil || ref.Value == nil {
+				continue
+			}
+			m.declare(m.comps[c], ref.Value)
+		}
+		for _, n := range sortedKeys(doc.Components.SecuritySchemes) {
+			if s, ok := securityScheme(n, doc.Components.SecuritySchemes[n]); ok {
+				m.schemes = append(m.schemes, s)
+			}
+		}
+	}
+
+	opNames := namer{}
+	for _, path := range sortedKeys(doc.Paths.Map()) {
+		item := doc.Paths.Value(path)
+		for _, method := range methods {
+			if item.GetOperation(method) == nil {
+				continue
+			}
+			m.ops = append(m.ops, m.operation(doc, method, path, opNames))
+		}
+	}
+	return m
+}
+
+var methods = []string{
+	http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch,
+	http.MethodDelete, http.MethodHead, http.MethodOptions, http.MethodTrace,
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func securityScheme(name string, ref *openapi3.SecuritySchemeRef) (scheme, bool) {
+	if ref == nil || ref.Value == nil {
+		return scheme{}, false
+	}
+	v := ref.Value
+	s := scheme{name: name, doc: firstLine(v.Description)}
+	switch strings.ToLower(v.Type) {
+	case "http":
+		switch strings.ToLower(v.Scheme) {
+		case "bearer":
+			s.kind = "bearer"
+		case "basic":
+			s.kind = "basic"
+		default:
+			return s, false
+		}
+	case "oauth2", "openidconnect":
+		s.kind = "bearer"
+	case "apikey":
+		s.kind, s.in, s.param = "apiKey", v.In, v.Name
+		if s.in != "header" && s.in != "query" && s.in != "cookie" || s.param == "" {
+			return s, false
+		}
+	default:
+		return s, false
+	}
+	return s, true
+}
+
+// declare adds the decl for a named schema.
+func (m *model) declare(name string, s *openapi3.Schema) *decl {
+	d := &decl{name: name, doc: firstLine(s.Description)}
+	switch {
+	case isStringEnum(s):
+		d.kind = dEnum
+		for _, v := range s.Enum {
+			if str, ok := v.(string); ok {
+				d.enum = append(d.enum, str)
+			}
+		}
+	case len(s.AllOf) == 1 && len(s.Properties) == 0:
+		d.kind, d.alias = dAlias, m.ref(s.AllOf[0], name+"Base")
+	case isObject(s):
+		d.kind = dObject
+		// Register first: fields may refer back to it.
+		m.decls = append(m.decls, d)
+		m.byName[name] = d
+		d.fields = m.fields(s, name)
+		return d
+	default:
+		d.kind, d.alias = dAlias, m.ref(openapi3.NewSchemaRef("", s), name+"Item")
+	}
+	m.decls = append(m.decls, d)
+	m.byName[name] = d
+	return d
+}
+
+func isObject(s *openapi3.Schema) bool {
+	return len(s.Properties) > 0 || len(s.AllOf) > 0
+}
+
+func isStringEnum(s *openapi3.Schema) bool {
+	if len(s.Enum) == 0 || s.Type != nil && !s.Type.Includes(openapi3.TypeString) {
+		return false
+	}
+	for _, v := range s.Enum {
+		if _, ok := v.(string); !ok && v != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// fields collects the properties of s and of the schemas it is allOf,
+// with the required ones marked.
+func (m *model) fields(s *openapi3.Schema, owner string) []field {
+	var out []field
+	index := map[string]int{}
+	add := func(f field) {
+		if i, ok := index[f.name]; ok {
+			out[i] = f
+			return
+		}
+		index[f.name] = len(out)
+		out = append(out, f)
+	}
+	for _, part := range s.AllOf {
+		if part == nil || part.Value == nil {
+			continue
+		}
+		for _, f := range m.fields(part.Value, owner) {
+			add(f)
+		}
+	}
+	required := set(s.Required...)
+	for _, name := range sortedKeys(s.Properties) {
+		p := s.Properties[name]
+		f := field{name: name, typ: m.ref(p, owner+pascal(name)), required: required[name]}
+		if p != nil && p.Value != nil {
+			f.doc = firstLine(p.Value.Description)
+		}
+		add(f)
+	}
+	// Required may name properties another allOf part declares.
+	for i := range out {
+		if required[out[i].name] {
+			out[i].required = true
+		}
+	}
+	return out
+}
+
+// ref is the type of a schema; inline objects and enums become decls
+// named hint.
+func (m *model) ref(r *openapi3.SchemaRef, hint string) *typ {
+	if r == nil {
+		return &typ{kind: kAny}
+	}
+	if name, ok := strings.CutPrefix(r.Ref, "#/components/schemas/"); ok {
+		if d, ok := m.comps[name]; ok {
+			t := &typ{kind: kNamed, name: d}
+			if r.Value != nil && r.Value.Nullable {
+				t.nullable = true
+			}
+			return t
+		}
+	}
+	s := r.Value
+	if s == nil {
+		return &typ{kind: kAny}
+	}
+	nullable := s.Nullable || s.Type != nil && s.Type.Includes(openapi3.TypeNull)
+
+	var t *typ
+	switch {
+	case len(s.AllOf) == 1 && len(s.Properties) == 0:
+		t = m.ref(s.AllOf[0], hint)
+		t = &typ{kind: t.kind, name: t.name, elem: t.elem, alts: t.alts}
+	case len(s.OneOf) > 0 || len(s.AnyOf) > 0:
+		alts := s.OneOf
+		if len(alts) == 0 {
+			alts = s.AnyOf
+		}
+		t = &typ{kind: kUnion}
+		for i, a := range alts {
+			t.alts = append(t.alts, m.ref(a, hint+"Option"+strconv.Itoa(i+1)))
+		}
+	case isStringEnum(s), isObject(s):
+		t = &typ{kind: kNamed, name: m.declare(m.names.unique(hint), s).name}
+	default:
+		t = m.prim(s, hint)
+	}
+	t.nullable = t.nullable || nullable
+	return t
+}
+
+func (m *model) prim(s *openapi3.Schema, hint string) *typ {
+	var types []string
+	if s.Type != nil {
+		for _, t := range *s.Type {
+			if t != openapi3.TypeNull {
+				types = append(types, t)
+			}
+		}
+	}
+	if len(types) != 1 {
+		return &typ{kind: kAny}
+	}
+	switch types[0] {
+	case openapi3.TypeString:
+		return &typ{kind: kString}
+	case openapi3.TypeInteger:
+		if s.Format == "int32" {
+			return &typ{kind: kInt32}
+		}
+		return &typ{kind: kInt}
+	case openapi3.TypeNumber:
+		return &typ{kind: kNumber}
+	case openapi3.TypeBoolean:
+		return &typ{kind: kBool}
+	case openapi3.TypeArray:
+		return &typ{kind: kArray, elem: m.ref(s.Items, hint+"Item")}
+	case openapi3.TypeObject:
+		if ap := s.AdditionalProperties.Schema; ap != nil {
+			return &typ{kind: kMap, elem: m.ref(ap, hint+"Value")}
+		}
+		return &typ{kind: kMap, elem: &typ{kind: kAny}}
+	}
+	return &typ{kind: kAny}
+}
+
+var pathParam = regexp.MustCompile(`\{([^}]+)\}`)
+
+func (m *model) operation(doc *openapi3.T, method, path string, names namer) *operation {
+	o, params, _ := openapi.Operation(doc, method, path)
+	name := pascal(o.OperationID)
+	if o.OperationID == "" {
+		name = pascal(strings.ToLower(method) + " " + strings.NewReplacer("{", " by ", "}", " ").Replace(path))
+	}
+	op := &operation{
+		name:   names.unique(name),
+		method: method,
+		path:   path,
+		doc:    firstLine(o.Summary),
+	}
+	if op.doc == "" {
+		op.doc = firstLine(o.Description)
+	}
+
+	byName := map[string]*openapi3.Parameter{}
+	for _, p := range params {
+		v := p.Value
+		switch v.In {
+		case openapi3.ParameterInPath:
+			byName[v.Name] = v
+		case openapi3.ParameterInQuery, openapi3.ParameterInHeader:
+			op.params = append(op.params, m.param(v, op.name))
+		}
+	}
+	// Path parameters in the order the path has them; ones the spec
+	// forgot to declare are strings.
+	for _, sub := range pathParam.FindAllStringSubmatch(path, -1) {
+		p := param{name: sub[1], in: openapi3.ParameterInPath, typ: &typ{kind: kString}, required: true}
+		if v, ok := byName[sub[1]]; ok {
+			p = m.param(v, op.name)
+			p.required = true
+		}
+		op.pathParams = append(op.pathParams, p)
+	}
+
+	if rb := o.RequestBody; rb != nil && rb.Value != nil {
+		ct, media := pickMedia(rb.Value.Content)
+		if media != nil {
+			op.contentType = ct
+			op.bodyRequired = rb.Value.Required
+			if isJSON(ct) {
+				op.body = m.ref(media.Schema, op.name+"Request")
+			} else {
+				op.body = &typ{kind: kRaw}
+			}
+		}
+	}
+	op.result = m.result(o, op.name)
+	op.pager = m.detectPager(op)
+	return op
+}
+
+func (m *model) param(v *openapi3.Parameter, opName string) param {
+	p := param{name: v.Name, in: v.In, required: v.Required, doc: firstLine(v.Description)}
+	schema := v.Schema
+	if schema == nil {
+		if _, media := pickMedia(v.Content); media != nil {
+			schema = media.Schema
+		}
+	}
+	p.typ = m.ref(schema, opName+pascal(v.Name))
+	// Objects go on the wire however the caller encodes them.
+	if p.typ.kind == kMap || p.typ.kind == kUnion || p.typ.kind == kNamed && m.byName[p.typ.name].kind == dObject {
+		p.typ = &typ{kind: kString}
+	}
+	if p.typ.kind == kArray && (p.typ.elem.kind == kMap || p.typ.elem.kind == kNamed && m.byName[p.typ.elem.name].kind == dObject) {
+		p.typ.elem = &typ{kind: kString}
+	}
+	return p
+}
+
+// result is the type of the first 2xx JSON response.
+func (m *model) result(o *openapi3.Operation, opName string) *typ {
+	if o.Responses == nil {
+		return nil
+	}
+	codes := sortedKeys(o.Responses.Map())
+	for _, code := range codes {
+		if len(code) != 3 || code[0] != '2' {
+			continue
+		}
+		r := o.Responses.Value(code)
+		if r == nil || r.Value == nil {
+			continue
+		}
+		ct, media := pickMedia(r.Value.Content)
+		if media == nil || !isJSON(ct) || media.Schema == nil {
+			continue
+		}
+		return m.ref(media.Schema, opName+"Response")
+	}
+	return nil
+}
+
+// pickMedia prefers JSON among the content types.
+func pickMedia(content openapi3.Content) (string, *openapi3.MediaType) {
+	keys := sortedKeys(content)
+	for _, k := range keys {
+		if isJSON(k) {
+			return k, content[k]
+		}
+	}
+	if len(keys) > 0 {
+		return keys[0], content[keys[0]]
+	}
+	return "", nil
+}
+
+func isJSON(ct string) bool {
+	return strings.Contains(strings.ToLower(ct), "json")
+}
+
+// isReference reports whether t already has a zero value that means
+// "absent" (nil), so optional fields need no pointer.
+func (m *model) isReference(t *typ) bool {
+	switch t.kind {
+	case kAny, kArray, kMap, kUnion, kRaw:
+		return true
+	case kNamed:
+		d := m.byName[t.name]
+		return d != nil && d.kind == dAlias && m.isReference(d.alias)
+	}
+	return false
+}
+
+// object is the object decl t names, if it does.
+func (m *model) object(t *typ) *decl {
+	if t == nil || t.kind != kNamed {
+		return nil
+	}
+	d := m.byName[t.name]
+	if d == nil {
+		return nil
+	}
+	if d.kind == dAlias {
+		return m.object(d.alias)
+	}
+	if d.kind != dObject {
+		return nil
+	}
+	return d
+}
+
+// elemOf is the element type of an array type, through aliases.
+func (m *model) elemOf(t *typ) *typ {
+	for t != nil && t.kind == kNamed {
+		d := m.byName[t.name]
+		if d == nil || d.kind != dAlias {
+			return nil
+		}
+		t = d.alias
+	}
+	if t != nil && t.kind == kArray {
+		return t.elem
+	}
+	return nil
+}
+
+func firstLine(s string) string {
+	s = strings.TrimSpace(s)
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		s = strings.TrimSpace(s[:i])
+	}
+	return s
+}
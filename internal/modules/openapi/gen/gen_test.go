@@ -0,0 +1,167 @@
+package gen
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+const petSpec = `{
+  "openapi": "3.0.3",
+  "info": {"title": "Pet Store", "version": "1"},
+  "servers": [{"url": "https://pets.example.com/v1"}],
+  "components": {
+    "securitySchemes": {"bearer": {"type": "http", "scheme": "bearer"}, "key": {"type": "apiKey", "in": "query", "name": "api_key"}},
+    "schemas": {
+      "Status": {"type": "string", "enum": ["available", "sold"]},
+      "Pet": {"type": "object", "required": ["id", "name"], "properties": {
+        "id": {"type": "integer"}, "name": {"type": "string"}, "status": {"$ref": "#/components/schemas/Status"},
+        "owner": {"type": "object", "nullable": true, "properties": {"e-mail": {"type": "string"}}}}},
+      "PetPage": {"type": "object", "properties": {
+        "data": {"type": "array", "items": {"$ref": "#/components/schemas/Pet"}}, "next_cursor": {"type": "string"}}}
+    }
+  },
+  "paths": {
+    "/pets": {
+      "get": {"operationId": "listPets", "parameters": [{"name": "page", "in": "query", "schema": {"type": "integer"}}],
+        "responses": {"200": {"description": "ok", "content": {"application/json": {"schema": {"type": "array", "items": {"$ref": "#/components/schemas/Pet"}}}}}}},
+      "post": {"operationId": "create_pet", "requestBody": {"required": true, "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Pet"}}}},
+        "responses": {"201": {"description": "created", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Pet"}}}}}}
+    },
+    "/pets/search": {
+      "get": {"operationId": "searchPets", "parameters": [{"name": "q", "in": "query", "required": true, "schema": {"type": "string"}},
+        {"name": "cursor", "in": "query", "schema": {"type": "string"}}],
+        "responses": {"200": {"description": "ok", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/PetPage"}}}}}}
+    },
+    "/pets/{petId}": {
+      "delete": {"operationId": "deletePet", "parameters": [{"name": "petId", "in": "path", "required": true, "schema": {"type": "integer"}}],
+        "responses": {"204": {"description": "gone"}}}
+    }
+  }
+}`
+
+func loadPets(t *testing.T) *openapi3.T {
+	t.Helper()
+	doc, err := openapi3.NewLoader().LoadFromData([]byte(petSpec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return doc
+}
+
+func TestGoClientTypeChecks(t *testing.T) {
+	files, st, err := Client(loadPets(t), Options{Lang: "go"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Operations != 4 || st.Paginated != 2 {
+		t.Fatalf("stats = %+v", st)
+	}
+
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, files[0].Name, files[0].Data, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	pkg, err := conf.Check("petstore", fset, []*ast.File{f}, nil)
+	if err != nil {
+		t.Fatalf("%v\n%s", err, files[0].Data)
+	}
+	for _, name := range []string{"ListPets", "ListPetsAll", "CreatePet", "SearchPetsAll", "DeletePet"} {
+		if obj, _, _ := types.LookupFieldOrMethod(types.NewPointer(pkg.Scope().Lookup("Client").Type()), true, pkg, name); obj == nil {
+			t.Fatalf("Client has no %s", name)
+		}
+	}
+}
+
+func TestScriptClients(t *testing.T) {
+	doc := loadPets(t)
+	for lang, want := range map[string][]string{
+		"ts":     {"async listPetsAll(", "async searchPetsAll(params: SearchPetsParams)", `url.searchParams.set("api_key"`},
+		"python": {"def list_pets_all(self, *, page: Optional[int] = None)", "def create_pet(self, body: Pet) -> Pet:", `PetOwner = TypedDict("PetOwner", {"e-mail": "str"}, total=False)`},
+	} {
+		files, _, err := Client(doc, Options{Lang: lang})
+		if err != nil {
+			t.Fatal(err)
+		}
+		src := string(files[0].Data)
+		for _, w := range want {
+			if !strings.Contains(src, w) {
+				t.Fatalf("%s client lacks %q:\n%s", lang, w, src)
+			}
+		}
+	}
+}
+
+// TestScriptClientsCompile checks the script clients with the tools for
+// their language, where those are installed.
+func TestScriptClientsCompile(t *testing.T) {
+	doc := loadPets(t)
+	dir := t.TempDir()
+	for _, lang := range []string{"python", "ts"} {
+		files, _, err := Client(doc, Options{Lang: lang})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, files[0].Name), files[0].Data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("tsc", func(t *testing.T) {
+		tsc, err := exec.LookPath("tsc")
+		if err != nil {
+			t.Skip("tsc not installed")
+		}
+		out, err := exec.Command(tsc, "--noEmit", "--strict", "--target", "es2020", "--lib", "es2020,dom", filepath.Join(dir, "client.ts")).CombinedOutput()
+		if err != nil {
+			t.Fatalf("tsc: %v\n%s", err, out)
+		}
+	})
+
+	t.Run("python", func(t *testing.T) {
+		py, err := exec.LookPath("python3")
+		if err != nil {
+			t.Skip("python3 not installed")
+		}
+		if out, err := exec.Command(py, "-m", "py_compile", filepath.Join(dir, "client.py")).CombinedOutput(); err != nil {
+			t.Fatalf("py_compile: %v\n%s", err, out)
+		}
+
+		// A body that is not JSON is not decoded.
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodDelete {
+				w.Header().Set("Content-Type", "text/plain")
+				io.WriteString(w, "gone")
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			io.WriteString(w, `[{"id": 1, "name": "rex"}]`)
+		}))
+		defer srv.Close()
+		script := "import client\n" +
+			"c = client.Client(" + strconv.Quote(srv.URL) + ")\n" +
+			"c.delete_pet(1)\n" +
+			"print(c.list_pets()[0][\"name\"])\n"
+		cmd := exec.Command(py, "-c", script)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		if err != nil || strings.TrimSpace(string(out)) != "rex" {
+			t.Fatalf("python client: %v\n%s", err, out)
+		}
+	})
+}
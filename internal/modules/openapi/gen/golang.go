@@ -0,0 +1,557 @@
+package gen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"strconv"
+	"strings"
+)
+
+var goInitialisms = set("ID", "URL", "URI", "HTTP", "HTTPS", "API", "JSON", "UUID", "IP", "HTML", "XML", "SQL", "TLS", "TTL", "CPU", "DNS", "EOF", "SSH", "TCP", "UDP", "UI")
+
+var goReserved = set("break", "case", "chan", "const", "continue", "default", "defer", "else", "fallthrough",
+	"for", "func", "go", "goto", "if", "import", "interface", "map", "package", "range", "return", "select",
+	"struct", "switch", "type", "var",
+	// Names the generated methods use.
+	"c", "ctx", "params", "body", "path", "query", "header", "out", "err", "payload", "all", "page", "items", "p")
+
+// goName is s as an exported Go name, initialisms in capitals.
+func goName(s string) string {
+	var b strings.Builder
+	for _, w := range words(s) {
+		if up := strings.ToUpper(w); goInitialisms[up] {
+			b.WriteString(up)
+		} else {
+			b.WriteString(title(w))
+		}
+	}
+	return leadingLetter(b.String(), "X")
+}
+
+func goLocal(s string) string {
+	ws := words(s)
+	if len(ws) == 0 {
+		return "x"
+	}
+	n := strings.ToLower(ws[0])
+	if len(ws) > 1 {
+		n += goName(strings.Join(ws[1:], " "))
+	}
+	return avoid(leadingLetter(n, "x"), goReserved, "_")
+}
+
+// goFieldNames are the struct field names for fields, in order.
+func goFieldNames(names []string) []string {
+	seen := namer{}
+	out := make([]string, len(names))
+	for i, n := range names {
+		out[i] = seen.unique(goName(n))
+	}
+	return out
+}
+
+func paramNames(ps []param) []string {
+	names := make([]string, len(ps))
+	for i, p := range ps {
+		names[i] = p.name
+	}
+	return names
+}
+
+func fieldNames(fs []field) []string {
+	names := make([]string, len(fs))
+	for i, f := range fs {
+		names[i] = f.name
+	}
+	return names
+}
+
+type goWriter struct {
+	m *model
+	b bytes.Buffer
+}
+
+func (w *goWriter) raw(s string) {
+	w.b.WriteString(s)
+	w.b.WriteByte('\n')
+}
+
+func (w *goWriter) p(format string, args ...any) {
+	fmt.Fprintf(&w.b, format, args...)
+	w.b.WriteByte('\n')
+}
+
+func (w *goWriter) typ(t *typ) string {
+	switch t.kind {
+	case kNamed:
+		return goName(t.name)
+	case kString:
+		return "string"
+	case kInt:
+		return "int64"
+	case kInt32:
+		return "int32"
+	case kNumber:
+		return "float64"
+	case kBool:
+		return "bool"
+	case kArray:
+		return "[]" + w.typ(t.elem)
+	case kMap:
+		return "map[string]" + w.typ(t.elem)
+	case kUnion:
+		// One of several shapes: the caller decodes it.
+		return "json.RawMessage"
+	case kRaw:
+		return "[]byte"
+	}
+	return "any"
+}
+
+// slot is the type of a field or parameter: optional and nullable
+// values are pointers unless their zero value is already nil.
+func (w *goWriter) slot(t *typ, required bool) string {
+	s := w.typ(t)
+	if (!required || t.nullable) && !w.m.isReference(t) {
+		return "*" + s
+	}
+	return s
+}
+
+func goComment(w *goWriter, indent, text string) {
+	if text != "" {
+		w.p("%s// %s", indent, text)
+	}
+}
+
+func goClient(m *model, pkg string) ([]File, error) {
+	w := &goWriter{m: m}
+	w.p("// Code generated by restless gen client; DO NOT EDIT.")
+	w.p("")
+	w.p("// Package %s is a client for %s.", pkg, strings.TrimSpace(m.title+" "+m.version))
+	w.p("package %s", pkg)
+	w.p("")
+	w.p("import (\n\"bytes\"\n\"context\"\n\"encoding/json\"\n\"fmt\"\n\"io\"\n\"net/http\"\n\"net/url\"\n\"strings\"\n)")
+	w.p("")
+	w.p("// DefaultBaseURL is the first server the spec declares.")
+	w.p("const DefaultBaseURL = %s", strconv.Quote(m.baseURL))
+	w.p("")
+
+	for _, d := range m.decls {
+		w.decl(d)
+	}
+	w.runtime()
+	for _, op := range m.ops {
+		w.operation(op)
+	}
+
+	src, err := format.Source(w.b.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("generated Go does not parse: %w", err)
+	}
+	return []File{{Name: "client.go", Data: src}}, nil
+}
+
+func (w *goWriter) decl(d *decl) {
+	name := goName(d.name)
+	goComment(w, "", d.doc)
+	switch d.kind {
+	case dObject:
+		w.p("type %s struct {", name)
+		for i, fn := range goFieldNames(fieldNames(d.fields)) {
+			f := d.fields[i]
+			goComment(w, "\t", f.doc)
+			tag := f.name
+			if !f.required {
+				tag += ",omitempty"
+			}
+			w.p("\t%s %s `json:%s`", fn, w.slot(f.typ, f.required), strconv.Quote(tag))
+		}
+		w.p("}")
+	case dEnum:
+		w.p("type %s string", name)
+		w.p("")
+		w.p("const (")
+		seen := namer{}
+		for _, v := range d.enum {
+			c := name + goName(v)
+			if goName(v) == "X" {
+				c = name + "Empty"
+			}
+			w.p("\t%s %s = %s", seen.unique(c), name, strconv.Quote(v))
+		}
+		w.p(")")
+	case dAlias:
+		w.p("type %s = %s", name, w.typ(d.alias))
+	}
+	w.p("")
+}
+
+func (w *goWriter) runtime() {
+	w.p("// Client calls the API. Credentials that are set are sent with every request.")
+	w.p("type Client struct {")
+	w.p("\tBaseURL    string")
+	w.p("\tHTTPClient *http.Client")
+	w.p("\t// Header is sent with every request.")
+	w.p("\tHeader http.Header")
+	basic := false
+	for _, s := range w.m.schemes {
+		w.p("")
+		doc := s.doc
+		switch s.kind {
+		case "bearer":
+			w.p("\t// %s is sent as a bearer token (%s).", w.schemeField(s), s.name)
+			goComment(w, "\t", doc)
+			w.p("\t%s string", w.schemeField(s))
+		case "basic":
+			basic = true
+			w.p("\t// %s is sent as HTTP basic auth (%s).", w.schemeField(s), s.name)
+			goComment(w, "\t", doc)
+			w.p("\t%s *BasicAuth", w.schemeField(s))
+		case "apiKey":
+			w.p("\t// %s is sent in the %s %s (%s).", w.schemeField(s), s.param, s.in, s.name)
+			goComment(w, "\t", doc)
+			w.p("\t%s string", w.schemeField(s))
+		}
+	}
+	w.p("}")
+	w.p("")
+	if basic {
+		w.p("// BasicAuth is a user name and password for HTTP basic auth.")
+		w.p("type BasicAuth struct {\n\tUsername string\n\tPassword string\n}")
+		w.p("")
+	}
+	w.raw(`// NewClient returns a client for baseURL, or DefaultBaseURL when it is empty.
+func NewClient(baseURL string) *Client {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	return &Client{BaseURL: strings.TrimRight(baseURL, "/"), HTTPClient: http.DefaultClient, Header: http.Header{}}
+}
+
+// APIError is a response with a status outside 2xx.
+type APIError struct {
+	StatusCode int
+	Body       []byte
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), bytes.TrimSpace(e.Body))
+}
+
+// do sends one request and decodes a JSON response into out, if given.
+// A []byte body is sent as is, anything else as JSON.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, header http.Header, contentType string, body, out any) error {
+	var rd io.Reader
+	switch b := body.(type) {
+	case nil:
+	case []byte:
+		rd = bytes.NewReader(b)
+	default:
+		buf, err := json.Marshal(b)
+		if err != nil {
+			return err
+		}
+		rd = bytes.NewReader(buf)
+	}`)
+	for _, s := range w.m.schemes {
+		if s.kind == "apiKey" && s.in == "query" {
+			w.p("\tif c.%s != \"\" {\n\t\tquery.Set(%s, c.%s)\n\t}", w.schemeField(s), strconv.Quote(s.param), w.schemeField(s))
+		}
+	}
+	w.raw(`	u := c.BaseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, rd)
+	if err != nil {
+		return err
+	}
+	for k, vs := range c.Header {
+		req.Header[k] = vs
+	}
+	for k, vs := range header {
+		req.Header[k] = vs
+	}
+	if rd != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+	req.Header.Set("Accept", "application/json")`)
+	for _, s := range w.m.schemes {
+		f := w.schemeField(s)
+		switch {
+		case s.kind == "bearer":
+			w.p("\tif c.%s != \"\" {\n\t\treq.Header.Set(\"Authorization\", \"Bearer \"+c.%s)\n\t}", f, f)
+		case s.kind == "basic":
+			w.p("\tif c.%s != nil {\n\t\treq.SetBasicAuth(c.%s.Username, c.%s.Password)\n\t}", f, f, f)
+		case s.in == "header":
+			w.p("\tif c.%s != \"\" {\n\t\treq.Header.Set(%s, c.%s)\n\t}", f, strconv.Quote(s.param), f)
+		case s.in == "cookie":
+			w.p("\tif c.%s != \"\" {\n\t\treq.AddCookie(&http.Cookie{Name: %s, Value: c.%s})\n\t}", f, strconv.Quote(s.param), f)
+		}
+	}
+	w.raw(`	hc := c.HTTPClient
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &APIError{StatusCode: resp.StatusCode, Body: data}
+	}
+	if out == nil || len(bytes.TrimSpace(data)) == 0 {
+		return nil
+	}
+	return json.Unmarshal(data, out)
+}
+`)
+}
+
+var goClientFields = set("BaseURL", "HTTPClient", "Header")
+
+func (w *goWriter) schemeField(s scheme) string {
+	return avoid(goName(s.name), goClientFields, "Auth")
+}
+
+// args are an operation's parameters in the Go signature.
+type goArgs struct {
+	path   []string // local names of the path parameters
+	params string   // params type, "" if none
+	byRef  bool     // params passed as a pointer
+}
+
+func (w *goWriter) args(op *operation) goArgs {
+	a := goArgs{}
+	seen := namer{}
+	for _, p := range op.pathParams {
+		a.path = append(a.path, seen.unique(goLocal(p.name)))
+	}
+	if len(op.params) > 0 {
+		a.params = goName(op.name) + "Params"
+		a.byRef = !op.paramsRequired()
+	}
+	return a
+}
+
+func (w *goWriter) operation(op *operation) {
+	name := goName(op.name)
+	a := w.args(op)
+
+	if len(op.params) > 0 {
+		w.p("// %s are the query and header parameters of %s.", a.params, name)
+		w.p("type %s struct {", a.params)
+		for i, fn := range goFieldNames(paramNames(op.params)) {
+			p := op.params[i]
+			goComment(w, "\t", p.doc)
+			w.p("\t%s %s // %s %s", fn, w.slot(p.typ, p.required), p.in, p.name)
+		}
+		w.p("}")
+		w.p("")
+	}
+
+	sig := []string{"ctx context.Context"}
+	for i, p := range op.pathParams {
+		sig = append(sig, a.path[i]+" "+w.typ(p.typ))
+	}
+	if a.params != "" {
+		ptr := ""
+		if a.byRef {
+			ptr = "*"
+		}
+		sig = append(sig, "params "+ptr+a.params)
+	}
+	if op.body != nil {
+		sig = append(sig, "body "+w.slot(op.body, op.bodyRequired))
+	}
+	ret := "error"
+	if op.result != nil {
+		ret = "(" + w.result(op.result) + ", error)"
+	}
+
+	w.p("// %s calls %s %s.", name, op.method, op.path)
+	if op.doc != "" {
+		w.p("//\n// %s", op.doc)
+	}
+	w.p("func (c *Client) %s(%s) %s {", name, strings.Join(sig, ", "), ret)
+	w.p("\tpath := %s", strconv.Quote(op.path))
+	for i, p := range op.pathParams {
+		w.p("\tpath = strings.ReplaceAll(path, %s, url.PathEscape(fmt.Sprint(%s)))", strconv.Quote("{"+p.name+"}"), a.path[i])
+	}
+	w.p("\tquery := url.Values{}")
+	w.p("\theader := http.Header{}")
+	if a.params != "" {
+		indent := "\t"
+		if a.byRef {
+			w.p("\tif params != nil {")
+			indent = "\t\t"
+		}
+		for i, fn := range goFieldNames(paramNames(op.params)) {
+			w.setParam(indent, op.params[i], "params."+fn)
+		}
+		if a.byRef {
+			w.p("\t}")
+		}
+	}
+	body := "nil"
+	if op.body != nil {
+		body = "body"
+		if w.slot(op.body, op.bodyRequired)[0] == '*' {
+			// A nil pointer is no body, not a JSON null.
+			w.p("\tvar payload any\n\tif body != nil {\n\t\tpayload = body\n\t}")
+			body = "payload"
+		}
+	}
+	call := fmt.Sprintf("c.do(ctx, %s, path, query, header, %s, %s, ", strconv.Quote(op.method), strconv.Quote(op.contentType), body)
+	if op.result == nil {
+		w.p("\treturn %snil)", call)
+		w.p("}")
+		w.p("")
+		w.pager(op, a)
+		return
+	}
+	w.p("\tvar out %s", w.typ(op.result))
+	if strings.HasPrefix(w.result(op.result), "*") {
+		w.p("\tif err := %s&out); err != nil {\n\t\treturn nil, err\n\t}", call)
+		w.p("\treturn &out, nil")
+	} else {
+		w.p("\terr := %s&out)", call)
+		w.p("\treturn out, err")
+	}
+	w.p("}")
+	w.p("")
+	w.pager(op, a)
+}
+
+func (w *goWriter) result(t *typ) string {
+	if w.m.isReference(t) {
+		return w.typ(t)
+	}
+	return "*" + w.typ(t)
+}
+
+func (w *goWriter) setParam(indent string, p param, expr string) {
+	add := "query.Add"
+	set := "query.Set"
+	if p.in == "header" {
+		add, set = "header.Add", "header.Set"
+	}
+	key := strconv.Quote(p.name)
+	switch {
+	case p.typ.kind == kArray:
+		w.p("%sfor _, v := range %s {\n%s\t%s(%s, fmt.Sprint(v))\n%s}", indent, expr, indent, add, key, indent)
+	case w.m.isReference(p.typ):
+		w.p("%sif %s != nil {\n%s\t%s(%s, fmt.Sprint(%s))\n%s}", indent, expr, indent, set, key, expr, indent)
+	case w.slot(p.typ, p.required)[0] == '*':
+		w.p("%sif %s != nil {\n%s\t%s(%s, fmt.Sprint(*%s))\n%s}", indent, expr, indent, set, key, expr, indent)
+	default:
+		w.p("%s%s(%s, fmt.Sprint(%s))", indent, set, key, expr)
+	}
+}
+
+func (w *goWriter) pager(op *operation, a goArgs) {
+	pg := op.pager
+	if pg == nil {
+		return
+	}
+	name := goName(op.name)
+	elem := w.typ(pg.elem)
+	pname := goFieldNames(paramNames(op.params))[indexOf(paramNames(op.params), pg.param.name)]
+	ptr := w.slot(pg.param.typ, pg.param.required)[0] == '*'
+
+	sig := []string{"ctx context.Context"}
+	call := []string{"ctx"}
+	for i, p := range op.pathParams {
+		sig = append(sig, a.path[i]+" "+w.typ(p.typ))
+		call = append(call, a.path[i])
+	}
+	if a.byRef {
+		sig = append(sig, "params *"+a.params)
+		call = append(call, "&p")
+	} else {
+		sig = append(sig, "params "+a.params)
+		call = append(call, "p")
+	}
+
+	w.p("// %sAll calls %s for every page and returns the items of all of them,", name, name)
+	switch pg.style {
+	case "page":
+		w.p("// moving the %s parameter on one page at a time until a page comes back empty.", pg.param.name)
+	case "offset":
+		w.p("// moving the %s parameter past the items seen until a page comes back empty.", pg.param.name)
+	case "cursor":
+		w.p("// passing each page's %s as the %s parameter until there is none.", pg.next, pg.param.name)
+	}
+	w.p("func (c *Client) %sAll(%s) ([]%s, error) {", name, strings.Join(sig, ", "), elem)
+	if a.byRef {
+		w.p("\tvar p %s\n\tif params != nil {\n\t\tp = *params\n\t}", a.params)
+	} else {
+		w.p("\tp := params")
+	}
+	w.p("\tvar all []%s", elem)
+	w.p("\tfor {")
+	w.p("\t\tpage, err := c.%s(%s)", name, strings.Join(call, ", "))
+	w.p("\t\tif err != nil {\n\t\t\treturn all, err\n\t\t}")
+	items := "page"
+	if pg.items != "" {
+		obj := w.m.object(op.result)
+		items = "page." + goFieldNames(fieldNames(obj.fields))[indexOf(fieldNames(obj.fields), pg.items)]
+	}
+	w.p("\t\tif len(%s) == 0 {\n\t\t\treturn all, nil\n\t\t}", items)
+	w.p("\t\tall = append(all, %s...)", items)
+
+	ptype := w.typ(pg.param.typ)
+	switch pg.style {
+	case "page", "offset":
+		step := " += " + ptype + "(len(" + items + "))"
+		if pg.style == "page" {
+			step = "++"
+		}
+		if ptr {
+			w.p("\t\tn := %s(%d)", ptype, pg.start)
+			w.p("\t\tif p.%s != nil {\n\t\t\tn = *p.%s\n\t\t}", pname, pname)
+			w.p("\t\tn%s", step)
+			w.p("\t\tp.%s = &n", pname)
+		} else {
+			if pg.style == "page" {
+				w.p("\t\tif p.%s == 0 {\n\t\t\tp.%s = %d\n\t\t}", pname, pname, pg.start)
+			}
+			w.p("\t\tp.%s%s", pname, step)
+		}
+	case "cursor":
+		obj := w.m.object(op.result)
+		nf := obj.fields[indexOf(fieldNames(obj.fields), pg.next)]
+		next := "page." + goFieldNames(fieldNames(obj.fields))[indexOf(fieldNames(obj.fields), pg.next)]
+		if w.slot(nf.typ, nf.required)[0] == '*' {
+			w.p("\t\tif %s == nil || *%s == \"\" {\n\t\t\treturn all, nil\n\t\t}", next, next)
+			next = "*" + next
+		} else {
+			w.p("\t\tif %s == \"\" {\n\t\t\treturn all, nil\n\t\t}", next)
+		}
+		if ptr {
+			w.p("\t\tcursor := %s", next)
+			w.p("\t\tp.%s = &cursor", pname)
+		} else {
+			w.p("\t\tp.%s = %s", pname, next)
+		}
+	}
+	w.p("\t}")
+	w.p("}")
+	w.p("")
+}
+
+func indexOf(names []string, name string) int {
+	for i, n := range names {
+		if n == name {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,126 @@
+package gen
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// words splits a name as specs write them (get_order, listPets,
+// x-rate-limit, HTTPServer) into its words.
+func words(s string) []string {
+	var out []string
+	for _, part := range strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		rs := []rune(part)
+		start := 0
+		for i := 1; i < len(rs); i++ {
+			prev, cur := rs[i-1], rs[i]
+			next := rune(0)
+			if i+1 < len(rs) {
+				next = rs[i+1]
+			}
+			if unicode.IsLower(prev) && unicode.IsUpper(cur) ||
+				unicode.IsUpper(prev) && unicode.IsUpper(cur) && unicode.IsLower(next) {
+				out = append(out, string(rs[start:i]))
+				start = i
+			}
+		}
+		out = append(out, string(rs[start:]))
+	}
+	return out
+}
+
+func title(w string) string {
+	if w == "" {
+		return w
+	}
+	rs := []rune(strings.ToLower(w))
+	rs[0] = unicode.ToUpper(rs[0])
+	return string(rs)
+}
+
+// pascal is s as an exported-style name: get_order → GetOrder.
+func pascal(s string) string {
+	var b strings.Builder
+	for _, w := range words(s) {
+		b.WriteString(title(w))
+	}
+	return leadingLetter(b.String(), "X")
+}
+
+// camel is s as a local-style name: get_order → getOrder.
+func camel(s string) string {
+	p := pascal(s)
+	rs := []rune(p)
+	rs[0] = unicode.ToLower(rs[0])
+	return string(rs)
+}
+
+// snake is s as a Python-style name: getOrder → get_order.
+func snake(s string) string {
+	ws := words(s)
+	for i, w := range ws {
+		ws[i] = strings.ToLower(w)
+	}
+	return leadingLetter(strings.Join(ws, "_"), "x_")
+}
+
+func leadingLetter(s, prefix string) string {
+	if s == "" {
+		return strings.TrimRight(prefix, "_")
+	}
+	if r := []rune(s)[0]; !unicode.IsLetter(r) {
+		return prefix + s
+	}
+	return s
+}
+
+// isIdent reports whether s can be written as a bare identifier (and
+// property name) in all three target languages.
+func isIdent(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, r := range s {
+		if r == '_' || r < unicode.MaxASCII && unicode.IsLetter(r) || i > 0 && unicode.IsDigit(r) {
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+// namer hands out names that are unique within one scope.
+type namer map[string]bool
+
+func (n namer) unique(name string) string {
+	if !n[name] {
+		n[name] = true
+		return name
+	}
+	for i := 2; ; i++ {
+		c := name + strconv.Itoa(i)
+		if !n[c] {
+			n[c] = true
+			return c
+		}
+	}
+}
+
+// avoid appends suffix to name while it is a reserved word.
+func avoid(name string, reserved map[string]bool, suffix string) string {
+	for reserved[name] {
+		name += suffix
+	}
+	return name
+}
+
+func set(ws ...string) map[string]bool {
+	m := map[string]bool{}
+	for _, w := range ws {
+		m[w] = true
+	}
+	return m
+}
@@ -0,0 +1,116 @@
+package gen
+
+import (
+	"strings"
+)
+
+// pager is how a list operation pages: the query parameter that moves
+// through the list, where the items are in a page and, for cursors,
+// where the next cursor is.
+type pager struct {
+	style string // page, offset or cursor
+	param param
+	start int64  // page: the first page when the caller gives none
+	items string // field holding the items; "" when a page is the array
+	elem  *typ
+	next  string // cursor: field holding the next cursor
+}
+
+var (
+	pageParams   = set("page", "page_number", "pagenumber", "pageno")
+	offsetParams = set("offset", "skip", "start")
+	cursorParams = set("cursor", "after", "page_token", "pagetoken", "next_token", "nexttoken",
+		"starting_after", "continuation", "continuation_token", "continuationtoken")
+	// Fields a page keeps its items in, most telling first.
+	itemFields = []string{"data", "items", "results", "records", "entries", "values", "elements", "content"}
+	// Fields a page keeps the next cursor in, most telling first.
+	nextFields = []string{"next_cursor", "nextcursor", "next_page_token", "nextpagetoken", "next_token",
+		"nexttoken", "end_cursor", "endcursor", "cursor", "next", "after"}
+)
+
+// detectPager recognizes list operations that page by a page number, an
+// offset or a cursor.
+func (m *model) detectPager(op *operation) *pager {
+	if op.method != "GET" || op.result == nil || op.body != nil {
+		return nil
+	}
+	pg := &pager{}
+	if pg.elem = m.elemOf(op.result); pg.elem == nil {
+		obj := m.object(op.result)
+		if obj == nil {
+			return nil
+		}
+		pg.items, pg.elem = m.itemsField(obj)
+		if pg.elem == nil {
+			return nil
+		}
+	}
+
+	for _, p := range op.params {
+		if p.in != "query" || p.typ.nullable {
+			continue
+		}
+		key := strings.ToLower(p.name)
+		switch {
+		case pageParams[key] && isInt(p.typ):
+			pg.style, pg.param, pg.start = "page", p, 1
+		case offsetParams[key] && isInt(p.typ):
+			pg.style, pg.param = "offset", p
+		case cursorParams[key] && p.typ.kind == kString:
+			obj := m.object(op.result)
+			if obj == nil {
+				continue
+			}
+			if pg.next = nextField(obj); pg.next == "" {
+				continue
+			}
+			pg.style, pg.param = "cursor", p
+		default:
+			continue
+		}
+		return pg
+	}
+	return nil
+}
+
+func isInt(t *typ) bool {
+	return t.kind == kInt || t.kind == kInt32
+}
+
+func (m *model) itemsField(obj *decl) (string, *typ) {
+	arrays := map[string]*typ{}
+	var first string
+	for _, f := range obj.fields {
+		if e := m.elemOf(f.typ); e != nil && !f.typ.nullable {
+			arrays[strings.ToLower(f.name)] = e
+			if first == "" {
+				first = f.name
+			}
+		}
+	}
+	for _, name := range itemFields {
+		if e, ok := arrays[name]; ok {
+			for _, f := range obj.fields {
+				if strings.ToLower(f.name) == name {
+					return f.name, e
+				}
+			}
+		}
+	}
+	// A page with a single list in it.
+	if len(arrays) == 1 {
+		return first, arrays[strings.ToLower(first)]
+	}
+	return "", nil
+}
+
+func nextField(obj *decl) string {
+	for _, name := range nextFields {
+		for _, f := range obj.fields {
+			if strings.ToLower(f.name) == name && f.typ.kind == kString {
+				return f.name
+			}
+		}
+	}
+	return ""
+}
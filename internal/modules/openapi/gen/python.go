@@ -0,0 +1,369 @@
+package gen
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+var pyReserved = set("False", "None", "True", "and", "as", "assert", "async", "await", "break", "class",
+	"continue", "def", "del", "elif", "else", "except", "finally", "for", "from", "global", "if", "import",
+	"in", "is", "lambda", "nonlocal", "not", "or", "pass", "raise", "return", "try", "while", "with", "yield",
+	// Names the generated methods use.
+	"self", "body")
+
+type pyWriter struct {
+	m *model
+	b bytes.Buffer
+}
+
+func (w *pyWriter) raw(s string) {
+	w.b.WriteString(s)
+	w.b.WriteByte('\n')
+}
+
+func (w *pyWriter) p(format string, args ...any) {
+	fmt.Fprintf(&w.b, format, args...)
+	w.b.WriteByte('\n')
+}
+
+// typ is t as a Python annotation. Named types are quoted where the
+// annotation is evaluated (type aliases), which is fine for forward
+// references anywhere.
+func (w *pyWriter) typ(t *typ, quote bool) string {
+	s := w.bare(t, quote)
+	if t.nullable && s != "Any" {
+		s = "Optional[" + s + "]"
+	}
+	return s
+}
+
+func (w *pyWriter) bare(t *typ, quote bool) string {
+	switch t.kind {
+	case kNamed:
+		if quote {
+			return jsString(pascal(t.name))
+		}
+		return pascal(t.name)
+	case kString:
+		return "str"
+	case kInt, kInt32:
+		return "int"
+	case kNumber:
+		return "float"
+	case kBool:
+		return "bool"
+	case kRaw:
+		return "bytes"
+	case kArray:
+		return "List[" + w.typ(t.elem, quote) + "]"
+	case kMap:
+		return "Dict[str, " + w.typ(t.elem, quote) + "]"
+	case kUnion:
+		alts := make([]string, len(t.alts))
+		for i, a := range t.alts {
+			alts[i] = w.typ(a, quote)
+		}
+		return "Union[" + strings.Join(alts, ", ") + "]"
+	}
+	return "Any"
+}
+
+func pyName(s string) string {
+	return avoid(snake(s), pyReserved, "_")
+}
+
+func pyClient(m *model) []File {
+	w := &pyWriter{m: m}
+	w.p("# Code generated by restless gen client; DO NOT EDIT.")
+	w.p(`"""Client for %s."""`, strings.ReplaceAll(strings.TrimSpace(m.title+" "+m.version), `"""`, `'''`))
+	w.p("")
+	w.p("from __future__ import annotations")
+	w.p("")
+	w.p("import base64\nimport json\nimport urllib.error\nimport urllib.parse\nimport urllib.request")
+	w.p("from typing import Any, Dict, List, Literal, Optional, Tuple, TypedDict, Union")
+	w.p("")
+	w.p("DEFAULT_BASE_URL = %s", jsString(m.baseURL))
+	for _, d := range m.decls {
+		w.decl(d)
+	}
+	w.runtime()
+	for _, op := range m.ops {
+		w.operation(op)
+	}
+	init := []byte("# Code generated by restless gen client; DO NOT EDIT.\nfrom .client import *  # noqa: F401,F403\n")
+	return []File{{Name: "client.py", Data: w.b.Bytes()}, {Name: "__init__.py", Data: init}}
+}
+
+func (w *pyWriter) decl(d *decl) {
+	name := pascal(d.name)
+	w.p("\n")
+	switch d.kind {
+	case dEnum:
+		vals := make([]string, len(d.enum))
+		for i, v := range d.enum {
+			vals[i] = jsString(v)
+		}
+		if len(vals) == 0 {
+			w.p("%s = str", name)
+		} else {
+			w.p("%s = Literal[%s]", name, strings.Join(vals, ", "))
+		}
+	case dAlias:
+		w.p("%s = %s", name, w.typ(d.alias, true))
+	case dObject:
+		idents := true
+		var req, opt []field
+		for _, f := range d.fields {
+			idents = idents && isIdent(f.name) && !pyReserved[f.name]
+			if f.required {
+				req = append(req, f)
+			} else {
+				opt = append(opt, f)
+			}
+		}
+		if !idents {
+			// Keys that are not identifiers need the functional form,
+			// which cannot mix required and optional keys.
+			var kvs []string
+			for _, f := range d.fields {
+				kvs = append(kvs, jsString(f.name)+": "+jsString(w.typ(f.typ, false)))
+			}
+			w.p("%s = TypedDict(%s, {%s}, total=False)", name, jsString(name), strings.Join(kvs, ", "))
+			return
+		}
+		base := "TypedDict"
+		if len(req) > 0 && len(opt) > 0 {
+			base = "_" + name + "Required"
+			w.p("class %s(TypedDict):", base)
+			for _, f := range req {
+				w.p("    %s: %s", f.name, w.typ(f.typ, false))
+			}
+			w.p("\n")
+			req = nil
+		}
+		total := ""
+		if len(opt) > 0 {
+			total = ", total=False"
+		}
+		w.p("class %s(%s%s):", name, base, total)
+		if d.doc != "" {
+			w.p(`    """%s"""`, strings.ReplaceAll(d.doc, `"""`, `'''`))
+		}
+		for _, f := range append(req, opt...) {
+			w.p("    %s: %s", f.name, w.typ(f.typ, false))
+		}
+		if d.doc == "" && len(d.fields) == 0 {
+			w.p("    pass")
+		}
+	}
+}
+
+func (w *pyWriter) schemeArg(s scheme) string {
+	return avoid(pyName(s.name), set("base_url", "headers", "timeout"), "_auth")
+}
+
+func (w *pyWriter) runtime() {
+	w.raw(`
+
+class ApiError(Exception):
+    """A response with a status outside 2xx."""
+
+    def __init__(self, status: int, body: str) -> None:
+        super().__init__(f"{status}: {body}")
+        self.status = status
+        self.body = body
+
+
+def _str(v: Any) -> str:
+    if isinstance(v, bool):
+        return "true" if v else "false"
+    return str(v)
+
+
+class Client:
+    """Calls the API. Credentials that are set are sent with every request."""
+`)
+	args := []string{"self", "base_url: str = DEFAULT_BASE_URL", "*", "headers: Optional[Dict[str, str]] = None"}
+	for _, s := range w.m.schemes {
+		t := "Optional[str]"
+		if s.kind == "basic" {
+			t = "Optional[Tuple[str, str]]"
+		}
+		args = append(args, w.schemeArg(s)+": "+t+" = None")
+	}
+	args = append(args, "timeout: float = 30.0")
+	w.p("    def __init__(%s) -> None:", strings.Join(args, ", "))
+	w.raw(`        self.base_url = base_url.rstrip("/")`)
+	w.p("        self.headers = dict(headers or {})")
+	for _, s := range w.m.schemes {
+		w.p("        self.%s = %s", w.schemeArg(s), w.schemeArg(s))
+	}
+	w.p("        self.timeout = timeout")
+	w.raw(`
+    def _request(self, method: str, path: str, query: Dict[str, Any], headers: Dict[str, Any],
+                 content_type: str, body: Any = None) -> Any:
+        params = []
+        for k, v in query.items():
+            if v is None:
+                continue
+            for item in v if isinstance(v, list) else [v]:
+                params.append((k, _str(item)))
+        h = {"Accept": "application/json", **self.headers}
+        h.update({k: _str(v) for k, v in headers.items() if v is not None})`)
+	for _, s := range w.m.schemes {
+		a := "self." + w.schemeArg(s)
+		w.p("        if %s:", a)
+		switch {
+		case s.kind == "bearer":
+			w.p(`            h["Authorization"] = "Bearer " + %s`, a)
+		case s.kind == "basic":
+			w.p(`            h["Authorization"] = "Basic " + base64.b64encode(":".join(%s).encode()).decode()`, a)
+		case s.in == "header":
+			w.p("            h[%s] = %s", jsString(s.param), a)
+		case s.in == "query":
+			w.p("            params.append((%s, %s))", jsString(s.param), a)
+		case s.in == "cookie":
+			w.p(`            h["Cookie"] = %s + "=" + urllib.parse.quote(%s)`, jsString(s.param), a)
+		}
+	}
+	w.raw(`        url = self.base_url + path
+        if params:
+            url += "?" + urllib.parse.urlencode(params)
+        data = None
+        if body is not None:
+            h["Content-Type"] = content_type
+            data = body if isinstance(body, bytes) else json.dumps(body).encode()
+        req = urllib.request.Request(url, data=data, headers=h, method=method)
+        try:
+            with urllib.request.urlopen(req, timeout=self.timeout) as resp:
+                text = resp.read().decode()
+                ctype = resp.headers.get("Content-Type", "")
+        except urllib.error.HTTPError as e:
+            raise ApiError(e.code, e.read().decode()) from None
+        if not text.strip():
+            return None
+        # Only JSON is decoded; anything else comes back as text.
+        return json.loads(text) if "json" in ctype.lower() else text`)
+}
+
+// args are an operation's arguments: path parameters and the body
+// positional, query and header parameters by keyword.
+func (w *pyWriter) args(op *operation) (args, path, params []string) {
+	seen := namer{}
+	args = []string{"self"}
+	for _, p := range op.pathParams {
+		n := seen.unique(pyName(p.name))
+		path = append(path, n)
+		args = append(args, n+": "+w.typ(p.typ, false))
+	}
+	if op.body != nil {
+		seen["body"] = true
+		if op.bodyRequired {
+			args = append(args, "body: "+w.typ(op.body, false))
+		} else {
+			args = append(args, "body: Optional["+w.bare(op.body, false)+"] = None")
+		}
+	}
+	if len(op.params) > 0 {
+		args = append(args, "*")
+	}
+	for _, p := range op.params {
+		n := seen.unique(pyName(p.name))
+		params = append(params, n)
+		if p.required {
+			args = append(args, n+": "+w.typ(p.typ, false))
+		} else {
+			args = append(args, n+": Optional["+w.bare(p.typ, false)+"] = None")
+		}
+	}
+	return args, path, params
+}
+
+func (w *pyWriter) operation(op *operation) {
+	args, path, params := w.args(op)
+	ret := "None"
+	if op.result != nil {
+		ret = w.typ(op.result, false)
+	}
+	name := pyName(op.name)
+	w.p("")
+	w.p("    def %s(%s) -> %s:", name, strings.Join(args, ", "), ret)
+	doc := op.method + " " + op.path
+	if op.doc != "" {
+		doc += " — " + op.doc
+	}
+	w.p(`        """%s"""`, strings.ReplaceAll(doc, `"""`, `'''`))
+	expr := jsString(op.path)
+	for i, p := range op.pathParams {
+		expr += fmt.Sprintf(".replace(%s, urllib.parse.quote(_str(%s), safe=\"\"))", jsString("{"+p.name+"}"), path[i])
+	}
+	w.p("        _path = %s", expr)
+	var query, headers []string
+	for i, p := range op.params {
+		kv := jsString(p.name) + ": " + params[i]
+		if p.in == "header" {
+			headers = append(headers, kv)
+		} else {
+			query = append(query, kv)
+		}
+	}
+	body := ""
+	if op.body != nil {
+		body = ", body"
+	}
+	call := fmt.Sprintf("self._request(%s, _path, {%s}, {%s}, %s%s)",
+		jsString(op.method), strings.Join(query, ", "), strings.Join(headers, ", "), jsString(op.contentType), body)
+	if op.result == nil {
+		w.p("        %s", call)
+	} else {
+		w.p("        return %s", call)
+	}
+	w.pager(op, args, path, params)
+}
+
+func (w *pyWriter) pager(op *operation, args, path, params []string) {
+	pg := op.pager
+	if pg == nil {
+		return
+	}
+	name := pyName(op.name)
+	pvar := params[indexOf(paramNames(op.params), pg.param.name)]
+	var call []string
+	call = append(call, path...)
+	for _, p := range params {
+		call = append(call, p+"="+p)
+	}
+	w.p("")
+	w.p("    def %s_all(%s) -> List[%s]:", name, strings.Join(args, ", "), w.typ(pg.elem, false))
+	switch pg.style {
+	case "page":
+		w.p(`        """Every item of %s, moving %s on a page at a time until a page comes back empty."""`, name, pg.param.name)
+	case "offset":
+		w.p(`        """Every item of %s, moving %s past the items seen until a page comes back empty."""`, name, pg.param.name)
+	case "cursor":
+		w.p(`        """Every item of %s, passing each page's %s as %s until there is none."""`, name, pg.next, pg.param.name)
+	}
+	w.p("        _out: List[%s] = []", w.typ(pg.elem, false))
+	w.p("        while True:")
+	w.p("            _page = self.%s(%s)", name, strings.Join(call, ", "))
+	items := "_page"
+	if pg.items != "" {
+		items = fmt.Sprintf("_page.get(%s)", jsString(pg.items))
+	}
+	w.p("            _items = %s or []", items)
+	w.p("            if not _items:")
+	w.p("                return _out")
+	w.p("            _out.extend(_items)")
+	switch pg.style {
+	case "page":
+		w.p("            %s = (%s if %s is not None else %d) + 1", pvar, pvar, pvar, pg.start)
+	case "offset":
+		w.p("            %s = (%s or 0) + len(_items)", pvar, pvar)
+	case "cursor":
+		w.p("            _next = _page.get(%s)", jsString(pg.next))
+		w.p("            if not _next:")
+		w.p("                return _out")
+		w.p("            %s = _next", pvar)
+	}
+}
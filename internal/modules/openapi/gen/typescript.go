@@ -0,0 +1,399 @@
+package gen
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+var tsReserved = set("break", "case", "catch", "class", "const", "continue", "debugger", "default", "delete",
+	"do", "else", "enum", "export", "extends", "false", "finally", "for", "function", "if", "import", "in",
+	"instanceof", "new", "null", "return", "super", "switch", "this", "throw", "true", "try", "typeof", "var",
+	"void", "while", "with", "implements", "interface", "let", "package", "private", "protected", "public",
+	"static", "yield", "await",
+	// Names the generated methods use.
+	"params", "body", "path", "options", "all", "page", "items", "p", "next")
+
+// jsString is s as a string literal JS and Python both read.
+func jsString(s string) string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
+
+// tsKey is a property name as an object key.
+func tsKey(name string) string {
+	if isIdent(name) {
+		return name
+	}
+	return jsString(name)
+}
+
+// tsAccess reads property name of expr.
+func tsAccess(expr, name string) string {
+	if isIdent(name) {
+		return expr + "." + name
+	}
+	return expr + "[" + jsString(name) + "]"
+}
+
+type tsWriter struct {
+	m *model
+	b bytes.Buffer
+}
+
+func (w *tsWriter) raw(s string) {
+	w.b.WriteString(s)
+	w.b.WriteByte('\n')
+}
+
+func (w *tsWriter) p(format string, args ...any) {
+	fmt.Fprintf(&w.b, format, args...)
+	w.b.WriteByte('\n')
+}
+
+func (w *tsWriter) typ(t *typ) string {
+	s := w.bare(t)
+	if t.nullable {
+		s += " | null"
+	}
+	return s
+}
+
+func (w *tsWriter) bare(t *typ) string {
+	switch t.kind {
+	case kNamed:
+		return pascal(t.name)
+	case kString, kRaw:
+		return "string"
+	case kInt, kInt32, kNumber:
+		return "number"
+	case kBool:
+		return "boolean"
+	case kArray:
+		e := w.typ(t.elem)
+		if strings.ContainsAny(e, " |") {
+			return "Array<" + e + ">"
+		}
+		return e + "[]"
+	case kMap:
+		return "Record<string, " + w.typ(t.elem) + ">"
+	case kUnion:
+		alts := make([]string, len(t.alts))
+		for i, a := range t.alts {
+			alts[i] = w.typ(a)
+		}
+		return strings.Join(alts, " | ")
+	}
+	return "unknown"
+}
+
+func tsDoc(w *tsWriter, indent, text string) {
+	if text != "" {
+		w.p("%s/** %s */", indent, strings.ReplaceAll(text, "*/", "* /"))
+	}
+}
+
+func tsClient(m *model) []File {
+	w := &tsWriter{m: m}
+	w.p("// Code generated by restless gen client; DO NOT EDIT.")
+	w.p("// Client for %s.", strings.TrimSpace(m.title+" "+m.version))
+	w.p("")
+	w.p("export const DEFAULT_BASE_URL = %s;", jsString(m.baseURL))
+	w.p("")
+	for _, d := range m.decls {
+		tsDoc(w, "", d.doc)
+		switch d.kind {
+		case dObject:
+			w.p("export interface %s {", pascal(d.name))
+			for _, f := range d.fields {
+				tsDoc(w, "  ", f.doc)
+				opt := "?"
+				if f.required {
+					opt = ""
+				}
+				w.p("  %s%s: %s;", tsKey(f.name), opt, w.typ(f.typ))
+			}
+			w.p("}")
+		case dEnum:
+			vals := make([]string, len(d.enum))
+			for i, v := range d.enum {
+				vals[i] = jsString(v)
+			}
+			if len(vals) == 0 {
+				vals = []string{"string"}
+			}
+			w.p("export type %s = %s;", pascal(d.name), strings.Join(vals, " | "))
+		case dAlias:
+			w.p("export type %s = %s;", pascal(d.name), w.typ(d.alias))
+		}
+		w.p("")
+	}
+	for _, op := range m.ops {
+		if len(op.params) == 0 {
+			continue
+		}
+		w.p("/** Query and header parameters of %s. */", camel(op.name))
+		w.p("export interface %sParams {", pascal(op.name))
+		for _, p := range op.params {
+			tsDoc(w, "  ", p.doc)
+			opt := "?"
+			if p.required {
+				opt = ""
+			}
+			w.p("  %s%s: %s;", tsKey(p.name), opt, w.typ(p.typ))
+		}
+		w.p("}")
+		w.p("")
+	}
+	w.runtime()
+	for _, op := range m.ops {
+		w.operation(op)
+	}
+	w.p("}")
+	return []File{{Name: "client.ts", Data: w.b.Bytes()}}
+}
+
+func (w *tsWriter) schemeOption(s scheme) string {
+	return avoid(camel(s.name), set("baseUrl", "headers", "fetch"), "Auth")
+}
+
+func (w *tsWriter) runtime() {
+	w.raw(`/** A response with a status outside 2xx. */
+export class ApiError extends Error {
+  readonly status: number;
+  readonly body: string;
+
+  constructor(status: number, body: string) {
+    super(` + "`${status}: ${body}`" + `);
+    this.name = "ApiError";
+    this.status = status;
+    this.body = body;
+  }
+}
+`)
+	basic := false
+	for _, s := range w.m.schemes {
+		basic = basic || s.kind == "basic"
+	}
+	if basic {
+		w.p("/** A user name and password for HTTP basic auth. */")
+		w.p("export interface BasicAuth {\n  username: string;\n  password: string;\n}")
+		w.p("")
+	}
+	w.p("export interface ClientOptions {")
+	w.p("  baseUrl?: string;")
+	w.p("  /** Sent with every request. */")
+	w.p("  headers?: Record<string, string>;")
+	for _, s := range w.m.schemes {
+		switch s.kind {
+		case "bearer":
+			w.p("  /** Sent as a bearer token (%s). */", s.name)
+			w.p("  %s?: string;", w.schemeOption(s))
+		case "basic":
+			w.p("  /** Sent as HTTP basic auth (%s). */", s.name)
+			w.p("  %s?: BasicAuth;", w.schemeOption(s))
+		case "apiKey":
+			w.p("  /** Sent in the %s %s (%s). */", s.param, s.in, s.name)
+			w.p("  %s?: string;", w.schemeOption(s))
+		}
+	}
+	w.p("  fetch?: typeof fetch;")
+	w.p("}")
+	w.p("")
+	w.raw(`/** Calls the API. Credentials that are set are sent with every request. */
+export class Client {
+  readonly baseUrl: string;
+  private readonly options: ClientOptions;
+
+  constructor(options: ClientOptions = {}) {
+    this.options = options;
+    this.baseUrl = (options.baseUrl || DEFAULT_BASE_URL).replace(/\/+$/, "");
+  }
+
+  private async request<T>(
+    method: string,
+    path: string,
+    query: Record<string, unknown>,
+    headers: Record<string, unknown>,
+    contentType: string,
+    body?: unknown,
+  ): Promise<T> {
+    const url = new URL(this.baseUrl + path);
+    for (const [k, v] of Object.entries(query)) {
+      if (v === undefined || v === null) continue;
+      for (const item of Array.isArray(v) ? v : [v]) url.searchParams.append(k, String(item));
+    }
+    const h: Record<string, string> = { Accept: "application/json", ...this.options.headers };
+    for (const [k, v] of Object.entries(headers)) {
+      if (v !== undefined && v !== null) h[k] = String(v);
+    }`)
+	for _, s := range w.m.schemes {
+		o := "this.options." + w.schemeOption(s)
+		switch {
+		case s.kind == "bearer":
+			w.p("    if (%s) h[\"Authorization\"] = `Bearer ${%s}`;", o, o)
+		case s.kind == "basic":
+			w.p("    if (%s) h[\"Authorization\"] = \"Basic \" + btoa(`${%s.username}:${%s.password}`);", o, o, o)
+		case s.in == "header":
+			w.p("    if (%s) h[%s] = %s;", o, jsString(s.param), o)
+		case s.in == "query":
+			w.p("    if (%s) url.searchParams.set(%s, %s);", o, jsString(s.param), o)
+		case s.in == "cookie":
+			w.p("    if (%s) h[\"Cookie\"] = %s + \"=\" + encodeURIComponent(%s);", o, jsString(s.param), o)
+		}
+	}
+	w.raw(`    let payload: string | undefined;
+    if (body !== undefined) {
+      h["Content-Type"] = contentType;
+      payload = typeof body === "string" ? body : JSON.stringify(body);
+    }
+    const res = await (this.options.fetch ?? fetch)(url.toString(), { method, headers: h, body: payload });
+    const text = await res.text();
+    if (!res.ok) throw new ApiError(res.status, text);
+    if (!text) return undefined as T;
+    // Only JSON is decoded; anything else comes back as text.
+    return (/json/i.test(res.headers.get("Content-Type") ?? "") ? JSON.parse(text) : text) as T;
+  }`)
+}
+
+// tsArg is one argument of a generated method.
+type tsArg struct {
+	decl     string
+	required bool
+}
+
+// tsArgs are an operation's arguments (required ones first) and the
+// local names of its path parameters.
+func (w *tsWriter) args(op *operation) ([]string, []string) {
+	seen := namer{}
+	var args []tsArg
+	var path []string
+	for _, p := range op.pathParams {
+		n := seen.unique(avoid(camel(p.name), tsReserved, "_"))
+		path = append(path, n)
+		args = append(args, tsArg{n + ": " + w.typ(p.typ), true})
+	}
+	if op.body != nil {
+		if op.bodyRequired {
+			args = append(args, tsArg{"body: " + w.typ(op.body), true})
+		} else {
+			args = append(args, tsArg{"body?: " + w.typ(op.body), false})
+		}
+	}
+	if len(op.params) > 0 {
+		if op.paramsRequired() {
+			args = append(args, tsArg{"params: " + pascal(op.name) + "Params", true})
+		} else {
+			args = append(args, tsArg{"params: " + pascal(op.name) + "Params = {}", false})
+		}
+	}
+	var out []string
+	for _, req := range []bool{true, false} {
+		for _, a := range args {
+			if a.required == req {
+				out = append(out, a.decl)
+			}
+		}
+	}
+	return out, path
+}
+
+func (w *tsWriter) operation(op *operation) {
+	args, path := w.args(op)
+	ret := "void"
+	if op.result != nil {
+		ret = w.typ(op.result)
+	}
+	w.p("")
+	doc := op.method + " " + op.path
+	if op.doc != "" {
+		doc += " — " + op.doc
+	}
+	tsDoc(w, "  ", doc)
+	w.p("  async %s(%s): Promise<%s> {", camel(op.name), strings.Join(args, ", "), ret)
+	expr := jsString(op.path)
+	for i, p := range op.pathParams {
+		expr += fmt.Sprintf(".replace(%s, encodeURIComponent(String(%s)))", jsString("{"+p.name+"}"), path[i])
+	}
+	w.p("    const path = %s;", expr)
+	var query, headers []string
+	for _, p := range op.params {
+		kv := tsKey(p.name) + ": " + tsAccess("params", p.name)
+		if p.in == "header" {
+			headers = append(headers, kv)
+		} else {
+			query = append(query, kv)
+		}
+	}
+	body := ""
+	if op.body != nil {
+		body = ", body"
+	}
+	w.p("    return this.request<%s>(%s, path, %s, %s, %s%s);",
+		ret, jsString(op.method), tsObject(query), tsObject(headers), jsString(op.contentType), body)
+	w.p("  }")
+	w.pager(op, args, path)
+}
+
+func tsObject(kvs []string) string {
+	if len(kvs) == 0 {
+		return "{}"
+	}
+	return "{ " + strings.Join(kvs, ", ") + " }"
+}
+
+func (w *tsWriter) pager(op *operation, args, path []string) {
+	pg := op.pager
+	if pg == nil {
+		return
+	}
+	elem := w.typ(pg.elem)
+	if strings.Contains(elem, " ") {
+		elem = "(" + elem + ")"
+	}
+	var call []string
+	for _, a := range args {
+		name, _, _ := strings.Cut(a, ":")
+		name = strings.TrimSuffix(name, "?")
+		if name == "params" {
+			name = "p"
+		}
+		call = append(call, name)
+	}
+	w.p("")
+	switch pg.style {
+	case "page":
+		w.p("  /** Every item of %s, moving %s on a page at a time until a page comes back empty. */", camel(op.name), pg.param.name)
+	case "offset":
+		w.p("  /** Every item of %s, moving %s past the items seen until a page comes back empty. */", camel(op.name), pg.param.name)
+	case "cursor":
+		w.p("  /** Every item of %s, passing each page's %s as %s until there is none. */", camel(op.name), pg.next, pg.param.name)
+	}
+	w.p("  async %sAll(%s): Promise<%s[]> {", camel(op.name), strings.Join(args, ", "), elem)
+	w.p("    const all: %s[] = [];", elem)
+	w.p("    let p: %sParams = { ...params };", pascal(op.name))
+	w.p("    for (;;) {")
+	w.p("      const page = await this.%s(%s);", camel(op.name), strings.Join(call, ", "))
+	items := "page"
+	if pg.items != "" {
+		items = tsAccess("page", pg.items)
+	}
+	w.p("      const items = %s ?? [];", items)
+	w.p("      if (items.length === 0) return all;")
+	w.p("      all.push(...items);")
+	cur := tsAccess("p", pg.param.name)
+	key := tsKey(pg.param.name)
+	switch pg.style {
+	case "page":
+		w.p("      p = { ...p, %s: (%s ?? %d) + 1 };", key, cur, pg.start)
+	case "offset":
+		w.p("      p = { ...p, %s: (%s ?? 0) + items.length };", key, cur)
+	case "cursor":
+		w.p("      const next = %s;", tsAccess("page", pg.next))
+		w.p("      if (!next) return all;")
+		w.p("      p = { ...p, %s: next };", key)
+	}
+	w.p("    }")
+	w.p("  }")
+}
@@ -2,67 +2,115 @@ package loader
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
 
+	"github.com/getkin/kin-openapi/openapi2"
+	"github.com/getkin/kin-openapi/openapi2conv"
 	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/oasdiff/yaml"
 )
 
 type LoadOptions struct {
+	// AllowRemoteRefs lets $refs reach other URLs; refs to files next
+	// to a local document are always followed.
 	AllowRemoteRefs bool
+	// Lenient skips validation, for specs that are usable but not
+	// quite valid (operations without responses and the like).
+	Lenient bool
 }
 
+// Load reads an OpenAPI document from a file or URL. Relative $refs are
+// resolved against ref and bundled into the document, and Swagger 2.0
+// documents are converted to OpenAPI 3.
 func Load(ctx context.Context, ref string, opt LoadOptions) (*openapi3.T, error) {
 	ldr := openapi3.NewLoader()
-	ldr.IsExternalRefsAllowed = opt.AllowRemoteRefs
+	ldr.IsExternalRefsAllowed = true
+	if !opt.AllowRemoteRefs {
+		ldr.ReadFromURIFunc = readLocal
+	}
 	ldr.Context = ctx
 
-	if strings.HasPrefix(ref, "http://") || strings.HasPrefix(ref, "https://") {
-		resp, err := http.Get(ref)
-		if err != nil {
-			return nil, err
-		}
-		defer resp.Body.Close()
+	data, loc, err := read(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
 
-		if resp.StatusCode < 200 || resp.StatusCode > 299 {
-			return nil, fmt.Errorf("openapi fetch failed: %s", resp.Status)
+	var doc *openapi3.T
+	if IsSwagger2(data) {
+		var doc2 openapi2.T
+		if err := yaml.Unmarshal(data, &doc2); err != nil {
+			return nil, fmt.Errorf("swagger 2.0: %w", err)
 		}
-
-		tmp, err := os.CreateTemp("", "restless-openapi-*.json")
-		if err != nil {
+		doc, err = openapi2conv.ToV3WithLoader(&doc2, ldr, loc)
+	} else {
+		doc, err = ldr.LoadFromDataWithPath(data, loc)
+	}
+	if err != nil {
+		return nil, err
+	}
+	// Bundle: what other files and URLs define moves into components,
+	// so the document stands on its own.
+	doc.InternalizeRefs(ctx, nil)
+	if !opt.Lenient {
+		if err := doc.Validate(ctx); err != nil {
 			return nil, err
 		}
-		defer os.Remove(tmp.Name())
+	}
+	return doc, nil
+}
 
-		if _, err := tmp.ReadFrom(resp.Body); err != nil {
-			return nil, err
+func read(ctx context.Context, ref string) ([]byte, *url.URL, error) {
+	if strings.HasPrefix(ref, "http://") || strings.HasPrefix(ref, "https://") {
+		loc, err := url.Parse(ref)
+		if err != nil {
+			return nil, nil, err
 		}
-		tmp.Close()
-
-		doc, err := ldr.LoadFromFile(tmp.Name())
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, ref, nil)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
-		if err := doc.Validate(ctx); err != nil {
-			return nil, err
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return nil, nil, err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return nil, nil, fmt.Errorf("openapi fetch failed: %s", resp.Status)
 		}
-		return doc, nil
+		data, err := io.ReadAll(resp.Body)
+		return data, loc, err
 	}
 
 	if !filepath.IsAbs(ref) {
 		ref, _ = filepath.Abs(ref)
 	}
+	data, err := os.ReadFile(ref)
+	return data, &url.URL{Path: filepath.ToSlash(ref)}, err
+}
 
-	doc, err := ldr.LoadFromFile(ref)
-	if err != nil {
-		return nil, err
-	}
-	if err := doc.Validate(ctx); err != nil {
-		return nil, err
+// readLocal reads what a $ref points to when only files may be read.
+func readLocal(ldr *openapi3.Loader, loc *url.URL) ([]byte, error) {
+	if loc.Host != "" || (loc.Scheme != "" && loc.Scheme != "file") {
+		return nil, fmt.Errorf("remote $ref not allowed: %s", loc)
 	}
+	return openapi3.ReadFromFile(ldr, loc)
+}
 
-	return doc, nil
+// IsSwagger2 reports whether data is a Swagger 2.0 document, JSON or YAML.
+func IsSwagger2(data []byte) bool {
+	var head struct {
+		Swagger string `json:"swagger"`
+	}
+	if json.Unmarshal(data, &head) != nil {
+		_ = yaml.Unmarshal(data, &head)
+	}
+	return strings.HasPrefix(head.Swagger, "2.")
 }
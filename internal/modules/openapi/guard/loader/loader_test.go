@@ -0,0 +1,63 @@
+package loader
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadFollowsLocalRefs(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"api.yaml": `openapi: 3.0.3
+info: {title: Pets, version: "1"}
+paths:
+  /pets:
+    get:
+      responses:
+        "200":
+          description: ok
+          content:
+            application/json:
+              schema: {$ref: "schemas/pet.yaml"}
+`,
+		"schemas/pet.yaml": "type: object\nproperties:\n  name: {type: string}\n",
+		"remote.yaml": `openapi: 3.0.3
+info: {title: Pets, version: "1"}
+paths:
+  /pets:
+    get:
+      responses:
+        "200":
+          description: ok
+          content:
+            application/json:
+              schema: {$ref: "http://127.0.0.1:1/pet.yaml"}
+`,
+	}
+	for name, body := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	doc, err := Load(context.Background(), filepath.Join(dir, "api.yaml"), LoadOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mt := doc.Paths.Find("/pets").Get.Responses.Status(200).Value.Content.Get("application/json")
+	if s := mt.Schema.Value; s == nil || s.Properties["name"] == nil {
+		t.Fatalf("schema: %+v", mt.Schema)
+	}
+
+	_, err = Load(context.Background(), filepath.Join(dir, "remote.yaml"), LoadOptions{})
+	if err == nil || !strings.Contains(err.Error(), "remote $ref not allowed") {
+		t.Fatalf("remote ref: %v", err)
+	}
+}
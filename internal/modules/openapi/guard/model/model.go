@@ -1,6 +1,12 @@
 package model
 
-import "time"
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/bspippi1337/restless/internal/redact"
+)
 
 type FindingSeverity string
 
@@ -36,6 +42,47 @@ type Finding struct {
 	Message  string
 	Expected string
 	Actual   string
+
+	// Request is the exchange that produced the finding, with secrets
+	// redacted, so it can be replayed.
+	Request *Request `json:",omitempty"`
+}
+
+// Request is a fully built HTTP request as it was sent.
+type Request struct {
+	Method string
+	URL    string
+	Header http.Header `json:",omitempty"`
+	Body   string      `json:",omitempty"`
+
+	// As names the identity that sent it when a run used several.
+	As string `json:",omitempty"`
+
+	// Returned holds response values that later requests of a sequence
+	// put in their URLs, keyed by JSON dot path ("id", "data.id").
+	Returned map[string]string `json:",omitempty"`
+}
+
+// NewRequest captures req and its body with credentials redacted.
+func NewRequest(req *http.Request, body []byte) *Request {
+	return &Request{
+		Method: req.Method,
+		URL:    redact.URL(req.URL.String()),
+		Header: redact.Headers(req.Header),
+		Body:   string(redact.Body(req.Header.Get("Content-Type"), body)),
+	}
+}
+
+// HTTP turns the request into an *http.Request.
+func (r Request) HTTP() (*http.Request, error) {
+	req, err := http.NewRequest(r.Method, r.URL, strings.NewReader(r.Body))
+	if err != nil {
+		return nil, err
+	}
+	for k, vs := range r.Header {
+		req.Header[k] = append([]string(nil), vs...)
+	}
+	return req, nil
 }
 
 type GuardResult struct {
@@ -66,3 +113,44 @@ type DiffResult struct {
 
 	RecommendedBump SemverBump
 }
+
+// Step is one step of a finding's JSONPath: a property name or, when
+// Index is set, an array element.
+type Step struct {
+	Key   string
+	Index int
+	Elem  bool
+}
+
+// SplitJSONPath splits a finding's JSONPath ($.items[0].id) into steps;
+// "$" is none.
+func SplitJSONPath(p string) []Step {
+	p = strings.TrimPrefix(p, "$")
+	var out []Step
+	for p != "" {
+		switch p[0] {
+		case '.':
+			p = p[1:]
+			end := strings.IndexAny(p, ".[")
+			if end < 0 {
+				end = len(p)
+			}
+			out = append(out, Step{Key: p[:end]})
+			p = p[end:]
+		case '[':
+			end := strings.IndexByte(p, ']')
+			if end < 0 {
+				return out
+			}
+			n := 0
+			for _, r := range p[1:end] {
+				n = n*10 + int(r-'0')
+			}
+			out = append(out, Step{Index: n, Elem: true})
+			p = p[end+1:]
+		default:
+			return out
+		}
+	}
+	return out
+}
@@ -62,16 +62,30 @@ type sarifRegion struct {
 	StartLine int `json:"startLine"`
 }
 
+// Rule and Result are the tool-neutral parts of a SARIF run, for reports
+// other than contract findings.
+type Rule struct {
+	ID          string
+	Description string
+}
+
+type Result struct {
+	RuleID   string
+	Severity model.FindingSeverity
+	Message  string
+	URI      string
+}
+
 func ToSARIF(appVersion string, res model.GuardResult) ([]byte, error) {
-	rules := []sarifRule{
-		rule("contract.extra_field", "Extra field not in contract"),
-		rule("contract.missing_field", "Missing required field"),
-		rule("contract.type_mismatch", "Type mismatch vs contract"),
-		rule("contract.enum_violation", "Enum violation vs contract"),
-		rule("contract.schema_violation", "Schema violation vs contract"),
+	rules := []Rule{
+		{"contract.extra_field", "Extra field not in contract"},
+		{"contract.missing_field", "Missing required field"},
+		{"contract.type_mismatch", "Type mismatch vs contract"},
+		{"contract.enum_violation", "Enum violation vs contract"},
+		{"contract.schema_violation", "Schema violation vs contract"},
 	}
 
-	var results []sarifResult
+	var results []Result
 	for _, f := range res.Findings {
 		rid := "contract.schema_violation"
 		switch f.Kind {
@@ -84,20 +98,37 @@ func ToSARIF(appVersion string, res model.GuardResult) ([]byte, error) {
 		case model.KindEnumViolation:
 			rid = "contract.enum_violation"
 		}
-		level := mapLevel(f.Severity)
-		text := fmt.Sprintf("%s %s %d %s: %s (%s)", f.Method, f.Path, f.Status, f.JSONPath, f.Message, f.OpID)
+		results = append(results, Result{
+			RuleID:   rid,
+			Severity: f.Severity,
+			Message:  fmt.Sprintf("%s %s %d %s: %s (%s)", f.Method, f.Path, f.Status, f.JSONPath, f.Message, f.OpID),
+			URI:      res.SpecRef,
+		})
+	}
+
+	return SARIF("restless-openapi-guard", appVersion, rules, results)
+}
+
+// SARIF renders a single-run SARIF 2.1.0 log.
+func SARIF(tool, appVersion string, rules []Rule, results []Result) ([]byte, error) {
+	var sr []sarifRule
+	for _, r := range rules {
+		sr = append(sr, rule(r.ID, r.Description))
+	}
 
+	var out []sarifResult
+	for _, res := range results {
 		var r sarifResult
-		r.RuleID = rid
-		r.Level = level
-		r.Message.Text = text
+		r.RuleID = res.RuleID
+		r.Level = mapLevel(res.Severity)
+		r.Message.Text = res.Message
 		r.Locations = []sarifLocation{{
 			PhysicalLocation: sarifPhysicalLocation{
-				ArtifactLocation: sarifArtifactLocation{URI: res.SpecRef},
+				ArtifactLocation: sarifArtifactLocation{URI: res.URI},
 				Region:           &sarifRegion{StartLine: 1},
 			},
 		}}
-		results = append(results, r)
+		out = append(out, r)
 	}
 
 	log := sarifLog{
@@ -105,11 +136,11 @@ func ToSARIF(appVersion string, res model.GuardResult) ([]byte, error) {
 		Schema:  "https://schemastore.azurewebsites.net/schemas/json/sarif-2.1.0.json",
 		Runs: []sarifRun{{
 			Tool: sarifTool{Driver: sarifDriver{
-				Name:    "restless-openapi-guard",
+				Name:    tool,
 				Version: appVersion,
-				Rules:   rules,
+				Rules:   sr,
 			}},
-			Results: results,
+			Results: out,
 		}},
 	}
 	return json.MarshalIndent(log, "", "  ")
@@ -9,7 +9,6 @@ import (
 	"strings"
 
 	"github.com/getkin/kin-openapi/openapi3"
-	"github.com/santhosh-tekuri/jsonschema/v5"
 
 	"github.com/bspippi1337/restless/internal/modules/openapi/guard/model"
 )
@@ -44,16 +43,7 @@ func (v *Validator) ValidateResponse(ctx context.Context, method, pathTemplate s
 		}}, nil
 	}
 
-	js, err := json.Marshal(mt.Schema.Value)
-	if err != nil {
-		return nil, err
-	}
-
-	compiler := jsonschema.NewCompiler()
-	if err := compiler.AddResource("schema.json", bytes.NewReader(js)); err != nil {
-		return nil, err
-	}
-	sch, err := compiler.Compile("schema.json")
+	sch, err := v.compile(mt.Schema.Value, false)
 	if err != nil {
 		return nil, err
 	}
@@ -71,7 +61,14 @@ func (v *Validator) ValidateResponse(ctx context.Context, method, pathTemplate s
 	}
 
 	if err := sch.Validate(payload); err != nil {
-		return mapSchemaError(opID, method, pathTemplate, status, contentType, err), nil
+		fs := mapSchemaError(opID, method, pathTemplate, status, contentType, err)
+		for i, f := range fs {
+			// What was there, so the spec can be widened to fit it.
+			if f.Kind == model.KindTypeMismatch || f.Kind == model.KindEnumViolation {
+				fs[i].Actual = observed(payload, f.JSONPath)
+			}
+		}
+		return fs, nil
 	}
 
 	return nil, nil
@@ -153,3 +150,30 @@ func findOperation(doc *openapi3.T, method, pathTemplate string) (*openapi3.Oper
 	}
 	return op, opID, nil
 }
+
+// observed is the JSON of the value at jsonPath in payload, cut short
+// when long.
+func observed(payload any, jsonPath string) string {
+	cur := payload
+	for _, st := range model.SplitJSONPath(jsonPath) {
+		switch t := cur.(type) {
+		case map[string]any:
+			cur = t[st.Key]
+		case []any:
+			if !st.Elem || st.Index >= len(t) {
+				return ""
+			}
+			cur = t[st.Index]
+		default:
+			return ""
+		}
+	}
+	b, err := json.Marshal(cur)
+	if err != nil {
+		return ""
+	}
+	if len(b) > 200 {
+		return string(b[:200]) + "…"
+	}
+	return string(b)
+}
@@ -38,6 +38,15 @@ func flattenValidationError(
 	ve *jsonschema.ValidationError,
 	out *[]model.Finding,
 ) {
+	// Only the leaves say what is wrong; the nodes above them just
+	// point at the (sub)schema that failed.
+	if len(ve.Causes) > 0 {
+		for _, c := range ve.Causes {
+			flattenValidationError(opID, method, path, status, contentType, c, out)
+		}
+		return
+	}
+
 	jp := "$"
 	if ve.InstanceLocation != "" {
 		jp = "$" + pointerToJSONPath(ve.InstanceLocation)
@@ -50,13 +59,13 @@ func flattenValidationError(
 	m := strings.ToLower(msg)
 
 	switch {
-	case strings.Contains(m, "required"):
+	case strings.Contains(m, "required"), strings.Contains(m, "missing propert"):
 		kind = model.KindMissingField
 		sev = model.SevCritical
-	case strings.Contains(m, "invalid type"), strings.Contains(m, "type"):
+	case strings.Contains(m, "invalid type"), strings.Contains(m, "type"), strings.HasPrefix(m, "expected "):
 		kind = model.KindTypeMismatch
 		sev = model.SevHigh
-	case strings.Contains(m, "enum"):
+	case strings.Contains(m, "enum"), strings.Contains(m, "must be one of"):
 		kind = model.KindEnumViolation
 		sev = model.SevMedium
 	}
@@ -72,10 +81,6 @@ func flattenValidationError(
 		JSONPath:    jp,
 		Message:     msg,
 	})
-
-	for _, c := range ve.Causes {
-		flattenValidationError(opID, method, path, status, contentType, c, out)
-	}
 }
 
 func pointerToJSONPath(ptr string) string {
@@ -0,0 +1,317 @@
+package runtime
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+
+	"github.com/bspippi1337/restless/internal/modules/openapi/guard/model"
+)
+
+// ValidateRequest checks a request against its operation before it is
+// sent: the path, query, header and cookie parameters it declares and,
+// for JSON, the body. pathParams are the values bound to pathTemplate.
+//
+// Parameter findings point at "<in>.<name>" (query.limit), body
+// findings at a JSON path into the body. Status is always 0.
+func (v *Validator) ValidateRequest(ctx context.Context, method, pathTemplate string, pathParams map[string]string, query url.Values, header http.Header, body []byte) ([]model.Finding, error) {
+	op, opID, err := findOperation(v.doc, method, pathTemplate)
+	if err != nil {
+		return nil, err
+	}
+	ct := header.Get("Content-Type")
+	finding := func(kind model.FindingKind, sev model.FindingSeverity, at, msg string) model.Finding {
+		return model.Finding{
+			OpID: opID, Method: strings.ToUpper(method), Path: pathTemplate, ContentType: ct,
+			Kind: kind, Severity: sev, JSONPath: at, Message: msg,
+		}
+	}
+
+	var out []model.Finding
+	cookies := (&http.Request{Header: header}).Cookies()
+	for _, p := range parameters(v.doc.Paths.Find(pathTemplate), op) {
+		at := p.In + "." + p.Name
+		raw, ok := paramValue(p, pathParams, query, header, cookies)
+		if !ok {
+			if p.Required {
+				f := finding(model.KindMissingField, model.SevCritical, at, fmt.Sprintf("required %s parameter %s is missing", p.In, p.Name))
+				f.Expected = schemaType(p.Schema)
+				out = append(out, f)
+			}
+			continue
+		}
+		if p.Schema == nil || p.Schema.Value == nil {
+			continue
+		}
+		sch, err := v.compile(p.Schema.Value, false)
+		if err != nil {
+			return nil, err
+		}
+		if err := sch.Validate(coerce(p.Schema.Value, raw)); err != nil {
+			for _, f := range mapSchemaError(opID, method, pathTemplate, 0, ct, err) {
+				f.JSONPath = at
+				f.Actual = raw
+				out = append(out, f)
+			}
+		}
+	}
+
+	rb := op.RequestBody
+	switch {
+	case rb == nil || rb.Value == nil:
+		if len(body) > 0 {
+			out = append(out, finding(model.KindSchemaViolation, model.SevLow, "$", "operation takes no request body"))
+		}
+		return out, nil
+	case len(body) == 0:
+		if rb.Value.Required {
+			out = append(out, finding(model.KindMissingField, model.SevCritical, "$", "request body is required"))
+		}
+		return out, nil
+	}
+
+	if ct != "" && !strings.Contains(strings.ToLower(ct), "json") {
+		return out, nil
+	}
+	mt := pickMediaType(&openapi3.Response{Content: rb.Value.Content}, ct)
+	if mt == nil {
+		if ct != "" || json.Valid(body) {
+			out = append(out, finding(model.KindSchemaViolation, model.SevMedium, "$", "operation takes no JSON request body"))
+		}
+		return out, nil
+	}
+	if mt.Schema == nil || mt.Schema.Value == nil {
+		return out, nil
+	}
+
+	var payload any
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	if err := dec.Decode(&payload); err != nil {
+		f := finding(model.KindSchemaViolation, model.SevHigh, "$", "request body is not valid JSON")
+		f.Actual = err.Error()
+		return append(out, f), nil
+	}
+	sch, err := v.compile(mt.Schema.Value, true)
+	if err != nil {
+		return nil, err
+	}
+	if err := sch.Validate(payload); err != nil {
+		out = append(out, mapSchemaError(opID, method, pathTemplate, 0, ct, err)...)
+	}
+	return out, nil
+}
+
+// parameters are those of the path item overridden by the operation's own.
+func parameters(item *openapi3.PathItem, op *openapi3.Operation) []*openapi3.Parameter {
+	var out []*openapi3.Parameter
+	seen := map[string]bool{}
+	sets := []openapi3.Parameters{op.Parameters}
+	if item != nil {
+		sets = append(sets, item.Parameters)
+	}
+	for _, set := range sets {
+		for _, ref := range set {
+			if ref == nil || ref.Value == nil || seen[ref.Value.In+":"+ref.Value.Name] {
+				continue
+			}
+			seen[ref.Value.In+":"+ref.Value.Name] = true
+			out = append(out, ref.Value)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return paramOrder[out[i].In] < paramOrder[out[j].In] })
+	return out
+}
+
+var paramOrder = map[string]int{
+	openapi3.ParameterInPath:   0,
+	openapi3.ParameterInQuery:  1,
+	openapi3.ParameterInHeader: 2,
+	openapi3.ParameterInCookie: 3,
+}
+
+func paramValue(p *openapi3.Parameter, pathParams map[string]string, query url.Values, header http.Header, cookies []*http.Cookie) (string, bool) {
+	switch p.In {
+	case openapi3.ParameterInPath:
+		v, ok := pathParams[p.Name]
+		return v, ok
+	case openapi3.ParameterInQuery:
+		if vv, ok := query[p.Name]; ok {
+			return strings.Join(vv, ","), true
+		}
+	case openapi3.ParameterInHeader:
+		if vv := header.Values(p.Name); len(vv) > 0 {
+			return strings.Join(vv, ","), true
+		}
+	case openapi3.ParameterInCookie:
+		for _, c := range cookies {
+			if c.Name == p.Name {
+				return c.Value, true
+			}
+		}
+	}
+	return "", false
+}
+
+// coerce turns a parameter's text into the JSON value its schema
+// describes. Text that does not parse is left for the schema to reject.
+func coerce(s *openapi3.Schema, v string) any {
+	switch {
+	case s.Type.Is(openapi3.TypeInteger):
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return json.Number(strconv.FormatInt(n, 10))
+		}
+	case s.Type.Is(openapi3.TypeNumber):
+		if _, err := strconv.ParseFloat(v, 64); err == nil {
+			return json.Number(v)
+		}
+	case s.Type.Is(openapi3.TypeBoolean):
+		if b, err := strconv.ParseBool(v); err == nil {
+			return b
+		}
+	case s.Type.Is(openapi3.TypeArray):
+		items := []any{}
+		for _, part := range strings.Split(v, ",") {
+			if s.Items != nil && s.Items.Value != nil {
+				items = append(items, coerce(s.Items.Value, part))
+			} else {
+				items = append(items, part)
+			}
+		}
+		return items
+	}
+	return v
+}
+
+// compile turns an OpenAPI schema into a JSON Schema validator. The
+// document's component schemas go along, so local $refs resolve, and
+// OpenAPI 3.0 keywords are rewritten the way 3.1 spells them. For
+// requests, readOnly properties are no longer required: the server fills
+// them in.
+func (v *Validator) compile(s *openapi3.Schema, request bool) (*jsonschema.Schema, error) {
+	js, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	var root map[string]any
+	if err := json.Unmarshal(js, &root); err != nil {
+		return nil, err
+	}
+	if v.doc.Components != nil && len(v.doc.Components.Schemas) > 0 {
+		cs, err := json.Marshal(v.doc.Components.Schemas)
+		if err != nil {
+			return nil, err
+		}
+		var schemas any
+		if err := json.Unmarshal(cs, &schemas); err != nil {
+			return nil, err
+		}
+		root["components"] = map[string]any{"schemas": schemas}
+	}
+	fromOpenAPI30(root)
+	if request {
+		dropReadOnlyRequired(root)
+	}
+	if js, err = json.Marshal(root); err != nil {
+		return nil, err
+	}
+
+	compiler := jsonschema.NewCompiler()
+	if err := compiler.AddResource("schema.json", bytes.NewReader(js)); err != nil {
+		return nil, err
+	}
+	return compiler.Compile("schema.json")
+}
+
+// fromOpenAPI30 rewrites what OpenAPI 3.0 says differently from JSON
+// Schema: nullable becomes a null type, and boolean exclusiveMinimum and
+// exclusiveMaximum become the bound itself.
+func fromOpenAPI30(v any) {
+	switch t := v.(type) {
+	case map[string]any:
+		if n, ok := t["nullable"].(bool); ok {
+			delete(t, "nullable")
+			if n {
+				nullable(t)
+			}
+		}
+		for _, k := range [][2]string{{"exclusiveMinimum", "minimum"}, {"exclusiveMaximum", "maximum"}} {
+			ex, ok := t[k[0]].(bool)
+			if !ok {
+				continue
+			}
+			delete(t, k[0])
+			if bound, ok := t[k[1]]; ok && ex {
+				t[k[0]] = bound
+				delete(t, k[1])
+			}
+		}
+		for _, c := range t {
+			fromOpenAPI30(c)
+		}
+	case []any:
+		for _, c := range t {
+			fromOpenAPI30(c)
+		}
+	}
+}
+
+// nullable lets a schema with a type also be null. One without a type
+// already allows null.
+func nullable(s map[string]any) {
+	switch t := s["type"].(type) {
+	case string:
+		s["type"] = []any{t, "null"}
+	case []any:
+		s["type"] = append(t, "null")
+	default:
+		return
+	}
+	if enum, ok := s["enum"].([]any); ok {
+		s["enum"] = append(enum, nil)
+	}
+}
+
+func dropReadOnlyRequired(v any) {
+	switch t := v.(type) {
+	case map[string]any:
+		props, _ := t["properties"].(map[string]any)
+		if req, ok := t["required"].([]any); ok && props != nil {
+			kept := []any{}
+			for _, name := range req {
+				if p, _ := props[fmt.Sprint(name)].(map[string]any); p != nil && p["readOnly"] == true {
+					continue
+				}
+				kept = append(kept, name)
+			}
+			if len(kept) > 0 {
+				t["required"] = kept
+			} else {
+				delete(t, "required")
+			}
+		}
+		for _, c := range t {
+			dropReadOnlyRequired(c)
+		}
+	case []any:
+		for _, c := range t {
+			dropReadOnlyRequired(c)
+		}
+	}
+}
+
+func schemaType(ref *openapi3.SchemaRef) string {
+	if ref == nil || ref.Value == nil || ref.Value.Type == nil {
+		return ""
+	}
+	return strings.Join(*ref.Value.Type, "|")
+}
@@ -1,9 +1,11 @@
 package openapi
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
-	"io"
-	"net/http"
+	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
@@ -14,6 +16,12 @@ func Import(source string) (SpecIndex, error) {
 	if source == "" {
 		return SpecIndex{}, errors.New("empty source")
 	}
+	if !looksLikeURL(source) {
+		// The same file imported from another directory is the same spec.
+		if abs, err := filepath.Abs(source); err == nil {
+			source = abs
+		}
+	}
 
 	id := idFor(source)
 	dir, err := cacheDir()
@@ -24,23 +32,37 @@ func Import(source string) (SpecIndex, error) {
 		return SpecIndex{}, err
 	}
 
-	raw, ext, err := readSource(source)
+	doc, err := loadSpec(context.Background(), source)
+	if err != nil {
+		return SpecIndex{}, fmt.Errorf("%s: not an OpenAPI document: %w", source, err)
+	}
+	// The loader bundled what it $refs, so the cached copy stands on
+	// its own.
+	raw, err := json.MarshalIndent(doc, "", "  ")
 	if err != nil {
 		return SpecIndex{}, err
 	}
 
-	rawPath := filepath.Join(dir, id+ext)
+	// Earlier imports may have kept the spec under another extension.
+	if prev, err := LoadIndex(id); err == nil && prev.RawPath != "" {
+		_ = os.Remove(prev.RawPath)
+	}
+	rawPath := specFile(dir, id, ".json")
 	if err := os.WriteFile(rawPath, raw, 0o644); err != nil {
 		return SpecIndex{}, err
 	}
-	// Parse minimal metadata if possible
-	title := ""
-	ver := ""
-	base := ""
-	if spec, err := LoadSpec(raw); err == nil {
-		title = spec.Info.Title
-		ver = spec.Info.Version
-		base = spec.BaseURL()
+
+	var title, ver string
+	if doc.Info != nil {
+		title, ver = doc.Info.Title, doc.Info.Version
+	}
+	base := BaseURL(doc)
+	// Relative servers ("/v1") are relative to where the spec came from.
+	if strings.HasPrefix(base, "/") && looksLikeURL(source) {
+		if u, err := url.Parse(source); err == nil {
+			u.Path, u.RawQuery, u.Fragment = base, "", ""
+			base = u.String()
+		}
 	}
 
 	idx := SpecIndex{
@@ -58,53 +80,6 @@ func Import(source string) (SpecIndex, error) {
 	return idx, nil
 }
 
-func readSource(source string) (raw []byte, ext string, err error) {
-	if looksLikeURL(source) {
-		resp, err := http.Get(source) //nolint:gosec
-		if err != nil {
-			return nil, "", err
-		}
-		defer resp.Body.Close()
-		b, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return nil, "", err
-		}
-		ext = sniffExt(source, resp.Header.Get("Content-Type"), b)
-		return b, ext, nil
-	}
-
-	b, err := os.ReadFile(source)
-	if err != nil {
-		return nil, "", err
-	}
-	ext = sniffExt(source, "", b)
-	return b, ext, nil
-}
-
 func looksLikeURL(s string) bool {
 	return strings.HasPrefix(s, "http://") || strings.HasPrefix(s, "https://")
 }
-
-func sniffExt(source, contentType string, raw []byte) string {
-	// If filename gives clue
-	ls := strings.ToLower(source)
-	if strings.HasSuffix(ls, ".json") {
-		return ".json"
-	}
-	if strings.HasSuffix(ls, ".yaml") || strings.HasSuffix(ls, ".yml") {
-		return ".yaml"
-	}
-	ct := strings.ToLower(contentType)
-	if strings.Contains(ct, "json") {
-		return ".json"
-	}
-	if strings.Contains(ct, "yaml") || strings.Contains(ct, "yml") {
-		return ".yaml"
-	}
-	// sniff content
-	trim := strings.TrimSpace(string(raw))
-	if strings.HasPrefix(trim, "{") || strings.HasPrefix(trim, "[") {
-		return ".json"
-	}
-	return ".yaml"
-}
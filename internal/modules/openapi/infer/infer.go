@@ -0,0 +1,400 @@
+package infer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"gopkg.in/yaml.v3"
+
+	"github.com/bspippi1337/restless/internal/pathtmpl"
+	"github.com/bspippi1337/restless/internal/schema"
+	"github.com/bspippi1337/restless/internal/store"
+)
+
+// Version is the OpenAPI version written by Build.
+const Version = "3.1.0"
+
+// EvidenceKey is the operation extension carrying discovery evidence.
+const EvidenceKey = "x-restless-evidence"
+
+type Options struct {
+	Title   string
+	Version string
+}
+
+// Evidence explains why an operation is part of the inferred spec.
+type Evidence struct {
+	Source     string `json:"source" yaml:"source"`
+	Confidence string `json:"confidence" yaml:"confidence"`
+	FirstSeen  string `json:"firstSeen,omitempty" yaml:"firstSeen,omitempty"`
+}
+
+// operation collects every learned endpoint that maps to one
+// templated path + method.
+type operation struct {
+	method   string
+	statuses map[int]bool
+	schema   *schema.Schema
+	request  *schema.Schema
+	query    []string
+	evidence Evidence
+	first    time.Time
+}
+
+// Build turns the learned API model into an OpenAPI 3.1 document.
+// Concrete paths are collapsed into templates with path parameters.
+func Build(api *store.API, opt Options) (*openapi3.T, error) {
+	if api == nil || len(api.Endpoints) == 0 {
+		return nil, errors.New("no endpoints learned. Run: restless learn <url>")
+	}
+
+	title := opt.Title
+	if title == "" {
+		title = "Inferred API"
+		if u, err := url.Parse(api.BaseURL); err == nil && u.Host != "" {
+			title = u.Host + " (inferred)"
+		}
+	}
+	ver := opt.Version
+	if ver == "" {
+		ver = "0.0.0-inferred"
+	}
+
+	byPath := map[string]map[string]*operation{}
+
+	for _, e := range api.Endpoints {
+		tpl, _ := pathtmpl.Template(e.Path)
+		ops, ok := byPath[tpl]
+		if !ok {
+			ops = map[string]*operation{}
+			byPath[tpl] = ops
+		}
+
+		for _, m := range e.Methods {
+			op := ops[m]
+			if op == nil {
+				op = &operation{method: m, statuses: map[int]bool{}}
+				ops[m] = op
+			}
+			for _, c := range e.Statuses[m] {
+				if c > 0 {
+					op.statuses[c] = true
+				}
+			}
+			resp, req, query := observed(e, m)
+			if resp != nil && (op.schema == nil || resp.Samples > op.schema.Samples) {
+				op.schema = resp
+			}
+			if req != nil && op.request == nil {
+				op.request = req
+			}
+			op.query = appendUnique(op.query, query...)
+			op.observe(e, e.Source, e.Confidence)
+		}
+
+		for _, m := range e.Allow {
+			if m == http.MethodOptions || m == http.MethodHead {
+				continue
+			}
+			op := ops[m]
+			if op == nil {
+				op = &operation{method: m, statuses: map[int]bool{}}
+				ops[m] = op
+			}
+			op.observe(e, "allow-header", "low")
+		}
+	}
+
+	doc := &openapi3.T{
+		OpenAPI: Version,
+		Info:    &openapi3.Info{Title: title, Version: ver},
+		Paths:   openapi3.NewPaths(),
+	}
+	if api.BaseURL != "" {
+		doc.Servers = openapi3.Servers{{URL: strings.TrimRight(api.BaseURL, "/")}}
+	}
+
+	usedIDs := map[string]bool{}
+	paths := make([]string, 0, len(byPath))
+	for p := range byPath {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	for _, p := range paths {
+		item := &openapi3.PathItem{}
+		for _, name := range pathtmpl.Params(p) {
+			item.Parameters = append(item.Parameters, &openapi3.ParameterRef{
+				Value: openapi3.NewPathParameter(name).WithSchema(openapi3.NewStringSchema()),
+			})
+		}
+
+		methods := make([]string, 0, len(byPath[p]))
+		for m := range byPath[p] {
+			methods = append(methods, m)
+		}
+		sort.Strings(methods)
+
+		for _, m := range methods {
+			op, err := byPath[p][m].build(p, usedIDs)
+			if err != nil {
+				return nil, err
+			}
+			item.SetOperation(m, op)
+		}
+
+		doc.Paths.Set(p, item)
+	}
+
+	return doc, nil
+}
+
+// observed is what e showed for method m. Traffic keeps this per
+// method; models without it (probing, older workspaces) only have the
+// view across methods, taken as the GET response and query and as the
+// request body of the other methods.
+func observed(e store.Endpoint, m string) (resp, req *schema.Schema, query []string) {
+	if e.Responses != nil || e.Requests != nil || e.Params != nil {
+		return e.Responses[m], e.Requests[m], e.Params[m]
+	}
+	if m == http.MethodGet {
+		return e.Schema, nil, e.Query
+	}
+	return nil, e.RequestSchema, nil
+}
+
+func (op *operation) observe(e store.Endpoint, source, confidence string) {
+	if op.evidence.Source == "" || rank(confidence) > rank(op.evidence.Confidence) {
+		op.evidence.Source = source
+		op.evidence.Confidence = confidence
+	}
+	if !e.FirstSeen.IsZero() && (op.first.IsZero() || e.FirstSeen.Before(op.first)) {
+		op.first = e.FirstSeen
+		op.evidence.FirstSeen = e.FirstSeen.UTC().Format(time.RFC3339)
+	}
+}
+
+func rank(confidence string) int {
+	switch strings.ToLower(confidence) {
+	case "high":
+		return 3
+	case "medium":
+		return 2
+	case "low":
+		return 1
+	}
+	return 0
+}
+
+func (op *operation) build(path string, used map[string]bool) (*openapi3.Operation, error) {
+	o := openapi3.NewOperation()
+	o.OperationID = operationID(op.method, path, used)
+	o.Extensions = map[string]any{EvidenceKey: op.evidence}
+
+	sort.Strings(op.query)
+	for _, q := range op.query {
+		o.AddParameter(openapi3.NewQueryParameter(q).WithSchema(openapi3.NewStringSchema()))
+	}
+
+	if op.request != nil {
+		s, err := toOpenAPI(op.request)
+		if err != nil {
+			return nil, fmt.Errorf("%s %s: %w", op.method, path, err)
+		}
+		o.RequestBody = &openapi3.RequestBodyRef{Value: openapi3.NewRequestBody().WithJSONSchema(s)}
+	}
+
+	var body *openapi3.Schema
+	if op.schema != nil {
+		s, err := toOpenAPI(op.schema)
+		if err != nil {
+			return nil, fmt.Errorf("%s %s: %w", op.method, path, err)
+		}
+		body = s
+	}
+
+	codes := make([]int, 0, len(op.statuses))
+	for c := range op.statuses {
+		codes = append(codes, c)
+	}
+	sort.Ints(codes)
+
+	o.Responses = openapi3.NewResponsesWithCapacity(len(codes) + 1)
+	for _, c := range codes {
+		desc := http.StatusText(c)
+		if desc == "" {
+			desc = "Observed response"
+		}
+		r := openapi3.NewResponse().WithDescription(desc)
+		if body != nil && c < 400 {
+			r.WithJSONSchema(body)
+		}
+		o.Responses.Set(strconv.Itoa(c), &openapi3.ResponseRef{Value: r})
+	}
+	if len(codes) == 0 {
+		desc := "Not observed; method advertised by the server"
+		o.Responses.Set("default", &openapi3.ResponseRef{Value: openapi3.NewResponse().WithDescription(desc)})
+	}
+
+	return o, nil
+}
+
+func appendUnique(in []string, vals ...string) []string {
+	for _, v := range vals {
+		found := false
+		for _, x := range in {
+			if x == v {
+				found = true
+				break
+			}
+		}
+		if !found {
+			in = append(in, v)
+		}
+	}
+	return in
+}
+
+func operationID(method, path string, used map[string]bool) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(method))
+	for _, seg := range strings.FieldsFunc(path, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9')
+	}) {
+		b.WriteString(strings.ToUpper(seg[:1]) + seg[1:])
+	}
+	id := b.String()
+	if id == strings.ToLower(method) {
+		id += "Root"
+	}
+	if !used[id] {
+		used[id] = true
+		return id
+	}
+	for i := 2; ; i++ {
+		n := id + strconv.Itoa(i)
+		if !used[n] {
+			used[n] = true
+			return n
+		}
+	}
+}
+
+// toOpenAPI converts an inferred JSON Schema into kin-openapi's model.
+// OpenAPI 3.1 schemas are JSON Schema, so a JSON round-trip is lossless;
+// the $schema and x-samples bookkeeping is left out of the document.
+func toOpenAPI(s *schema.Schema) (*openapi3.Schema, error) {
+	c := *s
+	c.Draft = ""
+	c.Samples = 0
+	b, err := json.Marshal(&c)
+	if err != nil {
+		return nil, err
+	}
+	var out openapi3.Schema
+	if err := json.Unmarshal(b, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Validate checks doc with kin-openapi. kin-openapi validates with
+// OpenAPI 3.0 rules, so 3.1 constructs (type: [..., "null"], arrays
+// without observed items) are projected onto their 3.0 equivalents first:
+// what passes is that 3.0 projection, not the 3.1 document as written.
+func Validate(ctx context.Context, doc *openapi3.T) error {
+	b, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	var cp openapi3.T
+	if err := json.Unmarshal(b, &cp); err != nil {
+		return err
+	}
+
+	for _, item := range cp.Paths.Map() {
+		for _, op := range item.Operations() {
+			if rb := op.RequestBody; rb != nil && rb.Value != nil {
+				for _, mt := range rb.Value.Content {
+					if mt.Schema != nil {
+						downgrade(mt.Schema.Value)
+					}
+				}
+			}
+			if op.Responses == nil {
+				continue
+			}
+			for _, rr := range op.Responses.Map() {
+				if rr.Value == nil {
+					continue
+				}
+				for _, mt := range rr.Value.Content {
+					if mt.Schema != nil {
+						downgrade(mt.Schema.Value)
+					}
+				}
+			}
+		}
+	}
+
+	return cp.Validate(ctx)
+}
+
+func downgrade(s *openapi3.Schema) {
+	if s == nil {
+		return
+	}
+	if s.Type != nil && s.Type.Includes("null") {
+		var keep openapi3.Types
+		for _, t := range *s.Type {
+			if t != "null" {
+				keep = append(keep, t)
+			}
+		}
+		s.Nullable = true
+		if len(keep) == 0 {
+			s.Type = nil
+		} else {
+			s.Type = &keep
+		}
+		var enum []any
+		for _, v := range s.Enum {
+			if v != nil {
+				enum = append(enum, v)
+			}
+		}
+		s.Enum = enum
+	}
+	if s.Type != nil && s.Type.Includes("array") && s.Items == nil {
+		s.Items = openapi3.NewSchemaRef("", &openapi3.Schema{})
+	}
+	if s.Items != nil {
+		downgrade(s.Items.Value)
+	}
+	for _, p := range s.Properties {
+		downgrade(p.Value)
+	}
+	for _, a := range s.AnyOf {
+		downgrade(a.Value)
+	}
+}
+
+// Marshal renders doc as "yaml" or "json".
+func Marshal(doc *openapi3.T, format string) ([]byte, error) {
+	switch strings.ToLower(format) {
+	case "json":
+		return json.MarshalIndent(doc, "", "  ")
+	case "yaml", "yml", "":
+		return yaml.Marshal(doc)
+	}
+	return nil, fmt.Errorf("unsupported format: %s", format)
+}
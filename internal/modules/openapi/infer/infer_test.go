@@ -0,0 +1,68 @@
+package infer
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/bspippi1337/restless/internal/traffic"
+)
+
+func exchange(method, rawURL string, status int, reqBody, respBody string) traffic.Exchange {
+	u, _ := url.Parse(rawURL)
+	json := http.Header{"Content-Type": {"application/json"}}
+	x := traffic.Exchange{Method: method, URL: u, Status: status, RequestHeader: http.Header{}, ResponseHeader: json, ResponseBody: []byte(respBody)}
+	if reqBody != "" {
+		x.RequestHeader = json
+		x.RequestBody = []byte(reqBody)
+	}
+	return x
+}
+
+func TestBuildKeepsMethodsApart(t *testing.T) {
+	l := traffic.NewLearner("har")
+	l.Add(exchange("GET", "https://api.test/pets?api_key=k", 200, "", `[{"id": 1, "name": "rex"}]`))
+	l.Add(exchange("GET", "https://api.test/pets?api_key=k", 200, "", `[{"id": 2, "name": "fido"}]`))
+	l.Add(exchange("POST", "https://api.test/pets", 201, `{"name": "tom"}`, `{"id": 3, "name": "tom"}`))
+
+	doc, err := Build(l.API(), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	item := doc.Paths.Find("/pets")
+	if item == nil || item.Get == nil || item.Post == nil {
+		t.Fatalf("operations: %+v", item)
+	}
+
+	get := item.Get.Responses.Status(200).Value.Content.Get("application/json").Schema.Value
+	if !get.Type.Is("array") {
+		t.Fatalf("GET 200 should be an array, got %v", get.Type)
+	}
+	post := item.Post.Responses.Status(201).Value.Content.Get("application/json").Schema.Value
+	if !post.Type.Is("object") {
+		t.Fatalf("POST 201 should be an object, got %v", post.Type)
+	}
+
+	if item.Get.Parameters.GetByInAndName("query", "api_key") == nil {
+		t.Fatalf("GET lost its api_key query parameter")
+	}
+	if item.Post.Parameters.GetByInAndName("query", "api_key") != nil {
+		t.Fatalf("POST never sent api_key but documents it")
+	}
+	if item.Get.RequestBody != nil || item.Post.RequestBody == nil {
+		t.Fatalf("request bodies: GET %v POST %v", item.Get.RequestBody, item.Post.RequestBody)
+	}
+
+	if err := Validate(context.Background(), doc); err != nil {
+		t.Fatal(err)
+	}
+	b, err := Marshal(doc, "yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(b), "x-samples") {
+		t.Fatalf("x-samples leaked into the document:\n%s", b)
+	}
+}
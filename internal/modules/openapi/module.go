@@ -1,9 +1,52 @@
 package openapi
 
-import "github.com/bspippi1337/restless/internal/core/app"
+import (
+	"net/http"
+	"sync"
 
-type Module struct{}
+	"github.com/bspippi1337/restless/internal/core/app"
+	"github.com/bspippi1337/restless/internal/modules/openapi/guard/model"
+)
 
-func New() *Module                               { return &Module{} }
-func (m *Module) Name() string                   { return "openapi" }
-func (m *Module) Register(r *app.Registry) error { return nil }
+// Module checks every response against the contract for its URL (see
+// MaybeValidateResponse) and keeps what it finds for the caller to
+// report.
+type Module struct {
+	mu       sync.Mutex
+	findings []model.Finding
+	total    int
+
+	contracts map[string]contract
+}
+
+func New() *Module             { return &Module{contracts: map[string]contract{}} }
+func (m *Module) Name() string { return "openapi" }
+
+func (m *Module) Register(r *app.Registry) error {
+	r.ResponseMutators = append(r.ResponseMutators, func(rc *app.ResponseContext) error {
+		ct := http.Header(rc.Header).Get("Content-Type")
+		fs := m.validate(rc.Context, rc.URL, rc.Method, rc.StatusCode, ct, rc.Body)
+		m.mu.Lock()
+		m.findings = append(m.findings, fs...)
+		m.total += len(fs)
+		m.mu.Unlock()
+		return nil
+	})
+	return nil
+}
+
+// Take returns the findings since the last Take.
+func (m *Module) Take() []model.Finding {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	fs := m.findings
+	m.findings = nil
+	return fs
+}
+
+// Violations counts every finding so far, taken or not.
+func (m *Module) Violations() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.total
+}
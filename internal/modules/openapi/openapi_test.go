@@ -0,0 +1,294 @@
+package openapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bspippi1337/restless/internal/core/app"
+	"github.com/bspippi1337/restless/internal/core/types"
+	"github.com/bspippi1337/restless/internal/modules/openapi/ai"
+	"github.com/bspippi1337/restless/internal/modules/openapi/guard/model"
+	gruntime "github.com/bspippi1337/restless/internal/modules/openapi/guard/runtime"
+)
+
+const petstore = `{
+  "openapi": "3.0.3",
+  "info": {"title": "Pets", "version": "1"},
+  "servers": [{"url": "https://pets.example/v1"}],
+  "paths": {
+    "/pets": {"get": {"summary": "List pets", "tags": ["pets"]}},
+    "/pets/{petId}": {
+      "parameters": [{"name": "petId", "in": "path", "required": true}],
+      "get": {"operationId": "showPet", "tags": ["pets"]},
+      "delete": {"tags": ["admin"]}
+    }
+  }
+}`
+
+func TestImportRun(t *testing.T) {
+	Root = t.TempDir()
+	defer func() { Root = "" }()
+
+	src := filepath.Join(t.TempDir(), "pets.json")
+	if err := os.WriteFile(src, []byte(petstore), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	idx, err := Import(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := Resolve(idx.ID[:5])
+	if err != nil || got.Title != "Pets" {
+		t.Fatalf("resolve: %v %+v", err, got)
+	}
+
+	eps, err := ListEndpoints(idx.ID)
+	if err != nil || len(eps) != 3 {
+		t.Fatalf("endpoints: %v %+v", err, eps)
+	}
+	if f := FilterEndpoints(eps, "PETS", "show"); len(f) != 1 || f[0].OperationID != "showPet" {
+		t.Fatalf("filter: %+v", f)
+	}
+
+	spec, _ := LoadSpecFromFile(got.RawPath)
+	tpl, vals, err := MatchEndpoint(spec, "get", "/pets/42")
+	if err != nil || tpl != "/pets/{petId}" || vals["petId"] != "42" {
+		t.Fatalf("match: %v %q %v", err, tpl, vals)
+	}
+	if _, _, err := MatchEndpoint(spec, "POST", "/pets/42"); err == nil {
+		t.Fatal("matched an undeclared method")
+	}
+	req, _, err := BuildRequest(got, spec, RunArgs{Method: "GET", Path: tpl, PathParams: vals, QueryParams: map[string]string{"x": "1"}})
+	if err != nil || req.URL != "https://pets.example/v1/pets/42?x=1" {
+		t.Fatalf("request: %v %s", err, req.URL)
+	}
+
+	if err := Remove(got); err != nil {
+		t.Fatal(err)
+	}
+	if specs, _ := ListSpecs(); len(specs) != 0 {
+		t.Fatalf("left behind: %+v", specs)
+	}
+}
+
+func TestImportBundlesAndConverts(t *testing.T) {
+	Root = t.TempDir()
+	defer func() { Root = "" }()
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"api.yaml": `openapi: 3.0.3
+info: {title: Shop, version: "2"}
+servers: [{url: "http://{host}/api", variables: {host: {default: shop.example}}}]
+paths:
+  /orders/{orderId}:
+    parameters:
+      - {name: orderId, in: path, required: true, schema: {type: integer}}
+    put:
+      parameters:
+        - {name: X-Tenant, in: header, required: true, schema: {type: string}}
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema: {$ref: "order.yaml#/Order"}
+      responses: {"204": {description: Updated}}
+`,
+		"order.yaml": `Order:
+  type: object
+  properties:
+    id: {type: integer, readOnly: true}
+    sku: {type: string}
+`,
+		"swagger.json": `{"swagger": "2.0", "info": {"title": "Old", "version": "1"},
+  "host": "old.example", "basePath": "/v1", "schemes": ["https"],
+  "paths": {"/search": {"get": {
+    "parameters": [{"name": "q", "in": "query", "required": true, "type": "string"}],
+    "responses": {"200": {"description": "ok"}}}}}}`,
+	}
+	for name, body := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	idx, err := Import(filepath.Join(dir, "api.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if idx.BaseURL != "http://shop.example/api" {
+		t.Fatalf("base: %s", idx.BaseURL)
+	}
+	raw, _ := os.ReadFile(idx.RawPath)
+	if strings.Contains(string(raw), "order.yaml") {
+		t.Fatalf("external $ref left in the cached spec:\n%s", raw)
+	}
+	// The cached copy must stand on its own.
+	os.Remove(filepath.Join(dir, "order.yaml"))
+	spec, err := LoadSpecFromFile(idx.RawPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	op, params, err := Operation(spec, "PUT", "/orders/{orderId}")
+	if err != nil || len(params) != 2 {
+		t.Fatalf("operation: %v %d params", err, len(params))
+	}
+	pf := NewPreflight(spec)
+	err = pf.Check("PUT", "http://shop.example/api/orders/x", http.Header{}, []byte(`{"sku": 1}`))
+	var ce *ContractError
+	if !errors.As(err, &ce) || len(ce.Findings) != 3 {
+		t.Fatalf("preflight: %v", err)
+	}
+	for i, at := range []string{"path.orderId", "header.X-Tenant", "$.sku"} {
+		if ce.Findings[i].JSONPath != at {
+			t.Fatalf("finding %d at %s, want %s: %v", i, ce.Findings[i].JSONPath, at, err)
+		}
+	}
+	h := http.Header{"X-Tenant": {"a"}, "Content-Type": {"application/json"}}
+	if err := pf.Check("PUT", "http://shop.example/api/orders/7", h, []byte(`{"sku": "a"}`)); err != nil {
+		t.Fatalf("preflight: %v", err)
+	}
+	ct, body, ok := BodyTemplate(op)
+	if !ok || ct != "application/json" || !strings.Contains(string(body), `"sku"`) || strings.Contains(string(body), `"id"`) {
+		t.Fatalf("template: %s %s", ct, body)
+	}
+
+	old, err := Import(filepath.Join(dir, "swagger.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if old.BaseURL != "https://old.example/v1" {
+		t.Fatalf("swagger base: %s", old.BaseURL)
+	}
+	eps, err := ListEndpoints(old.ID)
+	if err != nil || len(eps) != 1 || eps[0].Path != "/search" {
+		t.Fatalf("swagger endpoints: %v %+v", err, eps)
+	}
+}
+
+func TestModuleChecksResponses(t *testing.T) {
+	Root = t.TempDir()
+	defer func() { Root = "" }()
+	t.Setenv("HOME", t.TempDir())
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"id": "seven"}`)
+	}))
+	defer srv.Close()
+
+	spec := `{"openapi": "3.0.3", "info": {"title": "Pets", "version": "1"},
+  "servers": [{"url": "` + srv.URL + `/v1"}],
+  "paths": {"/pets/{petId}": {"get": {"responses": {"200": {"description": "ok",
+    "content": {"application/json": {"schema": {"type": "object",
+      "properties": {"id": {"type": "integer"}}}}}}}}}}}`
+	src := filepath.Join(t.TempDir(), "pets.json")
+	if err := os.WriteFile(src, []byte(spec), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Import(src); err != nil {
+		t.Fatal(err)
+	}
+
+	m := New()
+	a, err := app.New([]app.Module{m})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.RunOnce(context.Background(), types.Request{Method: "GET", URL: srv.URL + "/v1/pets/7"}); err != nil {
+		t.Fatal(err)
+	}
+	fs := m.Take()
+	if len(fs) != 1 || fs[0].JSONPath != "$.id" || fs[0].Kind != model.KindTypeMismatch {
+		t.Fatalf("findings: %+v", fs)
+	}
+	if m.Take() != nil || m.Violations() != 1 {
+		t.Fatalf("take/violations: %d", m.Violations())
+	}
+	snap, err := ai.Load(SnapshotBase(srv.URL + "/v1/pets/7"))
+	if err != nil || snap.TotalEvents != 1 {
+		t.Fatalf("snapshot: %v %+v", err, snap)
+	}
+}
+
+func TestModuleProbesOnce(t *testing.T) {
+	Root = t.TempDir()
+	defer func() { Root = "" }()
+	t.Setenv("HOME", t.TempDir())
+
+	var probes int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/items" {
+			probes++
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"items": []}`)
+	}))
+	defer srv.Close()
+
+	a, err := app.New([]app.Module{New()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for page := 1; page <= 3; page++ {
+		if _, err := a.RunOnce(context.Background(), types.Request{Method: "GET", URL: fmt.Sprintf("%s/items?page=%d", srv.URL, page)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if probes != 5 {
+		t.Fatalf("%d discovery requests for 3 responses, want 5", probes)
+	}
+
+	// Nor does a fresh lookup in the same process.
+	if fs := MaybeValidateResponse(context.Background(), srv.URL+"/items", "GET", 200, "application/json", []byte(`{}`)); fs != nil || probes != 5 {
+		t.Fatalf("second module: %d probes, %+v", probes, fs)
+	}
+}
+
+func TestPreflightOpenAPI30(t *testing.T) {
+	spec := `{"openapi": "3.0.3", "info": {"title": "Items", "version": "1"},
+  "paths": {"/items": {"post": {
+    "parameters": [{"name": "limit", "in": "query", "schema": {"type": "integer", "minimum": 0, "exclusiveMinimum": true}}],
+    "requestBody": {"content": {"application/json": {"schema": {"type": "object",
+      "properties": {"x": {"type": "string", "nullable": true, "enum": ["a", "b"]}}}}}},
+    "responses": {"200": {"description": "ok", "content": {"application/json": {"schema": {"type": "object",
+      "properties": {"score": {"type": "number", "maximum": 1, "exclusiveMaximum": true, "nullable": true}}}}}}}}}}}`
+	src := filepath.Join(t.TempDir(), "items.json")
+	if err := os.WriteFile(src, []byte(spec), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	doc, err := LoadSpecFromFile(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pf := NewPreflight(doc)
+	h := http.Header{"Content-Type": {"application/json"}}
+	if err := pf.Check("POST", "http://h/items?limit=1", h, []byte(`{"x": null}`)); err != nil {
+		t.Fatalf("valid request: %v", err)
+	}
+	err = pf.Check("POST", "http://h/items?limit=0", h, []byte(`{"x": "c"}`))
+	var ce *ContractError
+	if !errors.As(err, &ce) || len(ce.Findings) != 2 || ce.Findings[0].JSONPath != "query.limit" || ce.Findings[1].JSONPath != "$.x" {
+		t.Fatalf("invalid request: %v", err)
+	}
+
+	v := gruntime.NewValidator(doc)
+	for body, want := range map[string]int{`{"score": null}`: 0, `{"score": 0.5}`: 0, `{"score": 1}`: 1} {
+		fs, err := v.ValidateResponse(context.Background(), "POST", "/items", 200, "application/json", []byte(body))
+		if err != nil || len(fs) != want {
+			t.Fatalf("response %s: %v %+v", body, err, fs)
+		}
+	}
+}
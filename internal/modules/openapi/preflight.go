@@ -0,0 +1,152 @@
+package openapi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+
+	"github.com/bspippi1337/restless/internal/core/app"
+	"github.com/bspippi1337/restless/internal/modules/openapi/guard/model"
+	gruntime "github.com/bspippi1337/restless/internal/modules/openapi/guard/runtime"
+)
+
+// Preflight is a module that checks each request against its operation
+// before it is sent and fails it when it breaks the contract. Requests
+// to operations the spec does not declare go through unchecked.
+type Preflight struct {
+	// Doc is the contract; nil means the imported spec whose base URL
+	// the request falls under.
+	Doc *openapi3.T
+
+	specs map[string]*openapi3.T
+}
+
+func NewPreflight(doc *openapi3.T) *Preflight {
+	return &Preflight{Doc: doc, specs: map[string]*openapi3.T{}}
+}
+
+func (p *Preflight) Name() string { return "openapi-preflight" }
+
+// Register adds the check after the modules registered before it, so it
+// sees the request as templating and auth left it.
+func (p *Preflight) Register(r *app.Registry) error {
+	r.RequestMutators = append(r.RequestMutators, func(rc *app.RequestContext) error {
+		return p.Check(rc.Method, rc.URL, rc.Header, rc.Body)
+	})
+	return nil
+}
+
+// Check validates one request; the error is a *ContractError when the
+// request breaks the contract.
+func (p *Preflight) Check(method, rawURL string, header http.Header, body []byte) error {
+	doc := p.contract(rawURL)
+	if doc == nil {
+		return nil
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil
+	}
+	tpl, vals, ok := matchSuffix(doc, method, u.Path)
+	if !ok {
+		return nil
+	}
+	// Headers come from flags and flow files as typed; look them up
+	// the way a server would.
+	h := http.Header{}
+	for k, vv := range header {
+		for _, v := range vv {
+			h.Add(k, v)
+		}
+	}
+	findings, err := gruntime.NewValidator(doc).ValidateRequest(context.Background(), method, tpl, vals, u.Query(), h, body)
+	if err != nil {
+		return err
+	}
+	if len(findings) > 0 {
+		return &ContractError{Method: strings.ToUpper(method), Path: tpl, Findings: findings}
+	}
+	return nil
+}
+
+func (p *Preflight) contract(rawURL string) *openapi3.T {
+	if p.Doc != nil {
+		return p.Doc
+	}
+	idx, ok := ForURL(rawURL)
+	if !ok {
+		return nil
+	}
+	if doc, ok := p.specs[idx.ID]; ok {
+		return doc
+	}
+	doc, err := LoadSpecFromFile(idx.RawPath)
+	if err != nil {
+		doc = nil
+	}
+	p.specs[idx.ID] = doc
+	return doc
+}
+
+// OperationFor finds the operation a request to rawURL goes to in doc,
+// or in the imported spec the URL falls under when doc is nil.
+func OperationFor(doc *openapi3.T, method, rawURL string) (*openapi3.Operation, openapi3.Parameters, bool) {
+	if doc == nil {
+		doc = NewPreflight(nil).contract(rawURL)
+		if doc == nil {
+			return nil, nil, false
+		}
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, nil, false
+	}
+	tpl, _, ok := matchSuffix(doc, method, u.Path)
+	if !ok {
+		return nil, nil, false
+	}
+	op, params, err := Operation(doc, method, tpl)
+	return op, params, err == nil
+}
+
+// matchSuffix finds the operation for a request path that may carry the
+// server's base path (/api/v1/pets/42): the longest tail of it the spec
+// declares wins.
+func matchSuffix(doc *openapi3.T, method, path string) (string, map[string]string, bool) {
+	segs := strings.Split(strings.Trim(path, "/"), "/")
+	for i := range segs {
+		tail := "/" + strings.Join(segs[i:], "/")
+		if tpl, vals, err := MatchEndpoint(doc, method, tail); err == nil {
+			if vals == nil {
+				vals = map[string]string{}
+			}
+			return tpl, vals, true
+		}
+	}
+	return "", nil, false
+}
+
+// ContractError is a request that breaks the contract it is sent under.
+type ContractError struct {
+	Method   string
+	Path     string
+	Findings []model.Finding
+}
+
+func (e *ContractError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s does not match the contract:\n", e.Method, e.Path)
+	for _, f := range e.Findings {
+		fmt.Fprintf(&b, "  %-20s %s [%s/%s]", f.JSONPath, f.Message, f.Kind, f.Severity)
+		if f.Expected != "" {
+			fmt.Fprintf(&b, " (want %s)", f.Expected)
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("pass --no-validate to send it anyway")
+	return b.String()
+}
@@ -4,11 +4,12 @@ import (
 	"errors"
 	"fmt"
 	"net/url"
-	"os"
-	"path/filepath"
 	"strings"
 
+	"github.com/getkin/kin-openapi/openapi3"
+
 	"github.com/bspippi1337/restless/internal/core/types"
+	"github.com/bspippi1337/restless/internal/pathtmpl"
 )
 
 type RunArgs struct {
@@ -26,7 +27,7 @@ type RunArgs struct {
 	SaveAsName string
 }
 
-func BuildRequest(idx SpecIndex, spec Spec, ra RunArgs) (types.Request, string, error) {
+func BuildRequest(idx SpecIndex, spec *openapi3.T, ra RunArgs) (types.Request, string, error) {
 	method := strings.ToUpper(strings.TrimSpace(ra.Method))
 	if method == "" {
 		return types.Request{}, "", errors.New("missing method")
@@ -36,7 +37,10 @@ func BuildRequest(idx SpecIndex, spec Spec, ra RunArgs) (types.Request, string,
 		return types.Request{}, "", errors.New("missing path")
 	}
 
-	base := strings.TrimRight(spec.BaseURL(), "/")
+	base := BaseURL(spec)
+	if idx.BaseURL != "" {
+		base = strings.TrimRight(idx.BaseURL, "/")
+	}
 	if ra.BaseOverride != "" {
 		base = strings.TrimRight(ra.BaseOverride, "/")
 	}
@@ -117,16 +121,30 @@ func shellEscape(s string) string {
 	return "'" + strings.ReplaceAll(s, "'", `'"'"'`) + "'"
 }
 
-// Validate method/path exists
-func ValidateEndpoint(spec Spec, method, path string) error {
-	item, ok := spec.Paths[path]
-	if !ok {
-		return errors.New("path not found in spec")
-	}
-	if _, ok := item[strings.ToLower(method)]; !ok {
-		return errors.New("method not allowed for this path")
+// MatchEndpoint finds the declared path for method and path. A concrete
+// path (/pets/42) matches its template (/pets/{petId}); the values it
+// binds are returned.
+func MatchEndpoint(spec *openapi3.T, method, path string) (string, map[string]string, error) {
+	if err := ValidateEndpoint(spec, method, path); err == nil {
+		return path, nil, nil
+	}
+	for _, tpl := range spec.Paths.InMatchingOrder() {
+		vals, ok := pathtmpl.Match(tpl, path)
+		if !ok || !strings.Contains(tpl, "{") {
+			continue
+		}
+		if err := ValidateEndpoint(spec, method, tpl); err != nil {
+			return "", nil, fmt.Errorf("%s %s: %w", strings.ToUpper(method), tpl, err)
+		}
+		return tpl, vals, nil
 	}
-	return nil
+	return "", nil, fmt.Errorf("%s %s is not in the spec", strings.ToUpper(method), path)
+}
+
+// Validate method/path exists
+func ValidateEndpoint(spec *openapi3.T, method, path string) error {
+	_, _, err := Operation(spec, method, path)
+	return err
 }
 
 // Check missing path params
@@ -149,18 +167,7 @@ func ValidatePathParams(path string, params map[string]string) error {
 	return nil
 }
 
-func strictEnabled() bool {
-	return os.Getenv("RESTLESS_STRICT") == "1"
-}
-
-// SPEC_LOADER_START
-// --- DISK LOADER PATCH ---
-
-func loadSpecFromDisk(openapiDir, id string) ([]byte, error) {
-	specPath := filepath.Join(openapiDir, id+".json")
-	raw, err := os.ReadFile(specPath)
-	if err != nil {
-		return nil, fmt.Errorf("spec: failed to read stored spec: %w", err)
-	}
-	return raw, nil
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	return strings.TrimSpace(line)
 }
@@ -1,86 +1,108 @@
 package openapi
 
 import (
-	"encoding/json"
+	"context"
 	"errors"
-	"os"
+	"sort"
 	"strings"
 
-	"gopkg.in/yaml.v3"
-)
-
-type Spec struct {
-	OpenAPI string `json:"openapi" yaml:"openapi"`
-	Info    Info   `json:"info" yaml:"info"`
-	Servers []Srv  `json:"servers" yaml:"servers"`
-	Paths   Paths  `json:"paths" yaml:"paths"`
-}
-
-type Info struct {
-	Title   string `json:"title" yaml:"title"`
-	Version string `json:"version" yaml:"version"`
-}
+	"github.com/getkin/kin-openapi/openapi3"
 
-type Srv struct {
-	URL string `json:"url" yaml:"url"`
-}
-
-type Paths map[string]PathItem
-
-// PathItem keys are HTTP methods (get/post/put/patch/delete/options/head/trace)
-type PathItem map[string]Operation
+	gloader "github.com/bspippi1337/restless/internal/modules/openapi/guard/loader"
+)
 
-type Operation struct {
-	Summary     string `json:"summary" yaml:"summary"`
-	OperationID string `json:"operationId" yaml:"operationId"`
+// LoadSpecFromFile loads an OpenAPI document, following its $refs. Swagger
+// 2.0 is converted to OpenAPI 3.
+func LoadSpecFromFile(path string) (*openapi3.T, error) {
+	return loadSpec(context.Background(), path)
 }
 
-func LoadSpecFromFile(path string) (Spec, error) {
-	b, err := os.ReadFile(path)
+func loadSpec(ctx context.Context, ref string) (*openapi3.T, error) {
+	doc, err := gloader.Load(ctx, ref, gloader.LoadOptions{AllowRemoteRefs: true, Lenient: true})
 	if err != nil {
-		return Spec{}, err
+		return nil, err
+	}
+	if doc.OpenAPI == "" || doc.Paths == nil {
+		return nil, errors.New("invalid spec: missing openapi version or paths")
 	}
-	return LoadSpec(b)
+	return doc, nil
 }
 
-func LoadSpec(raw []byte) (Spec, error) {
-	// Try JSON first
-	var s Spec
-	if err := json.Unmarshal(raw, &s); err == nil && s.Paths != nil {
-		return validateSpec(s)
+// BaseURL is the first server's URL with its variables at their defaults.
+func BaseURL(doc *openapi3.T) string {
+	if len(doc.Servers) == 0 || doc.Servers[0] == nil {
+		return ""
 	}
-	// Then YAML
-	if err := yaml.Unmarshal(raw, &s); err == nil && s.Paths != nil {
-		return validateSpec(s)
+	s := doc.Servers[0]
+	u := s.URL
+	for name, v := range s.Variables {
+		if v != nil {
+			u = strings.ReplaceAll(u, "{"+name+"}", v.Default)
+		}
 	}
-	// Last attempt: YAML may parse into map if weird anchors; still fail fast
-	return Spec{}, errors.New("failed to parse spec as JSON or YAML")
+	return strings.TrimRight(u, "/")
 }
 
-func validateSpec(s Spec) (Spec, error) {
-	if s.Paths == nil {
-		return Spec{}, errors.New("invalid spec: missing paths")
+// Operation returns the operation declared for method on the path
+// template tpl, and the parameters it takes: those of the path item
+// overridden by the operation's own.
+func Operation(doc *openapi3.T, method, tpl string) (*openapi3.Operation, openapi3.Parameters, error) {
+	item := doc.Paths.Value(tpl)
+	if item == nil {
+		return nil, nil, errors.New("path not found in spec")
 	}
-	// Normalize method keys to lowercase
-	n := Spec{
-		OpenAPI: s.OpenAPI,
-		Info:    s.Info,
-		Servers: s.Servers,
-		Paths:   Paths{},
+	op := item.GetOperation(strings.ToUpper(method))
+	if op == nil {
+		return nil, nil, errors.New("method not allowed for this path")
 	}
-	for p, item := range s.Paths {
-		nItem := PathItem{}
-		for m, op := range item {
-			nItem[strings.ToLower(m)] = op
+
+	var params openapi3.Parameters
+	seen := map[string]bool{}
+	for _, set := range []openapi3.Parameters{op.Parameters, item.Parameters} {
+		for _, p := range set {
+			if p == nil || p.Value == nil {
+				continue
+			}
+			key := p.Value.In + ":" + p.Value.Name
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			params = append(params, p)
 		}
-		n.Paths[p] = nItem
 	}
-	return n, nil
+	sort.SliceStable(params, func(i, j int) bool {
+		return paramOrder[params[i].Value.In] < paramOrder[params[j].Value.In]
+	})
+	return op, params, nil
 }
 
-func (s Spec) BaseURL() string {
-	if len(s.Servers) == 0 {
-		return ""
+var paramOrder = map[string]int{
+	openapi3.ParameterInPath:   0,
+	openapi3.ParameterInQuery:  1,
+	openapi3.ParameterInHeader: 2,
+	openapi3.ParameterInCookie: 3,
+}
+
+// SchemaType is a short description of a schema's type, such as
+// "integer", "string(uuid)" or "array[string]".
+func SchemaType(ref *openapi3.SchemaRef) string {
+	if ref == nil || ref.Value == nil {
+		return "any"
+	}
+	s := ref.Value
+	switch {
+	case s.Type == nil || len(*s.Type) == 0:
+		if len(s.Enum) > 0 {
+			return "enum"
+		}
+		return "any"
+	case s.Type.Is(openapi3.TypeArray):
+		return "array[" + SchemaType(s.Items) + "]"
+	}
+	t := strings.Join(*s.Type, "|")
+	if s.Format != "" {
+		t += "(" + s.Format + ")"
 	}
-	return strings.TrimRight(s.Servers[0].URL, "/")
+	return t
 }
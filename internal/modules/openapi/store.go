@@ -4,8 +4,12 @@ import (
 	"crypto/sha1"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 )
 
 type SpecIndex struct {
@@ -19,7 +23,22 @@ type SpecIndex struct {
 	IndexPath string `json:"index_path"`
 }
 
+// ShortID is the abbreviated ID that commands print and accept.
+func (idx SpecIndex) ShortID() string {
+	if len(idx.ID) > 12 {
+		return idx.ID[:12]
+	}
+	return idx.ID
+}
+
+// Root is the workspace the catalog lives in (the CLI's --cache);
+// empty means ~/.restless.
+var Root string
+
 func cacheDir() (string, error) {
+	if Root != "" {
+		return filepath.Join(Root, "openapi"), nil
+	}
 	home, err := os.UserHomeDir()
 	if err != nil {
 		return "", err
@@ -32,6 +51,12 @@ func idFor(source string) string {
 	return hex.EncodeToString(h[:])
 }
 
+// specFile is where the imported document is kept, next to its index
+// <id>.json.
+func specFile(dir, id, ext string) string {
+	return filepath.Join(dir, id+".spec"+ext)
+}
+
 func SaveIndex(idx SpecIndex) error {
 	dir, err := cacheDir()
 	if err != nil {
@@ -73,12 +98,15 @@ func ListIndexFiles() ([]string, error) {
 		return nil, err
 	}
 	ents, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
 	if err != nil {
 		return nil, err
 	}
 	var out []string
 	for _, e := range ents {
-		if e.IsDir() {
+		if e.IsDir() || strings.Contains(e.Name(), ".spec.") {
 			continue
 		}
 		if filepath.Ext(e.Name()) == ".json" {
@@ -87,3 +115,84 @@ func ListIndexFiles() ([]string, error) {
 	}
 	return out, nil
 }
+
+// ListSpecs returns every imported spec, newest first.
+func ListSpecs() ([]SpecIndex, error) {
+	files, err := ListIndexFiles()
+	if err != nil {
+		return nil, err
+	}
+	var out []SpecIndex
+	for _, p := range files {
+		idx, err := LoadIndex(strings.TrimSuffix(filepath.Base(p), ".json"))
+		if err != nil {
+			continue
+		}
+		out = append(out, idx)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Imported != out[j].Imported {
+			return out[i].Imported > out[j].Imported
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out, nil
+}
+
+// Resolve finds an imported spec by ID, ID prefix or exact source.
+func Resolve(ref string) (SpecIndex, error) {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return SpecIndex{}, errors.New("missing spec id")
+	}
+	all, err := ListSpecs()
+	if err != nil {
+		return SpecIndex{}, err
+	}
+	var hits []SpecIndex
+	for _, idx := range all {
+		if idx.ID == ref || idx.Source == ref {
+			return idx, nil
+		}
+		if strings.HasPrefix(idx.ID, ref) {
+			hits = append(hits, idx)
+		}
+	}
+	switch len(hits) {
+	case 0:
+		return SpecIndex{}, fmt.Errorf("no imported spec %q (see: restless openapi ls)", ref)
+	case 1:
+		return hits[0], nil
+	}
+	return SpecIndex{}, fmt.Errorf("spec id %q is ambiguous: %d specs match", ref, len(hits))
+}
+
+// Remove deletes an imported spec and its index.
+func Remove(idx SpecIndex) error {
+	if idx.RawPath != "" {
+		if err := os.Remove(idx.RawPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return os.Remove(idx.IndexPath)
+}
+
+// ForURL finds the imported spec a request URL falls under: the one
+// with the longest base URL that prefixes it.
+func ForURL(rawURL string) (SpecIndex, bool) {
+	all, err := ListSpecs()
+	if err != nil {
+		return SpecIndex{}, false
+	}
+	var best SpecIndex
+	for _, idx := range all {
+		base := strings.TrimRight(idx.BaseURL, "/")
+		if base == "" || len(base) <= len(strings.TrimRight(best.BaseURL, "/")) {
+			continue
+		}
+		if rawURL == base || strings.HasPrefix(rawURL, base+"/") || strings.HasPrefix(rawURL, base+"?") {
+			best = idx
+		}
+	}
+	return best, best.ID != ""
+}
@@ -0,0 +1,440 @@
+package suggest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/oasdiff/yaml"
+
+	"github.com/bspippi1337/restless/internal/modules/openapi/guard/model"
+)
+
+// Op is one RFC 6902 JSON Patch operation.
+type Op struct {
+	Op    string `json:"op"`
+	Path  string `json:"path"`
+	Value any    `json:"value"`
+}
+
+// MarshalJSON leaves out the value of a remove, which takes none.
+func (o Op) MarshalJSON() ([]byte, error) {
+	if o.Op == "remove" {
+		return json.Marshal(struct {
+			Op   string `json:"op"`
+			Path string `json:"path"`
+		}{o.Op, o.Path})
+	}
+	type op Op
+	return json.Marshal(op(o))
+}
+
+// Skipped is a suggestion Patch did not turn into operations, and why.
+type Skipped struct {
+	Suggestion Suggestion
+	Reason     string
+}
+
+// LoadSpec reads a spec file as plain JSON values, the form Patch works
+// on, and reports whether it was YAML.
+func LoadSpec(path string) (doc any, isYAML bool, err error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false, err
+	}
+	if json.Unmarshal(b, &doc) == nil {
+		return doc, false, nil
+	}
+	js, err := yaml.YAMLToJSON(b)
+	if err != nil {
+		return nil, false, err
+	}
+	if err := json.Unmarshal(js, &doc); err != nil {
+		return nil, false, err
+	}
+	return doc, true, nil
+}
+
+// Patch turns the suggestions in r with at least minConfidence into a
+// JSON Patch against spec, which it leaves alone. Each suggestion is
+// worked out on the spec as the ones before it left it, so the patch
+// applies in order. Schemas are changed where they are defined: a fix to
+// a $ref'd schema applies wherever it is used.
+func Patch(spec any, r *Report, minConfidence float64) ([]Op, []Skipped, error) {
+	work, err := clone(spec)
+	if err != nil {
+		return nil, nil, err
+	}
+	oas31 := strings.HasPrefix(fmt.Sprint(get(work, "/openapi")), "3.1")
+
+	var ops []Op
+	var skipped []Skipped
+	for _, it := range r.Items {
+		if it.Confidence < minConfidence {
+			skipped = append(skipped, Skipped{it, fmt.Sprintf("confidence %.2f is below %.2f", it.Confidence, minConfidence)})
+			continue
+		}
+		next, err := planOne(work, it, oas31)
+		if err == nil && len(next) == 0 {
+			err = errors.New("the spec already allows it")
+		}
+		if err == nil {
+			err = ApplyPatch(&work, next)
+		}
+		if err != nil {
+			skipped = append(skipped, Skipped{it, err.Error()})
+			continue
+		}
+		ops = append(ops, next...)
+	}
+	return ops, skipped, nil
+}
+
+func planOne(doc any, it Suggestion, oas31 bool) ([]Op, error) {
+	opPtr, err := findOperation(doc, it.Selector.OperationID)
+	if err != nil {
+		return nil, err
+	}
+	responses := opPtr + "/responses"
+	if it.Action == ActionAddResponse {
+		code := strconv.Itoa(it.Selector.Status)
+		if get(doc, responses+"/"+code) != nil {
+			return nil, nil
+		}
+		desc := "Observed in traffic; describe it."
+		if get(doc, responses) == nil {
+			return []Op{{Op: "add", Path: responses, Value: map[string]any{code: map[string]any{"description": desc}}}}, nil
+		}
+		return []Op{{Op: "add", Path: responses + "/" + escape(code), Value: map[string]any{"description": desc}}}, nil
+	}
+
+	resp, err := findResponse(doc, responses, it.Selector.Status)
+	if err != nil {
+		return nil, err
+	}
+	slot, err := jsonSchema(doc, resp)
+	if err != nil {
+		return nil, err
+	}
+	for _, st := range model.SplitJSONPath(it.Selector.JSONPath) {
+		if slot, err = step(doc, slot, st); err != nil {
+			return nil, fmt.Errorf("%s: %w", it.Selector.JSONPath, err)
+		}
+	}
+	def := deref(doc, slot)
+
+	switch it.Action {
+	case ActionMakeOptional:
+		return makeOptional(doc, def, it.Evidence.Message)
+	case ActionBroadenType:
+		return broadenType(doc, slot, def, it.Evidence.Message, oas31)
+	case ActionExtendEnum:
+		return extendEnum(doc, def, it.Evidence.Values)
+	}
+	return nil, fmt.Errorf("%s needs a human", it.Action)
+}
+
+var quoted = regexp.MustCompile(`'([^']*)'`)
+
+// makeOptional drops the properties "missing properties: 'a', 'b'"
+// names from the schema's required list.
+func makeOptional(doc any, def, msg string) ([]Op, error) {
+	var missing []string
+	for _, m := range quoted.FindAllStringSubmatch(msg, -1) {
+		missing = append(missing, m[1])
+	}
+	if len(missing) == 0 {
+		return nil, errors.New("no property named in the finding")
+	}
+	req, _ := get(doc, def+"/required").([]any)
+	keep := []any{}
+	for _, name := range req {
+		if !slices.Contains(missing, fmt.Sprint(name)) {
+			keep = append(keep, name)
+		}
+	}
+	switch {
+	case len(keep) == len(req):
+		return nil, nil
+	case len(keep) == 0:
+		return []Op{{Op: "remove", Path: def + "/required"}}, nil
+	}
+	return []Op{{Op: "replace", Path: def + "/required", Value: keep}}, nil
+}
+
+// broadenType lets the schema at slot also take the type "expected x,
+// but got y" saw. 3.1 lists it in type; 3.0 has nullable for null and
+// anyOf for the rest, wrapped around the slot so other uses of a $ref'd
+// schema keep theirs. The slot moves whole into the first branch, so its
+// format, description and bounds still hold for the original type.
+func broadenType(doc any, slot, def, msg string, oas31 bool) ([]Op, error) {
+	_, got, ok := strings.Cut(msg, "but got ")
+	got = strings.TrimSpace(got)
+	if !ok || got == "" {
+		return nil, errors.New("no observed type in the finding")
+	}
+
+	if oas31 {
+		switch t := get(doc, def+"/type").(type) {
+		case string:
+			return []Op{{Op: "replace", Path: def + "/type", Value: []any{t, got}}}, nil
+		case []any:
+			if containsJSON(t, got) {
+				return nil, nil
+			}
+			return []Op{{Op: "add", Path: def + "/type/-", Value: got}}, nil
+		}
+		return nil, errors.New("schema has no type to broaden")
+	}
+
+	if got == "null" {
+		return []Op{{Op: "add", Path: def + "/nullable", Value: true}}, nil
+	}
+	if alts, ok := get(doc, slot+"/anyOf").([]any); ok {
+		if containsJSON(alts, map[string]any{"type": got}) {
+			return nil, nil
+		}
+		return []Op{{Op: "add", Path: slot + "/anyOf/-", Value: map[string]any{"type": got}}}, nil
+	}
+	orig, err := clone(get(doc, slot))
+	if err != nil {
+		return nil, err
+	}
+	return []Op{{Op: "replace", Path: slot, Value: map[string]any{"anyOf": []any{orig, map[string]any{"type": got}}}}}, nil
+}
+
+func extendEnum(doc any, def string, values []string) ([]Op, error) {
+	enum, ok := get(doc, def+"/enum").([]any)
+	if !ok {
+		return nil, errors.New("schema has no enum")
+	}
+	if len(values) == 0 {
+		return nil, errors.New("no observed values recorded")
+	}
+	var ops []Op
+	for _, raw := range values {
+		var v any
+		if err := json.Unmarshal([]byte(raw), &v); err != nil {
+			continue
+		}
+		if containsJSON(enum, v) {
+			continue
+		}
+		enum = append(enum, v)
+		ops = append(ops, Op{Op: "add", Path: def + "/enum/-", Value: v})
+	}
+	return ops, nil
+}
+
+// findOperation finds an operation by operationId, or by the
+// "method /path" guard uses for operations without one.
+func findOperation(doc any, opID string) (string, error) {
+	paths, _ := get(doc, "/paths").(map[string]any)
+	for p := range paths {
+		item := deref(doc, "/paths/"+escape(p))
+		for _, m := range []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"} {
+			ptr := item + "/" + m
+			op, ok := get(doc, ptr).(map[string]any)
+			if !ok {
+				continue
+			}
+			if op["operationId"] == opID || m+" "+p == opID {
+				return ptr, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("operation %s is not in the spec", opID)
+}
+
+// findResponse picks the response the way guard does: the code, its
+// class (2XX), then default.
+func findResponse(doc any, responses string, status int) (string, error) {
+	for _, code := range []string{strconv.Itoa(status), fmt.Sprintf("%dXX", status/100), "default"} {
+		if get(doc, responses+"/"+code) != nil {
+			return deref(doc, responses+"/"+code), nil
+		}
+	}
+	return "", fmt.Errorf("no response for status %d", status)
+}
+
+func jsonSchema(doc any, resp string) (string, error) {
+	content, _ := get(doc, resp+"/content").(map[string]any)
+	if _, ok := content["application/json"]; ok {
+		return resp + "/content/" + escape("application/json") + "/schema", nil
+	}
+	for ct := range content {
+		if strings.Contains(ct, "json") {
+			return resp + "/content/" + escape(ct) + "/schema", nil
+		}
+	}
+	return "", errors.New("response has no JSON schema")
+}
+
+// step goes from a schema to the one for a property or array element,
+// through $refs and allOf.
+func step(doc any, ptr string, st model.Step) (string, error) {
+	def := deref(doc, ptr)
+	if st.Elem {
+		if get(doc, def+"/items") == nil {
+			return "", errors.New("no items schema")
+		}
+		return def + "/items", nil
+	}
+	if p := def + "/properties/" + escape(st.Key); get(doc, p) != nil {
+		return p, nil
+	}
+	all, _ := get(doc, def+"/allOf").([]any)
+	for i := range all {
+		if p, err := step(doc, fmt.Sprintf("%s/allOf/%d", def, i), st); err == nil {
+			return p, nil
+		}
+	}
+	return "", fmt.Errorf("no property %s", st.Key)
+}
+
+// deref follows local $refs from ptr to where the value is defined.
+func deref(doc any, ptr string) string {
+	for range 32 {
+		m, ok := get(doc, ptr).(map[string]any)
+		if !ok {
+			return ptr
+		}
+		ref, ok := m["$ref"].(string)
+		if !ok || !strings.HasPrefix(ref, "#") {
+			return ptr
+		}
+		ptr = strings.TrimPrefix(ref, "#")
+	}
+	return ptr
+}
+
+// get returns the value at a JSON pointer, or nil.
+func get(doc any, ptr string) any {
+	cur := doc
+	for _, tok := range split(ptr) {
+		switch t := cur.(type) {
+		case map[string]any:
+			cur = t[tok]
+		case []any:
+			i, err := strconv.Atoi(tok)
+			if err != nil || i < 0 || i >= len(t) {
+				return nil
+			}
+			cur = t[i]
+		default:
+			return nil
+		}
+	}
+	return cur
+}
+
+// ApplyPatch applies add, remove and replace operations to doc.
+func ApplyPatch(doc *any, ops []Op) error {
+	for _, o := range ops {
+		v, err := clone(o.Value)
+		if err != nil {
+			return err
+		}
+		if *doc, err = apply(*doc, split(o.Path), o.Op, v); err != nil {
+			return fmt.Errorf("%s %s: %w", o.Op, o.Path, err)
+		}
+	}
+	return nil
+}
+
+func apply(cur any, toks []string, op string, v any) (any, error) {
+	if len(toks) == 0 {
+		if op == "remove" {
+			return nil, errors.New("cannot remove the document")
+		}
+		return v, nil
+	}
+	tok, last := toks[0], len(toks) == 1
+	switch t := cur.(type) {
+	case map[string]any:
+		child, ok := t[tok]
+		if last {
+			switch {
+			case op == "add":
+				t[tok] = v
+			case !ok:
+				return nil, errors.New("no such member")
+			case op == "remove":
+				delete(t, tok)
+			default:
+				t[tok] = v
+			}
+			return t, nil
+		}
+		if !ok {
+			return nil, errors.New("no such member")
+		}
+		next, err := apply(child, toks[1:], op, v)
+		t[tok] = next
+		return t, err
+	case []any:
+		if last && tok == "-" && op == "add" {
+			return append(t, v), nil
+		}
+		i, err := strconv.Atoi(tok)
+		if err != nil || i < 0 || i > len(t) || i == len(t) && !(last && op == "add") {
+			return nil, fmt.Errorf("bad index %s", tok)
+		}
+		if last {
+			switch op {
+			case "add":
+				return append(t[:i], append([]any{v}, t[i:]...)...), nil
+			case "remove":
+				return append(t[:i], t[i+1:]...), nil
+			}
+			t[i] = v
+			return t, nil
+		}
+		t[i], err = apply(t[i], toks[1:], op, v)
+		return t, err
+	}
+	return nil, errors.New("path goes through a scalar")
+}
+
+func split(ptr string) []string {
+	if ptr == "" {
+		return nil
+	}
+	toks := strings.Split(strings.TrimPrefix(ptr, "/"), "/")
+	for i, t := range toks {
+		toks[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(t)
+	}
+	return toks
+}
+
+func escape(tok string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(tok)
+}
+
+func clone(v any) (any, error) {
+	if v == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var out any
+	err = json.Unmarshal(b, &out)
+	return out, err
+}
+
+func containsJSON(vs []any, v any) bool {
+	want, _ := json.Marshal(v)
+	for _, x := range vs {
+		if b, _ := json.Marshal(x); string(b) == string(want) {
+			return true
+		}
+	}
+	return false
+}
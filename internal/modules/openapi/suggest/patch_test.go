@@ -0,0 +1,175 @@
+package suggest
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	yaml3 "gopkg.in/yaml.v3"
+)
+
+func TestPatchFixesSharedSchema(t *testing.T) {
+	var spec any
+	if err := json.Unmarshal([]byte(`{
+	  "openapi": "3.0.3",
+	  "paths": {"/orders/{id}": {"get": {"operationId": "getOrder", "responses": {
+	    "200": {"description": "ok", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/Order"}}}}}}}},
+	  "components": {"schemas": {"Order": {"type": "object", "required": ["sku", "qty"],
+	    "properties": {"id": {"type": "integer"}, "sku": {"type": "string"}, "qty": {"type": "integer"},
+	      "status": {"type": "string", "enum": ["open"]}}}}}
+	}`), &spec); err != nil {
+		t.Fatal(err)
+	}
+	sel := func(action SuggestionAction, path, msg string, values ...string) Suggestion {
+		return Suggestion{
+			Confidence: 0.9,
+			Action:     action,
+			Selector:   Selector{OperationID: "getOrder", Status: 200, JSONPath: path},
+			Evidence:   Evidence{Message: msg, Values: values},
+		}
+	}
+	r := &Report{Items: []Suggestion{
+		sel(ActionMakeOptional, "$", "missing properties: 'sku'"),
+		sel(ActionBroadenType, "$.id", "expected integer, but got null", "null"),
+		sel(ActionExtendEnum, "$.status", "value must be \"open\"", `"shipped"`),
+		{Confidence: 0.1, Action: ActionAddResponse, Selector: Selector{OperationID: "getOrder", Status: 500}},
+	}}
+
+	ops, skipped, err := Patch(spec, r, 0.6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(skipped) != 1 || skipped[0].Suggestion.Action != ActionAddResponse {
+		t.Fatalf("skipped = %+v", skipped)
+	}
+	if err := ApplyPatch(&spec, ops); err != nil {
+		t.Fatal(err)
+	}
+	order := get(spec, "/components/schemas/Order")
+	b, _ := json.Marshal(order)
+	want := `{"properties":{"id":{"nullable":true,"type":"integer"},"qty":{"type":"integer"},"sku":{"type":"string"},"status":{"enum":["open","shipped"],"type":"string"}},"required":["qty"],"type":"object"}`
+	if string(b) != want {
+		t.Fatalf("Order =\n%s\nwant\n%s", b, want)
+	}
+}
+
+func TestBroadenTypeKeepsKeywords(t *testing.T) {
+	var spec any
+	if err := json.Unmarshal([]byte(`{
+	  "openapi": "3.0.3",
+	  "paths": {"/orders": {"get": {"operationId": "listOrders", "responses": {
+	    "200": {"description": "ok", "content": {"application/json": {"schema": {"type": "array", "items": {
+	      "type": "object", "properties": {"qty": {"type": "integer", "format": "int64", "description": "units ordered", "minimum": 1}}}}}}}}}}}
+	}`), &spec); err != nil {
+		t.Fatal(err)
+	}
+	r := &Report{Items: []Suggestion{{
+		Confidence: 0.9,
+		Action:     ActionBroadenType,
+		Selector:   Selector{OperationID: "listOrders", Status: 200, JSONPath: "$[*].qty"},
+		Evidence:   Evidence{Message: "expected integer, but got string", Values: []string{`"3"`}},
+	}}}
+
+	ops, skipped, err := Patch(spec, r, 0.6)
+	if err != nil || len(skipped) != 0 {
+		t.Fatalf("err = %v, skipped = %+v", err, skipped)
+	}
+	if err := ApplyPatch(&spec, ops); err != nil {
+		t.Fatal(err)
+	}
+	qty := get(spec, "/paths/~1orders/get/responses/200/content/application~1json/schema/items/properties/qty")
+	b, _ := json.Marshal(qty)
+	want := `{"anyOf":[{"description":"units ordered","format":"int64","minimum":1,"type":"integer"},{"type":"string"}]}`
+	if string(b) != want {
+		t.Fatalf("qty =\n%s\nwant\n%s", b, want)
+	}
+}
+
+func TestRewriteKeepsLayout(t *testing.T) {
+	src := `# Orders API
+openapi: 3.0.3
+info: {title: Orders, version: "1"}
+paths:
+  /orders/{id}:
+    get:
+      operationId: getOrder
+      responses:
+        "200":
+          description: ok
+          content:
+            application/json:
+              schema:
+                type: object
+                required: [sku, qty]
+                properties:
+                  sku: {type: string}
+                  qty:
+                    # units ordered
+                    type: integer
+                    minimum: 1
+`
+	var spec any
+	if err := yaml3.Unmarshal([]byte(src), &spec); err != nil {
+		t.Fatal(err)
+	}
+	sel := func(action SuggestionAction, path, msg string) Suggestion {
+		return Suggestion{Confidence: 0.9, Action: action,
+			Selector: Selector{OperationID: "getOrder", Status: 200, JSONPath: path}, Evidence: Evidence{Message: msg}}
+	}
+	r := &Report{Items: []Suggestion{
+		sel(ActionMakeOptional, "$", "missing properties: 'sku'"),
+		sel(ActionBroadenType, "$.qty", "expected integer, but got string"),
+	}}
+	ops, skipped, err := Patch(spec, r, 0.6)
+	if err != nil || len(skipped) != 0 {
+		t.Fatalf("err = %v, skipped = %+v", err, skipped)
+	}
+	out, err := Rewrite([]byte(src), ops)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `# Orders API
+openapi: 3.0.3
+info: {title: Orders, version: "1"}
+paths:
+  /orders/{id}:
+    get:
+      operationId: getOrder
+      responses:
+        "200":
+          description: ok
+          content:
+            application/json:
+              schema:
+                type: object
+                required: [qty]
+                properties:
+                  sku: {type: string}
+                  qty:
+                    anyOf:
+                      - # units ordered
+                        type: integer
+                        minimum: 1
+                      - type: string
+`
+	if string(out) != want {
+		t.Fatalf("rewritten:\n%s\nwant:\n%s", out, want)
+	}
+
+	js := `{
+  "openapi": "3.0.3",
+  "paths": {"/orders/{id}": {"get": {"operationId": "getOrder", "responses": {"200": {"description": "<b>ok</b>",
+    "content": {"application/json": {"schema": {"type": "object", "required": ["sku", "qty"],
+      "properties": {"sku": {"type": "string"}, "qty": {"type": "integer", "minimum": 1}}}}}}}}}}
+}`
+	out, err = Rewrite([]byte(js), ops)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(out), `"description": "<b>ok</b>"`) ||
+		!strings.Contains(string(out), "\"openapi\": \"3.0.3\",\n  \"paths\"") ||
+		!strings.Contains(string(out), "\"type\": \"integer\",\n") ||
+		!strings.Contains(string(out), "\"required\": [\n") {
+		t.Fatalf("rewritten JSON:\n%s", out)
+	}
+}
@@ -0,0 +1,287 @@
+package suggest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	yaml3 "gopkg.in/yaml.v3"
+)
+
+// Rewrite applies ops to the spec file contents src and returns the
+// patched file. Keys stay in their order and YAML comments stay where
+// they were, so the result differs from src only where ops change it.
+// JSON comes back as indented JSON, YAML as YAML.
+func Rewrite(src []byte, ops []Op) ([]byte, error) {
+	var doc yaml3.Node
+	if err := yaml3.Unmarshal(src, &doc); err != nil {
+		return nil, err
+	}
+	if doc.Kind != yaml3.DocumentNode || len(doc.Content) == 0 {
+		return nil, errors.New("empty spec")
+	}
+	for _, o := range ops {
+		if err := applyNode(&doc, split(o.Path), o.Op, o.Value); err != nil {
+			return nil, fmt.Errorf("%s %s: %w", o.Op, o.Path, err)
+		}
+	}
+
+	if json.Valid(src) {
+		var b bytes.Buffer
+		if err := writeJSON(&b, doc.Content[0], ""); err != nil {
+			return nil, err
+		}
+		b.WriteByte('\n')
+		return b.Bytes(), nil
+	}
+	var b bytes.Buffer
+	enc := yaml3.NewEncoder(&b)
+	enc.SetIndent(indentOf(src))
+	if err := enc.Encode(&doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// applyNode is apply for a YAML node tree: the node at toks is set,
+// added or removed in place.
+func applyNode(parent *yaml3.Node, toks []string, op string, v any) error {
+	cur := parent
+	if cur.Kind == yaml3.DocumentNode {
+		cur = cur.Content[0]
+	}
+	if len(toks) == 0 {
+		if op == "remove" {
+			return errors.New("cannot remove the document")
+		}
+		n, err := valueNode(v, cur)
+		if err != nil {
+			return err
+		}
+		*cur = *n
+		return nil
+	}
+	for cur.Kind == yaml3.AliasNode {
+		cur = cur.Alias
+	}
+	tok, last := toks[0], len(toks) == 1
+
+	switch cur.Kind {
+	case yaml3.MappingNode:
+		i := -1
+		for k := 0; k+1 < len(cur.Content); k += 2 {
+			if cur.Content[k].Value == tok {
+				i = k
+				break
+			}
+		}
+		if !last {
+			if i < 0 {
+				return errors.New("no such member")
+			}
+			return applyNode(cur.Content[i+1], toks[1:], op, v)
+		}
+		switch {
+		case op == "remove" && i >= 0:
+			cur.Content = append(cur.Content[:i], cur.Content[i+2:]...)
+			return nil
+		case i < 0 && op != "add":
+			return errors.New("no such member")
+		case i < 0:
+			n, err := valueNode(v, nil)
+			if err != nil {
+				return err
+			}
+			key := &yaml3.Node{Kind: yaml3.ScalarNode, Tag: "!!str", Value: tok}
+			cur.Content = append(cur.Content, key, n)
+			return nil
+		}
+		n, err := valueNode(v, cur.Content[i+1])
+		if err != nil {
+			return err
+		}
+		cur.Content[i+1] = n
+		return nil
+
+	case yaml3.SequenceNode:
+		if last && tok == "-" && op == "add" {
+			n, err := valueNode(v, nil)
+			if err != nil {
+				return err
+			}
+			cur.Content = append(cur.Content, n)
+			return nil
+		}
+		i, err := strconv.Atoi(tok)
+		if err != nil || i < 0 || i > len(cur.Content) || i == len(cur.Content) && !(last && op == "add") {
+			return fmt.Errorf("bad index %s", tok)
+		}
+		if !last {
+			return applyNode(cur.Content[i], toks[1:], op, v)
+		}
+		switch op {
+		case "add":
+			n, err := valueNode(v, nil)
+			if err != nil {
+				return err
+			}
+			cur.Content = append(cur.Content[:i], append([]*yaml3.Node{n}, cur.Content[i:]...)...)
+		case "remove":
+			cur.Content = append(cur.Content[:i], cur.Content[i+1:]...)
+		default:
+			n, err := valueNode(v, cur.Content[i])
+			if err != nil {
+				return err
+			}
+			cur.Content[i] = n
+		}
+		return nil
+	}
+	return errors.New("path goes through a scalar")
+}
+
+// valueNode turns a patch value into a node. Where the value carries the
+// node it replaces unchanged, as broadenType's anyOf does, that node is
+// kept as it was written.
+func valueNode(v any, old *yaml3.Node) (*yaml3.Node, error) {
+	var n yaml3.Node
+	if err := n.Encode(v); err != nil {
+		return nil, err
+	}
+	if old == nil {
+		return &n, nil
+	}
+	if n.Kind == old.Kind {
+		n.Style = old.Style
+	}
+	want, err := canonical(old)
+	if err != nil {
+		return &n, nil
+	}
+	if got, err := canonical(&n); err == nil && got == want {
+		return old, nil
+	}
+	var keep func(n *yaml3.Node, depth int)
+	keep = func(n *yaml3.Node, depth int) {
+		for i, c := range n.Content {
+			if got, err := canonical(c); err == nil && got == want {
+				n.Content[i] = old
+				continue
+			}
+			if depth > 0 {
+				keep(c, depth-1)
+			}
+		}
+	}
+	keep(&n, 2)
+	return &n, nil
+}
+
+// canonical is n as JSON with sorted keys, to compare nodes by value.
+func canonical(n *yaml3.Node) (string, error) {
+	var v any
+	if err := n.Decode(&v); err != nil {
+		return "", err
+	}
+	b, err := json.Marshal(v)
+	return string(b), err
+}
+
+// writeJSON writes n as JSON indented by two spaces, members in the
+// order the node has them.
+func writeJSON(b *bytes.Buffer, n *yaml3.Node, indent string) error {
+	switch n.Kind {
+	case yaml3.DocumentNode:
+		if len(n.Content) == 0 {
+			b.WriteString("null")
+			return nil
+		}
+		return writeJSON(b, n.Content[0], indent)
+	case yaml3.AliasNode:
+		return writeJSON(b, n.Alias, indent)
+	case yaml3.MappingNode:
+		if len(n.Content) == 0 {
+			b.WriteString("{}")
+			return nil
+		}
+		b.WriteString("{\n")
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			if i > 0 {
+				b.WriteString(",\n")
+			}
+			b.WriteString(indent + "  ")
+			if err := writeValue(b, n.Content[i].Value); err != nil {
+				return err
+			}
+			b.WriteString(": ")
+			if err := writeJSON(b, n.Content[i+1], indent+"  "); err != nil {
+				return err
+			}
+		}
+		b.WriteString("\n" + indent + "}")
+		return nil
+	case yaml3.SequenceNode:
+		if len(n.Content) == 0 {
+			b.WriteString("[]")
+			return nil
+		}
+		b.WriteString("[\n")
+		for i, c := range n.Content {
+			if i > 0 {
+				b.WriteString(",\n")
+			}
+			b.WriteString(indent + "  ")
+			if err := writeJSON(b, c, indent+"  "); err != nil {
+				return err
+			}
+		}
+		b.WriteString("\n" + indent + "]")
+		return nil
+	case yaml3.ScalarNode:
+		var v any
+		if err := n.Decode(&v); err != nil {
+			return err
+		}
+		switch n.ShortTag() {
+		case "!!int", "!!float":
+			if json.Valid([]byte(n.Value)) {
+				b.WriteString(n.Value)
+				return nil
+			}
+		}
+		return writeValue(b, v)
+	}
+	return fmt.Errorf("cannot write node kind %d as JSON", n.Kind)
+}
+
+// writeValue writes v as JSON, leaving <, > and & as they are.
+func writeValue(b *bytes.Buffer, v any) error {
+	enc := json.NewEncoder(b)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	b.Truncate(b.Len() - 1) // Encode's newline
+	return nil
+}
+
+// indentOf is the indent the YAML in src uses: that of its first
+// indented line.
+func indentOf(src []byte) int {
+	for _, line := range strings.Split(string(src), "\n") {
+		trimmed := strings.TrimLeft(line, " ")
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "- ") {
+			continue
+		}
+		if n := len(line) - len(trimmed); n > 0 {
+			return n
+		}
+	}
+	return 2
+}
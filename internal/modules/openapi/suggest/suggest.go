@@ -5,6 +5,9 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
 	"strings"
 	"time"
 
@@ -42,6 +45,7 @@ type Evidence struct {
 	LastSeen  time.Time `json:"last_seen"`
 	AvgCDI    float64   `json:"avg_cdi"`
 	Message   string    `json:"message"`
+	Values    []string  `json:"values,omitempty"` // offending values seen, as JSON
 }
 
 type Report struct {
@@ -58,7 +62,7 @@ func Build(baseURL string, minCount int) (*Report, error) {
 		return nil, fmt.Errorf("no snapshot for %s (run restless against the host first): %w", baseURL, err)
 	}
 
-	stats := ai.TopFindings(s, minCount)
+	stats := ai.TopFindings(groupIndices(s), minCount)
 	items := make([]Suggestion, 0, len(stats))
 
 	for _, st := range stats {
@@ -81,6 +85,7 @@ func Build(baseURL string, minCount int) (*Report, error) {
 				LastSeen:  st.LastSeen,
 				AvgCDI:    st.AvgCDI,
 				Message:   st.Key.Message,
+				Values:    st.Values,
 			},
 		})
 	}
@@ -94,6 +99,54 @@ func Build(baseURL string, minCount int) (*Report, error) {
 	}, nil
 }
 
+// maxValues caps the offending values kept per suggestion, as the
+// snapshot does per finding.
+const maxValues = 20
+
+var arrayIndex = regexp.MustCompile(`\[\d+\]`)
+
+// groupIndices folds findings that differ only in array indices, such as
+// $.items[3].id and $.items[7].id, into one at $.items[*].id, so drift
+// spread over the elements of a list adds up to one suggestion.
+func groupIndices(s *ai.HostSnapshot) *ai.HostSnapshot {
+	hashes := make([]string, 0, len(s.Findings))
+	for h := range s.Findings {
+		hashes = append(hashes, h)
+	}
+	sort.Strings(hashes)
+
+	out := &ai.HostSnapshot{BaseURL: s.BaseURL, SpecRef: s.SpecRef, Findings: map[string]*ai.FindingStat{}}
+	for _, h := range hashes {
+		st := s.Findings[h]
+		key := st.Key
+		key.JSONPath = arrayIndex.ReplaceAllString(key.JSONPath, "[*]")
+		id := fmt.Sprintf("%s|%s|%s|%s|%d", key.OpID, key.Kind, key.JSONPath, key.Message, key.Status)
+
+		g, ok := out.Findings[id]
+		if !ok {
+			cp := *st
+			cp.Key = key
+			cp.Values = append([]string(nil), st.Values...)
+			out.Findings[id] = &cp
+			continue
+		}
+		g.AvgCDI = (g.AvgCDI*float64(g.Count) + st.AvgCDI*float64(st.Count)) / float64(g.Count+st.Count)
+		g.Count += st.Count
+		if st.FirstSeen.Before(g.FirstSeen) {
+			g.FirstSeen = st.FirstSeen
+		}
+		if st.LastSeen.After(g.LastSeen) {
+			g.LastSeen, g.LastCDI = st.LastSeen, st.LastCDI
+		}
+		for _, v := range st.Values {
+			if len(g.Values) < maxValues && !slices.Contains(g.Values, v) {
+				g.Values = append(g.Values, v)
+			}
+		}
+	}
+	return out
+}
+
 func Write(report *Report, outDir string) (mdPath, jsonPath, planPath string, err error) {
 	if report == nil {
 		return "", "", "", fmt.Errorf("nil report")
@@ -165,7 +218,11 @@ func ToMarkdown(r *Report) string {
 		fmt.Fprintf(&b, "- Avg CDI: `%.3f`\n", it.Evidence.AvgCDI)
 		fmt.Fprintf(&b, "- First seen: `%s`\n", it.Evidence.FirstSeen.Format(time.RFC3339))
 		fmt.Fprintf(&b, "- Last seen: `%s`\n", it.Evidence.LastSeen.Format(time.RFC3339))
-		fmt.Fprintf(&b, "- Message: `%s`\n\n", it.Evidence.Message)
+		fmt.Fprintf(&b, "- Message: `%s`\n", it.Evidence.Message)
+		if len(it.Evidence.Values) > 0 {
+			fmt.Fprintf(&b, "- Values seen: `%s`\n", strings.Join(it.Evidence.Values, "`, `"))
+		}
+		b.WriteString("\n")
 	}
 
 	return b.String()
@@ -208,14 +265,16 @@ func mapKindToSuggestion(kind, jsonPath, msg string) (SuggestionAction, string)
 	m := strings.ToLower(msg)
 
 	switch {
+	case strings.Contains(m, "response not defined"):
+		return ActionAddResponse, "Observed response code/body not defined in spec. Consider adding the missing response entry."
+	case strings.Contains(m, "schema missing"):
+		return ActionReviewSchema, "Observed a response content type the spec gives no schema for. Review the response's content."
 	case strings.Contains(k, "missing_field") || strings.Contains(m, "required"):
 		return ActionMakeOptional, fmt.Sprintf("Observed missing required field at %s. Consider removing it from required, making it nullable, or adjusting the response schema.", jsonPath)
 	case strings.Contains(k, "type_mismatch") || strings.Contains(m, "type"):
 		return ActionBroadenType, fmt.Sprintf("Observed type mismatch at %s. Consider changing the field type or using oneOf/anyOf to accept observed variants.", jsonPath)
 	case strings.Contains(k, "enum_violation") || strings.Contains(m, "enum"):
 		return ActionExtendEnum, fmt.Sprintf("Observed enum drift at %s. Consider extending the enum set to include observed values (or relaxing to string).", jsonPath)
-	case strings.Contains(m, "response not defined") || strings.Contains(m, "not defined"):
-		return ActionAddResponse, "Observed response code/body not defined in spec. Consider adding the missing response entry."
 	default:
 		return ActionReviewSchema, "Observed schema drift. Review the response schema for compatibility."
 	}
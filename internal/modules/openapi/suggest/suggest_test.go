@@ -0,0 +1,42 @@
+package suggest
+
+import (
+	"testing"
+
+	"github.com/bspippi1337/restless/internal/modules/openapi/ai"
+	"github.com/bspippi1337/restless/internal/modules/openapi/guard/model"
+)
+
+func TestBuildGroupsArrayIndices(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	const base = "https://api.example.com"
+
+	drift := func(path, actual string) model.Finding {
+		return model.Finding{OpID: "listOrders", Status: 200, Kind: model.KindTypeMismatch,
+			JSONPath: path, Message: "expected integer, but got string", Actual: actual}
+	}
+	for _, f := range [][]model.Finding{
+		{drift("$.items[0].qty", `"1"`), drift("$.items[3].qty", `"2"`)},
+		{drift("$.items[7].qty", `"1"`)},
+		{drift("$.total", `"9"`)},
+	} {
+		if err := ai.UpdateFromGuard(base, "spec.yaml", f, 0.5); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r, err := Build(base, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Items) != 1 {
+		t.Fatalf("items = %+v", r.Items)
+	}
+	it := r.Items[0]
+	if it.Selector.JSONPath != "$.items[*].qty" || it.Evidence.Count != 3 || it.Action != ActionBroadenType {
+		t.Fatalf("item = %+v", it)
+	}
+	if len(it.Evidence.Values) != 2 {
+		t.Fatalf("values = %q", it.Evidence.Values)
+	}
+}
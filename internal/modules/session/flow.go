@@ -4,6 +4,9 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"os"
+	"strconv"
 	"strings"
 
 	"github.com/bspippi1337/restless/internal/core/app"
@@ -18,8 +21,16 @@ type FlowStep struct {
 	Extract map[string]string `json:"extract"` // var -> json dot path
 }
 
+// StepFunc observes each executed flow step and its response.
+type StepFunc func(i int, step FlowStep, resp types.Response)
+
 func RunFlow(ctx context.Context, a *app.App, steps []FlowStep, sess *Module) error {
-	for _, s := range steps {
+	return RunFlowWith(ctx, a, steps, sess, nil)
+}
+
+// RunFlowWith is RunFlow with a per-step observer (nil is allowed).
+func RunFlowWith(ctx context.Context, a *app.App, steps []FlowStep, sess *Module, observe StepFunc) error {
+	for i, s := range steps {
 		req := types.Request{
 			Method:  s.Method,
 			URL:     s.URL,
@@ -29,7 +40,10 @@ func RunFlow(ctx context.Context, a *app.App, steps []FlowStep, sess *Module) er
 
 		resp, err := a.RunOnce(ctx, req)
 		if err != nil {
-			return err
+			return fmt.Errorf("step %d (%s %s): %w", i+1, s.Method, s.URL, err)
+		}
+		if observe != nil {
+			observe(i, s, resp)
 		}
 
 		for varName, path := range s.Extract {
@@ -43,6 +57,28 @@ func RunFlow(ctx context.Context, a *app.App, steps []FlowStep, sess *Module) er
 	return nil
 }
 
+// LoadFlow reads a flow file (a JSON array of steps).
+func LoadFlow(path string) ([]FlowStep, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var steps []FlowStep
+	if err := json.Unmarshal(b, &steps); err != nil {
+		return nil, err
+	}
+	return steps, nil
+}
+
+// SaveFlow writes steps as a flow file.
+func SaveFlow(path string, steps []FlowStep) error {
+	b, err := json.MarshalIndent(steps, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, b, 0644)
+}
+
 func extractDot(path string, body []byte) (string, error) {
 	if path == "" {
 		return "", errors.New("empty path")
@@ -56,6 +92,15 @@ func extractDot(path string, body []byte) (string, error) {
 	cur := v
 	parts := strings.Split(path, ".")
 	for _, part := range parts {
+		// A number indexes into an array: items.0.id.
+		if arr, ok := cur.([]any); ok {
+			i, err := strconv.Atoi(part)
+			if err != nil || i < 0 || i >= len(arr) {
+				return "", errors.New("path not found")
+			}
+			cur = arr[i]
+			continue
+		}
 		obj, ok := cur.(map[string]any)
 		if !ok {
 			return "", errors.New("path not found")
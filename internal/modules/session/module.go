@@ -47,6 +47,26 @@ func (m *Module) Set(key, value string) {
 	m.vars[key] = value
 }
 
+// Get returns a session var.
+func (m *Module) Get(key string) (string, bool) {
+	v, ok := m.vars[key]
+	return v, ok
+}
+
+// Vars returns a copy of the session vars.
+func (m *Module) Vars() map[string]string {
+	out := make(map[string]string, len(m.vars))
+	for k, v := range m.vars {
+		out[k] = v
+	}
+	return out
+}
+
+// Apply replaces {{var}} in s with the session vars, as requests get.
+func (m *Module) Apply(s string) string {
+	return applyTemplates(s, m.vars)
+}
+
 // ExtractJSON extracts a value from JSON response by a simple dot path (v1).
 func (m *Module) ExtractJSON(dotPath string, body []byte) (string, error) {
 	if dotPath == "" {
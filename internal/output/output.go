@@ -0,0 +1,279 @@
+// Package output renders API responses for the terminal and for pipes:
+// pretty JSON, YAML, CSV, tables, raw bodies and JSON lines, after an
+// optional --query and with nested fields flattened into dotted columns.
+package output
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/bspippi1337/restless/internal/pagination"
+)
+
+// Formats, as given to -o.
+const (
+	JSON   = "json"
+	YAML   = "yaml"
+	CSV    = "csv"
+	Table  = "table"
+	Raw    = "raw"
+	NDJSON = "ndjson"
+)
+
+// Formats lists the -o values.
+var Formats = []string{JSON, YAML, CSV, Table, Raw, NDJSON}
+
+// Options is how a response is printed.
+type Options struct {
+	// Format is one of Formats; "" is JSON as the default, not asked for.
+	Format string
+	// Columns picks and orders table and CSV columns, by dotted path.
+	Columns []string
+	// Query selects from the response before it is printed.
+	Query string
+}
+
+// Check rejects an unknown format or a query that does not parse.
+func (o Options) Check() error {
+	if o.Format != "" && !o.is(o.Format) {
+		return fmt.Errorf("unknown output format %q (want %s)", o.Format, strings.Join(Formats, "|"))
+	}
+	if o.Query != "" {
+		if _, err := Compile(o.Query); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (o Options) is(f string) bool {
+	for _, g := range Formats {
+		if f == g {
+			return true
+		}
+	}
+	return false
+}
+
+// Piped reports whether the output is meant for another program, so
+// status lines belong on stderr rather than mixed into it.
+func (o Options) Piped() bool {
+	return o.Format != "" && o.Format != Table
+}
+
+// Render writes body to w as o says. Bodies that are not JSON are
+// written as they are, unless a query needs them to be JSON.
+func Render(w io.Writer, body []byte, o Options) error {
+	format := o.Format
+	if format == "" {
+		format = JSON
+	}
+
+	v, err := Decode(body)
+	if err != nil {
+		if o.Query != "" {
+			return fmt.Errorf("--query needs a JSON response")
+		}
+		if len(body) == 0 {
+			return nil
+		}
+		_, err := w.Write(withNewline(body))
+		return err
+	}
+	if format == Raw && o.Query == "" {
+		_, err := w.Write(withNewline(body))
+		return err
+	}
+
+	results := []any{v}
+	if o.Query != "" {
+		q, err := Compile(o.Query)
+		if err != nil {
+			return err
+		}
+		if results, err = q.Run(v); err != nil {
+			return err
+		}
+	} else if format == Table || format == CSV {
+		// A page of a list is shown as its items.
+		if obj, ok := v.(*Object); ok {
+			for _, f := range pagination.ItemFields {
+				if items, ok := obj.Vals[f].([]any); ok {
+					results = []any{items}
+					break
+				}
+			}
+		}
+	}
+
+	switch format {
+	case JSON:
+		for _, r := range results {
+			if _, err := w.Write(append(Encode(r, "  "), '\n')); err != nil {
+				return err
+			}
+		}
+		return nil
+	case Raw:
+		for _, r := range results {
+			if _, err := fmt.Fprintln(w, Text(r)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case NDJSON:
+		for _, r := range spread(results) {
+			if _, err := w.Write(append(Encode(r, ""), '\n')); err != nil {
+				return err
+			}
+		}
+		return nil
+	case YAML:
+		return writeYAML(w, results)
+	}
+
+	rows := spread(results)
+	cols, cells := tabulate(rows, o.Columns)
+	if format == CSV {
+		cw := csv.NewWriter(w)
+		if err := cw.Write(cols); err != nil {
+			return err
+		}
+		if err := cw.WriteAll(cells); err != nil {
+			return err
+		}
+		return cw.Error()
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(cols, "\t"))
+	for _, line := range cells {
+		fmt.Fprintln(tw, strings.Join(line, "\t"))
+	}
+	return tw.Flush()
+}
+
+// spread makes rows of results: the elements of a single array, or the
+// results themselves.
+func spread(results []any) []any {
+	if len(results) == 1 {
+		if arr, ok := results[0].([]any); ok {
+			return arr
+		}
+	}
+	return results
+}
+
+// tabulate flattens rows and lays them out under cols: the given
+// columns, or every field in the order it first appears.
+func tabulate(rows []any, columns []string) ([]string, [][]string) {
+	flat := make([]*Object, len(rows))
+	for i, r := range rows {
+		flat[i] = Flatten(r)
+	}
+	cols := columns
+	if len(cols) == 0 {
+		seen := map[string]bool{}
+		for _, f := range flat {
+			for _, k := range f.Keys {
+				if !seen[k] {
+					seen[k] = true
+					cols = append(cols, k)
+				}
+			}
+		}
+	}
+	cells := make([][]string, len(rows))
+	for i, f := range flat {
+		line := make([]string, len(cols))
+		for j, c := range cols {
+			v, ok := f.Get(c)
+			if !ok {
+				v, _ = Field(rows[i], c)
+			}
+			line[j] = cell(Text(v))
+		}
+		cells[i] = line
+	}
+	return cols, cells
+}
+
+// cell keeps a value on one line of a table.
+func cell(s string) string {
+	return strings.NewReplacer("\n", `\n`, "\t", " ", "\r", "").Replace(s)
+}
+
+func writeYAML(w io.Writer, results []any) error {
+	for i, r := range results {
+		if i > 0 {
+			if _, err := io.WriteString(w, "---\n"); err != nil {
+				return err
+			}
+		}
+		var b bytes.Buffer
+		enc := yaml.NewEncoder(&b)
+		enc.SetIndent(2)
+		if err := enc.Encode(yamlNode(r)); err != nil {
+			return err
+		}
+		if err := enc.Close(); err != nil {
+			return err
+		}
+		if _, err := w.Write(b.Bytes()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func yamlNode(v any) *yaml.Node {
+	switch t := v.(type) {
+	case *Object:
+		n := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		for _, k := range t.Keys {
+			n.Content = append(n.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: k}, yamlNode(t.Vals[k]))
+		}
+		return n
+	case []any:
+		n := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		for _, e := range t {
+			n.Content = append(n.Content, yamlNode(e))
+		}
+		return n
+	case string:
+		n := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: t}
+		// YAML 1.1 readers take these for booleans.
+		switch strings.ToLower(t) {
+		case "y", "n", "yes", "no", "on", "off":
+			n.Style = yaml.DoubleQuotedStyle
+		}
+		return n
+	case json.Number:
+		tag := "!!int"
+		if strings.ContainsAny(t.String(), ".eE") {
+			tag = "!!float"
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: t.String()}
+	case bool:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: fmt.Sprint(t)}
+	case nil:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}
+	}
+	return &yaml.Node{Kind: yaml.ScalarNode, Value: Text(v)}
+}
+
+func withNewline(b []byte) []byte {
+	if len(b) > 0 && b[len(b)-1] != '\n' {
+		return append(b[:len(b):len(b)], '\n')
+	}
+	return b
+}
@@ -0,0 +1,69 @@
+package output
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+const users = `{"data": [
+  {"id": 2, "name": "ada", "user": {"email": "ada@example.com", "age": 36}, "tags": ["a", "b"]},
+  {"id": 10, "name": "linus", "user": {"email": "l@example.com", "age": 29}, "admin": true}
+], "next": null}`
+
+func render(t *testing.T, body string, o Options) string {
+	t.Helper()
+	var b bytes.Buffer
+	if err := Render(&b, []byte(body), o); err != nil {
+		t.Fatal(err)
+	}
+	return b.String()
+}
+
+func TestTableColumnsAreStable(t *testing.T) {
+	got := render(t, users, Options{Format: Table})
+	want := "id  name   user.email       user.age  tags       admin\n" +
+		"2   ada    ada@example.com  36        [\"a\",\"b\"]  \n" +
+		"10  linus  l@example.com    29                   true\n"
+	if got != want {
+		t.Fatalf("table:\n%s\nwant:\n%s", got, want)
+	}
+
+	got = render(t, users, Options{Format: CSV, Columns: []string{"user.email", "id"}})
+	if want := "user.email,id\nada@example.com,2\nl@example.com,10\n"; got != want {
+		t.Fatalf("csv:\n%s", got)
+	}
+}
+
+func TestQuery(t *testing.T) {
+	for q, want := range map[string]string{
+		`.data[] | select(.user.age > 30) | .name`:                          "ada\n",
+		`$.data[?(@.admin == true)].id`:                                     "10\n",
+		`.data[*].name`:                                                     "ada\nlinus\n",
+		`[.data[] | .id] | length`:                                          "2\n",
+		`.data | sort_by(.name) | last | .tags`:                             "\n",
+		`.data[0] | {id, email: .user.email}`:                               `{"id":2,"email":"ada@example.com"}` + "\n",
+		`.data[] | select(.name | startswith("li")) | .id`:                  "10\n",
+		`.data[] | select(has("tags") and (.tags | contains("b"))) | .name`: "ada\n",
+	} {
+		if got := render(t, users, Options{Format: Raw, Query: q}); got != want {
+			t.Fatalf("%s = %q, want %q", q, got, want)
+		}
+	}
+	if _, err := Compile(`.data[`); err == nil {
+		t.Fatal("bad query compiled")
+	}
+}
+
+func TestFormats(t *testing.T) {
+	if got := render(t, users, Options{Format: NDJSON, Query: ".data"}); strings.Count(got, "\n") != 2 || !strings.HasPrefix(got, `{"id":2,"name":"ada",`) {
+		t.Fatalf("ndjson:\n%s", got)
+	}
+	got := render(t, `{"b": "yes", "a": 1.5, "c": [true, null]}`, Options{Format: YAML})
+	if want := "b: \"yes\"\na: 1.5\nc:\n  - true\n  - null\n"; got != want {
+		t.Fatalf("yaml:\n%s", got)
+	}
+	if got := render(t, "plain text", Options{Format: Table}); got != "plain text\n" {
+		t.Fatalf("non-JSON body = %q", got)
+	}
+}
@@ -0,0 +1,847 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Query is a compiled --query expression. The language is a small jq
+// subset, with the JSONPath spellings people type out of habit:
+//
+//	.items[].name                 fields, indexes and [] / [*] to iterate
+//	.items[] | select(.age > 30)  pipes, filters with == != < <= > >= and or not
+//	{id, owner: .user.name}       new objects
+//	[.[] | .id] | length          collecting, length, keys, has, map, first,
+//	                              last, sort_by, join, contains, startswith,
+//	                              endswith, test
+//	$.items[?(@.age > 30)].name   $ for the document, @ for the current value
+//
+// A missing field is null rather than an error.
+type Query struct {
+	src  string
+	root any
+	run  expr
+}
+
+type expr func(in any) ([]any, error)
+
+// Compile parses a query.
+func Compile(src string) (*Query, error) {
+	toks, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	q := &Query{src: src}
+	p := &parser{toks: toks, q: q}
+	if q.run, err = p.pipe(); err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tEOF {
+		return nil, fmt.Errorf("query: unexpected %q at %d", t.text, t.pos)
+	}
+	return q, nil
+}
+
+// Run applies the query to v and returns everything it produced.
+func (q *Query) Run(v any) ([]any, error) {
+	q.root = v
+	out, err := q.run(v)
+	if err != nil {
+		return nil, fmt.Errorf("query %q: %w", q.src, err)
+	}
+	return out, nil
+}
+
+// Lexer.
+
+type tokKind int
+
+const (
+	tEOF tokKind = iota
+	tPunct
+	tIdent
+	tNumber
+	tString
+)
+
+type token struct {
+	kind tokKind
+	text string
+	pos  int
+}
+
+func lex(src string) ([]token, error) {
+	var toks []token
+	for i := 0; i < len(src); {
+		r, w := utf8.DecodeRuneInString(src[i:])
+		switch {
+		case unicode.IsSpace(r):
+			i += w
+		case r == '"' || r == '\'':
+			s, n, err := lexString(src[i:])
+			if err != nil {
+				return nil, fmt.Errorf("query: %v at %d", err, i)
+			}
+			toks = append(toks, token{tString, s, i})
+			i += n
+		case r >= '0' && r <= '9' || r == '-' && i+1 < len(src) && src[i+1] >= '0' && src[i+1] <= '9':
+			j := i + 1
+			for j < len(src) && strings.ContainsRune("0123456789.eE+-", rune(src[j])) {
+				if (src[j] == '+' || src[j] == '-') && src[j-1] != 'e' && src[j-1] != 'E' {
+					break
+				}
+				j++
+			}
+			toks = append(toks, token{tNumber, src[i:j], i})
+			i = j
+		case r == '_' || unicode.IsLetter(r):
+			j := i + w
+			for j < len(src) {
+				r, w := utf8.DecodeRuneInString(src[j:])
+				if r != '_' && r != '-' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+					break
+				}
+				j += w
+			}
+			toks = append(toks, token{tIdent, src[i:j], i})
+			i = j
+		default:
+			two := ""
+			if i+1 < len(src) {
+				two = src[i : i+2]
+			}
+			switch two {
+			case "==", "!=", "<=", ">=":
+				toks = append(toks, token{tPunct, two, i})
+				i += 2
+				continue
+			}
+			if !strings.ContainsRune(".[](){}|,:<>$@*?", r) {
+				return nil, fmt.Errorf("query: unexpected %q at %d", r, i)
+			}
+			toks = append(toks, token{tPunct, string(r), i})
+			i += w
+		}
+	}
+	return append(toks, token{tEOF, "end of query", len(src)}), nil
+}
+
+// lexString reads a quoted string at the start of s: JSON escapes in
+// double quotes, and single quotes as JSONPath writes them.
+func lexString(s string) (string, int, error) {
+	q := s[0]
+	for j := 1; j < len(s); j++ {
+		switch s[j] {
+		case '\\':
+			j++
+		case q:
+			if q == '"' {
+				var out string
+				err := json.Unmarshal([]byte(s[:j+1]), &out)
+				return out, j + 1, err
+			}
+			return strings.ReplaceAll(s[1:j], `\'`, `'`), j + 1, nil
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated string")
+}
+
+// Parser.
+
+type parser struct {
+	toks []token
+	at   int
+	q    *Query
+}
+
+func (p *parser) peek() token { return p.toks[p.at] }
+
+func (p *parser) next() token {
+	t := p.toks[p.at]
+	if t.kind != tEOF {
+		p.at++
+	}
+	return t
+}
+
+func (p *parser) is(text string) bool {
+	t := p.peek()
+	return (t.kind == tPunct || t.kind == tIdent) && t.text == text
+}
+
+func (p *parser) accept(text string) bool {
+	if p.is(text) {
+		p.at++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(text string) error {
+	if !p.accept(text) {
+		t := p.peek()
+		return fmt.Errorf("query: want %q, got %q at %d", text, t.text, t.pos)
+	}
+	return nil
+}
+
+func (p *parser) pipe() (expr, error) {
+	left, err := p.comma()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("|") {
+		right, err := p.comma()
+		if err != nil {
+			return nil, err
+		}
+		left = pipeTo(left, right)
+	}
+	return left, nil
+}
+
+func pipeTo(left, right expr) expr {
+	return func(in any) ([]any, error) {
+		mid, err := left(in)
+		if err != nil {
+			return nil, err
+		}
+		var out []any
+		for _, v := range mid {
+			r, err := right(v)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, r...)
+		}
+		return out, nil
+	}
+}
+
+func (p *parser) comma() (expr, error) {
+	first, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	parts := []expr{first}
+	for p.accept(",") {
+		e, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, e)
+	}
+	if len(parts) == 1 {
+		return first, nil
+	}
+	return func(in any) ([]any, error) {
+		var out []any
+		for _, e := range parts {
+			r, err := e(in)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, r...)
+		}
+		return out, nil
+	}, nil
+}
+
+func (p *parser) or() (expr, error) {
+	return p.logic("or", p.and, func(a, b bool) bool { return a || b })
+}
+
+func (p *parser) and() (expr, error) {
+	return p.logic("and", p.compare, func(a, b bool) bool { return a && b })
+}
+
+func (p *parser) logic(word string, operand func() (expr, error), op func(a, b bool) bool) (expr, error) {
+	left, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept(word) {
+		right, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		left = binary(left, right, func(a, b any) (any, error) {
+			return op(truthy(a), truthy(b)), nil
+		})
+	}
+	return left, nil
+}
+
+func (p *parser) compare() (expr, error) {
+	left, err := p.postfix()
+	if err != nil {
+		return nil, err
+	}
+	for _, op := range []string{"==", "!=", "<=", ">=", "<", ">"} {
+		if !p.accept(op) {
+			continue
+		}
+		right, err := p.postfix()
+		if err != nil {
+			return nil, err
+		}
+		return binary(left, right, func(a, b any) (any, error) {
+			if op == "==" || op == "!=" {
+				return equal(a, b) == (op == "=="), nil
+			}
+			c, ok := order(a, b)
+			if !ok {
+				return false, nil
+			}
+			switch op {
+			case "<":
+				return c < 0, nil
+			case "<=":
+				return c <= 0, nil
+			case ">":
+				return c > 0, nil
+			}
+			return c >= 0, nil
+		}), nil
+	}
+	return left, nil
+}
+
+// binary applies f to every pair of outputs of left and right.
+func binary(left, right expr, f func(a, b any) (any, error)) expr {
+	return func(in any) ([]any, error) {
+		ls, err := left(in)
+		if err != nil {
+			return nil, err
+		}
+		rs, err := right(in)
+		if err != nil {
+			return nil, err
+		}
+		var out []any
+		for _, r := range rs {
+			for _, l := range ls {
+				v, err := f(l, r)
+				if err != nil {
+					return nil, err
+				}
+				out = append(out, v)
+			}
+		}
+		return out, nil
+	}
+}
+
+func (p *parser) postfix() (expr, error) {
+	e, err := p.primary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		switch {
+		case p.is("."):
+			p.next()
+			name, ok := p.fieldName()
+			if !ok {
+				t := p.peek()
+				return nil, fmt.Errorf("query: want a field name after '.', got %q at %d", t.text, t.pos)
+			}
+			e = pipeTo(e, field(name))
+		case p.is("["):
+			step, err := p.bracket()
+			if err != nil {
+				return nil, err
+			}
+			e = pipeTo(e, step)
+		default:
+			return e, nil
+		}
+	}
+}
+
+// fieldName reads the name after a '.', if there is one.
+func (p *parser) fieldName() (string, bool) {
+	t := p.peek()
+	if t.kind == tIdent || t.kind == tString {
+		p.next()
+		return t.text, true
+	}
+	return "", false
+}
+
+// bracket parses [], [*], [n], ["key"] and [?(filter)].
+func (p *parser) bracket() (expr, error) {
+	if err := p.expect("["); err != nil {
+		return nil, err
+	}
+	if p.accept("]") {
+		return iterate, nil
+	}
+	if p.accept("*") {
+		return iterate, p.expect("]")
+	}
+	if p.accept("?") {
+		if err := p.expect("("); err != nil {
+			return nil, err
+		}
+		cond, err := p.pipe()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		return pipeTo(iterate, selectBy(cond)), p.expect("]")
+	}
+	t := p.next()
+	var step expr
+	switch t.kind {
+	case tNumber:
+		n, err := strconv.Atoi(t.text)
+		if err != nil {
+			return nil, fmt.Errorf("query: bad index %q at %d", t.text, t.pos)
+		}
+		step = index(n)
+	case tString:
+		step = field(t.text)
+	default:
+		return nil, fmt.Errorf("query: unexpected %q at %d", t.text, t.pos)
+	}
+	return step, p.expect("]")
+}
+
+func (p *parser) primary() (expr, error) {
+	t := p.peek()
+	switch {
+	case t.kind == tPunct && t.text == ".":
+		p.next()
+		if name, ok := p.fieldName(); ok {
+			return field(name), nil
+		}
+		return identity, nil
+	case t.kind == tPunct && t.text == "@":
+		p.next()
+		return identity, nil
+	case t.kind == tPunct && t.text == "$":
+		p.next()
+		return func(any) ([]any, error) { return []any{p.q.root}, nil }, nil
+	case t.kind == tPunct && t.text == "(":
+		p.next()
+		e, err := p.pipe()
+		if err != nil {
+			return nil, err
+		}
+		return e, p.expect(")")
+	case t.kind == tPunct && t.text == "[":
+		p.next()
+		if p.accept("]") {
+			return constant([]any{}), nil
+		}
+		e, err := p.pipe()
+		if err != nil {
+			return nil, err
+		}
+		return collect(e), p.expect("]")
+	case t.kind == tPunct && t.text == "{":
+		return p.object()
+	case t.kind == tNumber:
+		p.next()
+		return constant(json.Number(t.text)), nil
+	case t.kind == tString:
+		p.next()
+		return constant(t.text), nil
+	case t.kind == tIdent:
+		p.next()
+		switch t.text {
+		case "true", "false":
+			return constant(t.text == "true"), nil
+		case "null":
+			return constant(nil), nil
+		}
+		return p.call(t)
+	}
+	return nil, fmt.Errorf("query: unexpected %q at %d", t.text, t.pos)
+}
+
+// object parses {a, b: expr, "c-d": expr}.
+func (p *parser) object() (expr, error) {
+	p.next()
+	type entry struct {
+		key string
+		val expr
+	}
+	var entries []entry
+	for !p.accept("}") {
+		if len(entries) > 0 {
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+		}
+		t := p.next()
+		if t.kind != tIdent && t.kind != tString {
+			return nil, fmt.Errorf("query: want a key, got %q at %d", t.text, t.pos)
+		}
+		val := field(t.text)
+		if p.accept(":") {
+			var err error
+			if val, err = p.or(); err != nil {
+				return nil, err
+			}
+		}
+		entries = append(entries, entry{t.text, val})
+	}
+	return func(in any) ([]any, error) {
+		// One object per combination of the values' outputs.
+		objs := []*Object{newObject()}
+		for _, e := range entries {
+			vals, err := e.val(in)
+			if err != nil {
+				return nil, err
+			}
+			var next []*Object
+			for _, o := range objs {
+				for _, v := range vals {
+					c := &Object{Keys: append([]string(nil), o.Keys...), Vals: map[string]any{}}
+					for k, x := range o.Vals {
+						c.Vals[k] = x
+					}
+					c.Set(e.key, v)
+					next = append(next, c)
+				}
+			}
+			objs = next
+		}
+		out := make([]any, len(objs))
+		for i, o := range objs {
+			out[i] = o
+		}
+		return out, nil
+	}, nil
+}
+
+// call parses a builtin and its arguments.
+func (p *parser) call(name token) (expr, error) {
+	var args []expr
+	if p.accept("(") {
+		for {
+			a, err := p.pipe()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, a)
+			if p.accept(")") {
+				break
+			}
+			if err := p.expect(","); err != nil {
+				return nil, err
+			}
+		}
+	}
+	b, ok := builtins[name.text]
+	if !ok {
+		return nil, fmt.Errorf("query: unknown function %q at %d", name.text, name.pos)
+	}
+	if len(args) != b.args {
+		return nil, fmt.Errorf("query: %s takes %d argument(s), got %d", name.text, b.args, len(args))
+	}
+	return b.make(args), nil
+}
+
+// Evaluation.
+
+func identity(in any) ([]any, error) { return []any{in}, nil }
+
+func constant(v any) expr {
+	return func(any) ([]any, error) { return []any{v}, nil }
+}
+
+func field(name string) expr {
+	return func(in any) ([]any, error) {
+		if o, ok := in.(*Object); ok {
+			v, _ := o.Get(name)
+			return []any{v}, nil
+		}
+		return []any{nil}, nil
+	}
+}
+
+func index(n int) expr {
+	return func(in any) ([]any, error) {
+		arr, ok := in.([]any)
+		i := n
+		if i < 0 {
+			i += len(arr)
+		}
+		if !ok || i < 0 || i >= len(arr) {
+			return []any{nil}, nil
+		}
+		return []any{arr[i]}, nil
+	}
+}
+
+func iterate(in any) ([]any, error) {
+	switch t := in.(type) {
+	case []any:
+		return t, nil
+	case *Object:
+		out := make([]any, len(t.Keys))
+		for i, k := range t.Keys {
+			out[i] = t.Vals[k]
+		}
+		return out, nil
+	}
+	return nil, nil
+}
+
+func collect(e expr) expr {
+	return func(in any) ([]any, error) {
+		out, err := e(in)
+		if out == nil {
+			out = []any{}
+		}
+		return []any{out}, err
+	}
+}
+
+func selectBy(cond expr) expr {
+	return func(in any) ([]any, error) {
+		vs, err := cond(in)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range vs {
+			if truthy(v) {
+				return []any{in}, nil
+			}
+		}
+		return nil, nil
+	}
+}
+
+type builtin struct {
+	args int
+	make func(args []expr) expr
+}
+
+var builtins map[string]builtin
+
+func init() {
+	builtins = map[string]builtin{
+		"select": {1, func(a []expr) expr { return selectBy(a[0]) }},
+		"map":    {1, func(a []expr) expr { return collect(pipeTo(iterate, a[0])) }},
+		"not":    {0, func([]expr) expr { return unary(func(v any) (any, error) { return !truthy(v), nil }) }},
+		"length": {0, func([]expr) expr { return unary(length) }},
+		"keys":   {0, func([]expr) expr { return unary(keys) }},
+		"first":  {0, func([]expr) expr { return index(0) }},
+		"last":   {0, func([]expr) expr { return index(-1) }},
+		"has": {1, func(a []expr) expr {
+			return withArg(a[0], func(v, k any) (any, error) {
+				o, ok := v.(*Object)
+				s, _ := k.(string)
+				if !ok {
+					return false, nil
+				}
+				_, has := o.Get(s)
+				return has, nil
+			})
+		}},
+		"contains": {1, func(a []expr) expr {
+			return withArg(a[0], func(v, x any) (any, error) {
+				switch t := v.(type) {
+				case string:
+					s, ok := x.(string)
+					return ok && strings.Contains(t, s), nil
+				case []any:
+					for _, e := range t {
+						if equal(e, x) {
+							return true, nil
+						}
+					}
+				}
+				return false, nil
+			})
+		}},
+		"startswith": {1, func(a []expr) expr { return stringTest(a[0], strings.HasPrefix) }},
+		"endswith":   {1, func(a []expr) expr { return stringTest(a[0], strings.HasSuffix) }},
+		"test": {1, func(a []expr) expr {
+			return withArg(a[0], func(v, x any) (any, error) {
+				s, _ := v.(string)
+				pat, _ := x.(string)
+				re, err := regexp.Compile(pat)
+				if err != nil {
+					return nil, err
+				}
+				return re.MatchString(s), nil
+			})
+		}},
+		"join": {1, func(a []expr) expr {
+			return withArg(a[0], func(v, x any) (any, error) {
+				arr, _ := v.([]any)
+				sep, _ := x.(string)
+				parts := make([]string, len(arr))
+				for i, e := range arr {
+					parts[i] = Text(e)
+				}
+				return strings.Join(parts, sep), nil
+			})
+		}},
+		"sort_by": {1, func(a []expr) expr {
+			return func(in any) ([]any, error) {
+				arr, ok := in.([]any)
+				if !ok {
+					return nil, fmt.Errorf("sort_by needs an array")
+				}
+				type keyed struct {
+					key any
+					v   any
+				}
+				ks := make([]keyed, len(arr))
+				for i, e := range arr {
+					k, err := a[0](e)
+					if err != nil {
+						return nil, err
+					}
+					ks[i].v = e
+					if len(k) > 0 {
+						ks[i].key = k[0]
+					}
+				}
+				sort.SliceStable(ks, func(i, j int) bool {
+					c, _ := order(ks[i].key, ks[j].key)
+					return c < 0
+				})
+				out := make([]any, len(ks))
+				for i, k := range ks {
+					out[i] = k.v
+				}
+				return []any{out}, nil
+			}
+		}},
+	}
+}
+
+func unary(f func(v any) (any, error)) expr {
+	return func(in any) ([]any, error) {
+		v, err := f(in)
+		return []any{v}, err
+	}
+}
+
+// withArg evaluates arg against the input and applies f to each output.
+func withArg(arg expr, f func(v, x any) (any, error)) expr {
+	return func(in any) ([]any, error) {
+		xs, err := arg(in)
+		if err != nil {
+			return nil, err
+		}
+		var out []any
+		for _, x := range xs {
+			v, err := f(in, x)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, v)
+		}
+		return out, nil
+	}
+}
+
+func stringTest(arg expr, f func(s, x string) bool) expr {
+	return withArg(arg, func(v, x any) (any, error) {
+		s, ok1 := v.(string)
+		t, ok2 := x.(string)
+		return ok1 && ok2 && f(s, t), nil
+	})
+}
+
+func length(v any) (any, error) {
+	switch t := v.(type) {
+	case nil:
+		return 0, nil
+	case string:
+		return utf8.RuneCountInString(t), nil
+	case []any:
+		return len(t), nil
+	case *Object:
+		return len(t.Keys), nil
+	case json.Number:
+		f, _ := t.Float64()
+		if f < 0 {
+			f = -f
+		}
+		return json.Number(strconv.FormatFloat(f, 'f', -1, 64)), nil
+	}
+	return nil, fmt.Errorf("%s has no length", Text(v))
+}
+
+func keys(v any) (any, error) {
+	switch t := v.(type) {
+	case *Object:
+		out := make([]any, len(t.Keys))
+		for i, k := range t.Keys {
+			out[i] = k
+		}
+		return out, nil
+	case []any:
+		out := make([]any, len(t))
+		for i := range t {
+			out[i] = i
+		}
+		return out, nil
+	}
+	return nil, fmt.Errorf("%s has no keys", Text(v))
+}
+
+func truthy(v any) bool {
+	b, isBool := v.(bool)
+	return v != nil && (!isBool || b)
+}
+
+func number(v any) (float64, bool) {
+	switch t := v.(type) {
+	case json.Number:
+		f, err := t.Float64()
+		return f, err == nil
+	case int:
+		return float64(t), true
+	case float64:
+		return t, true
+	}
+	return 0, false
+}
+
+func equal(a, b any) bool {
+	if x, ok := number(a); ok {
+		y, ok := number(b)
+		return ok && x == y
+	}
+	return string(Encode(a, "")) == string(Encode(b, ""))
+}
+
+// order compares two numbers or two strings.
+func order(a, b any) (int, bool) {
+	if x, ok := number(a); ok {
+		y, ok := number(b)
+		switch {
+		case !ok:
+			return 0, false
+		case x < y:
+			return -1, true
+		case x > y:
+			return 1, true
+		}
+		return 0, true
+	}
+	s, ok1 := a.(string)
+	t, ok2 := b.(string)
+	if ok1 && ok2 {
+		return strings.Compare(s, t), true
+	}
+	return 0, false
+}
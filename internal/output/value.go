@@ -0,0 +1,227 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Object is a JSON object that keeps its keys in the order the response
+// sent them, so tables, YAML and re-encoded JSON read like the original.
+type Object struct {
+	Keys []string
+	Vals map[string]any
+}
+
+func newObject() *Object { return &Object{Vals: map[string]any{}} }
+
+// Set adds or replaces key, keeping its first position.
+func (o *Object) Set(key string, v any) {
+	if _, ok := o.Vals[key]; !ok {
+		o.Keys = append(o.Keys, key)
+	}
+	o.Vals[key] = v
+}
+
+func (o *Object) Get(key string) (any, bool) {
+	v, ok := o.Vals[key]
+	return v, ok
+}
+
+// Decode parses a JSON document into nil, bool, json.Number, string,
+// []any and *Object values.
+func Decode(data []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	v, err := decodeValue(dec)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("trailing data after JSON value")
+	}
+	return v, nil
+}
+
+func decodeValue(dec *json.Decoder) (any, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch t := tok.(type) {
+	case json.Delim:
+		switch t {
+		case '{':
+			o := newObject()
+			for dec.More() {
+				kt, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				v, err := decodeValue(dec)
+				if err != nil {
+					return nil, err
+				}
+				o.Set(kt.(string), v)
+			}
+			_, err := dec.Token()
+			return o, err
+		case '[':
+			arr := []any{}
+			for dec.More() {
+				v, err := decodeValue(dec)
+				if err != nil {
+					return nil, err
+				}
+				arr = append(arr, v)
+			}
+			_, err := dec.Token()
+			return arr, err
+		}
+		return nil, fmt.Errorf("unexpected %v", t)
+	default:
+		return t, nil
+	}
+}
+
+// Encode writes v as compact JSON, or indented when indent is set.
+func Encode(v any, indent string) []byte {
+	var b bytes.Buffer
+	writeJSON(&b, v, indent, "")
+	return b.Bytes()
+}
+
+func writeJSON(b *bytes.Buffer, v any, indent, prefix string) {
+	inner := prefix + indent
+	sep, colon := ",", ":"
+	if indent != "" {
+		colon = ": "
+	}
+	newline := func(p string) {
+		if indent != "" {
+			b.WriteByte('\n')
+			b.WriteString(p)
+		}
+	}
+	switch t := v.(type) {
+	case *Object:
+		if len(t.Keys) == 0 {
+			b.WriteString("{}")
+			return
+		}
+		b.WriteByte('{')
+		for i, k := range t.Keys {
+			if i > 0 {
+				b.WriteString(sep)
+			}
+			newline(inner)
+			writeString(b, k)
+			b.WriteString(colon)
+			writeJSON(b, t.Vals[k], indent, inner)
+		}
+		newline(prefix)
+		b.WriteByte('}')
+	case []any:
+		if len(t) == 0 {
+			b.WriteString("[]")
+			return
+		}
+		b.WriteByte('[')
+		for i, e := range t {
+			if i > 0 {
+				b.WriteString(sep)
+			}
+			newline(inner)
+			writeJSON(b, e, indent, inner)
+		}
+		newline(prefix)
+		b.WriteByte(']')
+	case string:
+		writeString(b, t)
+	case json.Number:
+		b.WriteString(t.String())
+	case float64:
+		b.WriteString(strconv.FormatFloat(t, 'f', -1, 64))
+	case int:
+		b.WriteString(strconv.Itoa(t))
+	case bool:
+		b.WriteString(strconv.FormatBool(t))
+	case nil:
+		b.WriteString("null")
+	default:
+		j, _ := json.Marshal(t)
+		b.Write(j)
+	}
+}
+
+func writeString(b *bytes.Buffer, s string) {
+	enc := json.NewEncoder(b)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(s)
+	b.Truncate(b.Len() - 1) // Encode's newline
+}
+
+// Text is v as a table cell or raw line: strings bare, null empty and
+// everything else as compact JSON.
+func Text(v any) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	default:
+		return string(Encode(t, ""))
+	}
+}
+
+// Flatten turns nested objects into one level with dotted keys
+// (user.name); arrays stay whole.
+func Flatten(v any) *Object {
+	out := newObject()
+	o, ok := v.(*Object)
+	if !ok {
+		out.Set("value", v)
+		return out
+	}
+	flattenInto(out, "", o)
+	return out
+}
+
+func flattenInto(out *Object, prefix string, o *Object) {
+	for _, k := range o.Keys {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+		if sub, ok := o.Vals[k].(*Object); ok && len(sub.Keys) > 0 {
+			flattenInto(out, key, sub)
+			continue
+		}
+		out.Set(key, o.Vals[k])
+	}
+}
+
+// Field looks up a dotted path in v; a key that itself contains dots is
+// tried whole first.
+func Field(v any, path string) (any, bool) {
+	o, ok := v.(*Object)
+	if !ok {
+		return nil, false
+	}
+	if x, ok := o.Get(path); ok {
+		return x, true
+	}
+	head, rest, ok := strings.Cut(path, ".")
+	if !ok {
+		return nil, false
+	}
+	next, ok := o.Get(head)
+	if !ok {
+		return nil, false
+	}
+	return Field(next, rest)
+}
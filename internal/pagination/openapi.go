@@ -0,0 +1,152 @@
+package pagination
+
+import (
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+
+	"github.com/bspippi1337/restless/internal/store"
+)
+
+// FromOperation reads how an OpenAPI operation pages from its query
+// parameters, its Link response header and the shape of its 2xx JSON
+// response. It returns nil when the operation does not look paged.
+func FromOperation(op *openapi3.Operation, params openapi3.Parameters) *store.Pagination {
+	if op == nil {
+		return nil
+	}
+	p := &store.Pagination{Source: "openapi"}
+	list, link := false, false
+	var page *openapi3.Schema
+	if op.Responses != nil {
+		for code, r := range op.Responses.Map() {
+			if !strings.HasPrefix(code, "2") || r == nil || r.Value == nil {
+				continue
+			}
+			for name := range r.Value.Headers {
+				link = link || strings.EqualFold(name, "Link")
+			}
+			for mt, m := range r.Value.Content {
+				if !strings.Contains(mt, "json") || m.Schema == nil || m.Schema.Value == nil {
+					continue
+				}
+				s := m.Schema.Value
+				if s.Type.Is("array") {
+					list = true
+				} else if f := arrayField(s); f != "" {
+					list, page, p.Items = true, s, f
+				}
+			}
+		}
+	}
+	if !list {
+		return nil
+	}
+
+	var query []string
+	for _, pr := range params {
+		if pr != nil && pr.Value != nil && pr.Value.In == openapi3.ParameterInQuery {
+			query = append(query, pr.Value.Name)
+		}
+	}
+	p.SizeParam = named(query, sizeParams)
+
+	if link {
+		p.Style = Link
+		return p
+	}
+	if page != nil {
+		if f := stringField(page, nextURLs); f != "" && isURLField(page, f) {
+			p.Style, p.Next = NextURL, f
+			return p
+		}
+		if c := named(query, cursorParams); c != "" {
+			if f := stringField(page, nextCursor); f != "" {
+				p.Style, p.Param, p.Next = Cursor, c, f
+				return p
+			}
+		}
+	}
+	if p.Param = named(query, pageParams); p.Param != "" {
+		p.Style = Page
+		return p
+	}
+	if p.Param = named(query, offsetParams); p.Param != "" {
+		p.Style = Offset
+		return p
+	}
+	return nil
+}
+
+// arrayField is the property of a page schema that holds the items.
+func arrayField(s *openapi3.Schema) string {
+	var arrays []string
+	for name, prop := range s.Properties {
+		if prop != nil && prop.Value != nil && prop.Value.Type.Is("array") {
+			arrays = append(arrays, name)
+		}
+	}
+	for _, f := range ItemFields {
+		for _, a := range arrays {
+			if a == f {
+				return f
+			}
+		}
+	}
+	if len(arrays) == 1 {
+		return arrays[0]
+	}
+	return ""
+}
+
+// stringField is the first of names the schema has as a string
+// property, directly or one object down (links.next, meta.next_cursor).
+func stringField(s *openapi3.Schema, names []string) string {
+	for _, meta := range metaFields {
+		obj := s
+		if meta != "" {
+			if obj = property(s, meta); obj == nil {
+				continue
+			}
+		}
+		for _, n := range names {
+			if prop := property(obj, n); prop != nil && prop.Type.Is("string") {
+				if meta != "" {
+					return meta + "." + n
+				}
+				return n
+			}
+		}
+	}
+	return ""
+}
+
+func property(s *openapi3.Schema, path string) *openapi3.Schema {
+	for _, part := range strings.Split(path, ".") {
+		ref := s.Properties[part]
+		if ref == nil || ref.Value == nil {
+			return nil
+		}
+		s = ref.Value
+	}
+	return s
+}
+
+// isURLField tells next links from cursors that share the name "next".
+func isURLField(s *openapi3.Schema, path string) bool {
+	prop := property(s, path)
+	name := strings.ToLower(path[strings.LastIndexByte(path, '.')+1:])
+	return prop.Format == "uri" || prop.Format == "url" || strings.Contains(name, "url") ||
+		strings.Contains(name, "link") || strings.HasSuffix(name, "href") || strings.Contains(path, "links.")
+}
+
+func named(have, names []string) string {
+	for _, n := range names {
+		for _, h := range have {
+			if h == n {
+				return n
+			}
+		}
+	}
+	return ""
+}
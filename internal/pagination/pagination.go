@@ -0,0 +1,283 @@
+// Package pagination recognizes how a collection endpoint pages (Link
+// headers, next links and cursors in the body, page and offset
+// parameters) and walks every page of one.
+package pagination
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/bspippi1337/restless/internal/store"
+)
+
+// Styles, as recorded in store.Pagination.
+const (
+	Link    = "link"     // RFC 8288 Link header with rel="next"
+	NextURL = "next-url" // the body links to the next page
+	Cursor  = "cursor"   // the body carries a cursor for the next request
+	Page    = "page"     // a page number parameter
+	Offset  = "offset"   // an offset parameter
+)
+
+var (
+	// ItemFields are the fields a page keeps its items in, most
+	// telling first.
+	ItemFields = []string{"data", "items", "results", "records", "entries", "values", "elements", "content", "list", "rows", "hits"}
+
+	// Objects that hold the paging details next to the items.
+	metaFields = []string{"", "links", "_links", "meta", "pagination", "paging", "page_info", "pageInfo", "response_metadata", "cursors", "paging.cursors"}
+	nextURLs   = []string{"next", "next_url", "nextUrl", "next_page_url", "nextPageUrl", "next.href", "nextLink", "@odata.nextLink"}
+	nextCursor = []string{"next_cursor", "nextCursor", "next_page_token", "nextPageToken", "next_token", "nextToken",
+		"end_cursor", "endCursor", "cursor", "after", "next", "continuation", "continuationToken", "scroll_id"}
+
+	// Query parameters by the style they belong to.
+	pageParams   = []string{"page", "page_number", "pageNumber", "pageNo", "p"}
+	offsetParams = []string{"offset", "skip", "start"}
+	sizeParams   = []string{"per_page", "perPage", "page_size", "pageSize", "limit", "size", "count", "take", "max_results", "maxResults"}
+	cursorParams = []string{"cursor", "after", "page_token", "pageToken", "next_token", "nextToken", "starting_after",
+		"continuation", "continuationToken", "scroll_id"}
+)
+
+// Detect works out how the response to a GET of u pages. It returns nil
+// for responses that do not look like a page of a longer list.
+func Detect(u *url.URL, h http.Header, body []byte) *store.Pagination {
+	var v any
+	_ = json.Unmarshal(body, &v)
+	q := url.Values{}
+	if u != nil {
+		q = u.Query()
+	}
+
+	p := &store.Pagination{Source: "response"}
+	if obj, ok := v.(map[string]any); ok {
+		p.Items, _ = findItems(obj)
+	}
+	p.SizeParam = first(q, sizeParams)
+
+	if NextLink(h) != "" {
+		p.Style = Link
+		return p
+	}
+	if _, isList := v.([]any); !isList && p.Items == "" {
+		return nil
+	}
+	if obj, ok := v.(map[string]any); ok {
+		if path, val, ok := findNext(obj); ok {
+			p.Next = path
+			if looksLikeURL(val) {
+				p.Style = NextURL
+			} else {
+				p.Style = Cursor
+				p.Param = first(q, cursorParams)
+				if p.Param == "" {
+					p.Param = cursorParam(path)
+				}
+			}
+			return p
+		}
+	}
+	if p.Param = first(q, pageParams); p.Param != "" {
+		p.Style = Page
+		return p
+	}
+	if p.Param = first(q, offsetParams); p.Param != "" {
+		p.Style = Offset
+		return p
+	}
+	// Paging details in the body, even though the request had none.
+	if obj, ok := v.(map[string]any); ok {
+		for _, meta := range metaFields {
+			m := lookupObject(obj, meta)
+			switch {
+			case m == nil:
+			case has(m, "total_pages", "totalPages", "last_page", "lastPage", "page_count", "pageCount"):
+				p.Style, p.Param = Page, "page"
+				return p
+			case has(m, "offset") && has(m, "total", "count", "total_count", "totalCount"):
+				p.Style, p.Param = Offset, "offset"
+				return p
+			}
+		}
+	}
+	return nil
+}
+
+// Items are the items of one page: the list itself, or the field p
+// names (or the one that looks like it holds them).
+func Items(body []byte, p *store.Pagination) ([]json.RawMessage, bool) {
+	var list []json.RawMessage
+	if json.Unmarshal(body, &list) == nil {
+		return list, true
+	}
+	var obj map[string]any
+	if json.Unmarshal(body, &obj) != nil {
+		return nil, false
+	}
+	field := ""
+	if p != nil {
+		field = p.Items
+	}
+	if field == "" {
+		if field, _ = findItems(obj); field == "" {
+			return nil, false
+		}
+	}
+	arr, ok := lookup(obj, field).([]any)
+	if !ok {
+		return nil, false
+	}
+	out := make([]json.RawMessage, 0, len(arr))
+	for _, it := range arr {
+		b, _ := json.Marshal(it)
+		out = append(out, b)
+	}
+	return out, true
+}
+
+// NextLink is the rel="next" target of a Link header, as written.
+func NextLink(h http.Header) string {
+	for _, v := range h.Values("Link") {
+		for _, part := range splitLinks(v) {
+			target, params, ok := strings.Cut(part, ";")
+			target = strings.TrimSpace(target)
+			if !ok || !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+				continue
+			}
+			for _, param := range strings.Split(params, ";") {
+				k, val, _ := strings.Cut(strings.TrimSpace(param), "=")
+				if !strings.EqualFold(k, "rel") {
+					continue
+				}
+				for _, rel := range strings.Fields(strings.Trim(val, `"`)) {
+					if strings.EqualFold(rel, "next") {
+						return target[1 : len(target)-1]
+					}
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// splitLinks splits a Link header at the commas between links, not
+// those inside a <URI>.
+func splitLinks(v string) []string {
+	var out []string
+	depth, start := 0, 0
+	for i, r := range v {
+		switch r {
+		case '<':
+			depth++
+		case '>':
+			depth--
+		case ',':
+			if depth == 0 {
+				out = append(out, v[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(out, v[start:])
+}
+
+func findItems(obj map[string]any) (string, bool) {
+	for _, f := range ItemFields {
+		if _, ok := obj[f].([]any); ok {
+			return f, true
+		}
+	}
+	// A page with a single list in it.
+	var only string
+	for k, v := range obj {
+		if _, ok := v.([]any); ok {
+			if only != "" {
+				return "", false
+			}
+			only = k
+		}
+	}
+	return only, only != ""
+}
+
+// findNext finds a non-empty next link or cursor in the body.
+func findNext(obj map[string]any) (string, string, bool) {
+	for _, names := range [][]string{nextURLs, nextCursor} {
+		for _, meta := range metaFields {
+			for _, n := range names {
+				path := n
+				if meta != "" {
+					path = meta + "." + n
+				}
+				if v, ok := lookup(obj, path).(string); ok && v != "" {
+					return path, v, true
+				}
+			}
+		}
+	}
+	return "", "", false
+}
+
+// cursorParam guesses the parameter a cursor field goes back in as.
+func cursorParam(field string) string {
+	name := field[strings.LastIndexByte(field, '.')+1:]
+	switch name {
+	case "next_page_token":
+		return "page_token"
+	case "nextPageToken":
+		return "pageToken"
+	case "next_token", "nextToken", "after", "continuation", "continuationToken", "scroll_id":
+		return name
+	case "endCursor", "end_cursor":
+		return "after"
+	}
+	return "cursor"
+}
+
+func looksLikeURL(s string) bool {
+	return strings.HasPrefix(s, "http://") || strings.HasPrefix(s, "https://") || strings.HasPrefix(s, "/") || strings.HasPrefix(s, "?")
+}
+
+func first(q url.Values, names []string) string {
+	for _, n := range names {
+		if q.Has(n) {
+			return n
+		}
+	}
+	return ""
+}
+
+func has(m map[string]any, keys ...string) bool {
+	for _, k := range keys {
+		if _, ok := m[k]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// lookup follows a dotted path through nested objects. A key that itself
+// contains dots (@odata.nextLink) is tried whole first.
+func lookup(obj map[string]any, path string) any {
+	if v, ok := obj[path]; ok {
+		return v
+	}
+	head, rest, ok := strings.Cut(path, ".")
+	if !ok {
+		return nil
+	}
+	next, _ := obj[head].(map[string]any)
+	if next == nil {
+		return nil
+	}
+	return lookup(next, rest)
+}
+
+func lookupObject(obj map[string]any, path string) map[string]any {
+	if path == "" {
+		return obj
+	}
+	m, _ := lookup(obj, path).(map[string]any)
+	return m
+}
@@ -0,0 +1,86 @@
+package pagination
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/bspippi1337/restless/internal/core/types"
+)
+
+func TestDetect(t *testing.T) {
+	link := http.Header{}
+	link.Set("Link", `<https://api.example.com/repos?page=1>; rel="prev", <https://api.example.com/repos?page=3>; rel="next"`)
+
+	for _, c := range []struct {
+		url   string
+		h     http.Header
+		body  string
+		style string
+		param string
+		next  string
+	}{
+		{"/repos?page=2", link, `[{"id":1}]`, Link, "", ""},
+		{"/pets", nil, `{"data":[{"id":1}],"links":{"next":"/pets?after=x"}}`, NextURL, "", "links.next"},
+		{"/pets?limit=2", nil, `{"items":[{"id":1}],"meta":{"next_cursor":"abc"}}`, Cursor, "cursor", "meta.next_cursor"},
+		{"/users?page=2&per_page=10", nil, `[{"id":1}]`, Page, "page", ""},
+		{"/users?offset=20", nil, `{"results":[{"id":1}],"count":99}`, Offset, "offset", ""},
+		{"/users", nil, `{"data":[{"id":1}],"total_pages":4}`, Page, "page", ""},
+		{"/users/1", nil, `{"id":1,"name":"ada"}`, "", "", ""},
+	} {
+		u, _ := url.Parse(c.url)
+		p := Detect(u, c.h, []byte(c.body))
+		if c.style == "" {
+			if p != nil {
+				t.Fatalf("%s: detected %+v", c.url, p)
+			}
+			continue
+		}
+		if p == nil || p.Style != c.style || p.Param != c.param || p.Next != c.next {
+			t.Fatalf("%s: got %+v, want %s %q %q", c.url, p, c.style, c.param, c.next)
+		}
+	}
+}
+
+func TestWalk(t *testing.T) {
+	all := []int{1, 2, 3, 4, 5, 6, 7}
+	pages := 0
+	fetch := func(_ context.Context, raw string) (types.Response, error) {
+		pages++
+		u, _ := url.Parse(raw)
+		at, _ := strconv.Atoi(u.Query().Get("cursor"))
+		end := min(at+3, len(all))
+		page := map[string]any{"data": all[at:end]}
+		if end < len(all) {
+			page["next_cursor"] = strconv.Itoa(end)
+		}
+		b, _ := json.Marshal(page)
+		return types.Response{StatusCode: 200, Headers: http.Header{}, Body: b}, nil
+	}
+
+	var got []string
+	emit := func(item json.RawMessage) error {
+		got = append(got, string(item))
+		return nil
+	}
+	res, err := Walk(context.Background(), "https://api.example.com/n", nil, 0, fetch, emit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Pages != 3 || res.Items != 7 || res.Style != Cursor || fmt.Sprint(got) != "[1 2 3 4 5 6 7]" {
+		t.Fatalf("walk = %+v %v", res, got)
+	}
+
+	got, pages = nil, 0
+	res, err = Walk(context.Background(), "https://api.example.com/n", nil, 2, fetch, emit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Truncated || res.Items != 6 || pages != 2 {
+		t.Fatalf("limited walk = %+v after %d fetches", res, pages)
+	}
+}
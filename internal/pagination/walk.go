@@ -0,0 +1,183 @@
+package pagination
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/bspippi1337/restless/internal/core/types"
+	"github.com/bspippi1337/restless/internal/store"
+)
+
+// Fetch GETs one page.
+type Fetch func(ctx context.Context, rawURL string) (types.Response, error)
+
+// Result sums up a walk.
+type Result struct {
+	Pages int
+	Items int
+	// Style is how the list paged: as given, or as detected on the
+	// first page ("" when it was a single page).
+	Style string
+	// Truncated is set when the walk stopped at the page limit with
+	// more pages to go.
+	Truncated bool
+}
+
+// Walk fetches start and the pages after it, up to maxPages (0 for no
+// limit), and hands every item to emit in order. p says how the list
+// pages; when nil it is detected on the first page. The walk ends at an
+// empty or short page, when there is no next page, or when a next page
+// points back at one already fetched.
+func Walk(ctx context.Context, start string, p *store.Pagination, maxPages int, fetch Fetch, emit func(json.RawMessage) error) (Result, error) {
+	var res Result
+	seen := map[string]bool{}
+	prevFirst := ""
+	cur := start
+	for {
+		if err := ctx.Err(); err != nil {
+			return res, err
+		}
+		resp, err := fetch(ctx, cur)
+		if err != nil {
+			return res, err
+		}
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return res, fmt.Errorf("page %d: %d %s", res.Pages+1, resp.StatusCode, http.StatusText(resp.StatusCode))
+		}
+		seen[cur] = true
+		res.Pages++
+
+		u, err := url.Parse(cur)
+		if err != nil {
+			return res, err
+		}
+		if p == nil {
+			p = Detect(u, resp.Headers, resp.Body)
+		}
+		if p != nil {
+			res.Style = p.Style
+		}
+
+		items, ok := Items(resp.Body, p)
+		if !ok {
+			// Not a list: the body is the one item.
+			res.Items++
+			return res, emit(json.RawMessage(resp.Body))
+		}
+		// A server that ignores the paging parameter sends the same
+		// page again.
+		if len(items) > 0 && string(items[0]) == prevFirst {
+			res.Pages--
+			return res, nil
+		}
+		if len(items) > 0 {
+			prevFirst = string(items[0])
+		}
+		for _, it := range items {
+			res.Items++
+			if err := emit(it); err != nil {
+				return res, err
+			}
+		}
+		if p == nil || len(items) == 0 {
+			return res, nil
+		}
+
+		next := nextURL(u, resp, p, len(items))
+		if next == "" || seen[next] {
+			return res, nil
+		}
+		if maxPages > 0 && res.Pages >= maxPages {
+			res.Truncated = true
+			return res, nil
+		}
+		cur = next
+	}
+}
+
+// nextURL is the URL of the page after the one at u, or "" at the end.
+func nextURL(u *url.URL, resp types.Response, p *store.Pagination, n int) string {
+	q := u.Query()
+	switch p.Style {
+	case Link:
+		return resolve(u, NextLink(resp.Headers))
+	case NextURL:
+		return resolve(u, bodyString(resp.Body, p.Next))
+	case Cursor:
+		c := bodyString(resp.Body, p.Next)
+		if c == "" || p.Param == "" {
+			return ""
+		}
+		q.Set(p.Param, c)
+	case Page, Offset:
+		if p.Param == "" {
+			return ""
+		}
+		// A page shorter than asked for is the last one.
+		if size, err := strconv.Atoi(q.Get(p.SizeParam)); err == nil && p.SizeParam != "" && n < size {
+			return ""
+		}
+		at, err := strconv.Atoi(q.Get(p.Param))
+		if err != nil {
+			at = 0
+			if p.Style == Page {
+				at = 1
+			}
+		}
+		if p.Style == Page {
+			if last := lastPage(resp.Body); last > 0 && at >= last {
+				return ""
+			}
+			at++
+		} else {
+			at += n
+		}
+		q.Set(p.Param, strconv.Itoa(at))
+	default:
+		return ""
+	}
+	next := *u
+	next.RawQuery = q.Encode()
+	return next.String()
+}
+
+// lastPage is the page count a page reports about its list, or 0.
+func lastPage(body []byte) int {
+	var obj map[string]any
+	if json.Unmarshal(body, &obj) != nil {
+		return 0
+	}
+	for _, meta := range metaFields {
+		m := lookupObject(obj, meta)
+		for _, k := range []string{"total_pages", "totalPages", "last_page", "lastPage", "page_count", "pageCount"} {
+			if n, ok := m[k].(float64); ok {
+				return int(n)
+			}
+		}
+	}
+	return 0
+}
+
+func resolve(base *url.URL, ref string) string {
+	if ref == "" {
+		return ""
+	}
+	r, err := url.Parse(ref)
+	if err != nil {
+		return ""
+	}
+	return base.ResolveReference(r).String()
+}
+
+func bodyString(body []byte, path string) string {
+	var obj map[string]any
+	if path == "" || json.Unmarshal(body, &obj) != nil {
+		return ""
+	}
+	s, _ := lookup(obj, path).(string)
+	return s
+}
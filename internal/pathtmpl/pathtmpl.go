@@ -0,0 +1,158 @@
+package pathtmpl
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	numericRe = regexp.MustCompile(`^[0-9]+$`)
+	uuidRe    = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	hexRe     = regexp.MustCompile(`^[0-9a-fA-F]{16,}$`)
+	nameRe    = regexp.MustCompile(`[^A-Za-z0-9]+`)
+)
+
+// Template collapses identifier-like segments of a concrete path
+// (/users/42/repos/9f1c...) into named parameters
+// (/users/{userId}/repos/{repoId}). Existing {name} and :name segments
+// are kept as parameters. It returns the template and parameter names.
+func Template(path string) (string, []string) {
+	segs := split(path)
+	if len(segs) == 0 {
+		return "/", nil
+	}
+
+	var params []string
+	used := map[string]bool{}
+
+	for i, seg := range segs {
+		name, ok := paramName(seg)
+		if !ok {
+			if !IsIdentifier(seg) {
+				continue
+			}
+			prev := ""
+			if i > 0 && !isParam(segs[i-1]) {
+				prev = segs[i-1]
+			}
+			name = nameFor(prev)
+		}
+
+		name = unique(name, used)
+		segs[i] = "{" + name + "}"
+		params = append(params, name)
+	}
+
+	return "/" + strings.Join(segs, "/"), params
+}
+
+// Match reports whether path is a concrete instance of tpl and
+// returns the values bound to each parameter.
+func Match(tpl, path string) (map[string]string, bool) {
+	ts := split(tpl)
+	ps := split(path)
+	if len(ts) != len(ps) {
+		return nil, false
+	}
+
+	vals := map[string]string{}
+	for i := range ts {
+		if name, ok := paramName(ts[i]); ok {
+			if ps[i] == "" {
+				return nil, false
+			}
+			vals[name] = ps[i]
+			continue
+		}
+		if ts[i] != ps[i] {
+			return nil, false
+		}
+	}
+	return vals, true
+}
+
+// Params lists the parameter names of a template in path order.
+func Params(tpl string) []string {
+	var out []string
+	for _, seg := range split(tpl) {
+		if name, ok := paramName(seg); ok {
+			out = append(out, name)
+		}
+	}
+	return out
+}
+
+// IsIdentifier reports whether a path segment looks like a resource id
+// rather than a collection or action name.
+func IsIdentifier(seg string) bool {
+	return numericRe.MatchString(seg) || uuidRe.MatchString(seg) || hexRe.MatchString(seg)
+}
+
+func split(path string) []string {
+	path = strings.Trim(strings.TrimSpace(path), "/")
+	if path == "" {
+		return nil
+	}
+	return strings.Split(path, "/")
+}
+
+func isParam(seg string) bool {
+	_, ok := paramName(seg)
+	return ok || IsIdentifier(seg)
+}
+
+func paramName(seg string) (string, bool) {
+	if len(seg) > 2 && strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}") {
+		return seg[1 : len(seg)-1], true
+	}
+	if len(seg) > 1 && strings.HasPrefix(seg, ":") {
+		return seg[1:], true
+	}
+	return "", false
+}
+
+func nameFor(collection string) string {
+	parts := nameRe.Split(singular(collection), -1)
+	var b strings.Builder
+	for _, p := range parts {
+		if p == "" {
+			continue
+		}
+		if b.Len() == 0 {
+			b.WriteString(strings.ToLower(p))
+			continue
+		}
+		b.WriteString(strings.ToUpper(p[:1]) + p[1:])
+	}
+	if b.Len() == 0 {
+		return "id"
+	}
+	return b.String() + "Id"
+}
+
+func singular(s string) string {
+	switch {
+	case strings.HasSuffix(s, "ies") && len(s) > 3:
+		return s[:len(s)-3] + "y"
+	case strings.HasSuffix(s, "ses") && len(s) > 3:
+		return s[:len(s)-2]
+	case strings.HasSuffix(s, "s") && !strings.HasSuffix(s, "ss") && len(s) > 1:
+		return s[:len(s)-1]
+	}
+	return s
+}
+
+func unique(name string, used map[string]bool) string {
+	if !used[name] {
+		used[name] = true
+		return name
+	}
+	for i := 2; ; i++ {
+		n := name + strconv.Itoa(i)
+		if !used[n] {
+			used[n] = true
+			return n
+		}
+	}
+}
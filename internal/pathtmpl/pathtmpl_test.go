@@ -0,0 +1,37 @@
+package pathtmpl
+
+import "testing"
+
+func TestTemplate(t *testing.T) {
+	cases := map[string]string{
+		"/users":                  "/users",
+		"/users/42":               "/users/{userId}",
+		"/users/42/repos/7":       "/users/{userId}/repos/{repoId}",
+		"/categories/3/items/4":   "/categories/{categoryId}/items/{itemId}",
+		"/orders/{id}":            "/orders/{id}",
+		"/a/1/2":                  "/a/{aId}/{id}",
+		"/blobs/0123456789abcdef": "/blobs/{blobId}",
+		"/":                       "/",
+	}
+
+	for in, want := range cases {
+		got, _ := Template(in)
+		if got != want {
+			t.Fatalf("Template(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	vals, ok := Match("/users/{userId}/repos/{repoId}", "/users/42/repos/7")
+	if !ok {
+		t.Fatalf("expected match")
+	}
+	if vals["userId"] != "42" || vals["repoId"] != "7" {
+		t.Fatalf("unexpected values: %v", vals)
+	}
+
+	if _, ok := Match("/users/{userId}", "/orgs/42"); ok {
+		t.Fatalf("unexpected match")
+	}
+}
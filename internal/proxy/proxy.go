@@ -0,0 +1,241 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+
+	"github.com/bspippi1337/restless/internal/auth"
+	"github.com/bspippi1337/restless/internal/cassette"
+	"github.com/bspippi1337/restless/internal/modules/openapi/guard/model"
+	gruntime "github.com/bspippi1337/restless/internal/modules/openapi/guard/runtime"
+	"github.com/bspippi1337/restless/internal/store"
+	"github.com/bspippi1337/restless/internal/traffic"
+)
+
+// DefaultMaxBody caps how much of a request body is buffered for learning.
+const DefaultMaxBody = 8 << 20
+
+type Options struct {
+	Upstream string
+
+	// Spec, when set, validates every JSON response against the contract.
+	Spec *openapi3.T
+
+	// Log receives one line per exchange. Nil discards.
+	Log io.Writer
+
+	MaxBody int64
+}
+
+// Recorder is a reverse proxy that learns the upstream API from the
+// traffic passing through it and keeps a replayable cassette.
+type Recorder struct {
+	upstream *url.URL
+	rp       *httputil.ReverseProxy
+	log      io.Writer
+	maxBody  int64
+
+	learner  *traffic.Learner
+	cassette *cassette.Cassette
+
+	spec      *openapi3.T
+	validator *gruntime.Validator
+
+	mu       sync.Mutex
+	findings []model.Finding
+}
+
+type ctxKey struct{}
+
+type pending struct {
+	start time.Time
+	body  []byte
+}
+
+func New(opt Options) (*Recorder, error) {
+	u, err := url.Parse(opt.Upstream)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+		return nil, fmt.Errorf("upstream must be an absolute http(s) URL: %q", opt.Upstream)
+	}
+
+	r := &Recorder{
+		upstream: u,
+		log:      opt.Log,
+		maxBody:  opt.MaxBody,
+		learner:  traffic.NewLearner("proxy"),
+		cassette: cassette.New(),
+		spec:     opt.Spec,
+	}
+	if r.log == nil {
+		r.log = io.Discard
+	}
+	if r.maxBody <= 0 {
+		r.maxBody = DefaultMaxBody
+	}
+	if opt.Spec != nil {
+		r.validator = gruntime.NewValidator(opt.Spec)
+	}
+
+	r.rp = &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			pr.SetURL(u)
+			// Let the transport negotiate compression so recorded
+			// bodies are plain and can be learned from.
+			pr.Out.Header.Del("Accept-Encoding")
+		},
+		ModifyResponse: r.record,
+		ErrorHandler: func(w http.ResponseWriter, req *http.Request, err error) {
+			fmt.Fprintf(r.log, "%s %s -> upstream error: %v\n", req.Method, req.URL.RequestURI(), err)
+			http.Error(w, "restless proxy: "+err.Error(), http.StatusBadGateway)
+		},
+	}
+
+	return r, nil
+}
+
+func (r *Recorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	p := &pending{start: time.Now()}
+
+	if req.Body != nil && req.Body != http.NoBody {
+		b, err := io.ReadAll(io.LimitReader(req.Body, r.maxBody+1))
+		req.Body.Close()
+		if err != nil {
+			http.Error(w, "restless proxy: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if int64(len(b)) > r.maxBody {
+			http.Error(w, "restless proxy: request body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		p.body = b
+		req.Body = io.NopCloser(bytes.NewReader(b))
+		req.ContentLength = int64(len(b))
+	}
+
+	r.rp.ServeHTTP(w, req.WithContext(context.WithValue(req.Context(), ctxKey{}, p)))
+}
+
+// record buffers the upstream response, hands the client an identical
+// copy and feeds the exchange to the learner, cassette and validator.
+func (r *Recorder) record(resp *http.Response) error {
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	out := resp.Request
+	p, _ := out.Context().Value(ctxKey{}).(*pending)
+	if p == nil {
+		return errors.New("restless proxy: lost request context")
+	}
+	dur := time.Since(p.start)
+
+	r.learner.Add(traffic.Exchange{
+		Time:           p.start,
+		Method:         out.Method,
+		URL:            out.URL,
+		RequestHeader:  out.Header,
+		RequestBody:    p.body,
+		Status:         resp.StatusCode,
+		ResponseHeader: resp.Header,
+		ResponseBody:   body,
+	})
+
+	r.cassette.Add(cassette.Interaction{
+		RecordedAt: p.start.UTC(),
+		Request: cassette.Request{
+			Method:  out.Method,
+			URL:     out.URL.String(),
+			Headers: out.Header.Clone(),
+			Body:    cassette.NewBody(p.body),
+		},
+		Response: cassette.Response{
+			Status:     resp.StatusCode,
+			Headers:    resp.Header.Clone(),
+			Body:       cassette.NewBody(body),
+			DurationMs: dur.Milliseconds(),
+		},
+	})
+
+	fmt.Fprintf(r.log, "%s %s -> %d (%dms)\n", out.Method, out.URL.RequestURI(), resp.StatusCode, dur.Milliseconds())
+
+	if r.validator != nil {
+		r.validate(out, p.body, resp, body)
+	}
+	return nil
+}
+
+func (r *Recorder) validate(out *http.Request, reqBody []byte, resp *http.Response, body []byte) {
+	ct := resp.Header.Get("Content-Type")
+	if !traffic.IsJSON(ct) {
+		return
+	}
+
+	var found []model.Finding
+	tpl, ok := gruntime.MatchPathTemplate(r.spec, out.URL.Path)
+	if !ok {
+		found = []model.Finding{{
+			Method: out.Method, Path: out.URL.Path, Status: resp.StatusCode, ContentType: ct,
+			Kind: model.KindSchemaViolation, Severity: model.SevMedium,
+			JSONPath: "$", Message: "path not defined in OpenAPI spec",
+		}}
+	} else {
+		fs, err := r.validator.ValidateResponse(out.Context(), out.Method, tpl, resp.StatusCode, ct, body)
+		if err != nil {
+			found = []model.Finding{{
+				Method: out.Method, Path: tpl, Status: resp.StatusCode, ContentType: ct,
+				Kind: model.KindSchemaViolation, Severity: model.SevMedium,
+				JSONPath: "$", Message: err.Error(),
+			}}
+		} else {
+			found = fs
+		}
+	}
+
+	sent := model.NewRequest(out, reqBody)
+	for i, f := range found {
+		found[i].Request = sent
+		fmt.Fprintf(r.log, "  ✗ %s [%s/%s] %s\n", f.JSONPath, f.Kind, f.Severity, f.Message)
+	}
+
+	r.mu.Lock()
+	r.findings = append(r.findings, found...)
+	r.mu.Unlock()
+}
+
+// API returns what has been learned so far, with endpoints classified
+// public or protected from the challenges seen and the spec, if any.
+func (r *Recorder) API() *store.API {
+	api := r.learner.API()
+	if api.BaseURL == "" {
+		api.BaseURL = r.upstream.Scheme + "://" + r.upstream.Host
+	}
+	auth.Classify(api, auth.Evidence{Challenges: r.learner.Challenges(), Sent: r.learner.SentSchemes(), Spec: r.spec})
+	return api
+}
+
+func (r *Recorder) Calls() int { return r.learner.Calls() }
+
+func (r *Recorder) Cassette() *cassette.Cassette { return r.cassette }
+
+// Findings returns contract violations seen so far (only with a spec).
+func (r *Recorder) Findings() []model.Finding {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]model.Finding(nil), r.findings...)
+}
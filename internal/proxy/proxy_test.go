@@ -0,0 +1,74 @@
+package proxy
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bspippi1337/restless/internal/redact"
+)
+
+func TestRecorderLearnsAndRedacts(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodPost {
+			w.WriteHeader(http.StatusCreated)
+			io.WriteString(w, `{"id":3}`)
+			return
+		}
+		io.WriteString(w, `{"id":1,"name":"a"}`)
+	}))
+	defer upstream.Close()
+
+	rec, err := New(Options{Upstream: upstream.URL})
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	front := httptest.NewServer(rec)
+	defer front.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, front.URL+"/users/42?expand=org", nil)
+	req.Header.Set("Authorization", "Bearer s3cret")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != `{"id":1,"name":"a"}` {
+		t.Fatalf("client got %q", body)
+	}
+
+	resp, err = http.Post(front.URL+"/users", "application/json", strings.NewReader(`{"name":"c"}`))
+	if err != nil {
+		t.Fatalf("post: %v", err)
+	}
+	resp.Body.Close()
+
+	api := rec.API()
+	if api.BaseURL != upstream.URL {
+		t.Fatalf("unexpected base: %s", api.BaseURL)
+	}
+	if len(api.Endpoints) != 2 || api.Endpoints[1].Path != "/users/{userId}" {
+		t.Fatalf("unexpected endpoints: %+v", api.Endpoints)
+	}
+	if api.Endpoints[0].RequestSchema == nil {
+		t.Fatalf("expected request schema on POST /users")
+	}
+	if api.Endpoints[1].Source != "proxy" || len(api.Endpoints[1].Query) != 1 {
+		t.Fatalf("unexpected evidence: %+v", api.Endpoints[1])
+	}
+
+	c := rec.Cassette()
+	if c.Len() != 2 {
+		t.Fatalf("expected 2 interactions, got %d", c.Len())
+	}
+	if got := c.Interactions[0].Request.Headers.Get("Authorization"); got != redact.Placeholder {
+		t.Fatalf("authorization not redacted: %q", got)
+	}
+	if got := string(c.Interactions[1].Request.Body.Bytes()); got != `{"name":"c"}` {
+		t.Fatalf("request body not recorded: %q", got)
+	}
+}
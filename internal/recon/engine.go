@@ -21,6 +21,7 @@ type Engine struct {
 	Timeout time.Duration
 	UA      string
 	Headers map[string]string
+	MaxBody int64 // response bytes kept; 0 means 512 KiB
 }
 
 func New() *Engine {
@@ -40,7 +41,8 @@ type Response struct {
 	DurationMS  int64
 	ContentType string
 	Body        []byte
-	Headers     map[string]string
+	Headers     map[string]string // rate-limit hints
+	Header      http.Header       // everything, for the security audit
 }
 
 func (e *Engine) Request(ctx context.Context, method, rawURL string, body []byte) (*Response, error) {
@@ -52,6 +54,9 @@ func (e *Engine) Request(ctx context.Context, method, rawURL string, body []byte
 	for k, v := range e.Headers {
 		req.Header.Set(k, v)
 	}
+	if req.Header.Get("Content-Type") == "" && LooksJSON("", body) {
+		req.Header.Set("Content-Type", "application/json")
+	}
 	start := time.Now()
 	resp, err := e.Client.Do(req)
 	if err != nil {
@@ -59,7 +64,11 @@ func (e *Engine) Request(ctx context.Context, method, rawURL string, body []byte
 	}
 	defer resp.Body.Close()
 
-	b, _ := io.ReadAll(io.LimitReader(resp.Body, 512*1024))
+	limit := e.MaxBody
+	if limit <= 0 {
+		limit = 512 * 1024
+	}
+	b, _ := io.ReadAll(io.LimitReader(resp.Body, limit))
 	ct := resp.Header.Get("Content-Type")
 
 	h := map[string]string{}
@@ -78,6 +87,7 @@ func (e *Engine) Request(ctx context.Context, method, rawURL string, body []byte
 		ContentType: ct,
 		Body:        b,
 		Headers:     h,
+		Header:      resp.Header,
 	}, nil
 }
 
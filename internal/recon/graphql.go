@@ -4,31 +4,72 @@ import (
 	"context"
 	"encoding/json"
 	"net/url"
+
+	"github.com/bspippi1337/restless/internal/graphql"
 )
 
 type GraphQLInfo struct {
 	Endpoint      string `json:"endpoint"`
 	Introspection bool   `json:"introspection"`
 	Types         int    `json:"types"`
+	Queries       int    `json:"queries,omitempty"`
+	Mutations     int    `json:"mutations,omitempty"`
+	Subscriptions int    `json:"subscriptions,omitempty"`
+	Deprecated    int    `json:"deprecated,omitempty"`
 	Note          string `json:"note"`
+
+	Schema *graphql.Schema `json:"-"`
 }
 
-// TryGraphQLIntrospection performs a tiny schema introspection (bounded) on /graphql.
+// TryGraphQLIntrospection runs a full schema introspection against the
+// common GraphQL endpoint paths and stops at the first that answers.
 func TryGraphQLIntrospection(ctx context.Context, e *Engine, base *url.URL) (*GraphQLInfo, error) {
-	u := *base
-	u.Path = "/graphql"
+	var first *GraphQLInfo
+	var firstErr error
+
+	// Full introspection of a large API runs to megabytes.
+	ge := *e
+	ge.MaxBody = 16 << 20
+
+	for _, p := range graphql.Paths {
+		u := *base
+		u.Path = p
+		u.RawQuery = ""
 
-	query := `{"query":"query IntrospectionQuery{__schema{types{name}}}"}`
-	resp, err := e.Request(ctx, "POST", u.String(), []byte(query))
+		gi, err := introspectAt(ctx, &ge, u.String(), p)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		if gi.Introspection {
+			return gi, nil
+		}
+		// Remember the most telling failure: an endpoint that exists
+		// beats one that is simply missing.
+		if first == nil || (first.Note == "not-found" && gi.Note != "not-found") {
+			first = gi
+		}
+	}
+
+	if first == nil {
+		return nil, firstErr
+	}
+	return first, nil
+}
+
+func introspectAt(ctx context.Context, e *Engine, rawURL, path string) (*GraphQLInfo, error) {
+	resp, err := e.Request(ctx, "POST", rawURL, graphql.IntrospectionRequest())
 	if err != nil {
 		return nil, err
 	}
-	gi := &GraphQLInfo{Endpoint: u.Path}
+	gi := &GraphQLInfo{Endpoint: path}
 	if resp.Status >= 500 {
 		gi.Note = "server-error"
 		return gi, nil
 	}
-	if resp.Status == 404 {
+	if resp.Status == 404 || resp.Status == 405 {
 		gi.Note = "not-found"
 		return gi, nil
 	}
@@ -45,18 +86,26 @@ func TryGraphQLIntrospection(ctx context.Context, e *Engine, base *url.URL) (*Gr
 		gi.Note = "json-parse-failed"
 		return gi, nil
 	}
-	data, ok := doc["data"].(map[string]any)
-	if !ok {
-		gi.Note = "no-data"
-		return gi, nil
-	}
-	schema, ok := data["__schema"].(map[string]any)
-	if !ok {
+	s, err := graphql.Parse(resp.Body)
+	if err != nil {
 		gi.Note = "no-schema"
 		return gi, nil
 	}
-	if types, ok := schema["types"].([]any); ok {
-		gi.Types = len(types)
+
+	gi.Schema = s
+	gi.Types = len(s.Types)
+	for _, op := range s.Operations() {
+		switch op.Kind {
+		case "query":
+			gi.Queries++
+		case "mutation":
+			gi.Mutations++
+		case "subscription":
+			gi.Subscriptions++
+		}
+		if op.Deprecated {
+			gi.Deprecated++
+		}
 	}
 	gi.Introspection = gi.Types > 0
 	gi.Note = "ok"
@@ -0,0 +1,163 @@
+package redact
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Placeholder replaces secret values in anything restless writes to disk.
+const Placeholder = "REDACTED"
+
+var secretHeaders = map[string]bool{
+	"authorization":       true,
+	"proxy-authorization": true,
+	"cookie":              true,
+	"set-cookie":          true,
+	"x-api-key":           true,
+	"api-key":             true,
+	"x-auth-token":        true,
+	"x-access-token":      true,
+	"x-csrf-token":        true,
+	"x-xsrf-token":        true,
+}
+
+// SecretHeader reports whether a header usually carries credentials.
+func SecretHeader(name string) bool {
+	n := strings.ToLower(strings.TrimSpace(name))
+	if secretHeaders[n] {
+		return true
+	}
+	return strings.Contains(n, "token") || strings.Contains(n, "secret") || strings.Contains(n, "api-key")
+}
+
+// Headers returns a copy of h with secret header values replaced.
+func Headers(h http.Header) http.Header {
+	out := http.Header{}
+	for k, vv := range h {
+		for _, v := range vv {
+			if SecretHeader(k) {
+				v = Placeholder
+			}
+			out.Add(k, v)
+		}
+	}
+	return out
+}
+
+var secretParams = map[string]bool{
+	"access_token":  true,
+	"refresh_token": true,
+	"id_token":      true,
+	"client_secret": true,
+	"api_key":       true,
+	"apikey":        true,
+	"key":           true,
+	"password":      true,
+	"passwd":        true,
+	"sig":           true,
+	"signature":     true,
+}
+
+// SecretParam reports whether a query parameter or body field name
+// usually carries a credential.
+func SecretParam(name string) bool {
+	n := strings.ToLower(strings.TrimSpace(name))
+	if secretParams[n] {
+		return true
+	}
+	return strings.Contains(n, "token") || strings.Contains(n, "secret") || strings.Contains(n, "password")
+}
+
+// URL returns raw with secret query values and any userinfo password
+// replaced. Unparseable input is returned unchanged.
+func URL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+	changed := false
+	if u.User != nil {
+		if _, ok := u.User.Password(); ok {
+			u.User = url.UserPassword(u.User.Username(), Placeholder)
+			changed = true
+		}
+	}
+	if u.RawQuery != "" {
+		q := u.Query()
+		for k, vv := range q {
+			if SecretParam(k) {
+				for i := range vv {
+					vv[i] = Placeholder
+				}
+				changed = true
+			}
+		}
+		if changed {
+			u.RawQuery = q.Encode()
+		}
+	}
+	if !changed {
+		return raw
+	}
+	return u.String()
+}
+
+// Body returns b with secret JSON fields or form values replaced. Bodies
+// without secrets, or of other types, are returned unchanged so their
+// formatting survives.
+func Body(contentType string, b []byte) []byte {
+	ct := strings.ToLower(contentType)
+	switch {
+	case strings.Contains(ct, "json"):
+		var v any
+		if json.Unmarshal(b, &v) != nil || !redactJSON(v) {
+			return b
+		}
+		out, err := json.Marshal(v)
+		if err != nil {
+			return b
+		}
+		return out
+	case strings.Contains(ct, "application/x-www-form-urlencoded"):
+		q, err := url.ParseQuery(string(b))
+		if err != nil {
+			return b
+		}
+		changed := false
+		for k, vv := range q {
+			if SecretParam(k) {
+				for i := range vv {
+					vv[i] = Placeholder
+				}
+				changed = true
+			}
+		}
+		if !changed {
+			return b
+		}
+		return []byte(q.Encode())
+	}
+	return b
+}
+
+func redactJSON(v any) bool {
+	changed := false
+	switch t := v.(type) {
+	case map[string]any:
+		for k, x := range t {
+			if _, ok := x.(string); ok && SecretParam(k) {
+				t[k] = Placeholder
+				changed = true
+				continue
+			}
+			changed = redactJSON(x) || changed
+		}
+	case []any:
+		for _, x := range t {
+			changed = redactJSON(x) || changed
+		}
+	}
+	return changed
+}
@@ -9,7 +9,12 @@ import (
 	"strings"
 	"time"
 
+	"github.com/bspippi1337/restless/internal/audit"
+	"github.com/bspippi1337/restless/internal/auth"
 	"github.com/bspippi1337/restless/internal/intel"
+	"github.com/bspippi1337/restless/internal/pagination"
+	"github.com/bspippi1337/restless/internal/schema"
+	"github.com/bspippi1337/restless/internal/store"
 )
 
 type Endpoint struct {
@@ -18,6 +23,10 @@ type Endpoint struct {
 	Status     int
 	Confidence string
 	Source     string
+	Schema     *schema.Schema
+	Allow      []string
+	Challenge  string // WWW-Authenticate on a 401/403
+	Pagination *store.Pagination
 }
 
 type Edge struct {
@@ -32,6 +41,14 @@ type ScanResult struct {
 	Fingerprints []string
 	Confirmed    []Endpoint
 	Topology     []Edge
+
+	// Security holds passive audit findings over the responses fetched.
+	Security []audit.Finding
+
+	// AuthServer is the OIDC / OAuth 2.0 metadata published under
+	// /.well-known, if any, and AuthServerURL where it was found.
+	AuthServer    *auth.OIDCConfig
+	AuthServerURL string
 }
 
 type CrawlNode struct {
@@ -39,19 +56,38 @@ type CrawlNode struct {
 	Depth int
 }
 
+// Options tunes a scan.
+type Options struct {
+	// NoAudit skips the security audit: no requests to sensitive paths
+	// such as /.env, and no findings in ScanResult.Security.
+	NoAudit bool
+}
+
 func Scan(target string, timeout time.Duration) (*ScanResult, error) {
+	return ScanWith(target, &http.Client{Timeout: timeout})
+}
+
+// ScanWith scans using client, e.g. one whose transport adds credentials.
+func ScanWith(target string, client *http.Client) (*ScanResult, error) {
+	return ScanWithOptions(target, client, Options{})
+}
+
+// ScanWithOptions is ScanWith with opt applied.
+func ScanWithOptions(target string, client *http.Client, opt Options) (*ScanResult, error) {
 	base := normalize(target)
-	client := &http.Client{Timeout: timeout}
 
 	r := &ScanResult{
 		Target:  target,
 		BaseURL: base,
 	}
 
+	sec := audit.New()
+
 	status, body, headers, err := fetch(client, base+"/")
 	if err != nil {
 		return nil, err
 	}
+	sec.Observe(audit.Observation{Method: "GET", URL: base + "/", Status: status, Header: headers, Body: body})
 
 	r.Fingerprints = fingerprints(headers, body)
 	r.APIType = detectAPIType(r.Fingerprints)
@@ -63,6 +99,9 @@ func Scan(target string, timeout time.Duration) (*ScanResult, error) {
 			Status:     status,
 			Confidence: "high",
 			Source:     "root",
+			Schema:     schema.Infer(body),
+			Challenge:  headers.Get("WWW-Authenticate"),
+			Pagination: paged(base+"/", status, headers, body),
 		})
 	}
 
@@ -74,7 +113,8 @@ func Scan(target string, timeout time.Duration) (*ScanResult, error) {
 		}
 
 		seen[p] = true
-		status, _, _, _ := fetch(client, base+p)
+		status, body, h, _ := fetch(client, base+p)
+		sec.Observe(audit.Observation{Method: "GET", URL: base + p, Status: status, Header: h, Body: body})
 
 		r.Confirmed = append(r.Confirmed, Endpoint{
 			Method:     "GET",
@@ -82,11 +122,45 @@ func Scan(target string, timeout time.Duration) (*ScanResult, error) {
 			Status:     status,
 			Confidence: "high",
 			Source:     "surface",
+			Schema:     schema.Infer(body),
+			Challenge:  h.Get("WWW-Authenticate"),
+			Pagination: paged(base+p, status, h, body),
 		})
 	}
 
 	r.Confirmed = uniqEndpoints(r.Confirmed)
 
+	// Sensitive seeds are only audited, never learned as endpoints.
+	if !opt.NoAudit {
+		for _, p := range audit.SensitiveSeeds() {
+			status, body, h, err := fetch(client, base+p)
+			if err == nil {
+				sec.Observe(audit.Observation{Method: "GET", URL: base + p, Status: status, Header: h, Body: body})
+			}
+		}
+	}
+
+	for _, p := range auth.WellKnownPaths {
+		status, body, _, err := fetch(client, base+p)
+		if err != nil || status != http.StatusOK {
+			continue
+		}
+		if c, err := auth.ParseOIDC(body); err == nil {
+			r.AuthServer, r.AuthServerURL = c, base+p
+			break
+		}
+	}
+
+	for i, ep := range r.Confirmed {
+		if ep.Status > 0 && ep.Status != http.StatusNotFound {
+			r.Confirmed[i].Allow = allowed(client, base+ep.Path, sec)
+		}
+	}
+
+	if !opt.NoAudit {
+		r.Security = sec.Findings()
+	}
+
 	sort.Slice(r.Confirmed, func(i, j int) bool {
 		return r.Confirmed[i].Path < r.Confirmed[j].Path
 	})
@@ -115,6 +189,18 @@ func Render(title string, r *ScanResult) string {
 	return intel.RenderNervousSystem(profile)
 }
 
+// paged is how a successful GET of u pages, if it is a page of a list.
+func paged(u string, status int, h http.Header, body []byte) *store.Pagination {
+	if status < 200 || status > 299 {
+		return nil
+	}
+	pu, err := url.Parse(u)
+	if err != nil {
+		return nil
+	}
+	return pagination.Detect(pu, h, body)
+}
+
 func normalize(raw string) string {
 	raw = strings.TrimSpace(raw)
 	raw = strings.TrimPrefix(raw, "https://")
@@ -139,6 +225,34 @@ func fetch(client *http.Client, u string) (int, []byte, http.Header, error) {
 	return resp.StatusCode, body, resp.Header, nil
 }
 
+// allowed asks the server which methods a path accepts (OPTIONS + Allow).
+// The request doubles as a CORS preflight from a foreign origin, which the
+// auditor checks for reflection.
+func allowed(client *http.Client, u string, sec *audit.Auditor) []string {
+	req, _ := http.NewRequest("OPTIONS", u, nil)
+	req.Header.Set("User-Agent", "restless-blckswan")
+	req.Header.Set("Origin", audit.ProbeOrigin)
+	req.Header.Set("Access-Control-Request-Method", "GET")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil
+	}
+	resp.Body.Close()
+
+	sec.Observe(audit.Observation{Method: "OPTIONS", URL: u, Status: resp.StatusCode, Header: resp.Header, Origin: audit.ProbeOrigin})
+
+	var out []string
+	for _, m := range strings.Split(resp.Header.Get("Allow"), ",") {
+		m = strings.ToUpper(strings.TrimSpace(m))
+		if m != "" {
+			out = append(out, m)
+		}
+	}
+
+	return uniqStrings(out)
+}
+
 func fingerprints(h http.Header, body []byte) []string {
 	var out []string
 
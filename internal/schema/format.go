@@ -0,0 +1,45 @@
+package schema
+
+import (
+	"net/url"
+	"regexp"
+	"time"
+)
+
+var (
+	uuidRe  = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	emailRe = regexp.MustCompile(`^[^@\s]+@[^@\s]+\.[A-Za-z]{2,}$`)
+)
+
+// detectFormat maps a string sample to a JSON Schema format, or "".
+func detectFormat(s string) string {
+	switch {
+	case s == "":
+		return ""
+	case uuidRe.MatchString(s):
+		return "uuid"
+	case isDateTime(s):
+		return "date-time"
+	case emailRe.MatchString(s):
+		return "email"
+	case isURI(s):
+		return "uri"
+	}
+	return ""
+}
+
+func isDateTime(s string) bool {
+	if len(s) < len("2006-01-02T15:04:05Z") {
+		return false
+	}
+	_, err := time.Parse(time.RFC3339Nano, s)
+	return err == nil
+}
+
+func isURI(s string) bool {
+	u, err := url.Parse(s)
+	if err != nil {
+		return false
+	}
+	return u.Scheme != "" && u.Host != ""
+}
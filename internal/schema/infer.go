@@ -0,0 +1,296 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"sort"
+)
+
+// Enum detection: a string field becomes an enum only when it has few
+// distinct values and those values repeat across samples.
+const (
+	maxEnumValues  = 6
+	minEnumSamples = 4
+)
+
+// Inferrer accumulates JSON samples and produces one merged schema.
+// It is not safe for concurrent use.
+type Inferrer struct {
+	root    *node
+	samples int
+}
+
+func NewInferrer() *Inferrer {
+	return &Inferrer{root: newNode()}
+}
+
+// AddJSON decodes body and adds it as a sample.
+func (in *Inferrer) AddJSON(body []byte) error {
+	var v any
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return err
+	}
+	in.Add(v)
+	return nil
+}
+
+// Add adds one decoded JSON value as a sample.
+func (in *Inferrer) Add(v any) {
+	in.samples++
+	in.root.observe(v)
+}
+
+// Samples is the number of top-level samples added so far.
+func (in *Inferrer) Samples() int {
+	if in == nil {
+		return 0
+	}
+	return in.samples
+}
+
+// Schema returns the merged schema, or nil when no samples were added.
+func (in *Inferrer) Schema() *Schema {
+	if in == nil || in.samples == 0 {
+		return nil
+	}
+	s := in.root.schema()
+	s.Draft = Draft
+	s.Samples = in.samples
+	return s
+}
+
+// Infer is a convenience for inferring a schema from a set of bodies.
+// Bodies that are not valid JSON are skipped.
+func Infer(bodies ...[]byte) *Schema {
+	in := NewInferrer()
+	for _, b := range bodies {
+		_ = in.AddJSON(b)
+	}
+	return in.Schema()
+}
+
+type node struct {
+	seen  int
+	nulls int
+	kinds map[string]int
+
+	objects int
+	props   map[string]*node
+
+	items *node
+
+	strings  map[string]int
+	overflow bool
+	formats  map[string]int
+}
+
+func newNode() *node {
+	return &node{kinds: map[string]int{}}
+}
+
+func (n *node) observe(v any) {
+	n.seen++
+
+	switch t := v.(type) {
+	case nil:
+		n.nulls++
+	case map[string]any:
+		n.kinds["object"]++
+		n.objects++
+		if n.props == nil {
+			n.props = map[string]*node{}
+		}
+		for k, val := range t {
+			child, ok := n.props[k]
+			if !ok {
+				child = newNode()
+				n.props[k] = child
+			}
+			child.observe(val)
+		}
+	case []any:
+		n.kinds["array"]++
+		if n.items == nil {
+			n.items = newNode()
+		}
+		for _, val := range t {
+			n.items.observe(val)
+		}
+	case string:
+		n.kinds["string"]++
+		n.observeString(t)
+	case json.Number:
+		if _, err := t.Int64(); err == nil {
+			n.kinds["integer"]++
+		} else {
+			n.kinds["number"]++
+		}
+	case float64:
+		if t == math.Trunc(t) {
+			n.kinds["integer"]++
+		} else {
+			n.kinds["number"]++
+		}
+	case bool:
+		n.kinds["boolean"]++
+	}
+}
+
+func (n *node) observeString(s string) {
+	if n.formats == nil {
+		n.formats = map[string]int{}
+	}
+	n.formats[detectFormat(s)]++
+
+	if n.overflow {
+		return
+	}
+	if n.strings == nil {
+		n.strings = map[string]int{}
+	}
+	n.strings[s]++
+	if len(n.strings) > maxEnumValues {
+		n.overflow = true
+		n.strings = nil
+	}
+}
+
+// kindOrder keeps emitted type lists stable across runs.
+var kindOrder = []string{"object", "array", "string", "integer", "number", "boolean"}
+
+func (n *node) schema() *Schema {
+	var kinds []string
+	for _, k := range kindOrder {
+		if n.kinds[k] > 0 {
+			kinds = append(kinds, k)
+		}
+	}
+	// An integer seen next to a fractional number is just a number.
+	if n.kinds["integer"] > 0 && n.kinds["number"] > 0 {
+		kinds = removeKind(kinds, "integer")
+	}
+
+	nullable := n.nulls > 0
+
+	switch {
+	case len(kinds) == 0:
+		if nullable {
+			return &Schema{Type: Types{"null"}}
+		}
+		return &Schema{}
+
+	case len(kinds) == 1:
+		s := n.kindSchema(kinds[0])
+		if nullable {
+			s.Type = append(s.Type, "null")
+			if s.Enum != nil {
+				s.Enum = append(s.Enum, nil)
+			}
+		}
+		return s
+
+	case allScalar(kinds):
+		s := &Schema{Type: Types(kinds)}
+		if n.kinds["string"] > 0 {
+			s.Format = n.format()
+		}
+		if nullable {
+			s.Type = append(s.Type, "null")
+		}
+		return s
+	}
+
+	s := &Schema{}
+	for _, k := range kinds {
+		s.AnyOf = append(s.AnyOf, n.kindSchema(k))
+	}
+	if nullable {
+		s.AnyOf = append(s.AnyOf, &Schema{Type: Types{"null"}})
+	}
+	return s
+}
+
+func (n *node) kindSchema(kind string) *Schema {
+	s := &Schema{Type: Types{kind}}
+
+	switch kind {
+	case "object":
+		if len(n.props) == 0 {
+			return s
+		}
+		s.Properties = map[string]*Schema{}
+		for k, child := range n.props {
+			s.Properties[k] = child.schema()
+			if child.seen == n.objects {
+				s.Required = append(s.Required, k)
+			}
+		}
+		sort.Strings(s.Required)
+
+	case "array":
+		if n.items != nil && n.items.seen > 0 {
+			s.Items = n.items.schema()
+		}
+
+	case "string":
+		s.Format = n.format()
+		if s.Format == "" {
+			s.Enum = n.enum()
+		}
+	}
+
+	return s
+}
+
+// format returns a string format only when every string sample agreed.
+func (n *node) format() string {
+	if len(n.formats) != 1 {
+		return ""
+	}
+	for f := range n.formats {
+		return f
+	}
+	return ""
+}
+
+func (n *node) enum() []any {
+	if n.overflow || len(n.strings) == 0 {
+		return nil
+	}
+	total := n.kinds["string"]
+	if total < minEnumSamples || len(n.strings)*2 > total {
+		return nil
+	}
+	vals := make([]string, 0, len(n.strings))
+	for v := range n.strings {
+		vals = append(vals, v)
+	}
+	sort.Strings(vals)
+	out := make([]any, 0, len(vals))
+	for _, v := range vals {
+		out = append(out, v)
+	}
+	return out
+}
+
+func allScalar(kinds []string) bool {
+	for _, k := range kinds {
+		if k == "object" || k == "array" {
+			return false
+		}
+	}
+	return true
+}
+
+func removeKind(kinds []string, kind string) []string {
+	out := kinds[:0]
+	for _, k := range kinds {
+		if k != kind {
+			out = append(out, k)
+		}
+	}
+	return out
+}
@@ -0,0 +1,101 @@
+package schema
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestInferMergesSamples(t *testing.T) {
+	bodies := [][]byte{
+		[]byte(`{"id":"6f1c2a4e-8b1d-4c55-9a43-0b6b2d9c1e10","status":"active","email":"a@example.com","created_at":"2024-01-02T03:04:05Z","nick":"al","tags":["x",1]}`),
+		[]byte(`{"id":"0d7e5f3b-2a9c-4e1d-8f6a-3c4b5d6e7f80","status":"active","email":"b@example.com","created_at":"2024-02-02T03:04:05Z","nick":null,"tags":[]}`),
+		[]byte(`{"id":"9a8b7c6d-5e4f-4a3b-8c2d-1e0f9a8b7c6d","status":"banned","email":"c@example.com","created_at":"2024-03-02T03:04:05Z","nick":"cy","site":"https://c.example.com"}`),
+		[]byte(`{"id":"1b2c3d4e-5f6a-4b7c-8d9e-0f1a2b3c4d5e","status":"active","email":"d@example.com","created_at":"2024-04-02T03:04:05Z","nick":"di"}`),
+	}
+
+	s := Infer(bodies...)
+	if s == nil {
+		t.Fatalf("expected schema")
+	}
+	if s.Samples != 4 {
+		t.Fatalf("expected 4 samples, got %d", s.Samples)
+	}
+
+	want := []string{"created_at", "email", "id", "nick", "status"}
+	if len(s.Required) != len(want) {
+		t.Fatalf("required mismatch: %v", s.Required)
+	}
+	for i := range want {
+		if s.Required[i] != want[i] {
+			t.Fatalf("required mismatch: %v", s.Required)
+		}
+	}
+
+	checks := map[string]string{
+		"id":         "uuid",
+		"email":      "email",
+		"created_at": "date-time",
+		"site":       "uri",
+	}
+	for field, format := range checks {
+		if got := s.Properties[field].Format; got != format {
+			t.Fatalf("%s: expected format %q, got %q", field, format, got)
+		}
+	}
+
+	if !s.Properties["nick"].Nullable() {
+		t.Fatalf("nick should be nullable")
+	}
+
+	enum := s.Properties["status"].Enum
+	if len(enum) != 2 || enum[0] != "active" || enum[1] != "banned" {
+		t.Fatalf("unexpected status enum: %v", enum)
+	}
+
+	items := s.Properties["tags"].Items
+	if items == nil || len(items.Type) != 2 {
+		t.Fatalf("expected item union, got %#v", items)
+	}
+}
+
+func TestTypesJSON(t *testing.T) {
+	b, _ := json.Marshal(&Schema{Type: Types{"string", "null"}})
+	if string(b) != `{"type":["string","null"]}` {
+		t.Fatalf("unexpected: %s", b)
+	}
+
+	var s Schema
+	if err := json.Unmarshal([]byte(`{"type":"integer"}`), &s); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if !s.Is("integer") {
+		t.Fatalf("expected integer, got %v", s.Type)
+	}
+}
+
+func TestMergeMatchesJointInference(t *testing.T) {
+	a := [][]byte{
+		[]byte(`{"id":1,"name":"ada","score":2,"tags":["x"],"owner":{"login":"ada"}}`),
+		[]byte(`{"id":2,"name":"bob","score":3,"tags":[],"owner":null}`),
+	}
+	b := [][]byte{
+		[]byte(`{"id":3,"score":2.5,"tags":[1],"site":"https://b.example.com","owner":{"login":"cy","id":7}}`),
+		[]byte(`{"id":4,"name":7,"score":1,"site":"https://d.example.com","owner":"cy"}`),
+	}
+
+	got, err := json.Marshal(Merge(Infer(a...), Infer(b...)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := json.Marshal(Infer(append(a, b...)...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(want) {
+		t.Fatalf("merged:\n%s\njoint:\n%s", got, want)
+	}
+
+	if s := Merge(nil, Infer(a...)); s == nil || s.Samples != 2 {
+		t.Fatalf("merge with nil: %#v", s)
+	}
+}
@@ -0,0 +1,208 @@
+package schema
+
+import (
+	"reflect"
+	"sort"
+)
+
+// Merge combines two inferred schemas as if their samples had been
+// inferred together: properties and types are unioned, a property stays
+// required only when both sides required it, and formats and enums survive
+// only where both sides agree. Either side may be nil.
+func Merge(a, b *Schema) *Schema {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+	s := merge(a, b)
+	s.Draft = a.Draft
+	if s.Draft == "" {
+		s.Draft = b.Draft
+	}
+	s.Samples = a.Samples + b.Samples
+	return s
+}
+
+func merge(a, b *Schema) *Schema {
+	kinds := map[string]*Schema{}
+	nullable := false
+	for _, s := range []*Schema{a, b} {
+		for _, k := range split(s) {
+			if k.Is("null") {
+				nullable = true
+				continue
+			}
+			if cur, ok := kinds[k.Type[0]]; ok {
+				kinds[k.Type[0]] = mergeKind(cur, k)
+			} else {
+				kinds[k.Type[0]] = k
+			}
+		}
+	}
+
+	var order []string
+	for _, k := range kindOrder {
+		if kinds[k] != nil {
+			order = append(order, k)
+		}
+	}
+	// An integer seen next to a fractional number is just a number.
+	if kinds["integer"] != nil && kinds["number"] != nil {
+		order = removeKind(order, "integer")
+	}
+
+	switch {
+	case len(order) == 0:
+		if nullable {
+			return &Schema{Type: Types{"null"}}
+		}
+		return &Schema{}
+
+	case len(order) == 1:
+		s := kinds[order[0]]
+		if nullable {
+			s.Type = append(s.Type, "null")
+			if s.Enum != nil {
+				s.Enum = append(s.Enum, nil)
+			}
+		}
+		return s
+
+	case allScalar(order):
+		s := &Schema{Type: Types(order)}
+		if str := kinds["string"]; str != nil {
+			s.Format = str.Format
+		}
+		if nullable {
+			s.Type = append(s.Type, "null")
+		}
+		return s
+	}
+
+	s := &Schema{}
+	for _, k := range order {
+		s.AnyOf = append(s.AnyOf, kinds[k])
+	}
+	if nullable {
+		s.AnyOf = append(s.AnyOf, &Schema{Type: Types{"null"}})
+	}
+	return s
+}
+
+// split breaks s into one fresh schema per type it allows, the way the
+// inferrer keeps them before it decides on anyOf or a type list.
+func split(s *Schema) []*Schema {
+	if len(s.AnyOf) > 0 {
+		var out []*Schema
+		for _, a := range s.AnyOf {
+			out = append(out, split(a)...)
+		}
+		return out
+	}
+
+	var out []*Schema
+	for _, t := range s.Type {
+		k := &Schema{Type: Types{t}}
+		switch t {
+		case "object":
+			k.Properties, k.Required = s.Properties, s.Required
+		case "array":
+			k.Items = s.Items
+		case "string":
+			k.Format = s.Format
+			for _, v := range s.Enum {
+				if v != nil {
+					k.Enum = append(k.Enum, v)
+				}
+			}
+		}
+		out = append(out, k)
+	}
+	return out
+}
+
+func mergeKind(a, b *Schema) *Schema {
+	s := &Schema{Type: a.Type}
+
+	switch a.Type[0] {
+	case "object":
+		if len(a.Properties)+len(b.Properties) > 0 {
+			s.Properties = map[string]*Schema{}
+		}
+		for k, p := range a.Properties {
+			s.Properties[k] = p
+		}
+		for k, p := range b.Properties {
+			if cur, ok := s.Properties[k]; ok {
+				s.Properties[k] = merge(cur, p)
+			} else {
+				s.Properties[k] = p
+			}
+		}
+		for _, k := range a.Required {
+			if contains(b.Required, k) {
+				s.Required = append(s.Required, k)
+			}
+		}
+		sort.Strings(s.Required)
+
+	case "array":
+		switch {
+		case a.Items == nil:
+			s.Items = b.Items
+		case b.Items == nil:
+			s.Items = a.Items
+		default:
+			s.Items = merge(a.Items, b.Items)
+		}
+
+	case "string":
+		if a.Format == b.Format {
+			s.Format = a.Format
+		}
+		if s.Format == "" && a.Enum != nil && b.Enum != nil {
+			s.Enum = unionEnum(a.Enum, b.Enum)
+		}
+	}
+
+	return s
+}
+
+// unionEnum keeps an enum only while it stays small enough to be one.
+func unionEnum(a, b []any) []any {
+	out := append([]any(nil), a...)
+	for _, v := range b {
+		if !containsValue(out, v) {
+			out = append(out, v)
+		}
+	}
+	if len(out) > maxEnumValues {
+		return nil
+	}
+	sort.Slice(out, func(i, j int) bool {
+		x, _ := out[i].(string)
+		y, _ := out[j].(string)
+		return x < y
+	})
+	return out
+}
+
+func contains(list []string, v string) bool {
+	for _, s := range list {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+func containsValue(list []any, v any) bool {
+	for _, x := range list {
+		if reflect.DeepEqual(x, v) {
+			return true
+		}
+	}
+	return false
+}
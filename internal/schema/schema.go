@@ -0,0 +1,76 @@
+package schema
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// Draft is the JSON Schema dialect emitted by the inferrer.
+const Draft = "https://json-schema.org/draft/2020-12/schema"
+
+// Schema is the subset of JSON Schema restless infers from samples.
+type Schema struct {
+	Draft      string             `json:"$schema,omitempty"`
+	Type       Types              `json:"type,omitempty"`
+	Format     string             `json:"format,omitempty"`
+	Enum       []any              `json:"enum,omitempty"`
+	Properties map[string]*Schema `json:"properties,omitempty"`
+	Required   []string           `json:"required,omitempty"`
+	Items      *Schema            `json:"items,omitempty"`
+	AnyOf      []*Schema          `json:"anyOf,omitempty"`
+	Samples    int                `json:"x-samples,omitempty"`
+}
+
+// Types marshals as a plain string when it holds a single type,
+// and as an array otherwise (e.g. ["string","null"]).
+type Types []string
+
+func (t Types) MarshalJSON() ([]byte, error) {
+	if len(t) == 1 {
+		return json.Marshal(t[0])
+	}
+	return json.Marshal([]string(t))
+}
+
+func (t *Types) UnmarshalJSON(b []byte) error {
+	var one string
+	if err := json.Unmarshal(b, &one); err == nil {
+		*t = Types{one}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(b, &many); err != nil {
+		return errors.New("schema: type must be a string or an array of strings")
+	}
+	*t = Types(many)
+	return nil
+}
+
+// Is reports whether the schema allows the given type.
+func (s *Schema) Is(typ string) bool {
+	if s == nil {
+		return false
+	}
+	for _, t := range s.Type {
+		if t == typ {
+			return true
+		}
+	}
+	return false
+}
+
+// Nullable reports whether null is an accepted value.
+func (s *Schema) Nullable() bool {
+	if s.Is("null") {
+		return true
+	}
+	if s == nil {
+		return false
+	}
+	for _, a := range s.AnyOf {
+		if a.Is("null") {
+			return true
+		}
+	}
+	return false
+}
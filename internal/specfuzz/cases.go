@@ -0,0 +1,397 @@
+// Package specfuzz fuzzes an API from its OpenAPI description: every
+// operation gets a valid baseline request built from its schemas, then
+// one mutation at a time is applied to a parameter or body field.
+package specfuzz
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+
+	"github.com/bspippi1337/restless/internal/mock"
+	"github.com/bspippi1337/restless/internal/modules/openapi/guard/model"
+)
+
+// Operation is one method + path template of the spec.
+type Operation struct {
+	ID     string
+	Method string
+	Path   string
+	Op     *openapi3.Operation
+	Params openapi3.Parameters
+}
+
+// Operations lists the spec's operations sorted by path, then method.
+func Operations(doc *openapi3.T) []Operation {
+	var out []Operation
+	if doc == nil || doc.Paths == nil {
+		return out
+	}
+	for tpl, item := range doc.Paths.Map() {
+		for m, op := range item.Operations() {
+			m = strings.ToUpper(m)
+			id := op.OperationID
+			if id == "" {
+				id = strings.ToLower(m) + " " + tpl
+			}
+			// Operation parameters override path-level ones of the same name.
+			params := append(openapi3.Parameters{}, op.Parameters...)
+			for _, p := range item.Parameters {
+				if p.Value != nil && op.Parameters.GetByInAndName(p.Value.In, p.Value.Name) == nil {
+					params = append(params, p)
+				}
+			}
+			out = append(out, Operation{ID: id, Method: m, Path: tpl, Op: op, Params: params})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Path != out[j].Path {
+			return out[i].Path < out[j].Path
+		}
+		return out[i].Method < out[j].Method
+	})
+	return out
+}
+
+// Case is one request to send: the baseline with at most one mutation.
+type Case struct {
+	Op       Operation
+	Target   string // "query.limit", "body.name", "body"; empty for the baseline
+	Mutation string // e.g. "max+1", "wrong-type", "oversized"
+
+	path   map[string]any
+	query  map[string]any
+	header map[string]any
+	body   any
+	raw    []byte // sent verbatim instead of body when set
+	json   bool   // body is JSON
+}
+
+// Request is a fully built HTTP request, kept on findings so they can be
+// replayed.
+type Request = model.Request
+
+// Build renders the case against base.
+func (c Case) Build(base string) Request {
+	p := c.Op.Path
+	for name, v := range c.path {
+		p = strings.ReplaceAll(p, "{"+name+"}", url.PathEscape(format(v)))
+	}
+	u := strings.TrimRight(base, "/") + p
+
+	q := url.Values{}
+	for _, name := range sortedKeys(c.query) {
+		if arr, ok := c.query[name].([]any); ok {
+			for _, v := range arr {
+				q.Add(name, format(v))
+			}
+			continue
+		}
+		q.Set(name, format(c.query[name]))
+	}
+	if len(q) > 0 {
+		u += "?" + q.Encode()
+	}
+
+	r := Request{Method: c.Op.Method, URL: u, Header: http.Header{}}
+	for _, name := range sortedKeys(c.header) {
+		// The client refuses CR/LF in header values.
+		r.Header.Set(name, strings.NewReplacer("\r", "", "\n", "").Replace(format(c.header[name])))
+	}
+	switch {
+	case c.raw != nil:
+		r.Body = string(c.raw)
+		r.Header.Set("Content-Type", "application/json")
+	case c.json:
+		b, _ := json.Marshal(c.body)
+		r.Body = string(b)
+		r.Header.Set("Content-Type", "application/json")
+	}
+	return r
+}
+
+// Cases returns the baseline followed by every mutation of op.
+func Cases(op Operation) []Case {
+	base := baseline(op)
+	out := []Case{base}
+
+	for _, ref := range op.Params {
+		p := ref.Value
+		if p == nil || p.In == openapi3.ParameterInCookie {
+			continue
+		}
+		var s *openapi3.Schema
+		if p.Schema != nil {
+			s = p.Schema.Value
+		}
+		target := p.In + "." + p.Name
+		for _, m := range mutations(s, p.In != openapi3.ParameterInPath) {
+			c := base.clone()
+			c.Target, c.Mutation = target, m.name
+			c.params(p.In)[p.Name] = m.value
+			out = append(out, c)
+		}
+		if p.Required && p.In != openapi3.ParameterInPath {
+			c := base.clone()
+			c.Target, c.Mutation = target, "missing-required"
+			delete(c.params(p.In), p.Name)
+			out = append(out, c)
+		}
+	}
+
+	s := bodySchema(op.Op)
+	if !base.json || s == nil {
+		return out
+	}
+	obj, _ := base.body.(map[string]any)
+	for _, name := range sortedKeys(s.Properties) {
+		ref := s.Properties[name]
+		if ref == nil || ref.Value == nil || ref.Value.ReadOnly || obj == nil {
+			continue
+		}
+		for _, m := range mutations(ref.Value, true) {
+			c := base.clone()
+			c.Target, c.Mutation = "body."+name, m.name
+			c.body.(map[string]any)[name] = m.value
+			out = append(out, c)
+		}
+	}
+	for _, name := range s.Required {
+		if obj == nil {
+			break
+		}
+		c := base.clone()
+		c.Target, c.Mutation = "body."+name, "missing-required"
+		delete(c.body.(map[string]any), name)
+		out = append(out, c)
+	}
+	for _, m := range []struct {
+		name string
+		raw  string
+	}{
+		{"malformed-json", `{"`},
+		{"empty-body", ``},
+		{"wrong-type", `"string"`},
+		{"null", `null`},
+	} {
+		c := base.clone()
+		c.Target, c.Mutation = "body", m.name
+		c.raw = []byte(m.raw)
+		out = append(out, c)
+	}
+	return out
+}
+
+// baseline fills every required parameter and the body from examples.
+func baseline(op Operation) Case {
+	c := Case{Op: op, path: map[string]any{}, query: map[string]any{}, header: map[string]any{}}
+	for _, ref := range op.Params {
+		p := ref.Value
+		if p == nil || !p.Required || p.In == openapi3.ParameterInCookie {
+			continue
+		}
+		c.params(p.In)[p.Name] = paramExample(p)
+	}
+	if s := bodySchema(op.Op); s != nil {
+		c.json = true
+		c.body = bodyExample(op.Op)
+	}
+	return c
+}
+
+func (c *Case) params(in string) map[string]any {
+	switch in {
+	case openapi3.ParameterInPath:
+		return c.path
+	case openapi3.ParameterInHeader:
+		return c.header
+	}
+	return c.query
+}
+
+func (c Case) clone() Case {
+	c.path = copyMap(c.path)
+	c.query = copyMap(c.query)
+	c.header = copyMap(c.header)
+	if m, ok := c.body.(map[string]any); ok {
+		c.body = copyMap(m)
+	}
+	return c
+}
+
+type mutation struct {
+	name  string
+	value any
+}
+
+// oversized is long enough to trip naive buffers and column limits.
+var oversized = strings.Repeat("A", 64*1024)
+
+// injections are classic payloads for SQL, NoSQL, template, path,
+// command and log-lookup injection.
+var injections = []mutation{
+	{"inject-sql", "' OR '1'='1' --"},
+	{"inject-nosql", `{"$gt": ""}`},
+	{"inject-template", "{{7*7}}${7*7}"},
+	{"inject-path", "../../../../etc/passwd"},
+	{"inject-cmd", "; cat /etc/passwd"},
+	{"inject-jndi", "${jndi:ldap://restless.invalid/a}"},
+	{"inject-xss", `<script>alert(1)</script>`},
+}
+
+// mutations lists hostile values for a schema. allowEmpty is false for
+// path parameters, where an empty value would change the route.
+func mutations(s *openapi3.Schema, allowEmpty bool) []mutation {
+	var out []mutation
+	t := "string"
+	if s != nil && s.Type != nil && len(s.Type.Slice()) > 0 {
+		t = s.Type.Slice()[0]
+	}
+
+	switch t {
+	case "integer", "number":
+		if s.Min != nil {
+			out = append(out, mutation{"min-1", *s.Min - 1})
+		}
+		if s.Max != nil {
+			out = append(out, mutation{"max+1", *s.Max + 1})
+		}
+		out = append(out,
+			mutation{"zero", 0},
+			mutation{"negative", -1},
+			mutation{"huge", int64(math.MaxInt64)},
+			mutation{"wrong-type", "abc"},
+		)
+		if t == "integer" {
+			out = append(out, mutation{"fraction", 1.5})
+		} else {
+			out = append(out, mutation{"huge-float", math.MaxFloat64})
+		}
+	case "boolean":
+		out = append(out, mutation{"wrong-type", "maybe"})
+	case "array":
+		out = append(out, mutation{"wrong-type", "not-an-array"})
+		if allowEmpty {
+			out = append(out, mutation{"empty", []any{}})
+		}
+	case "object":
+		out = append(out, mutation{"wrong-type", "not-an-object"}, mutation{"empty", map[string]any{}})
+	default:
+		if s != nil && len(s.Enum) > 0 {
+			out = append(out, mutation{"not-in-enum", "restless-not-in-enum"})
+		}
+		if s != nil && s.MinLength > 0 {
+			out = append(out, mutation{"below-min-length", strings.Repeat("a", int(s.MinLength)-1)})
+		}
+		if s != nil && s.MaxLength != nil {
+			out = append(out, mutation{"above-max-length", strings.Repeat("a", int(*s.MaxLength)+1)})
+		}
+		if s != nil && s.Format != "" {
+			out = append(out, mutation{"bad-format", "not-a-" + s.Format})
+		}
+		if allowEmpty {
+			out = append(out, mutation{"empty", ""})
+		}
+		out = append(out,
+			mutation{"oversized", oversized},
+			mutation{"unicode", "ʇsǝʇ 😀 ‮\u0000 𝕿"},
+		)
+		out = append(out, injections...)
+		if allowEmpty {
+			out = append(out, mutation{"wrong-type", 12345})
+		}
+	}
+	return out
+}
+
+func bodySchema(op *openapi3.Operation) *openapi3.Schema {
+	if op.RequestBody == nil || op.RequestBody.Value == nil {
+		return nil
+	}
+	mt := op.RequestBody.Value.Content.Get("application/json")
+	if mt == nil {
+		for ct, m := range op.RequestBody.Value.Content {
+			if strings.Contains(ct, "json") {
+				mt = m
+				break
+			}
+		}
+	}
+	if mt == nil || mt.Schema == nil {
+		return nil
+	}
+	return mt.Schema.Value
+}
+
+func bodyExample(op *openapi3.Operation) any {
+	mt := op.RequestBody.Value.Content.Get("application/json")
+	if mt != nil && mt.Example != nil {
+		return roundTrip(mt.Example)
+	}
+	return roundTrip(mock.Example(bodySchema(op)))
+}
+
+func paramExample(p *openapi3.Parameter) any {
+	if p.Example != nil {
+		return p.Example
+	}
+	if p.Schema != nil && p.Schema.Value != nil {
+		return mock.Example(p.Schema.Value)
+	}
+	return "1"
+}
+
+// roundTrip normalizes an example to plain JSON values so mutations can
+// edit it in place.
+func roundTrip(v any) any {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var out any
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	if dec.Decode(&out) != nil {
+		return v
+	}
+	return out
+}
+
+func format(v any) string {
+	switch x := v.(type) {
+	case string:
+		return x
+	case float64:
+		if x == math.Trunc(x) && math.Abs(x) < 1e15 {
+			return fmt.Sprintf("%d", int64(x))
+		}
+	case map[string]any, []any:
+		b, _ := json.Marshal(x)
+		return string(b)
+	}
+	return fmt.Sprint(v)
+}
+
+func copyMap(m map[string]any) map[string]any {
+	out := make(map[string]any, len(m))
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
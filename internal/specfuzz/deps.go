@@ -0,0 +1,173 @@
+package specfuzz
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// Dependency says that a value Producer returns feeds a path parameter
+// of Consumer, e.g. the id from POST /orders into GET /orders/{orderId}.
+type Dependency struct {
+	Producer string // operation ID
+	Field    string // response field
+	Consumer string // operation ID
+	Param    string // path parameter
+
+	// Convention is set when the link comes from REST naming alone
+	// (POST /orders feeds /orders/{id}) because the producer's response
+	// has no schema to match against.
+	Convention bool `json:",omitempty"`
+}
+
+// Dependencies infers the producer/consumer graph of the spec by matching
+// response field names and types against path parameters.
+func Dependencies(doc *openapi3.T) []Dependency {
+	ops := Operations(doc)
+	var out []Dependency
+	for _, cons := range ops {
+		for _, ref := range cons.Params {
+			p := ref.Value
+			if p == nil || p.In != openapi3.ParameterInPath {
+				continue
+			}
+			for _, prod := range ops {
+				if prod.ID == cons.ID || consumesParam(prod, p.Name) {
+					continue
+				}
+				if d, ok := link(prod, cons, p); ok {
+					out = append(out, d)
+				}
+			}
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Consumer != out[j].Consumer {
+			return out[i].Consumer < out[j].Consumer
+		}
+		return out[i].Param < out[j].Param
+	})
+	return out
+}
+
+func link(prod, cons Operation, p *openapi3.Parameter) (Dependency, bool) {
+	d := Dependency{Producer: prod.ID, Consumer: cons.ID, Param: p.Name}
+	if prod.Method != http.MethodPost && prod.Method != http.MethodPut && prod.Method != http.MethodGet {
+		return d, false
+	}
+	fields := responseFields(prod.Op)
+
+	// The field carries the parameter's own name: {orderId} <- orderId.
+	// A bare {id} says nothing about which resource it is.
+	if normalize(p.Name) != "id" {
+		for _, name := range sortedKeys(fields) {
+			if normalize(name) == normalize(p.Name) && compatible(p, fields[name]) {
+				d.Field = name
+				return d, true
+			}
+		}
+	}
+
+	// Otherwise the producer must be the collection the parameter
+	// indexes, and the link is its id.
+	if prod.Path != collectionOf(cons.Path, p.Name) {
+		return d, false
+	}
+	if s, ok := fields["id"]; ok && compatible(p, s) {
+		d.Field = "id"
+		return d, true
+	}
+	if len(fields) == 0 && prod.Method == http.MethodPost {
+		d.Field, d.Convention = "id", true
+		return d, true
+	}
+	return d, false
+}
+
+// collectionOf is the path before {param}: /orders for /orders/{id}/items.
+func collectionOf(path, param string) string {
+	i := strings.Index(path, "/{"+param+"}")
+	if i < 0 {
+		return ""
+	}
+	return path[:i]
+}
+
+func consumesParam(op Operation, name string) bool {
+	return strings.Contains(op.Path, "{"+name+"}")
+}
+
+// responseFields returns the properties of the success response's
+// objects: the object itself, array items, or the items of a wrapper
+// such as {"data": [...]}.
+func responseFields(op *openapi3.Operation) map[string]*openapi3.Schema {
+	out := map[string]*openapi3.Schema{}
+	if op.Responses == nil {
+		return out
+	}
+	var s *openapi3.Schema
+	for _, code := range []int{200, 201, 202} {
+		rr := op.Responses.Status(code)
+		if rr == nil || rr.Value == nil {
+			continue
+		}
+		for ct, mt := range rr.Value.Content {
+			if strings.Contains(ct, "json") && mt != nil && mt.Schema != nil {
+				s = mt.Schema.Value
+			}
+		}
+		if s != nil {
+			break
+		}
+	}
+	s = objectOf(s)
+	if s == nil {
+		return out
+	}
+	for name, ref := range s.Properties {
+		if ref != nil && ref.Value != nil {
+			out[name] = ref.Value
+		}
+	}
+	return out
+}
+
+func objectOf(s *openapi3.Schema) *openapi3.Schema {
+	if s == nil {
+		return nil
+	}
+	if s.Items != nil && s.Items.Value != nil {
+		return objectOf(s.Items.Value)
+	}
+	// A wrapper whose only array holds the objects.
+	for _, ref := range s.Properties {
+		if ref != nil && ref.Value != nil && ref.Value.Items != nil && len(s.Properties) <= 3 {
+			if _, hasID := s.Properties["id"]; !hasID {
+				return objectOf(ref.Value.Items.Value)
+			}
+		}
+	}
+	if len(s.Properties) > 0 {
+		return s
+	}
+	return nil
+}
+
+// compatible rejects string fields for integer parameters; numbers fit
+// anywhere because ids are often declared as strings.
+func compatible(p *openapi3.Parameter, field *openapi3.Schema) bool {
+	if p.Schema == nil || p.Schema.Value == nil || p.Schema.Value.Type == nil || field.Type == nil {
+		return true
+	}
+	pt := p.Schema.Value.Type
+	if pt.Is("integer") || pt.Is("number") {
+		return field.Type.Is("integer") || field.Type.Is("number")
+	}
+	return true
+}
+
+func normalize(s string) string {
+	return strings.ToLower(strings.NewReplacer("_", "", "-", "").Replace(s))
+}
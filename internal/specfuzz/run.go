@@ -0,0 +1,441 @@
+package specfuzz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+
+	"github.com/bspippi1337/restless/internal/modules/openapi/guard/model"
+	gruntime "github.com/bspippi1337/restless/internal/modules/openapi/guard/runtime"
+	"github.com/bspippi1337/restless/internal/redact"
+)
+
+// Kinds of finding.
+const (
+	Crash   = "crash"   // 5xx, or the server dropped the connection
+	Schema  = "schema"  // response does not match the spec
+	Timeout = "timeout" // no response within Options.Timeout
+)
+
+type Options struct {
+	BaseURL string
+	Client  *http.Client
+
+	// Timeout bounds each request; a slower answer is a finding.
+	Timeout time.Duration
+
+	// Methods limits which operations are fuzzed. Empty means GET and
+	// HEAD only: write methods must be asked for explicitly.
+	Methods []string
+
+	// Include filters operations by path template.
+	Include func(Operation) bool
+
+	// MaxCases caps the cases per operation (0 = all). When cutting, the
+	// baseline is kept and the rest are sampled with Seed.
+	MaxCases int
+	Seed     int64
+
+	// Header is added to every request, e.g. credentials.
+	Header http.Header
+
+	// Stateful runs only: identities to act as, how many sequences to
+	// walk and how long each may get.
+	Identities []Identity
+	Sequences  int
+	MaxSteps   int
+
+	// Progress is called after each case.
+	Progress func(c Case, status int, err error)
+}
+
+type Finding struct {
+	Kind     string
+	Severity model.FindingSeverity
+	OpID     string
+	Method   string
+	Path     string
+	Target   string `json:",omitempty"`
+	Mutation string `json:",omitempty"`
+	Status   int    `json:",omitempty"`
+	Message  string
+	JSONPath string `json:",omitempty"`
+
+	// Request reproduces the finding; after minimization it is the
+	// smallest variant that still fails the same way.
+	Request    Request
+	Reproduced bool
+	Minimized  bool
+
+	// Sequence is every request of a stateful run up to the finding.
+	Sequence []Request `json:",omitempty"`
+}
+
+type Report struct {
+	BaseURL    string
+	StartedAt  time.Time
+	FinishedAt time.Time
+	Operations int
+	Cases      int `json:",omitempty"`
+	Sequences  int `json:",omitempty"`
+	Requests   int `json:",omitempty"`
+	Findings   []Finding
+}
+
+// SafeMethods are fuzzed without asking.
+var SafeMethods = []string{http.MethodGet, http.MethodHead}
+
+// Writes lists the operations that would be fuzzed with a write method.
+func Writes(doc *openapi3.T, include func(Operation) bool) []Operation {
+	var out []Operation
+	for _, op := range Operations(doc) {
+		if !isSafe(op.Method) && (include == nil || include(op)) {
+			out = append(out, op)
+		}
+	}
+	return out
+}
+
+func Run(ctx context.Context, doc *openapi3.T, opt Options) (*Report, error) {
+	if opt.BaseURL == "" {
+		return nil, errors.New("specfuzz: missing base URL")
+	}
+	if opt.Client == nil {
+		opt.Client = &http.Client{}
+	}
+	if opt.Timeout <= 0 {
+		opt.Timeout = 10 * time.Second
+	}
+	methods := opt.Methods
+	if len(methods) == 0 {
+		methods = SafeMethods
+	}
+
+	f := &fuzzer{opt: opt, validator: gruntime.NewValidator(doc)}
+	rep := &Report{BaseURL: opt.BaseURL, StartedAt: time.Now().UTC()}
+	rnd := rand.New(rand.NewSource(opt.Seed))
+	seen := map[string]bool{}
+
+	for _, op := range Operations(doc) {
+		if !hasMethod(methods, op.Method) || (opt.Include != nil && !opt.Include(op)) {
+			continue
+		}
+		rep.Operations++
+
+		cases := Cases(op)
+		if opt.MaxCases > 0 && len(cases) > opt.MaxCases {
+			rest := cases[1:]
+			rnd.Shuffle(len(rest), func(i, j int) { rest[i], rest[j] = rest[j], rest[i] })
+			cases = cases[:opt.MaxCases]
+		}
+
+		for _, c := range cases {
+			if err := ctx.Err(); err != nil {
+				rep.FinishedAt = time.Now().UTC()
+				return rep, err
+			}
+			rep.Cases++
+			out := f.send(ctx, c)
+			if opt.Progress != nil {
+				opt.Progress(c, out.status, out.err)
+			}
+			if out.refused {
+				rep.FinishedAt = time.Now().UTC()
+				return rep, fmt.Errorf("specfuzz: %s is not reachable: %w", opt.BaseURL, out.err)
+			}
+			if out.kind == "" {
+				continue
+			}
+			// One finding per operation, target and failure class.
+			key := strings.Join([]string{op.ID, c.Target, out.kind, fmt.Sprint(out.status / 100)}, "|")
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			rep.Findings = append(rep.Findings, f.finding(ctx, c, out))
+		}
+	}
+
+	rep.FinishedAt = time.Now().UTC()
+	return rep, nil
+}
+
+type fuzzer struct {
+	opt       Options
+	validator *gruntime.Validator
+}
+
+type outcome struct {
+	kind    string
+	status  int
+	message string
+	path    string // JSONPath of a schema violation
+	err     error
+	refused bool
+}
+
+// build renders c with the common headers added.
+func (f *fuzzer) build(c Case) Request {
+	r := c.Build(f.opt.BaseURL)
+	for k, vs := range f.opt.Header {
+		if r.Header.Get(k) == "" {
+			r.Header[k] = vs
+		}
+	}
+	return r
+}
+
+func (f *fuzzer) send(ctx context.Context, c Case) outcome {
+	req, err := f.build(c).HTTP()
+	if err != nil {
+		return outcome{err: err}
+	}
+	ctx, cancel := context.WithTimeout(ctx, f.opt.Timeout)
+	defer cancel()
+
+	resp, err := f.opt.Client.Do(req.WithContext(ctx))
+	if err != nil {
+		var ne net.Error
+		switch {
+		case errors.Is(err, syscall.ECONNREFUSED):
+			return outcome{err: err, refused: true}
+		case errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &ne) && ne.Timeout()):
+			return outcome{kind: Timeout, err: err, message: fmt.Sprintf("no response within %s", f.opt.Timeout)}
+		case ctx.Err() != nil:
+			return outcome{err: err}
+		}
+		return outcome{kind: Crash, err: err, message: "connection dropped: " + err.Error()}
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+	resp.Body.Close()
+
+	out := outcome{status: resp.StatusCode}
+	if resp.StatusCode >= 500 {
+		out.kind = Crash
+		out.message = fmt.Sprintf("%d %s", resp.StatusCode, http.StatusText(resp.StatusCode))
+		return out
+	}
+	if path, msg := f.violation(c.Op, resp, body); msg != "" {
+		out.kind = Schema
+		out.path, out.message = path, msg
+	}
+	return out
+}
+
+// violation checks a response body against the spec. Undeclared 4xx
+// answers are the API rejecting bad input, not a contract break, and a
+// response declared without a schema has nothing to check.
+func (f *fuzzer) violation(op Operation, resp *http.Response, body []byte) (string, string) {
+	if len(body) == 0 || !strings.Contains(resp.Header.Get("Content-Type"), "json") {
+		return "", ""
+	}
+	rr := declared(op.Op, resp.StatusCode)
+	if rr == nil && resp.StatusCode >= 400 {
+		return "", ""
+	}
+	if rr != nil && !hasSchema(rr) {
+		return "", ""
+	}
+	fs, err := f.validator.ValidateResponse(context.Background(), op.Method, op.Path, resp.StatusCode, resp.Header.Get("Content-Type"), body)
+	if err != nil || len(fs) == 0 {
+		return "", ""
+	}
+	// The first error is usually the root "doesn't validate"; report the
+	// one that names a field.
+	first := fs[0]
+	for _, x := range fs {
+		if x.JSONPath != "" && x.JSONPath != "$" {
+			first = x
+			break
+		}
+	}
+	msg := first.Message
+	if len(fs) > 1 {
+		msg += fmt.Sprintf(" (+%d more)", len(fs)-1)
+	}
+	return first.JSONPath, msg
+}
+
+func (f *fuzzer) finding(ctx context.Context, c Case, out outcome) Finding {
+	fd := Finding{
+		Kind:     out.kind,
+		Severity: severity(out.kind),
+		OpID:     c.Op.ID,
+		Method:   c.Op.Method,
+		Path:     c.Op.Path,
+		Target:   c.Target,
+		Mutation: c.Mutation,
+		Status:   out.status,
+		Message:  out.message,
+		JSONPath: out.path,
+	}
+
+	// A second identical request tells flakes from real failures.
+	fails := func(c Case) bool {
+		o := f.send(ctx, c)
+		return o.kind == out.kind && (out.kind != Crash || o.status/100 == out.status/100)
+	}
+	fd.Reproduced = fails(c)
+	if fd.Reproduced && out.kind != Timeout {
+		c, fd.Minimized = minimize(c, fails)
+	}
+	fd.Request = f.build(c)
+	fd.Request.Header = redact.Headers(fd.Request.Header)
+	return fd
+}
+
+// minimizeBudget bounds the extra requests spent shrinking one finding.
+const minimizeBudget = 64
+
+// minimize drops everything the failure does not depend on: optional
+// parameters, unrelated body fields, and trailing characters of the
+// mutated string.
+func minimize(c Case, fails func(Case) bool) (Case, bool) {
+	budget := minimizeBudget
+	try := func(next Case) bool {
+		if budget <= 0 {
+			return false
+		}
+		budget--
+		return fails(next)
+	}
+	changed := false
+
+	for _, m := range []map[string]any{c.query, c.header} {
+		for _, k := range sortedKeys(m) {
+			if c.targets(k) {
+				continue
+			}
+			next := c.clone()
+			delete(next.query, k)
+			delete(next.header, k)
+			if try(next) {
+				c, changed = next, true
+			}
+		}
+	}
+	if obj, ok := c.body.(map[string]any); ok && c.raw == nil {
+		for _, k := range sortedKeys(obj) {
+			if c.Target == "body."+k {
+				continue
+			}
+			next := c.clone()
+			delete(next.body.(map[string]any), k)
+			if try(next) {
+				c, changed = next, true
+			}
+		}
+	}
+
+	// Binary search for the shortest prefix of the mutated string that
+	// still fails.
+	if s, ok := c.target(); ok && len(s) > 1 {
+		lo, hi := 0, len(s)
+		for lo+1 < hi && budget > 0 {
+			mid := (lo + hi) / 2
+			next := c.clone()
+			next.setTarget(s[:mid])
+			if try(next) {
+				hi = mid
+			} else {
+				lo = mid
+			}
+		}
+		if hi < len(s) {
+			c.setTarget(s[:hi])
+			changed = true
+		}
+	}
+	return c, changed
+}
+
+func (c Case) targets(name string) bool {
+	_, after, _ := strings.Cut(c.Target, ".")
+	return after == name && !strings.HasPrefix(c.Target, "body")
+}
+
+func (c Case) target() (string, bool) {
+	in, name, ok := strings.Cut(c.Target, ".")
+	if !ok {
+		return "", false
+	}
+	var v any
+	if in == "body" {
+		obj, _ := c.body.(map[string]any)
+		v = obj[name]
+	} else {
+		v = c.params(in)[name]
+	}
+	s, ok := v.(string)
+	return s, ok
+}
+
+func (c *Case) setTarget(s string) {
+	in, name, _ := strings.Cut(c.Target, ".")
+	if in == "body" {
+		c.body.(map[string]any)[name] = s
+		return
+	}
+	c.params(in)[name] = s
+}
+
+// declared is the response the spec gives for status, default included.
+func declared(op *openapi3.Operation, status int) *openapi3.ResponseRef {
+	if op.Responses == nil {
+		return nil
+	}
+	if rr := op.Responses.Status(status); rr != nil {
+		return rr
+	}
+	if rr := op.Responses.Map()[fmt.Sprintf("%dXX", status/100)]; rr != nil {
+		return rr
+	}
+	if status < 400 {
+		return op.Responses.Default()
+	}
+	return nil
+}
+
+func hasSchema(rr *openapi3.ResponseRef) bool {
+	if rr.Value == nil {
+		return false
+	}
+	for ct, mt := range rr.Value.Content {
+		if strings.Contains(ct, "json") && mt != nil && mt.Schema != nil {
+			return true
+		}
+	}
+	return false
+}
+
+func severity(kind string) model.FindingSeverity {
+	switch kind {
+	case Crash:
+		return model.SevHigh
+	case Timeout:
+		return model.SevMedium
+	}
+	return model.SevLow
+}
+
+func isSafe(method string) bool {
+	return hasMethod(SafeMethods, method)
+}
+
+func hasMethod(ms []string, m string) bool {
+	for _, x := range ms {
+		if strings.EqualFold(x, m) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,196 @@
+package specfuzz
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+const spec = `
+openapi: 3.0.3
+info: {title: t, version: "1"}
+paths:
+  /search:
+    get:
+      parameters:
+        - {name: q, in: query, required: true, schema: {type: string}}
+        - {name: limit, in: query, schema: {type: integer, minimum: 1, maximum: 50}}
+      responses:
+        "200":
+          description: ok
+          content:
+            application/json:
+              schema:
+                type: object
+                required: [total]
+                properties:
+                  total: {type: integer}
+  /users:
+    post:
+      requestBody:
+        content:
+          application/json:
+            schema:
+              type: object
+              properties:
+                name: {type: string}
+      responses:
+        "201": {description: created}
+`
+
+func TestRunFindsAndMinimizes(t *testing.T) {
+	posts := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			posts++
+		}
+		q := r.URL.Query().Get("q")
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case len(q) > 1000:
+			w.WriteHeader(500)
+		case r.URL.Query().Get("limit") == "0":
+			io.WriteString(w, `{"total":"zero"}`)
+		default:
+			io.WriteString(w, `{"total":1}`)
+		}
+	}))
+	defer srv.Close()
+
+	doc, err := openapi3.NewLoader().LoadFromData([]byte(spec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rep, err := Run(context.Background(), doc, Options{BaseURL: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if posts != 0 || rep.Operations != 1 {
+		t.Fatalf("write method fuzzed without being asked: posts=%d ops=%d", posts, rep.Operations)
+	}
+
+	var crash, schema *Finding
+	for i, f := range rep.Findings {
+		switch f.Kind {
+		case Crash:
+			crash = &rep.Findings[i]
+		case Schema:
+			schema = &rep.Findings[i]
+		}
+	}
+	if crash == nil || crash.Target != "query.q" || !crash.Reproduced || !crash.Minimized {
+		t.Fatalf("crash: %+v", crash)
+	}
+	// 1001 characters is the smallest failing input.
+	if n := len(crash.Request.URL) - len(srv.URL+"/search?q="); n != 1001 {
+		t.Fatalf("minimized to %d chars: %s", n, crash.Request.URL[:80])
+	}
+	if schema == nil || schema.Target != "query.limit" || schema.JSONPath != "$.total" {
+		t.Fatalf("schema: %+v", schema)
+	}
+}
+
+const orders = `
+openapi: 3.0.3
+info: {title: t, version: "1"}
+paths:
+  /orders:
+    post:
+      requestBody:
+        content:
+          application/json:
+            schema:
+              type: object
+              properties:
+                code: {type: string}
+      responses:
+        "201":
+          description: created
+          content:
+            application/json:
+              schema:
+                type: object
+                properties:
+                  id: {type: integer}
+                  code: {type: string}
+  /orders/{orderId}:
+    get:
+      parameters:
+        - {name: orderId, in: path, required: true, schema: {type: integer}}
+      responses:
+        "200": {description: ok}
+    delete:
+      parameters:
+        - {name: orderId, in: path, required: true, schema: {type: integer}}
+      responses:
+        "204": {description: gone}
+`
+
+// ordersAPI never really deletes and never checks who is asking.
+func ordersAPI() http.Handler {
+	next := 0
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost:
+			next++
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(201)
+			io.WriteString(w, `{"id":`+strings.Repeat("1", next)+`,"code":"c"}`)
+		case r.Method == http.MethodDelete:
+			w.WriteHeader(204)
+		default:
+			io.WriteString(w, `{}`)
+		}
+	})
+}
+
+func TestDependencies(t *testing.T) {
+	doc, err := openapi3.NewLoader().LoadFromData([]byte(orders))
+	if err != nil {
+		t.Fatal(err)
+	}
+	deps := Dependencies(doc)
+	if len(deps) != 2 || deps[0].Producer != "post /orders" || deps[0].Field != "id" || deps[0].Param != "orderId" {
+		t.Fatalf("deps: %+v", deps)
+	}
+}
+
+func TestRunStateful(t *testing.T) {
+	srv := httptest.NewServer(ordersAPI())
+	defer srv.Close()
+	doc, _ := openapi3.NewLoader().LoadFromData([]byte(orders))
+
+	run := func() *Report {
+		rep, err := RunStateful(context.Background(), doc, Options{
+			BaseURL:    srv.URL,
+			Seed:       7,
+			Sequences:  5,
+			Identities: []Identity{{Name: "alice"}, {Name: "bob", Header: http.Header{"Authorization": {"Bearer b"}}}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return rep
+	}
+	rep := run()
+	kinds := map[string]Finding{}
+	for _, f := range rep.Findings {
+		kinds[f.Kind] = f
+	}
+	for _, k := range []string{UseAfterDelete, DoubleCreate, AuthzLeak} {
+		if _, ok := kinds[k]; !ok {
+			t.Fatalf("missing %s in %+v", k, rep.Findings)
+		}
+	}
+	if leak := kinds[AuthzLeak]; leak.Request.As != "bob" || leak.Sequence[0].Method != "POST" || leak.Sequence[0].Returned["id"] == "" {
+		t.Fatalf("leak: %+v", leak)
+	}
+	if again := run(); again.Requests != rep.Requests {
+		t.Fatalf("same seed, different run: %d vs %d requests", again.Requests, rep.Requests)
+	}
+}
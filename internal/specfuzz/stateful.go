@@ -0,0 +1,563 @@
+package specfuzz
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+
+	"github.com/bspippi1337/restless/internal/modules/openapi/guard/model"
+	"github.com/bspippi1337/restless/internal/redact"
+)
+
+// Kinds found by stateful runs.
+const (
+	UseAfterDelete = "use-after-delete" // a deleted resource is still served
+	DoubleCreate   = "double-create"    // replaying a create is accepted
+	AuthzLeak      = "authz-leak"       // another identity reaches the resource
+)
+
+// Identity is a set of credentials sequences run as. The first identity
+// creates resources; the second tries to reach them.
+type Identity struct {
+	Name   string
+	Header http.Header
+}
+
+// uniqueFields are names that usually carry a uniqueness constraint.
+var uniqueFields = []string{"email", "username", "login", "slug", "handle", "sku", "code", "key"}
+
+// RunStateful chains operations along the dependency graph: resources
+// are created, then read, changed and deleted through the operations
+// that consume their ids, in random order drawn from Options.Seed. Each
+// new resource is checked for double creation and for access by the
+// second identity; each delete is followed by reads of the deleted id.
+// It sends write requests; callers must gate it.
+func RunStateful(ctx context.Context, doc *openapi3.T, opt Options) (*Report, error) {
+	if opt.BaseURL == "" {
+		return nil, errors.New("specfuzz: missing base URL")
+	}
+	if opt.Client == nil {
+		opt.Client = &http.Client{}
+	}
+	if opt.Timeout <= 0 {
+		opt.Timeout = 10 * time.Second
+	}
+	if opt.Sequences <= 0 {
+		opt.Sequences = 20
+	}
+	if opt.MaxSteps <= 0 {
+		opt.MaxSteps = 8
+	}
+	ids := append([]Identity(nil), opt.Identities...)
+	if len(ids) == 0 {
+		ids = []Identity{{Name: "default"}}
+	}
+	for i := range ids {
+		h := opt.Header.Clone()
+		if h == nil {
+			h = http.Header{}
+		}
+		for k, vs := range ids[i].Header {
+			h[k] = vs
+		}
+		ids[i].Header = h
+	}
+
+	w := &walker{
+		opt:  opt,
+		ids:  ids,
+		rnd:  rand.New(rand.NewSource(opt.Seed)),
+		deps: map[string][]Dependency{},
+		seen: map[string]bool{},
+		rep:  &Report{BaseURL: opt.BaseURL, StartedAt: time.Now().UTC()},
+	}
+	involved := map[string]bool{}
+	for _, d := range Dependencies(doc) {
+		w.deps[d.Consumer+"|"+d.Param] = append(w.deps[d.Consumer+"|"+d.Param], d)
+		w.produces = append(w.produces, d)
+		involved[d.Producer], involved[d.Consumer] = true, true
+	}
+	for _, op := range Operations(doc) {
+		if involved[op.ID] && (opt.Include == nil || opt.Include(op)) {
+			w.ops = append(w.ops, op)
+		}
+	}
+	w.rep.Operations = len(w.ops)
+
+	var err error
+	for i := 0; i < opt.Sequences && err == nil; i++ {
+		w.objs, w.trail = nil, nil
+		w.rep.Sequences++
+		for step := 0; step < opt.MaxSteps && err == nil; step++ {
+			acts := w.enabled()
+			if len(acts) == 0 {
+				break
+			}
+			err = w.act(ctx, acts[w.rnd.Intn(len(acts))])
+		}
+	}
+	w.rep.FinishedAt = time.Now().UTC()
+	if errors.Is(err, errStop) {
+		err = ctx.Err()
+	}
+	return w.rep, err
+}
+
+// object is a resource created (or listed) during a sequence.
+type object struct {
+	op      Operation
+	fields  map[string]any
+	path    map[string]any // parameters of the path it was created under
+	deleted bool
+
+	// at is the trail index of the response the fields were read from
+	// (-1 if none) and prefix their dot path in its body.
+	at     int
+	prefix string
+}
+
+type action struct {
+	op   Operation
+	path map[string]any
+	obj  *object // resource the last path parameter points at
+}
+
+type walker struct {
+	opt      Options
+	ids      []Identity
+	rnd      *rand.Rand
+	ops      []Operation
+	deps     map[string][]Dependency // consumer|param -> producers
+	produces []Dependency
+	seen     map[string]bool
+	rep      *Report
+
+	// Per sequence.
+	objs  []*object
+	trail []Request
+}
+
+var errStop = errors.New("specfuzz: stopped")
+
+// enabled lists the operations whose path parameters can be bound to
+// live resources, plus the producers that need none.
+func (w *walker) enabled() []action {
+	var out []action
+	for _, op := range w.ops {
+		params := pathParams(op.Path)
+		if len(params) == 0 {
+			if w.isProducer(op) {
+				out = append(out, action{op: op, path: map[string]any{}})
+			}
+			continue
+		}
+		if a, ok := w.bind(op, params); ok {
+			out = append(out, a)
+		}
+	}
+	return out
+}
+
+// bind picks a live resource for the last path parameter and fills the
+// rest from the path that resource was created under, so nested ids
+// stay consistent.
+func (w *walker) bind(op Operation, params []string) (action, bool) {
+	a := action{op: op, path: map[string]any{}}
+	for i := len(params) - 1; i >= 0; i-- {
+		name := params[i]
+		if _, ok := a.path[name]; ok {
+			continue
+		}
+		var cands []*object
+		var fields []string
+		for _, d := range w.deps[op.ID+"|"+name] {
+			for _, o := range w.objs {
+				if !o.deleted && o.op.ID == d.Producer && o.fields[d.Field] != nil {
+					cands = append(cands, o)
+					fields = append(fields, d.Field)
+				}
+			}
+		}
+		if len(cands) == 0 {
+			return a, false
+		}
+		k := w.rnd.Intn(len(cands))
+		o := cands[k]
+		a.path[name] = w.use(o, fields[k])
+		if a.obj == nil {
+			a.obj = o
+		}
+		for pk, pv := range o.path {
+			if _, ok := a.path[pk]; !ok {
+				a.path[pk] = pv
+			}
+		}
+	}
+	return a, true
+}
+
+func (w *walker) act(ctx context.Context, a action) error {
+	c := baseline(a.op)
+	for k, v := range a.path {
+		c.path[k] = v
+	}
+	res, err := w.send(ctx, c, 0)
+	if err != nil {
+		return err
+	}
+	if res.status/100 != 2 {
+		return nil
+	}
+
+	switch {
+	case a.op.Method == http.MethodDelete && a.obj != nil:
+		a.obj.deleted = true
+		return w.useAfterDelete(ctx, a.obj)
+	case w.isProducer(a.op) && a.op.Method != http.MethodGet:
+		o := w.object(a.op, a.path, res)
+		if o == nil {
+			return nil
+		}
+		w.objs = append(w.objs, o)
+		if err := w.doubleCreate(ctx, c, o); err != nil {
+			return err
+		}
+		return w.authz(ctx, o)
+	case w.isProducer(a.op):
+		objs := w.listed(a.op, a.path, res)
+		if o := w.object(a.op, a.path, res); len(objs) == 0 && o != nil {
+			objs = append(objs, o)
+		}
+		w.objs = append(w.objs, objs...)
+	}
+	return nil
+}
+
+func (w *walker) useAfterDelete(ctx context.Context, o *object) error {
+	for _, u := range w.consumers(o) {
+		if u.op.Method != http.MethodGet {
+			continue
+		}
+		res, err := w.send(ctx, u.build(), 0)
+		if err != nil {
+			return err
+		}
+		if res.status/100 == 2 {
+			w.report(UseAfterDelete, model.SevHigh, u.op, res.status,
+				fmt.Sprintf("%s %s still answers %d after the resource was deleted", u.op.Method, u.op.Path, res.status))
+		}
+	}
+	return nil
+}
+
+func (w *walker) doubleCreate(ctx context.Context, c Case, first *object) error {
+	key := DoubleCreate + "|" + c.Op.ID
+	if w.seen[key] {
+		return nil
+	}
+	w.seen[key] = true
+
+	res, err := w.send(ctx, c, 0)
+	if err != nil || res.status/100 != 2 {
+		return err
+	}
+	second := w.object(c.Op, first.path, res)
+	if second != nil {
+		w.objs = append(w.objs, second)
+	}
+	firstID, secondID := first.fields["id"], any(nil)
+	if second != nil {
+		secondID = second.fields["id"]
+	}
+	switch {
+	case firstID != nil && fmt.Sprint(firstID) == fmt.Sprint(secondID):
+		w.report(DoubleCreate, model.SevHigh, c.Op, res.status,
+			fmt.Sprintf("replaying the create returned the same id %v; the first resource may have been overwritten", firstID))
+	default:
+		if name, v := uniqueField(c.body); name != "" {
+			w.report(DoubleCreate, model.SevLow, c.Op, res.status,
+				fmt.Sprintf("the same create was accepted twice; %s=%v now names two resources", name, v))
+		}
+	}
+	return nil
+}
+
+func (w *walker) authz(ctx context.Context, o *object) error {
+	if len(w.ids) < 2 {
+		return nil
+	}
+	for _, u := range w.consumers(o) {
+		key := AuthzLeak + "|" + u.op.ID
+		if w.seen[key] {
+			continue
+		}
+		w.seen[key] = true
+
+		res, err := w.send(ctx, u.build(), 1)
+		if err != nil {
+			return err
+		}
+		if res.status/100 != 2 {
+			continue
+		}
+		sev := model.SevHigh
+		if u.op.Method == http.MethodGet {
+			sev = model.SevMedium
+		}
+		w.report(AuthzLeak, sev, u.op, res.status,
+			fmt.Sprintf("%s can %s a resource %s created (%d)", w.ids[1].Name, u.op.Method, w.ids[0].Name, res.status))
+		if u.op.Method == http.MethodDelete {
+			o.deleted = true
+			break
+		}
+	}
+	return nil
+}
+
+type use struct {
+	op   Operation
+	path map[string]any
+}
+
+func (u use) build() Case {
+	c := baseline(u.op)
+	for k, v := range u.path {
+		c.path[k] = v
+	}
+	return c
+}
+
+// consumers lists the operations that take o's id, reads first and
+// deletes last.
+func (w *walker) consumers(o *object) []use {
+	var out []use
+	for _, d := range w.produces {
+		if d.Producer != o.op.ID || o.fields[d.Field] == nil {
+			continue
+		}
+		for _, op := range w.ops {
+			if op.ID != d.Consumer {
+				continue
+			}
+			u := use{op: op, path: map[string]any{d.Param: w.use(o, d.Field)}}
+			complete := true
+			for _, name := range pathParams(op.Path) {
+				if _, ok := u.path[name]; ok {
+					continue
+				}
+				if v, ok := o.path[name]; ok {
+					u.path[name] = v
+					continue
+				}
+				complete = false
+			}
+			if complete {
+				out = append(out, u)
+			}
+		}
+	}
+	rank := map[string]int{http.MethodGet: 0, http.MethodHead: 0, http.MethodPatch: 1, http.MethodPut: 1, http.MethodPost: 2, http.MethodDelete: 3}
+	for i := 1; i < len(out); i++ {
+		for j := i; j > 0 && rank[out[j].op.Method] < rank[out[j-1].op.Method]; j-- {
+			out[j], out[j-1] = out[j-1], out[j]
+		}
+	}
+	return out
+}
+
+type result struct {
+	status int
+	header http.Header
+	body   []byte
+}
+
+// send runs c as identity i and appends it to the sequence trail.
+func (w *walker) send(ctx context.Context, c Case, i int) (result, error) {
+	if ctx.Err() != nil {
+		return result{}, errStop
+	}
+	r := c.Build(w.opt.BaseURL)
+	for k, vs := range w.ids[i].Header {
+		r.Header[k] = vs
+	}
+	req, err := r.HTTP()
+	if err != nil {
+		return result{}, nil
+	}
+	r.Header = redact.Headers(r.Header)
+	if len(w.ids) > 1 {
+		r.As = w.ids[i].Name
+	}
+	w.trail = append(w.trail, r)
+	w.rep.Requests++
+
+	rctx, cancel := context.WithTimeout(ctx, w.opt.Timeout)
+	defer cancel()
+	resp, err := w.opt.Client.Do(req.WithContext(rctx))
+	if w.opt.Progress != nil {
+		status := 0
+		if resp != nil {
+			status = resp.StatusCode
+		}
+		w.opt.Progress(c, status, err)
+	}
+	if err != nil {
+		if errors.Is(err, syscall.ECONNREFUSED) {
+			return result{}, fmt.Errorf("specfuzz: %s is not reachable: %w", w.opt.BaseURL, err)
+		}
+		if ctx.Err() != nil {
+			return result{}, errStop
+		}
+		return result{}, nil
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+	resp.Body.Close()
+
+	if resp.StatusCode >= 500 {
+		w.report(Crash, model.SevHigh, c.Op, resp.StatusCode,
+			fmt.Sprintf("%d %s after %d requests in the sequence", resp.StatusCode, http.StatusText(resp.StatusCode), len(w.trail)))
+	}
+	return result{status: resp.StatusCode, header: resp.Header, body: body}, nil
+}
+
+// report records a finding once per kind and operation, with the trail
+// that led to it.
+func (w *walker) report(kind string, sev model.FindingSeverity, op Operation, status int, msg string) {
+	key := "report|" + kind + "|" + op.ID
+	if w.seen[key] {
+		return
+	}
+	w.seen[key] = true
+	seq := append([]Request(nil), w.trail...)
+	w.rep.Findings = append(w.rep.Findings, Finding{
+		Kind:       kind,
+		Severity:   sev,
+		OpID:       op.ID,
+		Method:     op.Method,
+		Path:       op.Path,
+		Status:     status,
+		Message:    msg,
+		Request:    seq[len(seq)-1],
+		Sequence:   seq,
+		Reproduced: true,
+	})
+}
+
+func (w *walker) isProducer(op Operation) bool {
+	for _, d := range w.produces {
+		if d.Producer == op.ID {
+			return true
+		}
+	}
+	return false
+}
+
+// object reads the created resource from the response body, or its id
+// from Location when the body is empty.
+func (w *walker) object(op Operation, path map[string]any, res result) *object {
+	fields := map[string]any{}
+	prefix := ""
+	var v any
+	if json.Unmarshal(res.body, &v) == nil {
+		m, _ := v.(map[string]any)
+		if inner, ok := m["data"].(map[string]any); ok {
+			m, prefix = inner, "data."
+		}
+		for k, x := range m {
+			switch x.(type) {
+			case map[string]any, []any:
+			default:
+				fields[k] = x
+			}
+		}
+	}
+	at := len(w.trail) - 1
+	if fields["id"] == nil {
+		if loc := res.header.Get("Location"); loc != "" {
+			fields["id"] = loc[strings.LastIndex(loc, "/")+1:]
+			at = -1
+		}
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	return &object{op: op, fields: fields, path: copyMap(path), at: at, prefix: prefix}
+}
+
+// use returns o's field and notes on the request that produced it that
+// later requests depend on the value, so exports can chain them.
+func (w *walker) use(o *object, field string) any {
+	v := o.fields[field]
+	if o.at >= 0 && o.at < len(w.trail) {
+		r := &w.trail[o.at]
+		if r.Returned == nil {
+			r.Returned = map[string]string{}
+		}
+		r.Returned[o.prefix+field] = format(v)
+	}
+	return v
+}
+
+// listed turns a few items of a list response into resources.
+func (w *walker) listed(op Operation, path map[string]any, res result) []*object {
+	var v any
+	if json.Unmarshal(res.body, &v) != nil {
+		return nil
+	}
+	arr, _ := v.([]any)
+	if m, ok := v.(map[string]any); ok && m["id"] == nil {
+		for _, k := range sortedKeys(m) {
+			if a, ok := m[k].([]any); ok {
+				arr = a
+				break
+			}
+		}
+	}
+	var out []*object
+	for i, it := range arr {
+		if i == 3 {
+			break
+		}
+		m, ok := it.(map[string]any)
+		if !ok {
+			continue
+		}
+		b, _ := json.Marshal(m)
+		if o := w.object(op, path, result{body: b, header: http.Header{}}); o != nil {
+			o.at = -1
+			out = append(out, o)
+		}
+	}
+	return out
+}
+
+func uniqueField(body any) (string, any) {
+	m, _ := body.(map[string]any)
+	for _, name := range uniqueFields {
+		for k, v := range m {
+			if strings.EqualFold(k, name) && v != nil {
+				return k, v
+			}
+		}
+	}
+	return "", nil
+}
+
+func pathParams(tpl string) []string {
+	var out []string
+	for _, seg := range strings.Split(tpl, "/") {
+		if strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}") {
+			out = append(out, strings.Trim(seg, "{}"))
+		}
+	}
+	return out
+}
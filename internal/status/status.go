@@ -46,6 +46,28 @@ func IncError() {
 	mu.Unlock()
 }
 
+// Counts is a copy of the counters at one moment.
+type Counts struct {
+	Requests  int
+	Endpoints int
+	Probes    int
+	Consensus int
+	Errors    int
+}
+
+func Snapshot() Counts {
+	mu.Lock()
+	defer mu.Unlock()
+
+	return Counts{
+		Requests:  reqCount,
+		Endpoints: epCount,
+		Probes:    probeCount,
+		Consensus: consCount,
+		Errors:    errCount,
+	}
+}
+
 func Print() {
 
 	mu.Lock()
@@ -4,16 +4,288 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bspippi1337/restless/internal/pathtmpl"
+	"github.com/bspippi1337/restless/internal/schema"
 )
 
 type Endpoint struct {
 	Path    string
 	Methods []string
+	Schema  *schema.Schema `json:",omitempty"`
+
+	// Request shape observed in traffic (query names, header names, JSON body).
+	Query         []string       `json:",omitempty"`
+	Headers       []string       `json:",omitempty"`
+	RequestSchema *schema.Schema `json:",omitempty"`
+
+	// The same shape per method, where traffic told methods apart; the
+	// fields above stay the view across all methods.
+	Responses map[string]*schema.Schema `json:",omitempty"` // method -> success response schema
+	Requests  map[string]*schema.Schema `json:",omitempty"` // method -> request body schema
+	Params    map[string][]string       `json:",omitempty"` // method -> query names
+
+	// Evidence gathered while learning the endpoint.
+	Statuses   map[string][]int `json:",omitempty"` // method -> status codes
+	Allow      []string         `json:",omitempty"`
+	Source     string           `json:",omitempty"`
+	Confidence string           `json:",omitempty"`
+	Score      float64          `json:",omitempty"` // council consensus, 0..1
+	FirstSeen  time.Time        `json:",omitzero"`
+
+	// Access is public, protected or mixed (differs by method); Auth
+	// lists the IDs of the API.Auth schemes the endpoint accepts.
+	Access string   `json:",omitempty"`
+	Auth   []string `json:",omitempty"`
+
+	// Pagination is how the endpoint pages its collection, if it does.
+	Pagination *Pagination `json:",omitempty"`
+}
+
+// Pagination describes how a collection endpoint hands out the next page.
+type Pagination struct {
+	Style     string // link, next-url, cursor, page or offset
+	Param     string `json:",omitempty"` // query parameter that moves: cursor, page, offset
+	SizeParam string `json:",omitempty"` // query parameter for the page size: per_page, limit
+	Items     string `json:",omitempty"` // field holding the items; "" when the body is the list
+	Next      string `json:",omitempty"` // field (dotted) holding the next URL or cursor
+	Source    string `json:",omitempty"` // response or openapi
 }
 
 type API struct {
 	BaseURL   string
 	Endpoints []Endpoint
+	GraphQL   *GraphQL `json:",omitempty"`
+	Auth      *Auth    `json:",omitempty"`
+}
+
+// Auth is what discovery learned about how the API authenticates.
+type Auth struct {
+	Schemes        []AuthScheme
+	Issuer         string   `json:",omitempty"` // OIDC issuer
+	TokenEndpoints []string `json:",omitempty"`
+}
+
+// AuthScheme follows the OpenAPI securityScheme vocabulary so specs and
+// observed challenges describe credentials the same way.
+type AuthScheme struct {
+	ID     string // referenced from Endpoint.Auth
+	Type   string // http, apiKey, oauth2, openIdConnect, mutualTLS
+	Scheme string `json:",omitempty"` // http: basic, bearer, digest, ...
+
+	BearerFormat string `json:",omitempty"`
+	In           string `json:",omitempty"` // apiKey: header, query, cookie
+	Name         string `json:",omitempty"` // apiKey: parameter name
+	Realm        string `json:",omitempty"`
+
+	AuthorizationURL       string   `json:",omitempty"`
+	TokenURL               string   `json:",omitempty"`
+	DeviceAuthorizationURL string   `json:",omitempty"`
+	OpenIDConnectURL       string   `json:",omitempty"`
+	Scopes                 []string `json:",omitempty"`
+
+	Source string // www-authenticate, request-header, openapi, oidc, token-endpoint
+}
+
+// Scheme returns the scheme with the given ID.
+func (a *Auth) Scheme(id string) (AuthScheme, bool) {
+	if a == nil {
+		return AuthScheme{}, false
+	}
+	for _, s := range a.Schemes {
+		if s.ID == id {
+			return s, true
+		}
+	}
+	return AuthScheme{}, false
+}
+
+// Lookup finds the endpoint whose (possibly templated) path matches.
+func (a *API) Lookup(path string) (Endpoint, bool) {
+	if i := strings.IndexAny(path, "?#"); i >= 0 {
+		path = path[:i]
+	}
+	if path == "" {
+		path = "/"
+	}
+	for _, e := range a.Endpoints {
+		if e.Path == path {
+			return e, true
+		}
+	}
+	for _, e := range a.Endpoints {
+		if _, ok := pathtmpl.Match(e.Path, path); ok {
+			return e, true
+		}
+	}
+	return Endpoint{}, false
+}
+
+// GraphQL records an introspected GraphQL endpoint. The full schema lives
+// in the Snapshot file; Operations is the flattened root-field list.
+type GraphQL struct {
+	Endpoint     string
+	Snapshot     string `json:",omitempty"`
+	Introspected time.Time
+	Operations   []Operation
+}
+
+type Operation struct {
+	Kind       string // query, mutation, subscription
+	Name       string
+	Args       []string `json:",omitempty"` // name: Type
+	Returns    string
+	Deprecated bool `json:",omitempty"`
+}
+
+// CarryFirstSeen copies first-seen timestamps from a previous model of the
+// same API, so re-learning does not reset endpoint age.
+func CarryFirstSeen(prev, next *API) {
+	if prev == nil || next == nil || prev.BaseURL != next.BaseURL {
+		return
+	}
+	seen := map[string]time.Time{}
+	for _, e := range prev.Endpoints {
+		seen[e.Path] = e.FirstSeen
+	}
+	for i := range next.Endpoints {
+		t, ok := seen[next.Endpoints[i].Path]
+		if ok && !t.IsZero() && (next.Endpoints[i].FirstSeen.IsZero() || t.Before(next.Endpoints[i].FirstSeen)) {
+			next.Endpoints[i].FirstSeen = t
+		}
+	}
+}
+
+// CarrySchemas merges the response schema inferred for each endpoint into
+// the one a previous model of the same API held, so re-learning refines
+// what was seen before instead of replacing it with the latest samples.
+func CarrySchemas(prev, next *API) {
+	if prev == nil || next == nil || prev.BaseURL != next.BaseURL {
+		return
+	}
+	old := map[string]*schema.Schema{}
+	for _, e := range prev.Endpoints {
+		old[e.Path] = e.Schema
+	}
+	for i := range next.Endpoints {
+		if s, ok := old[next.Endpoints[i].Path]; ok {
+			next.Endpoints[i].Schema = schema.Merge(s, next.Endpoints[i].Schema)
+		}
+	}
+}
+
+// Merge folds endpoints learned from traffic into a previous model of the
+// same API. Unlike re-learning, nothing already known is dropped: methods,
+// statuses and request shape are unioned and fresh schemas win.
+func Merge(prev, next *API) *API {
+	if prev == nil || prev.BaseURL != next.BaseURL {
+		return next
+	}
+
+	out := &API{BaseURL: prev.BaseURL, GraphQL: prev.GraphQL, Auth: prev.Auth}
+	if next.GraphQL != nil {
+		out.GraphQL = next.GraphQL
+	}
+	if next.Auth != nil {
+		out.Auth = next.Auth
+	}
+	idx := map[string]int{}
+	for _, e := range prev.Endpoints {
+		idx[e.Path] = len(out.Endpoints)
+		out.Endpoints = append(out.Endpoints, e)
+	}
+
+	for _, e := range next.Endpoints {
+		i, ok := idx[e.Path]
+		if !ok {
+			idx[e.Path] = len(out.Endpoints)
+			out.Endpoints = append(out.Endpoints, e)
+			continue
+		}
+		cur := &out.Endpoints[i]
+		cur.Methods = union(cur.Methods, e.Methods)
+		cur.Query = union(cur.Query, e.Query)
+		cur.Headers = union(cur.Headers, e.Headers)
+		cur.Allow = union(cur.Allow, e.Allow)
+		if e.Schema != nil {
+			cur.Schema = e.Schema
+		}
+		if e.RequestSchema != nil {
+			cur.RequestSchema = e.RequestSchema
+		}
+		cur.Responses = mergeSchemas(cur.Responses, e.Responses)
+		cur.Requests = mergeSchemas(cur.Requests, e.Requests)
+		if len(e.Params) > 0 {
+			params := map[string][]string{}
+			for m, names := range cur.Params {
+				params[m] = names
+			}
+			for m, names := range e.Params {
+				params[m] = union(params[m], names)
+			}
+			cur.Params = params
+		}
+		if len(e.Statuses) > 0 {
+			merged := map[string][]int{}
+			for m, codes := range cur.Statuses {
+				merged[m] = append([]int(nil), codes...)
+			}
+			for m, codes := range e.Statuses {
+				for _, c := range codes {
+					if !slices.Contains(merged[m], c) {
+						merged[m] = append(merged[m], c)
+					}
+				}
+				slices.Sort(merged[m])
+			}
+			cur.Statuses = merged
+		}
+		if e.Access != "" {
+			cur.Access, cur.Auth = e.Access, e.Auth
+		}
+		if e.Pagination != nil {
+			cur.Pagination = e.Pagination
+		}
+		if cur.Source == "" {
+			cur.Source, cur.Confidence = e.Source, e.Confidence
+		}
+		if !e.FirstSeen.IsZero() && (cur.FirstSeen.IsZero() || e.FirstSeen.Before(cur.FirstSeen)) {
+			cur.FirstSeen = e.FirstSeen
+		}
+	}
+
+	sort.Slice(out.Endpoints, func(i, j int) bool { return out.Endpoints[i].Path < out.Endpoints[j].Path })
+	return out
+}
+
+// mergeSchemas overlays per-method schemas, fresh ones winning.
+func mergeSchemas(cur, next map[string]*schema.Schema) map[string]*schema.Schema {
+	if len(next) == 0 {
+		return cur
+	}
+	out := map[string]*schema.Schema{}
+	for m, s := range cur {
+		out[m] = s
+	}
+	for m, s := range next {
+		out[m] = s
+	}
+	return out
+}
+
+func union(a, b []string) []string {
+	out := append([]string(nil), a...)
+	for _, v := range b {
+		if !slices.Contains(out, v) {
+			out = append(out, v)
+		}
+	}
+	sort.Strings(out)
+	return out
 }
 
 func DefaultRoot(custom string) (string, error) {
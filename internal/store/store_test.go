@@ -0,0 +1,44 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/bspippi1337/restless/internal/schema"
+)
+
+func TestCarrySchemas(t *testing.T) {
+	prev := &API{BaseURL: "https://api.example.com", Endpoints: []Endpoint{
+		{Path: "/users", Schema: schema.Infer([]byte(`{"id":1,"name":"ada"}`))},
+		{Path: "/gone", Schema: schema.Infer([]byte(`{"ok":true}`))},
+	}}
+	next := &API{BaseURL: "https://api.example.com", Endpoints: []Endpoint{
+		{Path: "/users", Schema: schema.Infer([]byte(`{"id":2,"email":"b@example.com"}`))},
+		{Path: "/new", Schema: schema.Infer([]byte(`[]`))},
+	}}
+
+	CarrySchemas(prev, next)
+
+	s := next.Endpoints[0].Schema
+	if s.Samples != 2 {
+		t.Fatalf("expected 2 samples, got %d", s.Samples)
+	}
+	for _, p := range []string{"id", "name", "email"} {
+		if s.Properties[p] == nil {
+			t.Fatalf("missing %s in %v", p, s.Properties)
+		}
+	}
+	if len(s.Required) != 1 || s.Required[0] != "id" {
+		t.Fatalf("required: %v", s.Required)
+	}
+	if !next.Endpoints[1].Schema.Is("array") {
+		t.Fatalf("new endpoint schema changed: %#v", next.Endpoints[1].Schema)
+	}
+
+	other := &API{BaseURL: "https://other.example.com", Endpoints: []Endpoint{
+		{Path: "/users", Schema: schema.Infer([]byte(`{"id":3}`))},
+	}}
+	CarrySchemas(prev, other)
+	if other.Endpoints[0].Schema.Samples != 1 {
+		t.Fatalf("schemas carried across APIs")
+	}
+}
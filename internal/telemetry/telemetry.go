@@ -66,6 +66,34 @@ func SetWorkers(n int) {
 	T.mu.Unlock()
 }
 
+// Counts is a copy of the counters at one moment.
+type Counts struct {
+	Requests  int
+	Endpoints int
+	Probes    int
+	Consensus int
+	Queue     int
+	Workers   int
+	Errors    int
+	Uptime    time.Duration
+}
+
+func Snapshot() Counts {
+	T.mu.Lock()
+	defer T.mu.Unlock()
+
+	return Counts{
+		Requests:  T.Requests,
+		Endpoints: T.Endpoints,
+		Probes:    T.Probes,
+		Consensus: T.Consensus,
+		Queue:     T.Queue,
+		Workers:   T.Workers,
+		Errors:    T.Errors,
+		Uptime:    time.Since(T.start),
+	}
+}
+
 func Print() {
 
 	T.mu.Lock()
@@ -0,0 +1,328 @@
+package traffic
+
+import (
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bspippi1337/restless/internal/pagination"
+	"github.com/bspippi1337/restless/internal/pathtmpl"
+	"github.com/bspippi1337/restless/internal/schema"
+	"github.com/bspippi1337/restless/internal/store"
+)
+
+// Exchange is one observed request/response pair, whatever captured it
+// (HAR file, recording proxy, ...).
+type Exchange struct {
+	Time time.Time
+
+	Method        string
+	URL           *url.URL
+	RequestHeader http.Header
+	RequestBody   []byte
+
+	Status         int
+	ResponseHeader http.Header
+	ResponseBody   []byte
+}
+
+// Learner folds exchanges into templated endpoints of one API.
+// It is safe for concurrent use.
+type Learner struct {
+	mu     sync.Mutex
+	source string
+	base   string
+	calls  int
+	byTpl  map[string]*accum
+}
+
+type accum struct {
+	ep      store.Endpoint
+	query   map[string]bool
+	headers map[string]bool
+	get     *schema.Inferrer
+	resp    *schema.Inferrer
+	req     *schema.Inferrer
+	methods map[string]*methodAccum
+
+	challenge string // WWW-Authenticate
+	sent      string // the scheme the client sent, e.g. "Bearer"
+}
+
+// methodAccum is what one method of an endpoint was seen to send and get.
+type methodAccum struct {
+	query map[string]bool
+	resp  *schema.Inferrer
+	req   *schema.Inferrer
+}
+
+// noiseHeaders are browser/transport headers that say nothing about the API.
+var noiseHeaders = map[string]bool{
+	"accept-encoding":           true,
+	"accept-language":           true,
+	"cache-control":             true,
+	"connection":                true,
+	"content-length":            true,
+	"dnt":                       true,
+	"host":                      true,
+	"origin":                    true,
+	"pragma":                    true,
+	"priority":                  true,
+	"referer":                   true,
+	"te":                        true,
+	"upgrade-insecure-requests": true,
+	"user-agent":                true,
+	"x-forwarded-for":           true,
+	"x-forwarded-host":          true,
+	"x-forwarded-proto":         true,
+}
+
+// NewLearner returns a learner that tags endpoints with source
+// (e.g. "har", "proxy") as discovery evidence.
+func NewLearner(source string) *Learner {
+	return &Learner{source: source, byTpl: map[string]*accum{}}
+}
+
+// Add records one exchange.
+func (l *Learner) Add(x Exchange) {
+	if x.URL == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.calls++
+	if l.base == "" {
+		l.base = x.URL.Scheme + "://" + x.URL.Host
+	}
+
+	tpl, _ := pathtmpl.Template(x.URL.Path)
+	a, ok := l.byTpl[tpl]
+	if !ok {
+		a = &accum{
+			ep: store.Endpoint{
+				Path:       tpl,
+				Statuses:   map[string][]int{},
+				Source:     l.source,
+				Confidence: "high",
+			},
+			query:   map[string]bool{},
+			headers: map[string]bool{},
+			get:     schema.NewInferrer(),
+			resp:    schema.NewInferrer(),
+			req:     schema.NewInferrer(),
+			methods: map[string]*methodAccum{},
+		}
+		l.byTpl[tpl] = a
+	}
+	a.observe(x)
+}
+
+// Calls is the number of exchanges added so far.
+func (l *Learner) Calls() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.calls
+}
+
+// API returns the learned model, endpoints sorted by path.
+func (l *Learner) API() *store.API {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	tpls := make([]string, 0, len(l.byTpl))
+	for t := range l.byTpl {
+		tpls = append(tpls, t)
+	}
+	sort.Strings(tpls)
+
+	api := &store.API{BaseURL: l.base}
+	for _, t := range tpls {
+		api.Endpoints = append(api.Endpoints, l.byTpl[t].endpoint())
+	}
+	return api
+}
+
+// Challenges maps each endpoint path to the WWW-Authenticate header it
+// answered with.
+func (l *Learner) Challenges() map[string]string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	out := map[string]string{}
+	for t, a := range l.byTpl {
+		if a.challenge != "" {
+			out[t] = a.challenge
+		}
+	}
+	return out
+}
+
+// SentSchemes maps each endpoint path that never answered with a
+// challenge to the scheme its requests carried credentials in (e.g.
+// "Bearer"), since captured traffic is usually already authenticated.
+func (l *Learner) SentSchemes() map[string]string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	out := map[string]string{}
+	for t, a := range l.byTpl {
+		if a.challenge == "" && a.sent != "" {
+			out[t] = a.sent
+		}
+	}
+	return out
+}
+
+func (a *accum) observe(x Exchange) {
+	m := strings.ToUpper(x.Method)
+	if !contains(a.ep.Methods, m) {
+		a.ep.Methods = append(a.ep.Methods, m)
+	}
+	if s := x.Status; s > 0 && !containsInt(a.ep.Statuses[m], s) {
+		a.ep.Statuses[m] = append(a.ep.Statuses[m], s)
+	}
+	if t := x.Time.UTC(); !t.IsZero() && (a.ep.FirstSeen.IsZero() || t.Before(a.ep.FirstSeen)) {
+		a.ep.FirstSeen = t
+	}
+
+	ma := a.methods[m]
+	if ma == nil {
+		ma = &methodAccum{query: map[string]bool{}, resp: schema.NewInferrer(), req: schema.NewInferrer()}
+		a.methods[m] = ma
+	}
+
+	for k := range x.URL.Query() {
+		a.query[k] = true
+		ma.query[k] = true
+	}
+	for k := range x.RequestHeader {
+		if n := strings.ToLower(k); !IsNoise(n) {
+			a.headers[n] = true
+		}
+	}
+
+	if h := x.ResponseHeader.Get("WWW-Authenticate"); h != "" {
+		a.challenge = h
+	}
+	if a.sent == "" {
+		a.sent = sentScheme(x.RequestHeader)
+	}
+
+	if len(x.RequestBody) > 0 && IsJSON(x.RequestHeader.Get("Content-Type")) {
+		_ = a.req.AddJSON(x.RequestBody)
+		_ = ma.req.AddJSON(x.RequestBody)
+	}
+
+	if x.Status < 400 && len(x.ResponseBody) > 0 && IsJSON(x.ResponseHeader.Get("Content-Type")) {
+		if m == http.MethodGet {
+			_ = a.get.AddJSON(x.ResponseBody)
+			if x.Status < 300 {
+				if p := pagination.Detect(x.URL, x.ResponseHeader, x.ResponseBody); p != nil {
+					a.ep.Pagination = p
+				}
+			}
+		}
+		_ = a.resp.AddJSON(x.ResponseBody)
+		_ = ma.resp.AddJSON(x.ResponseBody)
+	}
+}
+
+func (a *accum) endpoint() store.Endpoint {
+	ep := a.ep
+	ep.Methods = append([]string(nil), ep.Methods...)
+	sort.Strings(ep.Methods)
+	ep.Statuses = map[string][]int{}
+	for m, codes := range a.ep.Statuses {
+		c := append([]int(nil), codes...)
+		sort.Ints(c)
+		ep.Statuses[m] = c
+	}
+	ep.Query = sortedKeys(a.query)
+	ep.Headers = sortedKeys(a.headers)
+	if a.get.Samples() > 0 {
+		ep.Schema = a.get.Schema()
+	} else {
+		ep.Schema = a.resp.Schema()
+	}
+	ep.RequestSchema = a.req.Schema()
+
+	ep.Responses, ep.Requests, ep.Params = nil, nil, nil
+	for m, ma := range a.methods {
+		if ma.resp.Samples() > 0 {
+			if ep.Responses == nil {
+				ep.Responses = map[string]*schema.Schema{}
+			}
+			ep.Responses[m] = ma.resp.Schema()
+		}
+		if ma.req.Samples() > 0 {
+			if ep.Requests == nil {
+				ep.Requests = map[string]*schema.Schema{}
+			}
+			ep.Requests[m] = ma.req.Schema()
+		}
+		if q := sortedKeys(ma.query); q != nil {
+			if ep.Params == nil {
+				ep.Params = map[string][]string{}
+			}
+			ep.Params[m] = q
+		}
+	}
+	return ep
+}
+
+// sentScheme names the credential a request carried, in challenge form.
+func sentScheme(h http.Header) string {
+	if v := h.Get("Authorization"); v != "" {
+		scheme, _, _ := strings.Cut(v, " ")
+		return scheme
+	}
+	if h.Get("X-API-Key") != "" {
+		return "ApiKey"
+	}
+	return ""
+}
+
+// IsNoise reports whether a (lower-case) header name is transport or
+// browser noise rather than part of the API contract.
+func IsNoise(name string) bool {
+	return noiseHeaders[name] || strings.HasPrefix(name, "sec-") || strings.HasPrefix(name, ":")
+}
+
+func IsJSON(mime string) bool {
+	return strings.Contains(strings.ToLower(mime), "json")
+}
+
+func sortedKeys(m map[string]bool) []string {
+	if len(m) == 0 {
+		return nil
+	}
+	out := make([]string, 0, len(m))
+	for k := range m {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func contains(in []string, v string) bool {
+	for _, x := range in {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
+
+func containsInt(in []int, v int) bool {
+	for _, x := range in {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,295 @@
+package tui
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/bspippi1337/restless/internal/core/app"
+	"github.com/bspippi1337/restless/internal/core/types"
+	"github.com/bspippi1337/restless/internal/store"
+	"github.com/bspippi1337/restless/internal/tui/views"
+	"github.com/bspippi1337/restless/internal/util"
+)
+
+// ExploreOptions is what the explorer runs against.
+type ExploreOptions struct {
+	API     *store.API
+	App     *app.App
+	Timeout time.Duration
+	// Session is the file the explorer keeps its state in between
+	// launches; empty keeps nothing.
+	Session string
+}
+
+// Explore runs the multi-pane explorer: endpoints on the left with the
+// history under them, the request editor and the response on the right.
+func Explore(stdin, stdout *os.File, opt ExploreOptions) error {
+	s, err := loadSession(opt.Session)
+	if err != nil {
+		return fmt.Errorf("explorer session %s: %w", opt.Session, err)
+	}
+	m := newExplorer(opt, s)
+	p := tea.NewProgram(
+		m,
+		tea.WithInput(stdin),
+		tea.WithOutput(stdout),
+		tea.WithAltScreen(),
+	)
+	final, err := p.Run()
+	if err != nil {
+		return err
+	}
+	if e, ok := final.(explorer); ok && e.saveErr != nil {
+		return fmt.Errorf("saving explorer session: %w", e.saveErr)
+	}
+	return nil
+}
+
+type pane int
+
+const (
+	paneTree pane = iota
+	paneEditor
+	paneResponse
+	paneHistory
+	paneCount
+)
+
+type explorer struct {
+	opt  ExploreOptions
+	w, h int
+
+	focus  pane
+	tree   views.Tree
+	editor views.Editor
+	resp   views.Response
+	hist   views.History
+
+	sending bool
+	saveErr error
+}
+
+type sentMsg struct{ x views.Exchange }
+
+func newExplorer(opt ExploreOptions, s session) explorer {
+	if opt.Timeout <= 0 {
+		opt.Timeout = 12 * time.Second
+	}
+	var routes []views.Route
+	for _, ep := range opt.API.Endpoints {
+		methods := ep.Methods
+		if len(methods) == 0 {
+			methods = []string{"GET"}
+		}
+		for _, m := range methods {
+			routes = append(routes, views.Route{Method: m, Path: ep.Path, Query: ep.Query})
+		}
+	}
+
+	e := explorer{
+		opt:    opt,
+		tree:   views.NewTree(routes),
+		editor: views.NewEditor(),
+		resp:   views.NewResponse(),
+		hist:   views.NewHistory(s.History),
+	}
+	e.tree.SetFilter(s.Filter)
+	if s.Draft.Path != "" {
+		e.editor.SetDraft(s.Draft)
+	}
+	if n := len(s.History); n > 0 {
+		e.resp.Show(s.History[n-1])
+	}
+	return e
+}
+
+func (e explorer) Init() tea.Cmd { return nil }
+
+func (e explorer) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		e.w, e.h = msg.Width, msg.Height
+		e.layout()
+		return e, nil
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c":
+			e.save()
+			return e, tea.Quit
+		case "ctrl+s":
+			return e.send()
+		case "tab":
+			if e.focus == paneEditor {
+				if ok, cmd := e.editor.Next(); ok {
+					return e, cmd
+				}
+			}
+			return e, e.setFocus((e.focus+1)%paneCount, false)
+		case "shift+tab":
+			if e.focus == paneEditor {
+				if ok, cmd := e.editor.Prev(); ok {
+					return e, cmd
+				}
+			}
+			return e, e.setFocus((e.focus+paneCount-1)%paneCount, true)
+		}
+		var cmd tea.Cmd
+		switch e.focus {
+		case paneTree:
+			e.tree, cmd = e.tree.Update(msg)
+		case paneEditor:
+			e.editor, cmd = e.editor.Update(msg)
+		case paneResponse:
+			e.resp, cmd = e.resp.Update(msg)
+		case paneHistory:
+			e.hist, cmd = e.hist.Update(msg)
+		}
+		return e, cmd
+
+	case views.RouteMsg:
+		e.editor.Load(msg.Route)
+		return e, e.setFocus(paneEditor, false)
+
+	case views.SendMsg:
+		return e.send()
+
+	case sentMsg:
+		e.sending = false
+		e.hist.Add(msg.x)
+		e.hist.Trim(sessionHistory)
+		e.resp.Show(msg.x)
+		e.save()
+		return e, nil
+
+	case views.ShowMsg:
+		e.resp.Show(msg.Exchange)
+		return e, e.setFocus(paneResponse, false)
+
+	case views.DiffMsg:
+		e.resp.ShowDiff(msg.Old, msg.New)
+		return e, e.setFocus(paneResponse, false)
+	}
+
+	// Cursor blinks and the like belong to the editor's fields.
+	var cmd tea.Cmd
+	e.editor, cmd = e.editor.Update(msg)
+	return e, cmd
+}
+
+func (e *explorer) setFocus(p pane, fromAfter bool) tea.Cmd {
+	e.focus = p
+	if p == paneEditor {
+		return e.editor.Focus(fromAfter)
+	}
+	e.editor.Blur()
+	return nil
+}
+
+// send runs the request in the editor through the app, so session vars
+// and auth apply as they do to call.
+func (e explorer) send() (tea.Model, tea.Cmd) {
+	d := e.editor.Draft()
+	if e.sending || d.Path == "" {
+		return e, nil
+	}
+	e.sending = true
+
+	target := d.Target()
+	url := target
+	if !strings.HasPrefix(target, "http://") && !strings.HasPrefix(target, "https://") {
+		url = util.JoinURL(e.opt.API.BaseURL, target)
+	}
+	h := http.Header{}
+	for k, v := range d.HeaderMap() {
+		h.Set(k, v)
+	}
+	if d.Body != "" && h.Get("Content-Type") == "" && json.Valid([]byte(d.Body)) {
+		h.Set("Content-Type", "application/json")
+	}
+	req := types.Request{Method: d.Method, URL: url, Headers: h, Body: []byte(d.Body)}
+	a, timeout := e.opt.App, e.opt.Timeout
+
+	return e, func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		start := time.Now()
+		x := views.Exchange{At: start, Draft: d, Method: d.Method, URL: url}
+		res, err := a.RunOnce(ctx, req)
+		if err != nil {
+			x.Err = err.Error()
+			return sentMsg{x}
+		}
+		x.Status, x.Headers, x.Body, x.DurationMs = res.StatusCode, res.Headers, string(res.Body), res.DurationMs
+		if x.DurationMs == 0 {
+			x.DurationMs = time.Since(start).Milliseconds()
+		}
+		return sentMsg{x}
+	}
+}
+
+func (e *explorer) save() {
+	e.saveErr = saveSession(e.opt.Session, session{
+		Filter:  e.tree.Filter(),
+		Draft:   e.editor.Draft(),
+		History: e.hist.Entries(),
+	})
+}
+
+// Pane sizes, outer, borders included.
+func (e explorer) sizes() (lw, rw, treeH, histH, editH, respH int) {
+	avail := max(8, e.h-3) // header, its rule and footer
+	lw = min(max(e.w/3, 30), 56)
+	rw = max(20, e.w-lw)
+	treeH = avail * 3 / 5
+	histH = avail - treeH
+	editH = min(max(avail*2/5, 10), 16)
+	respH = avail - editH
+	return
+}
+
+func (e *explorer) layout() {
+	lw, rw, treeH, histH, editH, respH := e.sizes()
+	e.tree.SetSize(lw-2, treeH-2)
+	e.hist.SetSize(lw-2, histH-2)
+	e.editor.SetSize(rw-2, editH-2)
+	e.resp.SetSize(rw-2, respH-2)
+}
+
+var paneKeys = [paneCount]string{
+	paneTree:     "type to filter · ↑↓ move · enter pick · ←→ fold · esc clear",
+	paneEditor:   "enter/ctrl+s send · tab next field · ↑↓ method",
+	paneResponse: "↑↓ move · enter fold · e/c open/fold all · h headers",
+	paneHistory:  "enter show · m mark · d diff with mark or last call · x delete",
+}
+
+func (e explorer) View() string {
+	if e.w == 0 {
+		return ""
+	}
+	lw, rw, treeH, histH, editH, respH := e.sizes()
+
+	busy := ""
+	if e.sending {
+		busy = "sending…"
+	}
+	head := views.Header("restless explore", e.opt.API.BaseURL, busy)
+
+	left := lipgloss.JoinVertical(lipgloss.Left,
+		views.Pane(e.tree.View(e.focus == paneTree), lw, treeH, e.focus == paneTree),
+		views.Pane(e.hist.View(e.focus == paneHistory), lw, histH, e.focus == paneHistory))
+	right := lipgloss.JoinVertical(lipgloss.Left,
+		views.Pane(e.editor.View(e.focus == paneEditor), rw, editH, e.focus == paneEditor),
+		views.Pane(e.resp.View(e.focus == paneResponse), rw, respH, e.focus == paneResponse))
+
+	foot := views.Footer(paneKeys[e.focus] + " · tab pane · ctrl+c quit")
+	return head + "\n" + lipgloss.JoinHorizontal(lipgloss.Top, left, right) + "\n" + foot
+}
@@ -0,0 +1,145 @@
+package tui
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/bspippi1337/restless/internal/redact"
+	"github.com/bspippi1337/restless/internal/tui/views"
+)
+
+const (
+	// sessionHistory is how many exchanges a session keeps.
+	sessionHistory = 100
+	// sessionBodyLimit is the largest response body kept in a saved
+	// session; larger ones are kept for the run only.
+	sessionBodyLimit = 1 << 20
+)
+
+// session is what the explorer keeps between launches for one API.
+type session struct {
+	Filter  string           `json:"filter,omitempty"`
+	Draft   views.Draft      `json:"draft"`
+	History []views.Exchange `json:"history,omitempty"`
+}
+
+var unsafeName = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// SessionFile is where the explorer session for baseURL is kept under
+// dir: one file per API, named after its host and path.
+func SessionFile(dir, baseURL string) string {
+	name := baseURL
+	if _, rest, ok := strings.Cut(name, "://"); ok {
+		name = rest
+	}
+	name = strings.Trim(unsafeName.ReplaceAllString(name, "_"), "_")
+	if name == "" {
+		name = "default"
+	}
+	return filepath.Join(dir, "explore", name+".json")
+}
+
+// loadSession reads a saved session; a missing file is an empty one.
+func loadSession(path string) (session, error) {
+	var s session
+	if path == "" {
+		return s, nil
+	}
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return s, err
+	}
+	err = json.Unmarshal(b, &s)
+	return s, err
+}
+
+func saveSession(path string, s session) error {
+	if path == "" {
+		return nil
+	}
+	if extra := len(s.History) - sessionHistory; extra > 0 {
+		s.History = s.History[extra:]
+	}
+	hist := make([]views.Exchange, len(s.History))
+	for i, x := range s.History {
+		if len(x.Body) > sessionBodyLimit {
+			x.Body = ""
+		}
+		hist[i] = redactExchange(x)
+	}
+	s.History = hist
+	s.Draft = redactDraft(s.Draft)
+
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// redactExchange is x as it may be written to disk: credentials and
+// cookies in its headers, URL and body replaced.
+func redactExchange(x views.Exchange) views.Exchange {
+	x.Draft = redactDraft(x.Draft)
+	x.URL = redact.URL(x.URL)
+	if x.Headers != nil {
+		x.Headers = redact.Headers(x.Headers)
+	}
+	x.Body = string(redact.Body(x.Headers.Get("Content-Type"), []byte(x.Body)))
+	return x
+}
+
+// redactDraft replaces the values of secret headers, query parameters
+// and JSON body fields in d, keeping the rest as typed.
+func redactDraft(d views.Draft) views.Draft {
+	lines := strings.Split(d.Headers, "\n")
+	ct := ""
+	for i, line := range lines {
+		name, v, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		if strings.EqualFold(strings.TrimSpace(name), "Content-Type") {
+			ct = strings.TrimSpace(v)
+		}
+		if redact.SecretHeader(name) {
+			lines[i] = name + ": " + redact.Placeholder
+		}
+	}
+	d.Headers = strings.Join(lines, "\n")
+
+	q, hasMark := strings.CutPrefix(d.Query, "?")
+	parts := strings.Split(q, "&")
+	for i, kv := range parts {
+		k, _, ok := strings.Cut(kv, "=")
+		if name, err := url.QueryUnescape(k); ok && err == nil && redact.SecretParam(name) {
+			parts[i] = k + "=" + redact.Placeholder
+		}
+	}
+	d.Query = strings.Join(parts, "&")
+	if hasMark {
+		d.Query = "?" + d.Query
+	}
+
+	if ct == "" && json.Valid([]byte(d.Body)) {
+		ct = "application/json"
+	}
+	d.Body = string(redact.Body(ct, []byte(d.Body)))
+	return d
+}
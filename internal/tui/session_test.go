@@ -0,0 +1,45 @@
+package tui
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bspippi1337/restless/internal/tui/views"
+)
+
+func TestSaveSessionRedacts(t *testing.T) {
+	d := views.Draft{
+		Method: "POST", Path: "/login", Query: "?page=2&api_key=k3y",
+		Headers: "Accept: application/json\nAuthorization: Bearer s3cret",
+		Body:    `{"user": "ada", "password": "hunter2"}`,
+	}
+	x := views.Exchange{
+		Draft: d, Method: "POST", URL: "https://h/login?page=2&api_key=k3y", Status: 200,
+		Headers: http.Header{"Set-Cookie": {"sid=abc"}, "Content-Type": {"application/json"}},
+		Body:    `{"access_token": "t0ken", "user": "ada"}`,
+	}
+	path := filepath.Join(t.TempDir(), "s.json")
+	if err := saveSession(path, session{Draft: d, History: []views.Exchange{x}}); err != nil {
+		t.Fatal(err)
+	}
+	b, _ := os.ReadFile(path)
+	for _, secret := range []string{"k3y", "s3cret", "hunter2", "abc", "t0ken"} {
+		if strings.Contains(string(b), secret) {
+			t.Fatalf("session keeps %q:\n%s", secret, b)
+		}
+	}
+
+	s, err := loadSession(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Draft.Query != "?page=2&api_key=REDACTED" || s.Draft.Headers != "Accept: application/json\nAuthorization: REDACTED" {
+		t.Fatalf("draft: %+v", s.Draft)
+	}
+	if h := s.History[0]; h.Headers.Get("Content-Type") != "application/json" || !strings.Contains(h.Body, `"user":"ada"`) {
+		t.Fatalf("exchange: %+v", h)
+	}
+}
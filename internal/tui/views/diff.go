@@ -0,0 +1,240 @@
+package views
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/bspippi1337/restless/internal/output"
+)
+
+// DiffLine is one line of a diff: Op is '+' added, '-' removed, '~'
+// changed, '#' a section heading or ' ' unchanged.
+type DiffLine struct {
+	Op   byte
+	Text string
+}
+
+// diffLineLimit caps the bodies compared line by line; past it the
+// quadratic comparison is not worth the wait.
+const diffLineLimit = 2000
+
+// ignoredHeaders change on every response and would drown the rest.
+var ignoredHeaders = map[string]bool{"Date": true, "Age": true, "X-Request-Id": true}
+
+// Diff compares two exchanges, a the older: status, response headers
+// and body. JSON bodies are compared field by field, anything else line
+// by line.
+func Diff(a, b Exchange) []DiffLine {
+	var out []DiffLine
+	if a.Method != b.Method || a.URL != b.URL {
+		out = append(out,
+			DiffLine{'-', a.Method + " " + a.URL},
+			DiffLine{'+', b.Method + " " + b.URL})
+	}
+	if a.Status != b.Status {
+		out = append(out, DiffLine{'~', fmt.Sprintf("status: %d → %d", a.Status, b.Status)})
+	}
+
+	if h := diffHeaders(a.Headers, b.Headers); len(h) > 0 {
+		out = append(out, DiffLine{'#', "headers"})
+		out = append(out, h...)
+	}
+
+	var body []DiffLine
+	av, aerr := output.Decode([]byte(a.Body))
+	bv, berr := output.Decode([]byte(b.Body))
+	if aerr == nil && berr == nil {
+		diffValues("", av, bv, &body)
+	} else if a.Body != b.Body {
+		body = diffText(a.Body, b.Body)
+	}
+	if len(body) > 0 {
+		out = append(out, DiffLine{'#', "body"})
+		out = append(out, body...)
+	}
+
+	if len(out) == 0 {
+		out = append(out, DiffLine{' ', "no differences"})
+	}
+	return out
+}
+
+func diffHeaders(a, b http.Header) []DiffLine {
+	names := map[string]bool{}
+	for k := range a {
+		names[k] = true
+	}
+	for k := range b {
+		names[k] = true
+	}
+	var keys []string
+	for k := range names {
+		if !ignoredHeaders[http.CanonicalHeaderKey(k)] {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	var out []DiffLine
+	for _, k := range keys {
+		av, bv := strings.Join(a.Values(k), ", "), strings.Join(b.Values(k), ", ")
+		switch {
+		case av == bv:
+		case av == "":
+			out = append(out, DiffLine{'+', k + ": " + bv})
+		case bv == "":
+			out = append(out, DiffLine{'-', k + ": " + av})
+		default:
+			out = append(out, DiffLine{'~', k + ": " + av + " → " + bv})
+		}
+	}
+	return out
+}
+
+// diffValues walks two JSON values together. Arrays of objects that all
+// carry an id are matched by id, so an insert is not a change to every
+// element after it.
+func diffValues(path string, a, b any, out *[]DiffLine) {
+	at := func(p string) string {
+		if p == "" {
+			return "."
+		}
+		return p
+	}
+	switch x := a.(type) {
+	case *output.Object:
+		if y, ok := b.(*output.Object); ok {
+			for _, k := range x.Keys {
+				if bv, ok := y.Get(k); ok {
+					diffValues(path+"."+k, x.Vals[k], bv, out)
+				} else {
+					*out = append(*out, DiffLine{'-', path + "." + k + ": " + brief(x.Vals[k])})
+				}
+			}
+			for _, k := range y.Keys {
+				if _, ok := x.Get(k); !ok {
+					*out = append(*out, DiffLine{'+', path + "." + k + ": " + brief(y.Vals[k])})
+				}
+			}
+			return
+		}
+	case []any:
+		if y, ok := b.([]any); ok {
+			if xi, yi := byID(x), byID(y); xi != nil && yi != nil {
+				diffByID(path, x, y, xi, yi, out)
+				return
+			}
+			for i := 0; i < len(x) || i < len(y); i++ {
+				p := fmt.Sprintf("%s[%d]", path, i)
+				switch {
+				case i >= len(y):
+					*out = append(*out, DiffLine{'-', p + ": " + brief(x[i])})
+				case i >= len(x):
+					*out = append(*out, DiffLine{'+', p + ": " + brief(y[i])})
+				default:
+					diffValues(p, x[i], y[i], out)
+				}
+			}
+			return
+		}
+	}
+	if !bytes.Equal(output.Encode(a, ""), output.Encode(b, "")) {
+		*out = append(*out, DiffLine{'~', at(path) + ": " + brief(a) + " → " + brief(b)})
+	}
+}
+
+// byID maps each element's id to its index, or is nil unless every
+// element is an object with a distinct id.
+func byID(arr []any) map[string]int {
+	if len(arr) == 0 {
+		return nil
+	}
+	ids := map[string]int{}
+	for i, e := range arr {
+		o, ok := e.(*output.Object)
+		if !ok {
+			return nil
+		}
+		id, ok := o.Get("id")
+		if !ok {
+			return nil
+		}
+		k := output.Text(id)
+		if _, dup := ids[k]; dup {
+			return nil
+		}
+		ids[k] = i
+	}
+	return ids
+}
+
+func diffByID(path string, x, y []any, xi, yi map[string]int, out *[]DiffLine) {
+	id := func(e any) string {
+		v, _ := e.(*output.Object).Get("id")
+		return output.Text(v)
+	}
+	for _, e := range x {
+		k := id(e)
+		p := fmt.Sprintf("%s[id=%s]", path, k)
+		if j, ok := yi[k]; ok {
+			diffValues(p, e, y[j], out)
+		} else {
+			*out = append(*out, DiffLine{'-', p + ": " + brief(e)})
+		}
+	}
+	for _, e := range y {
+		if _, ok := xi[id(e)]; !ok {
+			*out = append(*out, DiffLine{'+', fmt.Sprintf("%s[id=%s]: %s", path, id(e), brief(e))})
+		}
+	}
+}
+
+func brief(v any) string {
+	s := string(output.Encode(v, ""))
+	if r := []rune(s); len(r) > 80 {
+		s = string(r[:79]) + "…"
+	}
+	return s
+}
+
+// diffText is a line diff by longest common subsequence.
+func diffText(a, b string) []DiffLine {
+	x, y := strings.Split(a, "\n"), strings.Split(b, "\n")
+	if len(x) > diffLineLimit || len(y) > diffLineLimit {
+		return []DiffLine{{'~', fmt.Sprintf("bodies differ (%d → %d lines, too long to compare)", len(x), len(y))}}
+	}
+	// lcs[i][j] is the common length of x[i:] and y[j:].
+	lcs := make([][]int, len(x)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(y)+1)
+	}
+	for i := len(x) - 1; i >= 0; i-- {
+		for j := len(y) - 1; j >= 0; j-- {
+			if x[i] == y[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	var out []DiffLine
+	i, j := 0, 0
+	for i < len(x) || j < len(y) {
+		switch {
+		case i < len(x) && j < len(y) && x[i] == y[j]:
+			out = append(out, DiffLine{' ', x[i]})
+			i++
+			j++
+		case i < len(x) && (j == len(y) || lcs[i+1][j] >= lcs[i][j+1]):
+			out = append(out, DiffLine{'-', x[i]})
+			i++
+		default:
+			out = append(out, DiffLine{'+', y[j]})
+			j++
+		}
+	}
+	return out
+}
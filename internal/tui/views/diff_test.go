@@ -0,0 +1,69 @@
+package views
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func diffString(lines []DiffLine) string {
+	var b strings.Builder
+	for _, l := range lines {
+		b.WriteByte(l.Op)
+		b.WriteString(" " + l.Text + "\n")
+	}
+	return b.String()
+}
+
+func TestDiff(t *testing.T) {
+	a := Exchange{Method: "GET", URL: "/users", Status: 200,
+		Headers: http.Header{"Etag": {`"1"`}, "Date": {"Mon"}},
+		Body:    `{"data": [{"id": 1, "name": "ada"}, {"id": 2, "name": "bob"}], "total": 2}`}
+	b := Exchange{Method: "GET", URL: "/users", Status: 200,
+		Headers: http.Header{"Etag": {`"2"`}, "Date": {"Tue"}},
+		Body:    `{"data": [{"id": 0, "name": "eve"}, {"id": 1, "name": "ada"}, {"id": 2, "name": "rob"}], "total": 3}`}
+
+	got := diffString(Diff(a, b))
+	want := `# headers
+~ Etag: "1" → "2"
+# body
+~ .data[id=2].name: "bob" → "rob"
++ .data[id=0]: {"id":0,"name":"eve"}
+~ .total: 2 → 3
+`
+	if got != want {
+		t.Fatalf("diff:\n%s\nwant:\n%s", got, want)
+	}
+
+	if got := diffString(Diff(a, a)); got != "  no differences\n" {
+		t.Fatalf("same exchange: %q", got)
+	}
+
+	got = diffString(Diff(Exchange{Status: 200, Body: "a\nb\nc"}, Exchange{Status: 500, Body: "a\nx\nc"}))
+	want = "~ status: 200 → 500\n# body\n  a\n- b\n+ x\n  c\n"
+	if got != want {
+		t.Fatalf("text diff:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestDraftTarget(t *testing.T) {
+	d := Draft{Path: "/users/{id}/repos/{repo}/{ref}", Params: "id=7 repo=ré ref=", Query: "?page=2"}
+	if got := d.Target(); got != "/users/7/repos/r%C3%A9/{ref}?page=2" {
+		t.Fatalf("target: %s", got)
+	}
+
+	d = Draft{Path: "/orgs/{org}/{{team}}", Params: "org=acme corp"}
+	if got := d.Target(); got != "/orgs/acme/{{team}}" {
+		t.Fatalf("target with var: %s", got)
+	}
+
+	d = Draft{Path: "/x/{id}", Params: "id={{uid}}"}
+	if got := d.Target(); got != "/x/{{uid}}" {
+		t.Fatalf("target with var param: %s", got)
+	}
+
+	h := Draft{Headers: "Accept: application/json\nnot a header\n X-Trace : abc "}.HeaderMap()
+	if len(h) != 2 || h["Accept"] != "application/json" || h["X-Trace"] != "abc" {
+		t.Fatalf("headers: %v", h)
+	}
+}
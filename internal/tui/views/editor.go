@@ -0,0 +1,302 @@
+package views
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// SendMsg asks for the request in the editor to be sent.
+type SendMsg struct{}
+
+// Draft is what the editor holds, in the form it is typed and saved.
+type Draft struct {
+	Method  string `json:"method"`
+	Path    string `json:"path"`
+	Params  string `json:"params,omitempty"`  // id=7 owner=bob, for {id} and {owner}
+	Query   string `json:"query,omitempty"`   // page=2&limit=10
+	Headers string `json:"headers,omitempty"` // Name: value, one per line
+	Body    string `json:"body,omitempty"`
+}
+
+// placeholder matches {name} path params, and {{vars}} so they can be
+// told apart and skipped.
+var placeholder = regexp.MustCompile(`\{\{[^{}]*\}\}|\{([^{}/]+)\}`)
+
+// Target is the path with params filled in and the query appended.
+// Placeholders without a value are left for the server to reject.
+// {{vars}} are left alone for the session to fill.
+func (d Draft) Target() string {
+	vals := map[string]string{}
+	for _, kv := range strings.Fields(d.Params) {
+		if k, v, ok := strings.Cut(kv, "="); ok && v != "" {
+			vals[k] = v
+		}
+	}
+	path := placeholder.ReplaceAllStringFunc(d.Path, func(m string) string {
+		if strings.HasPrefix(m, "{{") {
+			return m
+		}
+		v, ok := vals[m[1:len(m)-1]]
+		if !ok {
+			return m
+		}
+		if strings.Contains(v, "{{") {
+			return v
+		}
+		return url.PathEscape(v)
+	})
+	if q := strings.TrimLeft(strings.TrimSpace(d.Query), "?"); q != "" {
+		sep := "?"
+		if strings.Contains(path, "?") {
+			sep = "&"
+		}
+		path += sep + q
+	}
+	return path
+}
+
+// HeaderMap reads the header lines; lines without a colon are skipped.
+func (d Draft) HeaderMap() map[string]string {
+	h := map[string]string{}
+	for _, line := range strings.Split(d.Headers, "\n") {
+		k, v, ok := strings.Cut(line, ":")
+		if k = strings.TrimSpace(k); ok && k != "" {
+			h[k] = strings.TrimSpace(v)
+		}
+	}
+	return h
+}
+
+const (
+	fieldMethod = iota
+	fieldPath
+	fieldParams
+	fieldQuery
+	fieldHeaders
+	fieldBody
+	fieldCount
+)
+
+var editorMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS"}
+
+// Editor edits one request: method, path, path params, query, headers
+// and body. Enter on a one-line field sends it; tab moves to the next
+// field.
+type Editor struct {
+	w, h   int
+	inputs [fieldQuery + 1]textinput.Model
+	areas  [2]textarea.Model // headers, body
+	field  int
+}
+
+func NewEditor() Editor {
+	var e Editor
+	for i, label := range []string{"Method", "Path", "Params", "Query"} {
+		in := textinput.New()
+		in.Prompt = ""
+		in.Placeholder = strings.ToLower(label)
+		e.inputs[i] = in
+	}
+	e.inputs[fieldMethod].SetValue("GET")
+	e.inputs[fieldMethod].CharLimit = 10
+	e.inputs[fieldParams].Placeholder = "name=value …"
+	e.inputs[fieldQuery].Placeholder = "key=value&…"
+
+	for i, ph := range []string{"Header-Name: value", "request body"} {
+		ta := textarea.New()
+		ta.Prompt = ""
+		ta.Placeholder = ph
+		ta.ShowLineNumbers = false
+		ta.CharLimit = 0
+		ta.MaxHeight = 0
+		ta.EndOfBufferCharacter = ' '
+		ta.FocusedStyle.CursorLine = lipgloss.NewStyle()
+		e.areas[i] = ta
+	}
+	return e
+}
+
+func (e *Editor) SetSize(w, h int) {
+	e.w, e.h = w, h
+	fw := max(10, w-10)
+	for i := range e.inputs {
+		e.inputs[i].Width = fw
+	}
+	// Title and four one-line fields; what is left goes to headers and
+	// body, a third and two thirds.
+	rest := max(2, h-5)
+	hh := max(1, rest/3)
+	e.areas[0].SetWidth(fw)
+	e.areas[0].SetHeight(hh)
+	e.areas[1].SetWidth(fw)
+	e.areas[1].SetHeight(max(1, rest-hh))
+}
+
+// Load puts a route into the editor. Params keep the values already
+// typed for names the new path shares.
+func (e *Editor) Load(r Route) {
+	old := e.Draft()
+	typed := map[string]string{}
+	for _, kv := range strings.Fields(old.Params) {
+		if k, v, ok := strings.Cut(kv, "="); ok {
+			typed[k] = v
+		}
+	}
+	var params []string
+	for _, m := range placeholder.FindAllStringSubmatch(r.Path, -1) {
+		if m[1] == "" {
+			continue
+		}
+		params = append(params, m[1]+"="+typed[m[1]])
+	}
+
+	e.inputs[fieldMethod].SetValue(r.Method)
+	e.inputs[fieldPath].SetValue(r.Path)
+	e.inputs[fieldParams].SetValue(strings.Join(params, " "))
+	e.inputs[fieldQuery].SetValue("")
+	e.inputs[fieldQuery].Placeholder = "key=value&…"
+	if len(r.Query) > 0 {
+		e.inputs[fieldQuery].Placeholder = strings.Join(r.Query, "=&") + "="
+	}
+	if r.Path != old.Path || r.Method == "GET" || r.Method == "DELETE" {
+		e.areas[1].SetValue("")
+	}
+}
+
+func (e Editor) Draft() Draft {
+	return Draft{
+		Method:  strings.ToUpper(strings.TrimSpace(e.inputs[fieldMethod].Value())),
+		Path:    strings.TrimSpace(e.inputs[fieldPath].Value()),
+		Params:  e.inputs[fieldParams].Value(),
+		Query:   e.inputs[fieldQuery].Value(),
+		Headers: e.areas[0].Value(),
+		Body:    e.areas[1].Value(),
+	}
+}
+
+func (e *Editor) SetDraft(d Draft) {
+	if d.Method == "" {
+		d.Method = "GET"
+	}
+	e.inputs[fieldMethod].SetValue(d.Method)
+	e.inputs[fieldPath].SetValue(d.Path)
+	e.inputs[fieldParams].SetValue(d.Params)
+	e.inputs[fieldQuery].SetValue(d.Query)
+	e.areas[0].SetValue(d.Headers)
+	e.areas[1].SetValue(d.Body)
+}
+
+// Focus gives the editor focus at its first field, or its last when
+// coming back from the pane after it.
+func (e *Editor) Focus(last bool) tea.Cmd {
+	if last {
+		return e.focusField(fieldCount - 1)
+	}
+	return e.focusField(0)
+}
+
+func (e *Editor) Blur() {
+	e.blurAll()
+}
+
+// Next moves to the next field and reports false when there is none,
+// so focus can leave the editor.
+func (e *Editor) Next() (bool, tea.Cmd) {
+	if e.field+1 >= fieldCount {
+		return false, nil
+	}
+	return true, e.focusField(e.field + 1)
+}
+
+// Prev is Next backwards.
+func (e *Editor) Prev() (bool, tea.Cmd) {
+	if e.field == 0 {
+		return false, nil
+	}
+	return true, e.focusField(e.field - 1)
+}
+
+func (e *Editor) focusField(f int) tea.Cmd {
+	e.blurAll()
+	e.field = f
+	if f >= fieldHeaders {
+		return e.areas[f-fieldHeaders].Focus()
+	}
+	return e.inputs[f].Focus()
+}
+
+func (e *Editor) blurAll() {
+	for i := range e.inputs {
+		e.inputs[i].Blur()
+	}
+	for i := range e.areas {
+		e.areas[i].Blur()
+	}
+}
+
+func (e Editor) Update(msg tea.Msg) (Editor, tea.Cmd) {
+	var cmd tea.Cmd
+	if e.field >= fieldHeaders {
+		a := &e.areas[e.field-fieldHeaders]
+		*a, cmd = a.Update(msg)
+		return e, cmd
+	}
+	if k, ok := msg.(tea.KeyMsg); ok {
+		switch k.String() {
+		case "enter":
+			return e, func() tea.Msg { return SendMsg{} }
+		case "up", "down":
+			if e.field == fieldMethod {
+				e.cycleMethod(k.String() == "down")
+				return e, nil
+			}
+		}
+	}
+	in := &e.inputs[e.field]
+	*in, cmd = in.Update(msg)
+	return e, cmd
+}
+
+func (e *Editor) cycleMethod(forward bool) {
+	cur := strings.ToUpper(e.inputs[fieldMethod].Value())
+	i := 0
+	for j, m := range editorMethods {
+		if m == cur {
+			i = j
+			break
+		}
+	}
+	if forward {
+		i = (i + 1) % len(editorMethods)
+	} else {
+		i = (i + len(editorMethods) - 1) % len(editorMethods)
+	}
+	e.inputs[fieldMethod].SetValue(editorMethods[i])
+}
+
+func (e Editor) View(focused bool) string {
+	label := func(f int, name string) string {
+		s := lipgloss.NewStyle().Width(9).Foreground(cDim)
+		if focused && e.field == f {
+			s = s.Foreground(cTitle).Bold(true)
+		}
+		return s.Render(name)
+	}
+	lines := []string{
+		paneTitle("Request", focused) + lipgloss.NewStyle().Foreground(cDim).Render("  → "+e.Draft().Target()),
+		label(fieldMethod, "Method") + e.inputs[fieldMethod].View(),
+		label(fieldPath, "Path") + e.inputs[fieldPath].View(),
+		label(fieldParams, "Params") + e.inputs[fieldParams].View(),
+		label(fieldQuery, "Query") + e.inputs[fieldQuery].View(),
+	}
+	for i, name := range []string{"Headers", "Body"} {
+		lines = append(lines, lipgloss.JoinHorizontal(lipgloss.Top, label(fieldHeaders+i, name), e.areas[i].View()))
+	}
+	return strings.Join(lines, "\n")
+}
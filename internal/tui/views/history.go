@@ -0,0 +1,183 @@
+package views
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Exchange is one request sent from the explorer and what came back.
+type Exchange struct {
+	At         time.Time   `json:"at"`
+	Draft      Draft       `json:"draft"`
+	Method     string      `json:"method"`
+	URL        string      `json:"url"`
+	Status     int         `json:"status,omitempty"`
+	DurationMs int64       `json:"duration_ms,omitempty"`
+	Headers    http.Header `json:"headers,omitempty"`
+	Body       string      `json:"body,omitempty"`
+	Err        string      `json:"error,omitempty"`
+}
+
+// ShowMsg asks for an exchange to be shown in the response pane.
+type ShowMsg struct{ Exchange Exchange }
+
+// DiffMsg asks for two exchanges to be compared, Old the earlier.
+type DiffMsg struct{ Old, New Exchange }
+
+// History lists exchanges newest first. m marks one to diff against;
+// without a mark d diffs against the previous call to the same path.
+type History struct {
+	w, h    int
+	entries []Exchange // oldest first
+	cursor  int        // into the list as shown, newest first
+	offset  int
+	mark    int // index into entries, or -1
+}
+
+func NewHistory(entries []Exchange) History {
+	return History{entries: entries, mark: -1}
+}
+
+func (hv *History) SetSize(w, h int) {
+	hv.w, hv.h = w, h
+	hv.offset = scroll(hv.offset, hv.cursor, hv.body())
+}
+
+func (hv History) Entries() []Exchange { return hv.entries }
+
+func (hv History) Len() int { return len(hv.entries) }
+
+// Add puts x at the top and moves the cursor to it.
+func (hv *History) Add(x Exchange) {
+	hv.entries = append(hv.entries, x)
+	hv.cursor, hv.offset = 0, 0
+}
+
+// Trim drops the oldest entries beyond n.
+func (hv *History) Trim(n int) {
+	if extra := len(hv.entries) - n; extra > 0 {
+		hv.entries = hv.entries[extra:]
+		hv.mark -= extra
+		if hv.mark < 0 {
+			hv.mark = -1
+		}
+		hv.cursor = clamp(hv.cursor, 0, len(hv.entries)-1)
+	}
+}
+
+func (hv History) index() int { return len(hv.entries) - 1 - hv.cursor }
+
+func (hv History) body() int { return max(1, hv.h-1) }
+
+func (hv History) Update(msg tea.Msg) (History, tea.Cmd) {
+	k, ok := msg.(tea.KeyMsg)
+	if !ok || len(hv.entries) == 0 {
+		return hv, nil
+	}
+	switch k.String() {
+	case "up", "k":
+		hv.cursor--
+	case "down", "j":
+		hv.cursor++
+	case "home", "g":
+		hv.cursor = 0
+	case "end", "G":
+		hv.cursor = len(hv.entries) - 1
+	case "enter":
+		x := hv.entries[hv.index()]
+		return hv, func() tea.Msg { return ShowMsg{Exchange: x} }
+	case "m", " ":
+		if hv.mark == hv.index() {
+			hv.mark = -1
+		} else {
+			hv.mark = hv.index()
+		}
+	case "d":
+		i, j := hv.against(), hv.index()
+		if i < 0 {
+			return hv, nil
+		}
+		if i > j {
+			i, j = j, i
+		}
+		old, cur := hv.entries[i], hv.entries[j]
+		return hv, func() tea.Msg { return DiffMsg{Old: old, New: cur} }
+	case "x", "delete":
+		i := hv.index()
+		hv.entries = append(hv.entries[:i:i], hv.entries[i+1:]...)
+		switch {
+		case hv.mark == i:
+			hv.mark = -1
+		case hv.mark > i:
+			hv.mark--
+		}
+	}
+	hv.cursor = clamp(hv.cursor, 0, len(hv.entries)-1)
+	hv.offset = scroll(hv.offset, hv.cursor, hv.body())
+	return hv, nil
+}
+
+// against picks what the selected entry is diffed with: the mark, else
+// the last earlier call to the same path, else its neighbour.
+func (hv History) against() int {
+	cur := hv.index()
+	if hv.mark >= 0 && hv.mark != cur {
+		return hv.mark
+	}
+	for i := cur - 1; i >= 0; i-- {
+		if hv.entries[i].Method == hv.entries[cur].Method && hv.entries[i].Draft.Path == hv.entries[cur].Draft.Path {
+			return i
+		}
+	}
+	if cur == 0 && len(hv.entries) > 1 {
+		return 1
+	}
+	return cur - 1
+}
+
+func (hv History) View(focused bool) string {
+	title := paneTitle("History", focused)
+	if len(hv.entries) == 0 {
+		return title + "\n" + lipgloss.NewStyle().Foreground(cDim).Render("nothing sent yet")
+	}
+	title += lipgloss.NewStyle().Foreground(cDim).Render(fmt.Sprintf("  %d", len(hv.entries)))
+	lines := []string{title}
+	for row := hv.offset; row < len(hv.entries) && row < hv.offset+hv.body(); row++ {
+		i := len(hv.entries) - 1 - row
+		x := hv.entries[i]
+		mark := " "
+		if i == hv.mark {
+			mark = lipgloss.NewStyle().Foreground(cWarn).Render("●")
+		}
+		line := fmt.Sprintf("%s %s %s %s %s", mark,
+			lipgloss.NewStyle().Foreground(cDim).Render(x.At.Local().Format("15:04:05")),
+			StatusBadge(x.Status, x.Err),
+			MethodBadge(x.Method),
+			x.Draft.Target())
+		lines = append(lines, cursorLine(line, row == hv.cursor, focused, hv.w))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// StatusBadge is a status code coloured by class, or ERR for a request
+// that got no response.
+func StatusBadge(status int, err string) string {
+	if err != "" || status == 0 {
+		return lipgloss.NewStyle().Foreground(cErr).Render("ERR")
+	}
+	c := cOk
+	switch {
+	case status >= 500:
+		c = cErr
+	case status >= 400:
+		c = cWarn
+	case status >= 300:
+		c = cDim
+	}
+	return lipgloss.NewStyle().Foreground(c).Render(fmt.Sprint(status))
+}
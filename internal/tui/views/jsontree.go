@@ -0,0 +1,284 @@
+package views
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/bspippi1337/restless/internal/output"
+)
+
+var (
+	cKey    = lipgloss.Color("75")
+	cString = lipgloss.Color("114")
+	cNumber = lipgloss.Color("215")
+	cConst  = lipgloss.Color("176")
+)
+
+// unfoldedLimit is how many lines a document may take fully open before
+// everything below the second level starts folded.
+const unfoldedLimit = 300
+
+// JSONTree shows a JSON document with foldable objects and arrays.
+// Bodies that are not JSON are shown as plain lines.
+type JSONTree struct {
+	w, h   int
+	root   *jsonNode
+	raw    []string
+	folded map[string]bool
+
+	lines  []jsonLine
+	cursor int
+	offset int
+}
+
+type jsonNode struct {
+	path   string // .data[0].name, the node's identity for folding
+	key    string // "name": , or empty in arrays and at the root
+	depth  int
+	value  any
+	kids   []*jsonNode
+	parent *jsonNode
+	last   bool // no comma after it
+}
+
+type jsonLine struct {
+	node    *jsonNode
+	closing bool
+}
+
+func (n *jsonNode) container() bool {
+	switch n.value.(type) {
+	case *output.Object, []any:
+		return true
+	}
+	return false
+}
+
+func (n *jsonNode) brackets() (string, string) {
+	if _, ok := n.value.([]any); ok {
+		return "[", "]"
+	}
+	return "{", "}"
+}
+
+// SetBody shows body, folding deep levels of large documents.
+func (t *JSONTree) SetBody(body []byte) {
+	t.root, t.raw, t.cursor, t.offset = nil, nil, 0, 0
+	t.folded = map[string]bool{}
+	v, err := output.Decode(body)
+	if err != nil {
+		t.raw = strings.Split(strings.TrimRight(string(body), "\n"), "\n")
+		if len(body) == 0 {
+			t.raw = nil
+		}
+		return
+	}
+	t.root = buildNode(v, "", "", 0, nil)
+	t.root.last = true
+	t.relayout()
+	if len(t.lines) > unfoldedLimit {
+		t.foldFrom(2)
+	}
+}
+
+func buildNode(v any, path, key string, depth int, parent *jsonNode) *jsonNode {
+	n := &jsonNode{path: path, key: key, depth: depth, value: v, parent: parent}
+	switch t := v.(type) {
+	case *output.Object:
+		for i, k := range t.Keys {
+			kid := buildNode(t.Vals[k], path+"."+k, k, depth+1, n)
+			kid.last = i == len(t.Keys)-1
+			n.kids = append(n.kids, kid)
+		}
+	case []any:
+		for i, e := range t {
+			kid := buildNode(e, fmt.Sprintf("%s[%d]", path, i), "", depth+1, n)
+			kid.last = i == len(t)-1
+			n.kids = append(n.kids, kid)
+		}
+	}
+	return n
+}
+
+func (t *JSONTree) SetSize(w, h int) {
+	t.w, t.h = w, h
+	t.offset = scroll(t.offset, t.cursor, max(1, h))
+}
+
+// foldFrom folds every container at depth d or deeper and opens the rest.
+func (t *JSONTree) foldFrom(d int) {
+	t.folded = map[string]bool{}
+	var walk func(n *jsonNode)
+	walk = func(n *jsonNode) {
+		if n.container() && n.depth >= d && len(n.kids) > 0 {
+			t.folded[n.path] = true
+		}
+		for _, k := range n.kids {
+			walk(k)
+		}
+	}
+	if t.root != nil {
+		walk(t.root)
+	}
+	t.relayout()
+}
+
+func (t *JSONTree) relayout() {
+	t.lines = t.lines[:0]
+	var walk func(n *jsonNode)
+	walk = func(n *jsonNode) {
+		t.lines = append(t.lines, jsonLine{node: n})
+		if !n.container() || len(n.kids) == 0 || t.folded[n.path] {
+			return
+		}
+		for _, k := range n.kids {
+			walk(k)
+		}
+		t.lines = append(t.lines, jsonLine{node: n, closing: true})
+	}
+	if t.root != nil {
+		walk(t.root)
+	}
+	t.cursor = clamp(t.cursor, 0, len(t.lines)-1)
+}
+
+// Len is how many lines the document takes as it is folded now.
+func (t JSONTree) Len() int {
+	if t.root == nil {
+		return len(t.raw)
+	}
+	return len(t.lines)
+}
+
+func (t JSONTree) Update(msg tea.Msg) (JSONTree, tea.Cmd) {
+	k, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return t, nil
+	}
+	switch k.String() {
+	case "up", "k":
+		t.cursor--
+	case "down", "j":
+		t.cursor++
+	case "pgup":
+		t.cursor -= max(1, t.h-1)
+	case "pgdown":
+		t.cursor += max(1, t.h-1)
+	case "home", "g":
+		t.cursor = 0
+	case "end", "G":
+		t.cursor = t.Len() - 1
+	case "enter", " ":
+		if n := t.node(); n != nil && n.container() && len(n.kids) > 0 {
+			t.setFolded(n, !t.folded[n.path])
+		}
+	case "left":
+		if n := t.node(); n != nil {
+			if n.container() && len(n.kids) > 0 && !t.folded[n.path] {
+				t.setFolded(n, true)
+			} else if n.parent != nil {
+				t.setFolded(n.parent, true)
+			}
+		}
+	case "right":
+		if n := t.node(); n != nil && n.container() && t.folded[n.path] {
+			t.setFolded(n, false)
+		}
+	case "e":
+		t.foldFrom(1 << 30)
+	case "c":
+		t.foldFrom(1)
+	}
+	t.cursor = clamp(t.cursor, 0, t.Len()-1)
+	t.offset = scroll(t.offset, t.cursor, max(1, t.h))
+	return t, nil
+}
+
+func (t JSONTree) node() *jsonNode {
+	if t.root == nil || t.cursor >= len(t.lines) {
+		return nil
+	}
+	return t.lines[t.cursor].node
+}
+
+// setFolded folds or opens n and keeps the cursor on n's first line.
+func (t *JSONTree) setFolded(n *jsonNode, folded bool) {
+	t.folded[n.path] = folded
+	t.relayout()
+	for i, l := range t.lines {
+		if l.node == n && !l.closing {
+			t.cursor = i
+			return
+		}
+	}
+}
+
+func (t JSONTree) View(focused bool) string {
+	h := max(1, t.h)
+	var out []string
+	if t.root == nil {
+		if len(t.raw) == 0 {
+			return lipgloss.NewStyle().Foreground(cDim).Render("(empty body)")
+		}
+		for i := t.offset; i < len(t.raw) && i < t.offset+h; i++ {
+			out = append(out, cursorLine(t.raw[i], i == t.cursor, focused, t.w))
+		}
+		return strings.Join(out, "\n")
+	}
+	for i := t.offset; i < len(t.lines) && i < t.offset+h; i++ {
+		out = append(out, cursorLine(t.render(t.lines[i]), i == t.cursor, focused, t.w))
+	}
+	return strings.Join(out, "\n")
+}
+
+func (t JSONTree) render(l jsonLine) string {
+	n := l.node
+	indent := strings.Repeat("  ", n.depth)
+	comma := ","
+	if n.last {
+		comma = ""
+	}
+	open, close := n.brackets()
+	if l.closing {
+		return indent + close + comma
+	}
+
+	var b strings.Builder
+	b.WriteString(indent)
+	if n.key != "" {
+		b.WriteString(lipgloss.NewStyle().Foreground(cKey).Render(string(output.Encode(n.key, ""))))
+		b.WriteString(": ")
+	}
+	switch {
+	case !n.container():
+		b.WriteString(scalarStyle(n.value).Render(string(output.Encode(n.value, ""))))
+	case len(n.kids) == 0:
+		b.WriteString(open + close)
+	case t.folded[n.path]:
+		b.WriteString(open + "…" + close)
+		b.WriteString(comma)
+		what := "keys"
+		if open == "[" {
+			what = "items"
+		}
+		b.WriteString(lipgloss.NewStyle().Foreground(cDim).Render(fmt.Sprintf("  %d %s", len(n.kids), what)))
+		return b.String()
+	default:
+		return b.String() + open
+	}
+	return b.String() + comma
+}
+
+func scalarStyle(v any) lipgloss.Style {
+	switch v.(type) {
+	case string:
+		return lipgloss.NewStyle().Foreground(cString)
+	case json.Number:
+		return lipgloss.NewStyle().Foreground(cNumber)
+	}
+	return lipgloss.NewStyle().Foreground(cConst)
+}
@@ -0,0 +1,76 @@
+package views
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+var cErr = lipgloss.Color("203")
+
+// Pane frames content in a rounded border of outer size w×h, clipping
+// what does not fit. The border is bright when the pane has focus.
+func Pane(content string, w, h int, focused bool) string {
+	border := cLine
+	if focused {
+		border = cTitle
+	}
+	iw, ih := max(1, w-2), max(1, h-2)
+	lines := strings.Split(content, "\n")
+	if len(lines) > ih {
+		lines = lines[:ih]
+	}
+	clip := lipgloss.NewStyle().MaxWidth(iw)
+	for i, l := range lines {
+		lines[i] = clip.Render(l)
+	}
+	return lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(border).
+		Width(iw).
+		Height(ih).
+		Render(strings.Join(lines, "\n"))
+}
+
+func paneTitle(title string, focused bool) string {
+	s := lipgloss.NewStyle().Bold(true)
+	if focused {
+		s = s.Foreground(cTitle)
+	} else {
+		s = s.Foreground(cDim)
+	}
+	return s.Render(title)
+}
+
+// cursorLine marks the line under a list cursor.
+func cursorLine(line string, at, focused bool, w int) string {
+	if !at {
+		return "  " + line
+	}
+	if !focused {
+		return lipgloss.NewStyle().Foreground(cDim).Render("› ") + line
+	}
+	return lipgloss.NewStyle().Foreground(cTitle).Render("› ") +
+		lipgloss.NewStyle().Background(lipgloss.Color("236")).Width(max(0, w-2)).Render(line)
+}
+
+// scroll moves offset just enough to keep cursor inside a window of n rows.
+func scroll(offset, cursor, n int) int {
+	if cursor < offset {
+		return cursor
+	}
+	if cursor >= offset+n {
+		return cursor - n + 1
+	}
+	return max(0, offset)
+}
+
+func clamp(v, lo, hi int) int {
+	if v > hi {
+		v = hi
+	}
+	if v < lo {
+		v = lo
+	}
+	return v
+}
@@ -0,0 +1,181 @@
+package views
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	showBody = iota
+	showHeaders
+	showDiff
+)
+
+// Response shows one exchange: its body as a foldable JSON tree, or its
+// headers (h), or a diff between two exchanges.
+type Response struct {
+	w, h int
+	x    *Exchange
+	body JSONTree
+	mode int
+
+	diff      []DiffLine
+	diffTitle string
+	offset    int // headers and diff scroll
+}
+
+func NewResponse() Response { return Response{} }
+
+func (r *Response) SetSize(w, h int) {
+	r.w, r.h = w, h
+	r.body.SetSize(w, r.rows())
+}
+
+// rows is the height left under the title and status lines.
+func (r Response) rows() int { return max(1, r.h-2) }
+
+func (r *Response) Show(x Exchange) {
+	r.x = &x
+	r.mode = showBody
+	r.offset = 0
+	r.body.SetBody([]byte(x.Body))
+	r.body.SetSize(r.w, r.rows())
+}
+
+func (r *Response) ShowDiff(old, cur Exchange) {
+	r.diff = Diff(old, cur)
+	r.diffTitle = fmt.Sprintf("%s → %s", old.At.Local().Format("15:04:05"), cur.At.Local().Format("15:04:05"))
+	r.mode = showDiff
+	r.offset = 0
+}
+
+func (r Response) Update(msg tea.Msg) (Response, tea.Cmd) {
+	k, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return r, nil
+	}
+	switch k.String() {
+	case "h":
+		if r.mode == showHeaders {
+			r.mode = showBody
+		} else if r.x != nil {
+			r.mode, r.offset = showHeaders, 0
+		}
+		return r, nil
+	case "b", "esc":
+		r.mode = showBody
+		return r, nil
+	}
+	if r.mode == showBody {
+		var cmd tea.Cmd
+		r.body, cmd = r.body.Update(msg)
+		return r, cmd
+	}
+	n := len(r.lines())
+	switch k.String() {
+	case "up", "k":
+		r.offset--
+	case "down", "j":
+		r.offset++
+	case "pgup":
+		r.offset -= r.rows()
+	case "pgdown", " ":
+		r.offset += r.rows()
+	case "home", "g":
+		r.offset = 0
+	case "end", "G":
+		r.offset = n
+	}
+	r.offset = clamp(r.offset, 0, max(0, n-r.rows()))
+	return r, nil
+}
+
+// lines is the headers or diff view, already styled.
+func (r Response) lines() []string {
+	var out []string
+	switch r.mode {
+	case showHeaders:
+		if r.x == nil {
+			return nil
+		}
+		names := make([]string, 0, len(r.x.Headers))
+		for k := range r.x.Headers {
+			names = append(names, k)
+		}
+		sort.Strings(names)
+		for _, k := range names {
+			for _, v := range r.x.Headers[k] {
+				out = append(out, lipgloss.NewStyle().Foreground(cKey).Render(k)+": "+v)
+			}
+		}
+		if len(out) == 0 {
+			out = append(out, lipgloss.NewStyle().Foreground(cDim).Render("(no headers)"))
+		}
+	case showDiff:
+		for _, d := range r.diff {
+			switch d.Op {
+			case '#':
+				out = append(out, lipgloss.NewStyle().Bold(true).Foreground(cTitle).Render(d.Text))
+			case '+':
+				out = append(out, lipgloss.NewStyle().Foreground(cOk).Render("+ "+d.Text))
+			case '-':
+				out = append(out, lipgloss.NewStyle().Foreground(cErr).Render("- "+d.Text))
+			case '~':
+				out = append(out, lipgloss.NewStyle().Foreground(cWarn).Render("~ "+d.Text))
+			default:
+				out = append(out, lipgloss.NewStyle().Foreground(cDim).Render("  "+d.Text))
+			}
+		}
+	}
+	return out
+}
+
+func (r Response) View(focused bool) string {
+	dim := lipgloss.NewStyle().Foreground(cDim)
+	title := paneTitle("Response", focused)
+
+	if r.mode == showDiff {
+		title += dim.Render("  diff " + r.diffTitle)
+		return r.page(title, dim.Render("esc back"))
+	}
+	if r.x == nil {
+		return title + "\n" + dim.Render("send a request: pick an endpoint, then enter or ctrl+s")
+	}
+
+	x := r.x
+	status := StatusBadge(x.Status, x.Err)
+	if x.Err == "" {
+		status += " " + http.StatusText(x.Status)
+	}
+	title += "  " + status + dim.Render(fmt.Sprintf(" · %dms · %s · %s %s", x.DurationMs, size(len(x.Body)), x.Method, x.URL))
+	if x.Err != "" {
+		return title + "\n" + lipgloss.NewStyle().Foreground(cErr).Render(x.Err)
+	}
+
+	if r.mode == showHeaders {
+		return r.page(title, dim.Render("headers · h body"))
+	}
+	hint := "body · enter fold · e/c open/fold all · h headers"
+	return title + "\n" + dim.Render(hint) + "\n" + r.body.View(focused)
+}
+
+func (r Response) page(title, hint string) string {
+	lines := r.lines()
+	end := min(len(lines), r.offset+r.rows())
+	return title + "\n" + hint + "\n" + strings.Join(lines[min(r.offset, end):end], "\n")
+}
+
+func size(n int) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", n)
+}
@@ -0,0 +1,238 @@
+package views
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Route is one method on one learned path, as listed in the tree.
+type Route struct {
+	Method string
+	Path   string
+	Query  []string
+}
+
+// RouteMsg is sent when a route is picked in the tree.
+type RouteMsg struct{ Route Route }
+
+// Tree lists learned routes grouped by their first path segment. Typing
+// filters it; groups fold with left/right.
+type Tree struct {
+	w, h   int
+	routes []Route
+	filter string
+	folded map[string]bool
+
+	rows   []treeRow
+	cursor int
+	offset int
+}
+
+type treeRow struct {
+	group string
+	route *Route // nil for a group heading
+	count int
+}
+
+func NewTree(routes []Route) Tree {
+	t := Tree{routes: routes, folded: map[string]bool{}}
+	sort.SliceStable(t.routes, func(i, j int) bool {
+		if t.routes[i].Path != t.routes[j].Path {
+			return t.routes[i].Path < t.routes[j].Path
+		}
+		return methodRank(t.routes[i].Method) < methodRank(t.routes[j].Method)
+	})
+	t.rebuild()
+	return t
+}
+
+func (t *Tree) SetSize(w, h int) { t.w, t.h = w, h }
+
+func (t Tree) Filter() string { return t.filter }
+
+func (t *Tree) SetFilter(f string) {
+	t.filter = f
+	t.cursor = 0
+	t.rebuild()
+}
+
+// Selected is the route under the cursor, if the cursor is on one.
+func (t Tree) Selected() (Route, bool) {
+	if t.cursor < len(t.rows) && t.rows[t.cursor].route != nil {
+		return *t.rows[t.cursor].route, true
+	}
+	return Route{}, false
+}
+
+func (t Tree) Update(msg tea.Msg) (Tree, tea.Cmd) {
+	k, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return t, nil
+	}
+	switch k.String() {
+	case "up", "ctrl+p":
+		t.move(-1)
+	case "down", "ctrl+n":
+		t.move(1)
+	case "pgup":
+		t.move(-t.page())
+	case "pgdown":
+		t.move(t.page())
+	case "home":
+		t.cursor = 0
+	case "end":
+		t.cursor = max(0, len(t.rows)-1)
+	case "left":
+		t.fold(true)
+	case "right":
+		t.fold(false)
+	case "esc":
+		t.SetFilter("")
+	case "backspace":
+		if r := []rune(t.filter); len(r) > 0 {
+			t.SetFilter(string(r[:len(r)-1]))
+		}
+	case "enter":
+		if t.cursor >= len(t.rows) {
+			return t, nil
+		}
+		row := t.rows[t.cursor]
+		if row.route == nil {
+			t.folded[row.group] = !t.folded[row.group]
+			t.rebuild()
+			return t, nil
+		}
+		route := *row.route
+		return t, func() tea.Msg { return RouteMsg{Route: route} }
+	default:
+		if k.Type == tea.KeyRunes {
+			t.SetFilter(t.filter + string(k.Runes))
+		}
+	}
+	t.offset = scroll(t.offset, t.cursor, t.body())
+	return t, nil
+}
+
+// body is how many rows fit under the title and filter lines.
+func (t Tree) body() int { return max(1, t.h-2) }
+
+func (t *Tree) move(n int) {
+	t.cursor = clamp(t.cursor+n, 0, len(t.rows)-1)
+}
+
+func (t Tree) page() int { return max(1, t.body()-1) }
+
+// fold folds or unfolds the group under the cursor and puts the cursor
+// on its heading.
+func (t *Tree) fold(folded bool) {
+	if t.cursor >= len(t.rows) {
+		return
+	}
+	g := t.rows[t.cursor].group
+	t.folded[g] = folded
+	t.rebuild()
+	for i, r := range t.rows {
+		if r.group == g && r.route == nil {
+			t.cursor = i
+			break
+		}
+	}
+}
+
+// rebuild lays out the visible rows. While filtering every group is
+// open, so a match is never hidden.
+func (t *Tree) rebuild() {
+	terms := strings.Fields(strings.ToLower(t.filter))
+	var rows []treeRow
+	head := -1
+	for i := range t.routes {
+		r := &t.routes[i]
+		if !matches(r, terms) {
+			continue
+		}
+		g := group(r.Path)
+		if head < 0 || rows[head].group != g {
+			rows = append(rows, treeRow{group: g})
+			head = len(rows) - 1
+		}
+		rows[head].count++
+		if len(terms) > 0 || !t.folded[g] {
+			rows = append(rows, treeRow{group: g, route: r})
+		}
+	}
+	t.rows = rows
+	t.cursor = clamp(t.cursor, 0, len(rows)-1)
+}
+
+func matches(r *Route, terms []string) bool {
+	s := strings.ToLower(r.Method + " " + r.Path)
+	for _, term := range terms {
+		if !strings.Contains(s, term) {
+			return false
+		}
+	}
+	return true
+}
+
+func group(path string) string {
+	seg, _, _ := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+	return "/" + seg
+}
+
+func (t Tree) View(focused bool) string {
+	title := paneTitle("Endpoints", focused)
+	filter := lipgloss.NewStyle().Foreground(cDim).Render("type to filter")
+	if t.filter != "" {
+		filter = "/ " + t.filter
+		if focused {
+			filter += "█"
+		}
+	}
+	lines := []string{title, filter}
+
+	body := t.body()
+	if len(t.rows) == 0 {
+		lines = append(lines, lipgloss.NewStyle().Foreground(cDim).Render("no match"))
+	}
+	for i := t.offset; i < len(t.rows) && i < t.offset+body; i++ {
+		r := t.rows[i]
+		var line string
+		if r.route == nil {
+			mark := "▾"
+			if t.folded[r.group] && t.filter == "" {
+				mark = "▸"
+			}
+			line = lipgloss.NewStyle().Bold(true).Render(fmt.Sprintf("%s %s", mark, r.group)) +
+				lipgloss.NewStyle().Foreground(cDim).Render(fmt.Sprintf(" %d", r.count))
+		} else {
+			line = "  " + MethodBadge(r.route.Method) + " " + r.route.Path
+		}
+		lines = append(lines, cursorLine(line, i == t.cursor, focused, t.w))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// MethodBadge is a method padded to line up and coloured by what it does.
+func MethodBadge(m string) string {
+	c := cOk
+	switch m {
+	case "POST", "PUT", "PATCH":
+		c = cWarn
+	case "DELETE":
+		c = cErr
+	}
+	return lipgloss.NewStyle().Foreground(c).Render(fmt.Sprintf("%-6s", m))
+}
+
+func methodRank(m string) int {
+	for i, x := range []string{"GET", "POST", "PUT", "PATCH", "DELETE"} {
+		if m == x {
+			return i
+		}
+	}
+	return 9
+}
@@ -15,6 +15,8 @@ import (
 	"time"
 
 	"github.com/getkin/kin-openapi/openapi3"
+
+	"github.com/bspippi1337/restless/internal/modules/openapi/guard/model"
 )
 
 type Options struct {
@@ -33,6 +35,8 @@ type Finding struct {
 	ExpectedCodes string `json:"expectedCodes"`
 	ActualCode    int    `json:"actualCode"`
 	Problem       string `json:"problem"`
+
+	Request *model.Request `json:"request,omitempty"`
 }
 
 type Report struct {
@@ -62,12 +66,7 @@ func Run(ctx context.Context, opt Options) (Report, error) {
 		return Report{}, fmt.Errorf("invalid --base: %q", opt.BaseURL)
 	}
 
-	client := &http.Client{
-		Timeout: opt.Timeout,
-		Transport: &http.Transport{
-			TLSClientConfig: &tls.Config{MinVersion: tls.VersionTLS12},
-		},
-	}
+	client := &http.Client{Timeout: opt.Timeout, Transport: transport()}
 
 	var findings []Finding
 	checked := 0
@@ -81,7 +80,7 @@ func Run(ctx context.Context, opt Options) (Report, error) {
 			u.Path = joinURLPath(base.Path, materializePath(path))
 
 			exp := expectedCodes(op)
-			code, problem := hit(ctx, client, method, u.String(), opt.AuthHeader)
+			code, problem, sent := hit(ctx, client, method, u.String(), opt.AuthHeader)
 			// Core rule: 404 is drift (endpoint missing)
 			// In non-strict mode we don't fail on 401/403 (auth required), but we still report mismatched codes
 			fail := false
@@ -107,6 +106,7 @@ func Run(ctx context.Context, opt Options) (Report, error) {
 					ExpectedCodes: exp,
 					ActualCode:    code,
 					Problem:       problemOrDefault(problem, "drift detected"),
+					Request:       sent,
 				})
 			}
 		}
@@ -124,6 +124,22 @@ func Run(ctx context.Context, opt Options) (Report, error) {
 	return rep, nil
 }
 
+// transport pins TLS 1.2 or newer on a copy of the stock transport. When
+// http.DefaultTransport is not the stock one, the CLI has installed a
+// record/replay cassette there, and nil lets the requests go through it.
+func transport() http.RoundTripper {
+	stock, ok := http.DefaultTransport.(*http.Transport)
+	if !ok {
+		return nil
+	}
+	t := stock.Clone()
+	if t.TLSClientConfig == nil {
+		t.TLSClientConfig = &tls.Config{}
+	}
+	t.TLSClientConfig.MinVersion = tls.VersionTLS12
+	return t
+}
+
 func loadSpec(ctx context.Context, path string) (*openapi3.T, error) {
 	ldr := &openapi3.Loader{Context: ctx, IsExternalRefsAllowed: true}
 	doc, err := ldr.LoadFromFile(path)
@@ -240,10 +256,10 @@ func atoiSafe(s string) int {
 	return n
 }
 
-func hit(ctx context.Context, client *http.Client, method, target, authHeader string) (int, string) {
+func hit(ctx context.Context, client *http.Client, method, target, authHeader string) (int, string, *model.Request) {
 	req, err := http.NewRequestWithContext(ctx, method, target, nil)
 	if err != nil {
-		return 0, "request build failed"
+		return 0, "request build failed", nil
 	}
 	// Optional single header "Key: Value"
 	if authHeader != "" {
@@ -254,13 +270,15 @@ func hit(ctx context.Context, client *http.Client, method, target, authHeader st
 	// Give servers something sane
 	req.Header.Set("Accept", "application/json")
 
+	sent := model.NewRequest(req, nil)
+
 	resp, err := client.Do(req)
 	if err != nil {
-		return 0, "request failed: " + err.Error()
+		return 0, "request failed: " + err.Error(), sent
 	}
 	defer resp.Body.Close()
 	io.Copy(io.Discard, resp.Body)
-	return resp.StatusCode, ""
+	return resp.StatusCode, "", sent
 }
 
 func problemOrDefault(p, d string) string {